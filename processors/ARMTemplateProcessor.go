@@ -0,0 +1,204 @@
+package processors
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// armTemplate is an Azure Resource Manager deployment template. Resources are
+// a list, or with languageVersion 2.0 a map keyed by symbolic name.
+type armTemplate struct {
+	Schema          string                 `json:"$schema"`
+	ContentVersion  string                 `json:"contentVersion"`
+	LanguageVersion string                 `json:"languageVersion"`
+	Metadata        map[string]interface{} `json:"metadata"`
+	Parameters      map[string]struct {
+		Type          string        `json:"type"`
+		DefaultValue  interface{}   `json:"defaultValue"`
+		AllowedValues []interface{} `json:"allowedValues"`
+		Metadata      struct {
+			Description string `json:"description"`
+		} `json:"metadata"`
+	} `json:"parameters"`
+	Resources json.RawMessage `json:"resources"`
+}
+
+// armResource is an entry of a template's resources.
+type armResource struct {
+	Type       string                 `json:"type"`
+	APIVersion string                 `json:"apiVersion"`
+	Name       string                 `json:"name"`
+	Location   string                 `json:"location"`
+	Kind       string                 `json:"kind"`
+	Condition  interface{}            `json:"condition"`
+	Copy       map[string]interface{} `json:"copy"`
+	Existing   bool                   `json:"existing"`
+	SKU        map[string]interface{} `json:"sku"`
+	Properties map[string]interface{} `json:"properties"`
+	Resources  json.RawMessage        `json:"resources"`
+}
+
+// isARMTemplate reports whether a $schema is one of the deployment template
+// schemas, at resource group, subscription, management group or tenant scope.
+func isARMTemplate(schema string) bool {
+	return strings.Contains(strings.ToLower(schema), "deploymenttemplate.json")
+}
+
+// armResources decodes a resources value in either of its forms. Symbolic
+// names are empty for the list form.
+func armResources(raw json.RawMessage) ([]string, []armResource) {
+	var list []armResource
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return make([]string, len(list)), list
+	}
+	var symbolic map[string]armResource
+	if err := json.Unmarshal(raw, &symbolic); err != nil {
+		return nil, nil
+	}
+	names := sortedKeys(symbolic)
+	resources := make([]armResource, 0, len(names))
+	for _, name := range names {
+		resources = append(resources, symbolic[name])
+	}
+	return names, resources
+}
+
+// ARMTemplateProcessor reports the resources and parameters of Azure Resource
+// Manager JSON templates, including those in inline nested deployments.
+type ARMTemplateProcessor struct {
+}
+
+func (a ARMTemplateProcessor) Supports(filePath string) bool {
+	return strings.ToLower(filepath.Ext(filePath)) == ".json"
+}
+
+func (a ARMTemplateProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	// Most JSON files are not templates; avoid decoding them.
+	if !strings.Contains(strings.ToLower(content), "deploymenttemplate.json") {
+		return nil, nil
+	}
+	var template armTemplate
+	if err := json.Unmarshal([]byte(content), &template); err != nil || !isARMTemplate(template.Schema) {
+		return nil, nil
+	}
+
+	newFinding := func(name string, findingType string, properties map[string]interface{}) core.Finding {
+		return core.Finding{
+			Name:       name,
+			Type:       findingType,
+			Category:   "Azure",
+			Properties: properties,
+			RepoName:   repoName,
+			Path:       path,
+		}
+	}
+
+	var matches []core.Finding
+	generator := ""
+	if metadata, ok := template.Metadata["_generator"].(map[string]interface{}); ok {
+		generator = fmt.Sprint(metadata["name"])
+	}
+	for _, name := range sortedKeys(template.Parameters) {
+		parameter := template.Parameters[name]
+		properties := map[string]interface{}{
+			"type":   parameter.Type,
+			"secure": strings.HasPrefix(strings.ToLower(parameter.Type), "secure"),
+		}
+		if parameter.DefaultValue != nil {
+			properties["default"] = parameter.DefaultValue
+		}
+		if len(parameter.AllowedValues) > 0 {
+			properties["allowed"] = parameter.AllowedValues
+		}
+		if parameter.Metadata.Description != "" {
+			properties["description"] = parameter.Metadata.Description
+		}
+		matches = append(matches, newFinding(name, "ARM Parameter", properties))
+	}
+
+	matches = append(matches, armResourceFindings(newFinding, template.Resources, "", "", "")...)
+	if generator != "" {
+		for i := range matches {
+			matches[i].Properties["generator"] = generator
+		}
+	}
+	return matches, nil
+}
+
+// armResourceFindings reports resources and their child resources, whose type
+// and name are relative to the parent's. Inline templates of nested
+// deployments are reported with the deployment they belong to.
+func armResourceFindings(newFinding func(string, string, map[string]interface{}) core.Finding, raw json.RawMessage, parentType string, parentName string, deployment string) []core.Finding {
+	var matches []core.Finding
+	names, resources := armResources(raw)
+	for i, resource := range resources {
+		resourceType, name := resource.Type, resource.Name
+		if parentType != "" && !strings.Contains(resourceType, ".") {
+			resourceType = parentType + "/" + resourceType
+			name = parentName + "/" + name
+		}
+
+		properties := map[string]interface{}{}
+		for key, value := range resource.Properties {
+			properties[key] = value
+		}
+		properties["resource_type"] = resourceType
+		properties["api_version"] = resource.APIVersion
+		properties["resource_name"] = name
+		if namespace, _, ok := strings.Cut(resourceType, "/"); ok {
+			properties["namespace"] = namespace
+		}
+		if resource.Location != "" {
+			properties["location"] = resource.Location
+		}
+		if resource.Kind != "" {
+			properties["kind"] = resource.Kind
+		}
+		if sku, ok := resource.SKU["name"]; ok {
+			properties["sku"] = sku
+		}
+		properties["conditional"] = resource.Condition != nil
+		properties["loop"] = resource.Copy != nil
+		properties["existing"] = resource.Existing
+		if names[i] != "" {
+			properties["symbolic_name"] = names[i]
+		}
+		if parentType != "" {
+			properties["parent"] = parentName
+		}
+		if deployment != "" {
+			properties["deployment"] = deployment
+		}
+
+		findingName := names[i]
+		if findingName == "" {
+			findingName = name
+		}
+		matches = append(matches, newFinding(findingName, "ARM Resource", properties))
+
+		if len(resource.Resources) > 0 {
+			matches = append(matches, armResourceFindings(newFinding, resource.Resources, resourceType, name, deployment)...)
+		}
+		if strings.EqualFold(resourceType, "Microsoft.Resources/deployments") {
+			if template, ok := resource.Properties["template"].(map[string]interface{}); ok {
+				// The inline template is reported through its resources.
+				delete(properties, "template")
+				nested, _ := json.Marshal(template["resources"])
+				matches = append(matches, armResourceFindings(newFinding, nested, "", "", name)...)
+			}
+			if link, ok := resource.Properties["templateLink"].(map[string]interface{}); ok {
+				for _, key := range []string{"relativePath", "uri", "id"} {
+					if value, ok := link[key]; ok {
+						properties["template_link"] = value
+						break
+					}
+				}
+			}
+		}
+	}
+	return matches
+}
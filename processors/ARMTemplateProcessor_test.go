@@ -0,0 +1,141 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestARMTemplateProcessor_Process(t *testing.T) {
+	content := `{
+  "$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#",
+  "contentVersion": "1.0.0.0",
+  "metadata": {
+    "_generator": { "name": "bicep", "version": "0.24.24.22086" }
+  },
+  "parameters": {
+    "location": {
+      "type": "string",
+      "defaultValue": "[resourceGroup().location]",
+      "metadata": { "description": "Location for all resources" }
+    },
+    "adminPassword": { "type": "securestring" }
+  },
+  "resources": [
+    {
+      "type": "Microsoft.Storage/storageAccounts",
+      "apiVersion": "2023-01-01",
+      "name": "acmeassets",
+      "location": "[parameters('location')]",
+      "kind": "StorageV2",
+      "sku": { "name": "Standard_LRS" },
+      "properties": { "accessTier": "Hot" },
+      "resources": [
+        {
+          "type": "blobServices",
+          "apiVersion": "2023-01-01",
+          "name": "default"
+        }
+      ]
+    },
+    {
+      "type": "Microsoft.Web/sites",
+      "apiVersion": "2022-09-01",
+      "name": "[format('app-{0}', copyIndex())]",
+      "copy": { "name": "apps", "count": 2 },
+      "condition": "[equals(parameters('location'), 'uksouth')]"
+    },
+    {
+      "type": "Microsoft.Resources/deployments",
+      "apiVersion": "2022-09-01",
+      "name": "network",
+      "properties": {
+        "mode": "Incremental",
+        "template": {
+          "resources": [
+            {
+              "type": "Microsoft.Network/virtualNetworks",
+              "apiVersion": "2023-04-01",
+              "name": "acme-vnet"
+            }
+          ]
+        }
+      }
+    },
+    {
+      "type": "Microsoft.Resources/deployments",
+      "apiVersion": "2022-09-01",
+      "name": "linked",
+      "properties": {
+        "templateLink": { "relativePath": "nested/db.json" }
+      }
+    }
+  ]
+}`
+
+	processor := ARMTemplateProcessor{}
+	assert.True(t, processor.Supports("infra/azuredeploy.json"))
+
+	findings, err := processor.Process("infra/azuredeploy.json", "repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		byType[finding.Type] = append(byType[finding.Type], finding)
+		assert.Equal(t, "bicep", finding.Properties["generator"])
+	}
+
+	params := findingsByName(byType["ARM Parameter"])
+	require.Len(t, params, 2)
+	assert.Equal(t, "Location for all resources", params["location"].Properties["description"])
+	assert.Equal(t, true, params["adminPassword"].Properties["secure"])
+
+	resources := findingsByName(byType["ARM Resource"])
+	require.Len(t, resources, 6)
+	storage := resources["acmeassets"].Properties
+	assert.Equal(t, "Microsoft.Storage/storageAccounts", storage["resource_type"])
+	assert.Equal(t, "2023-01-01", storage["api_version"])
+	assert.Equal(t, "Standard_LRS", storage["sku"])
+	assert.Equal(t, "Hot", storage["accessTier"])
+
+	blobs := resources["acmeassets/default"].Properties
+	assert.Equal(t, "Microsoft.Storage/storageAccounts/blobServices", blobs["resource_type"])
+	assert.Equal(t, "acmeassets", blobs["parent"])
+
+	app := resources["[format('app-{0}', copyIndex())]"].Properties
+	assert.Equal(t, true, app["loop"])
+	assert.Equal(t, true, app["conditional"])
+
+	assert.Nil(t, resources["network"].Properties["template"])
+	assert.Equal(t, "network", resources["acme-vnet"].Properties["deployment"])
+	assert.Equal(t, "nested/db.json", resources["linked"].Properties["template_link"])
+}
+
+func TestARMTemplateProcessor_SymbolicNamesAndOtherJSON(t *testing.T) {
+	content := `{
+  "$schema": "https://schema.management.azure.com/schemas/2018-05-01/subscriptionDeploymentTemplate.json#",
+  "languageVersion": "2.0",
+  "contentVersion": "1.0.0.0",
+  "resources": {
+    "rg": {
+      "type": "Microsoft.Resources/resourceGroups",
+      "apiVersion": "2022-09-01",
+      "name": "acme-rg",
+      "location": "uksouth"
+    }
+  }
+}`
+	findings, err := ARMTemplateProcessor{}.Process("main.json", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "rg", findings[0].Name)
+	assert.Equal(t, "acme-rg", findings[0].Properties["resource_name"])
+	assert.Equal(t, "rg", findings[0].Properties["symbolic_name"])
+
+	findings, err = ARMTemplateProcessor{}.Process("package.json", "repo", `{"name": "app", "version": "1.0.0"}`)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
@@ -0,0 +1,129 @@
+package processors
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// AndroidManifest is the subset of AndroidManifest.xml used to report the
+// permissions an app requests and the SDK levels it supports.
+type AndroidManifest struct {
+	XMLName     xml.Name `xml:"manifest"`
+	Package     string   `xml:"package,attr"`
+	Permissions []struct {
+		Name   string `xml:"name,attr"`
+		MaxSdk string `xml:"maxSdkVersion,attr"`
+	} `xml:"uses-permission"`
+	SdkPermissions []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"uses-permission-sdk-23"`
+	UsesSdk struct {
+		MinSdk    string `xml:"minSdkVersion,attr"`
+		TargetSdk string `xml:"targetSdkVersion,attr"`
+		MaxSdk    string `xml:"maxSdkVersion,attr"`
+	} `xml:"uses-sdk"`
+	Features []struct {
+		Name     string `xml:"name,attr"`
+		Required string `xml:"required,attr"`
+	} `xml:"uses-feature"`
+}
+
+// AndroidManifestProcessor reports the permissions, features and SDK levels
+// declared in Android manifests.
+type AndroidManifestProcessor struct {
+}
+
+func (a AndroidManifestProcessor) Supports(filePath string) bool {
+	return filepath.Base(filePath) == "AndroidManifest.xml"
+}
+
+func (a AndroidManifestProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	var manifest AndroidManifest
+	if err := xml.Unmarshal([]byte(content), &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse Android manifest '%s': %w", path, err)
+	}
+
+	var matches []core.Finding
+	for _, permission := range manifest.Permissions {
+		properties := map[string]interface{}{}
+		if permission.MaxSdk != "" {
+			properties["MaxSdkVersion"] = permission.MaxSdk
+		}
+		if manifest.Package != "" {
+			properties["Package"] = manifest.Package
+		}
+		matches = append(matches, core.Finding{
+			Name:       permission.Name,
+			Type:       "Android Permission",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	for _, permission := range manifest.SdkPermissions {
+		properties := map[string]interface{}{
+			"MinSdkVersion": "23",
+		}
+		if manifest.Package != "" {
+			properties["Package"] = manifest.Package
+		}
+		matches = append(matches, core.Finding{
+			Name:       permission.Name,
+			Type:       "Android Permission",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	for _, feature := range manifest.Features {
+		if feature.Name == "" {
+			continue
+		}
+		matches = append(matches, core.Finding{
+			Name:     feature.Name,
+			Type:     "Android Feature",
+			Category: "",
+			Properties: map[string]interface{}{
+				"Required": feature.Required != "false",
+			},
+			Path:     path,
+			RepoName: repoName,
+		})
+	}
+
+	// Modern projects set SDK levels in Gradle, so <uses-sdk> is often absent.
+	sdk := manifest.UsesSdk
+	if sdk.MinSdk != "" || sdk.TargetSdk != "" {
+		version := sdk.TargetSdk
+		if version == "" {
+			version = sdk.MinSdk
+		}
+		properties := map[string]interface{}{
+			"Version": version,
+		}
+		if sdk.MinSdk != "" {
+			properties["MinSdkVersion"] = sdk.MinSdk
+		}
+		if sdk.TargetSdk != "" {
+			properties["TargetSdkVersion"] = sdk.TargetSdk
+		}
+		if sdk.MaxSdk != "" {
+			properties["MaxSdkVersion"] = sdk.MaxSdk
+		}
+		matches = append(matches, core.Finding{
+			Name:       "Android SDK",
+			Type:       "Runtime",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+
+	return matches, nil
+}
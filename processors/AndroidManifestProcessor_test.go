@@ -0,0 +1,54 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAndroidManifestProcessor_Supports(t *testing.T) {
+	processor := AndroidManifestProcessor{}
+
+	assert.True(t, processor.Supports("app/src/main/AndroidManifest.xml"))
+	assert.False(t, processor.Supports("app/src/main/res/values/strings.xml"))
+}
+
+func TestAndroidManifestProcessor_Process(t *testing.T) {
+	processor := AndroidManifestProcessor{}
+
+	content := `<?xml version="1.0" encoding="utf-8"?>
+<manifest xmlns:android="http://schemas.android.com/apk/res/android"
+    package="com.example.app">
+    <uses-sdk android:minSdkVersion="24" android:targetSdkVersion="34" />
+    <uses-permission android:name="android.permission.INTERNET" />
+    <uses-permission android:name="android.permission.READ_EXTERNAL_STORAGE" android:maxSdkVersion="32" />
+    <uses-permission-sdk-23 android:name="android.permission.CAMERA" />
+    <uses-feature android:name="android.hardware.camera" android:required="false" />
+    <application android:label="App" />
+</manifest>`
+
+	findings, err := processor.Process("app/src/main/AndroidManifest.xml", "repo", content)
+	require.NoError(t, err)
+
+	permissions := findingsOfType(findings, "Android Permission")
+	require.Len(t, permissions, 3)
+	assert.Equal(t, "com.example.app", permissions["android.permission.INTERNET"].Properties["Package"])
+	assert.Equal(t, "32", permissions["android.permission.READ_EXTERNAL_STORAGE"].Properties["MaxSdkVersion"])
+	assert.Equal(t, "23", permissions["android.permission.CAMERA"].Properties["MinSdkVersion"])
+
+	features := findingsOfType(findings, "Android Feature")
+	assert.Equal(t, false, features["android.hardware.camera"].Properties["Required"])
+
+	sdk := findingsOfType(findings, "Runtime")["Android SDK"]
+	assert.Equal(t, "34", sdk.Properties["Version"])
+	assert.Equal(t, "24", sdk.Properties["MinSdkVersion"])
+	assert.Equal(t, "34", sdk.Properties["TargetSdkVersion"])
+}
+
+func TestAndroidManifestProcessor_InvalidXML(t *testing.T) {
+	processor := AndroidManifestProcessor{}
+
+	_, err := processor.Process("AndroidManifest.xml", "repo", "<manifest")
+	assert.Error(t, err)
+}
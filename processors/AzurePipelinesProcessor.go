@@ -0,0 +1,241 @@
+package processors
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// azureRepository is an entry of resources.repositories.
+type azureRepository struct {
+	Repository string `yaml:"repository"`
+	Type       string `yaml:"type"`
+	Name       string `yaml:"name"`
+	Ref        string `yaml:"ref"`
+	Endpoint   string `yaml:"endpoint"`
+}
+
+// azurePipeline is the top level of an azure-pipelines.yml. Stages, jobs and
+// steps can also appear at the top level, so the rest is walked generically.
+type azurePipeline struct {
+	Resources struct {
+		Repositories []azureRepository `yaml:"repositories"`
+		Containers   []struct {
+			Container string `yaml:"container"`
+			Image     string `yaml:"image"`
+		} `yaml:"containers"`
+		Pipelines []struct {
+			Pipeline string `yaml:"pipeline"`
+			Source   string `yaml:"source"`
+			Project  string `yaml:"project"`
+		} `yaml:"pipelines"`
+	} `yaml:"resources"`
+}
+
+// azureWalker collects findings while walking the stages, jobs and steps of a
+// pipeline, tracking the stage and job each one belongs to.
+type azureWalker struct {
+	newFinding   ciFindingFunc
+	repositories map[string]azureRepository
+	containers   map[string]string
+	matches      []core.Finding
+	stages       int
+}
+
+func (w *azureWalker) context(stage string, job string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	if stage != "" {
+		properties["stage"] = stage
+	}
+	if job != "" {
+		properties["job"] = job
+	}
+	return properties
+}
+
+// walk visits a pipeline node. Keys carry meaning wherever they appear: a map
+// with "stage" is a stage, one with "job" or "deployment" is a job, and
+// "task", "template", "pool", "container" and "group" are reported as found.
+func (w *azureWalker) walk(value interface{}, stage string, job string) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			w.walk(item, stage, job)
+		}
+	case map[string]interface{}:
+		if name, ok := v["stage"].(string); ok {
+			stage = name
+			properties := w.context("", "")
+			properties["order"] = w.stages
+			w.stages++
+			w.matches = append(w.matches, w.newFinding(stage, ciStage, properties))
+		}
+		for _, key := range []string{"job", "deployment"} {
+			if name, ok := v[key].(string); ok {
+				job = name
+				properties := w.context(stage, "")
+				properties["kind"] = key
+				w.matches = append(w.matches, w.newFinding(job, ciJob, properties))
+			}
+		}
+		if task, ok := v["task"].(string); ok {
+			name, version, _ := strings.Cut(task, "@")
+			properties := w.context(stage, job)
+			properties["action"] = name
+			if version != "" {
+				properties["ref"] = version
+				properties["ref_type"] = "version"
+			}
+			w.matches = append(w.matches, w.newFinding(task, ciAction, properties))
+		}
+		if template, ok := v["template"].(string); ok {
+			w.addTemplate(template, stage, job)
+		}
+		if pool, ok := v["pool"]; ok {
+			w.addPool(pool, stage, job)
+		}
+		if container, ok := v["container"].(string); ok && job != "" {
+			// A job container is either a resources.containers alias or an image.
+			image := container
+			if aliased, ok := w.containers[container]; ok {
+				image = aliased
+			}
+			w.matches = append(w.matches, ciImageFinding(w.newFinding, image, job, "container"))
+		}
+		if group, ok := v["group"].(string); ok {
+			properties := w.context(stage, job)
+			properties["variable_group"] = true
+			w.matches = append(w.matches, w.newFinding(group, ciSecret, properties))
+		}
+		for _, key := range sortedKeys(v) {
+			switch key {
+			case "stages", "jobs", "steps", "variables", "extends", "strategy", "runOnce", "rolling", "canary", "deploy", "preDeploy", "postRouteTraffic", "on", "success", "failure":
+				w.walk(v[key], stage, job)
+			}
+		}
+	}
+}
+
+// addTemplate reports a template reference. "path@alias" templates come from
+// the repository resource with that alias.
+func (w *azureWalker) addTemplate(template string, stage string, job string) {
+	path, alias, _ := strings.Cut(template, "@")
+	properties := w.context(stage, job)
+	properties["include_type"] = "template"
+	properties["file"] = path
+	if alias != "" && alias != "self" {
+		properties["repository"] = alias
+		if repository, ok := w.repositories[alias]; ok {
+			properties["project"] = repository.Name
+			if repository.Ref != "" {
+				properties["ref"] = repository.Ref
+			}
+		}
+	}
+	w.matches = append(w.matches, w.newFinding(template, ciInclude, properties))
+}
+
+// addPool reports the agent pool of a pipeline, stage or job. Microsoft-hosted
+// pools are identified by vmImage, self-hosted ones by name and demands.
+func (w *azureWalker) addPool(pool interface{}, stage string, job string) {
+	properties := w.context(stage, job)
+	name := ""
+	switch p := pool.(type) {
+	case string:
+		name = p
+		properties["self_hosted"] = true
+	case map[string]interface{}:
+		if image, ok := p["vmImage"].(string); ok {
+			name = image
+			properties["self_hosted"] = false
+		} else if poolName, ok := p["name"].(string); ok {
+			name = poolName
+			properties["self_hosted"] = true
+		}
+		if poolName, ok := p["name"].(string); ok {
+			properties["pool"] = poolName
+		}
+		if demands := stringList(p["demands"]); len(demands) > 0 {
+			properties["demands"] = demands
+		}
+	}
+	if name != "" {
+		w.matches = append(w.matches, w.newFinding(name, ciRunner, properties))
+	}
+}
+
+// AzurePipelinesProcessor reports the pools, tasks, templates, resources,
+// stages and jobs of Azure Pipelines definitions.
+type AzurePipelinesProcessor struct {
+}
+
+func (a AzurePipelinesProcessor) Supports(filePath string) bool {
+	base := strings.ToLower(filepath.Base(filePath))
+	ext := filepath.Ext(base)
+	return strings.HasPrefix(base, "azure-pipelines") && (ext == ".yml" || ext == ".yaml")
+}
+
+func (a AzurePipelinesProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	var pipeline azurePipeline
+	var document map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &pipeline); err != nil {
+		return nil, fmt.Errorf("failed to parse Azure pipeline '%s': %w", path, err)
+	}
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		return nil, fmt.Errorf("failed to parse Azure pipeline '%s': %w", path, err)
+	}
+
+	name := filepath.Base(path)
+	walker := &azureWalker{
+		newFinding:   newCIFindingFunc("Azure Pipelines", name, path, repoName),
+		repositories: make(map[string]azureRepository),
+		containers:   make(map[string]string),
+	}
+	walker.matches = append(walker.matches, walker.newFinding(name, ciPipeline, map[string]interface{}{
+		"kind": "pipeline",
+	}))
+
+	for _, repository := range pipeline.Resources.Repositories {
+		walker.repositories[repository.Repository] = repository
+		properties := map[string]interface{}{
+			"include_type":    "repository",
+			"alias":           repository.Repository,
+			"repository_type": repository.Type,
+		}
+		if repository.Ref != "" {
+			properties["ref"] = repository.Ref
+		}
+		if repository.Endpoint != "" {
+			properties["endpoint"] = repository.Endpoint
+		}
+		walker.matches = append(walker.matches, walker.newFinding(repository.Name, ciInclude, properties))
+	}
+	for _, container := range pipeline.Resources.Containers {
+		walker.containers[container.Container] = container.Image
+		finding := ciImageFinding(walker.newFinding, container.Image, "", "resource")
+		finding.Properties["alias"] = container.Container
+		walker.matches = append(walker.matches, finding)
+	}
+	for _, upstream := range pipeline.Resources.Pipelines {
+		properties := map[string]interface{}{
+			"include_type": "pipeline",
+			"alias":        upstream.Pipeline,
+		}
+		if upstream.Project != "" {
+			properties["project"] = upstream.Project
+		}
+		walker.matches = append(walker.matches, walker.newFinding(upstream.Source, ciInclude, properties))
+	}
+
+	if pool, ok := document["pool"]; ok {
+		walker.addPool(pool, "", "")
+	}
+	for _, key := range []string{"extends", "variables", "stages", "jobs", "steps"} {
+		walker.walk(document[key], "", "")
+	}
+	return walker.matches, nil
+}
@@ -0,0 +1,121 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestAzurePipelinesProcessor_Supports(t *testing.T) {
+	processor := AzurePipelinesProcessor{}
+
+	assert.True(t, processor.Supports("azure-pipelines.yml"))
+	assert.True(t, processor.Supports("build/azure-pipelines-release.yaml"))
+	assert.False(t, processor.Supports("pipelines/build.yml"))
+}
+
+func TestAzurePipelinesProcessor_Process(t *testing.T) {
+	processor := AzurePipelinesProcessor{}
+
+	content := `trigger: [main]
+resources:
+  repositories:
+    - repository: templates
+      type: github
+      name: org/pipeline-templates
+      ref: refs/tags/v1.2.0
+      endpoint: github-connection
+  containers:
+    - container: sdk
+      image: mcr.microsoft.com/dotnet/sdk:8.0
+  pipelines:
+    - pipeline: upstream
+      source: Build-Core
+pool:
+  vmImage: ubuntu-latest
+variables:
+  - group: release-secrets
+  - template: vars/common.yml
+stages:
+  - stage: Build
+    jobs:
+      - job: compile
+        container: sdk
+        steps:
+          - task: UseDotNet@2
+          - task: DotNetCoreCLI@2
+          - template: steps/test.yml@templates
+  - stage: Deploy
+    jobs:
+      - deployment: release
+        pool:
+          name: OnPrem
+          demands: [docker]
+        strategy:
+          runOnce:
+            deploy:
+              steps:
+                - task: AzureWebApp@1
+`
+
+	findings, err := processor.Process("azure-pipelines.yml", "repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		assert.Equal(t, "Azure Pipelines", finding.Category)
+		byType[finding.Type] = append(byType[finding.Type], finding)
+	}
+
+	require.Len(t, byType["CI Pipeline"], 1)
+
+	stages := byType["CI Stage"]
+	require.Len(t, stages, 2)
+	assert.Equal(t, "Deploy", stages[1].Name)
+	assert.Equal(t, 1, stages[1].Properties["order"])
+
+	jobs := findingsByName(byType["CI Job"])
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "Build", jobs["compile"].Properties["stage"])
+	assert.Equal(t, "deployment", jobs["release"].Properties["kind"])
+
+	tasks := findingsByName(byType["CI Action"])
+	require.Len(t, tasks, 3)
+	assert.Equal(t, "UseDotNet", tasks["UseDotNet@2"].Properties["action"])
+	assert.Equal(t, "2", tasks["UseDotNet@2"].Properties["ref"])
+	assert.Equal(t, "release", tasks["AzureWebApp@1"].Properties["job"])
+	assert.Equal(t, "Deploy", tasks["AzureWebApp@1"].Properties["stage"])
+
+	includes := findingsByName(byType["CI Include"])
+	require.Len(t, includes, 4)
+	repository := includes["org/pipeline-templates"].Properties
+	assert.Equal(t, "repository", repository["include_type"])
+	assert.Equal(t, "refs/tags/v1.2.0", repository["ref"])
+	assert.Equal(t, "github", repository["repository_type"])
+	template := includes["steps/test.yml@templates"].Properties
+	assert.Equal(t, "template", template["include_type"])
+	assert.Equal(t, "org/pipeline-templates", template["project"])
+	assert.Equal(t, "refs/tags/v1.2.0", template["ref"])
+	assert.Equal(t, "template", includes["vars/common.yml"].Properties["include_type"])
+	assert.Equal(t, "pipeline", includes["Build-Core"].Properties["include_type"])
+
+	runners := findingsByName(byType["CI Runner"])
+	require.Len(t, runners, 2)
+	assert.Equal(t, false, runners["ubuntu-latest"].Properties["self_hosted"])
+	assert.Equal(t, true, runners["OnPrem"].Properties["self_hosted"])
+	assert.Equal(t, []string{"docker"}, runners["OnPrem"].Properties["demands"])
+
+	images := byType["CI Image"]
+	require.Len(t, images, 2)
+	assert.Equal(t, "resource", images[0].Properties["role"])
+	assert.Equal(t, "sdk", images[0].Properties["alias"])
+	assert.Equal(t, "mcr.microsoft.com/dotnet/sdk:8.0", images[1].Name)
+	assert.Equal(t, "compile", images[1].Properties["job"])
+
+	secrets := byType["CI Secret"]
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "release-secrets", secrets[0].Name)
+}
@@ -0,0 +1,347 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+var (
+	bicepResourceRe  = regexp.MustCompile(`^resource\s+(\w+)\s+'([^']+)'\s*(existing\s*)?=\s*(.*)$`)
+	bicepModuleRe    = regexp.MustCompile(`^module\s+(\w+)\s+'([^']+)'\s*=\s*(.*)$`)
+	bicepParamRe     = regexp.MustCompile(`^param\s+(\w+)\s+([^=]+?)\s*(?:=\s*(.*))?$`)
+	bicepDecoratorRe = regexp.MustCompile(`^@(?:sys\.)?(\w+)\s*\((.*)$`)
+	bicepPropertyRe  = regexp.MustCompile(`^(\w+)\s*:\s*(.+)$`)
+	bicepStringRe    = regexp.MustCompile(`^'([^'$]*)'$`)
+	bicepScopeRe     = regexp.MustCompile(`^targetScope\s*=\s*'([^']+)'`)
+)
+
+// bicepRegistryAliases are the module registry aliases Bicep defines itself.
+var bicepRegistryAliases = map[string]string{
+	"public": "mcr.microsoft.com/bicep",
+}
+
+// bicepLine is a line of Bicep source with comments removed and the depth of
+// braces open at its start.
+type bicepLine struct {
+	Text  string
+	Depth int
+}
+
+// bicepLines splits Bicep source into lines, skipping comments and tracking
+// brace depth outside strings. Interpolations inside strings are code, so
+// braces and strings within them are followed too. Multi-line strings are
+// kept on the line they start on.
+func bicepLines(content string) []bicepLine {
+	var lines []bicepLine
+	var text strings.Builder
+	depth, lineDepth := 0, 0
+	inString := false
+	var interpolations []int
+
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		if inString {
+			switch {
+			case ch == '\\' && i+1 < len(content):
+				text.WriteByte(ch)
+				i++
+				text.WriteByte(content[i])
+			case strings.HasPrefix(content[i:], "${"):
+				text.WriteString("${")
+				i++
+				inString = false
+				interpolations = append(interpolations, 0)
+			case ch == '\'':
+				text.WriteByte(ch)
+				inString = false
+			case ch == '\n':
+				// Strings cannot span lines; recover at the line end.
+				inString = false
+				i--
+			default:
+				text.WriteByte(ch)
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(content[i:], "//"):
+			for i+1 < len(content) && content[i+1] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end + 3
+			}
+		case strings.HasPrefix(content[i:], "'''"):
+			end := strings.Index(content[i+3:], "'''")
+			if end < 0 {
+				end = len(content) - i - 3
+			}
+			text.WriteString(strings.ReplaceAll(content[i:i+3+end], "\n", " "))
+			text.WriteString("'''")
+			i += end + 5
+		case ch == '\'':
+			text.WriteByte(ch)
+			inString = true
+		case ch == '{':
+			text.WriteByte(ch)
+			if n := len(interpolations); n > 0 {
+				interpolations[n-1]++
+			} else {
+				depth++
+			}
+		case ch == '}':
+			text.WriteByte(ch)
+			if n := len(interpolations); n > 0 {
+				if interpolations[n-1] == 0 {
+					interpolations = interpolations[:n-1]
+					inString = true
+				} else {
+					interpolations[n-1]--
+				}
+			} else {
+				depth--
+			}
+		case ch == '\n':
+			lines = append(lines, bicepLine{Text: strings.TrimSpace(text.String()), Depth: lineDepth})
+			text.Reset()
+			lineDepth = depth
+			interpolations = interpolations[:0]
+		default:
+			text.WriteByte(ch)
+		}
+	}
+	if text.Len() > 0 {
+		lines = append(lines, bicepLine{Text: strings.TrimSpace(text.String()), Depth: lineDepth})
+	}
+	return lines
+}
+
+// bicepLiteral returns the value of a literal string, number or boolean, and
+// false for any other expression.
+func bicepLiteral(expr string) (interface{}, bool) {
+	expr = strings.TrimSpace(expr)
+	if match := bicepStringRe.FindStringSubmatch(expr); match != nil {
+		return match[1], true
+	}
+	if expr == "true" || expr == "false" {
+		return expr == "true", true
+	}
+	if n, err := strconv.ParseInt(expr, 10, 64); err == nil {
+		return n, true
+	}
+	return nil, false
+}
+
+// parseBicepModuleSource classifies a module path: a local file, a module in
+// an OCI registry ("br:host/path:tag" or "br/alias:path:tag") or a template
+// spec ("ts:subscription/group/name:version" or "ts/alias:name:version").
+func parseBicepModuleSource(source string) map[string]interface{} {
+	props := map[string]interface{}{}
+	scheme, rest, ok := strings.Cut(source, ":")
+	kind, alias, _ := strings.Cut(scheme, "/")
+	if !ok || (kind != "br" && kind != "ts") {
+		props["source_type"] = "local"
+		return props
+	}
+
+	address, version := rest, ""
+	if idx := strings.LastIndex(rest, ":"); idx >= 0 {
+		address, version = rest[:idx], rest[idx+1:]
+	}
+	if kind == "br" {
+		props["source_type"] = "registry"
+		props["tag"] = version
+		if alias != "" {
+			props["alias"] = alias
+			props["path"] = address
+			if registry, ok := bicepRegistryAliases[alias]; ok {
+				props["registry"] = registry
+			}
+		} else {
+			registry, path, _ := strings.Cut(address, "/")
+			props["registry"] = registry
+			props["path"] = path
+		}
+		return props
+	}
+
+	props["source_type"] = "template_spec"
+	props["version"] = version
+	props["path"] = address
+	if alias != "" {
+		props["alias"] = alias
+	}
+	return props
+}
+
+// bicepResource is a resource declaration whose body is still open, so the
+// resources nested in it can take their type from it.
+type bicepResource struct {
+	Symbol     string
+	Type       string
+	APIVersion string
+	BodyDepth  int
+}
+
+// BicepProcessor reports the resources, modules and parameters declared in
+// Azure Bicep files.
+type BicepProcessor struct {
+}
+
+func (b BicepProcessor) Supports(filePath string) bool {
+	return strings.ToLower(filepath.Ext(filePath)) == ".bicep"
+}
+
+func (b BicepProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	newFinding := func(name string, findingType string, properties map[string]interface{}) core.Finding {
+		return core.Finding{
+			Name:       name,
+			Type:       findingType,
+			Category:   "Azure",
+			Properties: properties,
+			RepoName:   repoName,
+			Path:       path,
+		}
+	}
+
+	targetScope := "resourceGroup"
+	var matches []core.Finding
+	var open []*bicepResource
+	var body map[string]interface{}
+	bodyDepth := -1
+	decorators := map[string]string{}
+
+	for _, line := range bicepLines(content) {
+		for len(open) > 0 && line.Depth < open[len(open)-1].BodyDepth {
+			open = open[:len(open)-1]
+		}
+		if body != nil && line.Depth < bodyDepth {
+			body = nil
+		}
+		if line.Text == "" {
+			continue
+		}
+
+		// Decorators apply to the next declaration; an argument spanning
+		// lines, such as @allowed([...]), only has its first line kept.
+		if match := bicepDecoratorRe.FindStringSubmatch(line.Text); match != nil {
+			decorators[match[1]] = strings.TrimSuffix(strings.TrimSpace(match[2]), ")")
+			continue
+		}
+		pending := decorators
+		if bicepResourceRe.MatchString(line.Text) || bicepModuleRe.MatchString(line.Text) || bicepParamRe.MatchString(line.Text) {
+			decorators = map[string]string{}
+		}
+
+		if match := bicepScopeRe.FindStringSubmatch(line.Text); match != nil {
+			targetScope = match[1]
+			continue
+		}
+
+		if match := bicepResourceRe.FindStringSubmatch(line.Text); match != nil {
+			resourceType, apiVersion, _ := strings.Cut(match[2], "@")
+			properties := map[string]interface{}{
+				"symbolic_name": match[1],
+				"existing":      match[3] != "",
+			}
+			if len(open) > 0 && !strings.Contains(resourceType, ".") {
+				// A nested resource names its type relative to its parent and
+				// inherits the parent's API version unless it sets its own.
+				parent := open[len(open)-1]
+				resourceType = parent.Type + "/" + resourceType
+				if apiVersion == "" {
+					apiVersion = parent.APIVersion
+				}
+				properties["parent"] = parent.Symbol
+			}
+			properties["resource_type"] = resourceType
+			properties["api_version"] = apiVersion
+			if namespace, _, ok := strings.Cut(resourceType, "/"); ok {
+				properties["namespace"] = namespace
+			}
+			rest := strings.TrimSpace(match[4])
+			properties["conditional"] = strings.HasPrefix(rest, "if")
+			properties["loop"] = strings.HasPrefix(rest, "[")
+			if _, ok := pending["batchSize"]; ok {
+				properties["batch_size"] = pending["batchSize"]
+			}
+			matches = append(matches, newFinding(match[1], "Bicep Resource", properties))
+			open = append(open, &bicepResource{
+				Symbol:     match[1],
+				Type:       resourceType,
+				APIVersion: apiVersion,
+				BodyDepth:  line.Depth + 1,
+			})
+			body, bodyDepth = properties, line.Depth+1
+			continue
+		}
+
+		if match := bicepModuleRe.FindStringSubmatch(line.Text); match != nil {
+			properties := parseBicepModuleSource(match[2])
+			properties["module"] = match[1]
+			if properties["source_type"] == "local" {
+				_, err := os.Stat(filepath.Join(filepath.Dir(path), match[2]))
+				properties["resolved"] = err == nil
+			}
+			rest := strings.TrimSpace(match[3])
+			properties["conditional"] = strings.HasPrefix(rest, "if")
+			properties["loop"] = strings.HasPrefix(rest, "[")
+			matches = append(matches, newFinding(match[2], "Bicep Module", properties))
+			body, bodyDepth = properties, line.Depth+1
+			continue
+		}
+
+		if match := bicepParamRe.FindStringSubmatch(line.Text); match != nil && line.Depth == 0 {
+			properties := map[string]interface{}{
+				"type":   strings.TrimSpace(match[2]),
+				"secure": false,
+			}
+			if _, ok := pending["secure"]; ok {
+				properties["secure"] = true
+			}
+			if description, ok := bicepLiteral(pending["description"]); ok {
+				properties["description"] = description
+			}
+			if match[3] != "" {
+				if value, ok := bicepLiteral(match[3]); ok {
+					properties["default"] = value
+				} else {
+					properties["default"] = strings.TrimSpace(match[3])
+				}
+			}
+			matches = append(matches, newFinding(match[1], "Bicep Parameter", properties))
+			continue
+		}
+
+		// Literal top-level properties of a resource or module body, such as
+		// name, location and kind, are reported with the declaration.
+		if body != nil && line.Depth == bodyDepth {
+			if match := bicepPropertyRe.FindStringSubmatch(line.Text); match != nil {
+				key := match[1]
+				if _, taken := body[key]; taken {
+					continue
+				}
+				if value, ok := bicepLiteral(match[2]); ok {
+					body[key] = value
+				} else if key == "parent" || key == "scope" {
+					body[key] = strings.TrimSpace(match[2])
+				}
+			}
+		}
+	}
+
+	for i := range matches {
+		matches[i].Properties["target_scope"] = targetScope
+	}
+	return matches, nil
+}
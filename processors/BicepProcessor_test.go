@@ -0,0 +1,154 @@
+package processors
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestBicepProcessor_Process(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "modules", "network.bicep"), "param vnetName string\n")
+	path := filepath.Join(dir, "main.bicep")
+	content := `
+targetScope = 'resourceGroup'
+
+@description('Location for all resources')
+param location string = resourceGroup().location
+
+@secure()
+param adminPassword string
+
+@allowed([
+  'Standard_LRS'
+  'Standard_GRS'
+])
+param skuName string = 'Standard_LRS'
+
+/* Storage for
+   application assets */
+resource storage 'Microsoft.Storage/storageAccounts@2023-01-01' = {
+  name: 'acmeassets'
+  location: location
+  kind: 'StorageV2'
+  sku: {
+    name: skuName
+  }
+  properties: {
+    accessTier: 'Hot' // not a top-level property
+  }
+
+  resource blobs 'blobServices' = {
+    name: 'default'
+  }
+}
+
+resource vault 'Microsoft.KeyVault/vaults@2023-07-01' existing = {
+  name: 'acme-${location}-kv'
+}
+
+resource plans 'Microsoft.Web/serverfarms@2022-09-01' = [for i in range(0, 2): {
+  name: 'plan-${i}'
+}]
+
+resource diagnostics 'Microsoft.Insights/diagnosticSettings@2021-05-01-preview' = if (location == 'uksouth') {
+  name: 'diag'
+  scope: storage
+}
+
+module network './modules/network.bicep' = {
+  name: 'network'
+  params: {
+    vnetName: 'acme'
+  }
+}
+
+module avm 'br/public:avm/res/network/virtual-network:0.1.8' = {
+  name: 'avm'
+}
+
+module acr 'br:acme.azurecr.io/bicep/modules/storage:v1' = {
+  name: 'acr'
+}
+
+module spec 'ts:00000000-0000-0000-0000-000000000000/templates/webapp:2.0' = {
+  name: 'spec'
+}
+`
+
+	findings, err := BicepProcessor{}.Process(path, "repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		byType[finding.Type] = append(byType[finding.Type], finding)
+		assert.Equal(t, "Azure", finding.Category)
+		assert.Equal(t, "resourceGroup", finding.Properties["target_scope"])
+	}
+
+	resources := findingsByName(byType["Bicep Resource"])
+	require.Len(t, resources, 5)
+	storage := resources["storage"].Properties
+	assert.Equal(t, "Microsoft.Storage/storageAccounts", storage["resource_type"])
+	assert.Equal(t, "2023-01-01", storage["api_version"])
+	assert.Equal(t, "Microsoft.Storage", storage["namespace"])
+	assert.Equal(t, "acmeassets", storage["name"])
+	assert.Equal(t, "StorageV2", storage["kind"])
+	assert.Nil(t, storage["accessTier"])
+
+	blobs := resources["blobs"].Properties
+	assert.Equal(t, "Microsoft.Storage/storageAccounts/blobServices", blobs["resource_type"])
+	assert.Equal(t, "2023-01-01", blobs["api_version"])
+	assert.Equal(t, "storage", blobs["parent"])
+	assert.Equal(t, "default", blobs["name"])
+
+	assert.Equal(t, true, resources["vault"].Properties["existing"])
+	assert.Nil(t, resources["vault"].Properties["name"])
+	assert.Equal(t, true, resources["plans"].Properties["loop"])
+	assert.Equal(t, true, resources["diagnostics"].Properties["conditional"])
+	assert.Equal(t, "storage", resources["diagnostics"].Properties["scope"])
+
+	modules := findingsByName(byType["Bicep Module"])
+	require.Len(t, modules, 4)
+	assert.Equal(t, "local", modules["./modules/network.bicep"].Properties["source_type"])
+	assert.Equal(t, true, modules["./modules/network.bicep"].Properties["resolved"])
+	assert.Equal(t, "network", modules["./modules/network.bicep"].Properties["module"])
+	avm := modules["br/public:avm/res/network/virtual-network:0.1.8"].Properties
+	assert.Equal(t, "registry", avm["source_type"])
+	assert.Equal(t, "mcr.microsoft.com/bicep", avm["registry"])
+	assert.Equal(t, "avm/res/network/virtual-network", avm["path"])
+	assert.Equal(t, "0.1.8", avm["tag"])
+	acr := modules["br:acme.azurecr.io/bicep/modules/storage:v1"].Properties
+	assert.Equal(t, "acme.azurecr.io", acr["registry"])
+	assert.Equal(t, "bicep/modules/storage", acr["path"])
+	spec := modules["ts:00000000-0000-0000-0000-000000000000/templates/webapp:2.0"].Properties
+	assert.Equal(t, "template_spec", spec["source_type"])
+	assert.Equal(t, "2.0", spec["version"])
+
+	params := findingsByName(byType["Bicep Parameter"])
+	require.Len(t, params, 3)
+	assert.Equal(t, "Location for all resources", params["location"].Properties["description"])
+	assert.Equal(t, "resourceGroup().location", params["location"].Properties["default"])
+	assert.Equal(t, true, params["adminPassword"].Properties["secure"])
+	assert.Equal(t, "Standard_LRS", params["skuName"].Properties["default"])
+	assert.Equal(t, "string", params["skuName"].Properties["type"])
+}
+
+func TestBicepProcessor_TargetScope(t *testing.T) {
+	content := `targetScope = 'subscription'
+
+resource rg 'Microsoft.Resources/resourceGroups@2022-09-01' = {
+  name: 'acme-rg'
+  location: 'uksouth'
+}
+`
+	findings, err := BicepProcessor{}.Process("main.bicep", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "subscription", findings[0].Properties["target_scope"])
+	assert.Equal(t, "uksouth", findings[0].Properties["location"])
+}
@@ -0,0 +1,271 @@
+package processors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+var (
+	cdkTSNamespaceImportRe = regexp.MustCompile(`import\s+\*\s+as\s+(\w+)\s+from\s+['"]((?:aws-cdk-lib|@aws-cdk)/aws-[\w-]+)['"]`)
+	cdkTSNamedImportRe     = regexp.MustCompile(`import\s*\{([^}]*)\}\s*from\s*['"](aws-cdk-lib(?:/aws-[\w-]+)?|@aws-cdk/aws-[\w-]+)['"]`)
+	cdkTSRequireRe         = regexp.MustCompile(`(?:const|let|var)\s+(\w+)\s*=\s*require\(\s*['"]((?:aws-cdk-lib|@aws-cdk)/aws-[\w-]+)['"]\s*\)`)
+	cdkPythonFromImportRe  = regexp.MustCompile(`from\s+(aws_cdk(?:\.aws_\w+)?)\s+import\s+(\([^)]*\)|[^\n]+)`)
+	cdkPythonImportRe      = regexp.MustCompile(`import\s+(aws_cdk\.aws_\w+)(?:\s+as\s+(\w+))?`)
+	cdkJavaImportRe        = regexp.MustCompile(`import\s+(software\.amazon\.awscdk\.services\.[\w.]+?)\.([A-Z]\w*)\s*;`)
+	cdkGoImportRe          = regexp.MustCompile(`(?:(\w+)\s+)?"(github\.com/aws/aws-cdk-go/awscdk(?:/v2)?/(aws\w+))"`)
+	pythonCommentRe        = regexp.MustCompile(`#[^\n]*`)
+)
+
+// cdkConstructPatterns match the instantiation of a construct, whose first
+// argument is the scope and second its ID, in each language.
+var cdkConstructPatterns = map[string][]*regexp.Regexp{
+	"TypeScript": {regexp.MustCompile(`new\s+(?:(\w+)\.)?(\w+)\s*\(\s*\w+\s*,\s*(?:['"` + "`" + `]([^'"` + "`" + `]*)['"` + "`" + `]|\w+)`)},
+	"JavaScript": {regexp.MustCompile(`new\s+(?:(\w+)\.)?(\w+)\s*\(\s*\w+\s*,\s*(?:['"` + "`" + `]([^'"` + "`" + `]*)['"` + "`" + `]|\w+)`)},
+	"Python":     {regexp.MustCompile(`(?:\b(\w+)\.)?\b([A-Z]\w*)\s*\(\s*\w+\s*,\s*(?:['"]([^'"]*)['"]|\w+)`)},
+	"Java": {
+		regexp.MustCompile(`new\s+()(\w+)\s*\(\s*\w+\s*,\s*(?:"([^"]*)"|\w+)`),
+		regexp.MustCompile(`\b()(\w+)\.Builder\.create\s*\(\s*\w+\s*,\s*(?:"([^"]*)"|\w+)`),
+	},
+	"Go": {regexp.MustCompile(`\b(\w+)\.New(\w+)\s*\(\s*\w+\s*,\s*(?:jsii\.String\(\s*"([^"]*)"\s*\)|\w+)`)},
+}
+
+// cdkModule is a construct library module a program imports, such as
+// aws-cdk-lib/aws-s3, with the AWS service it is for.
+type cdkModule struct {
+	Import  string
+	Service string
+}
+
+// cdkImports are the modules a program imports, by the alias the program
+// refers to them with and by the classes it imports from them directly.
+type cdkImports struct {
+	Modules map[string]cdkModule
+	Aliases map[string]cdkModule
+	Classes map[string]cdkModule
+}
+
+func (c cdkImports) add(module cdkModule, alias string, classes ...string) {
+	c.Modules[module.Import] = module
+	if alias != "" {
+		c.Aliases[alias] = module
+	}
+	for _, class := range classes {
+		c.Classes[class] = module
+	}
+}
+
+// importedNames splits the names of a TypeScript or Python import list,
+// returning each name with the alias it is bound to.
+func importedNames(list string) [][2]string {
+	list = strings.Trim(strings.TrimSpace(pythonCommentRe.ReplaceAllString(list, "")), "()")
+	var names [][2]string
+	for _, item := range strings.Split(list, ",") {
+		fields := strings.Fields(strings.TrimSpace(item))
+		switch {
+		case len(fields) == 1:
+			names = append(names, [2]string{fields[0], fields[0]})
+		case len(fields) == 3 && fields[1] == "as":
+			names = append(names, [2]string{fields[0], fields[2]})
+		}
+	}
+	return names
+}
+
+// parseCDKImports reads the construct library imports of a program.
+func parseCDKImports(language string, content string) cdkImports {
+	imports := cdkImports{Modules: map[string]cdkModule{}, Aliases: map[string]cdkModule{}, Classes: map[string]cdkModule{}}
+	switch language {
+	case "TypeScript", "JavaScript":
+		tsModule := func(module string) cdkModule {
+			return cdkModule{Import: module, Service: strings.TrimPrefix(path.Base(module), "aws-")}
+		}
+		for _, match := range cdkTSNamespaceImportRe.FindAllStringSubmatch(content, -1) {
+			imports.add(tsModule(match[2]), match[1])
+		}
+		for _, match := range cdkTSRequireRe.FindAllStringSubmatch(content, -1) {
+			imports.add(tsModule(match[2]), match[1])
+		}
+		for _, match := range cdkTSNamedImportRe.FindAllStringSubmatch(content, -1) {
+			for _, name := range importedNames(match[1]) {
+				if match[2] != "aws-cdk-lib" {
+					imports.add(tsModule(match[2]), "", name[1])
+				} else if strings.HasPrefix(name[0], "aws_") {
+					// import { aws_s3 as s3 } from 'aws-cdk-lib'
+					imports.add(tsModule("aws-cdk-lib/"+strings.ReplaceAll(name[0], "_", "-")), name[1])
+				}
+			}
+		}
+	case "Python":
+		pyModule := func(module string) cdkModule {
+			return cdkModule{Import: module, Service: strings.TrimPrefix(module[strings.LastIndex(module, ".")+1:], "aws_")}
+		}
+		for _, match := range cdkPythonImportRe.FindAllStringSubmatch(content, -1) {
+			alias := match[2]
+			if alias == "" {
+				alias = match[1]
+			}
+			imports.add(pyModule(match[1]), alias)
+		}
+		for _, match := range cdkPythonFromImportRe.FindAllStringSubmatch(content, -1) {
+			for _, name := range importedNames(match[2]) {
+				if match[1] != "aws_cdk" {
+					imports.add(pyModule(match[1]), "", name[1])
+				} else if strings.HasPrefix(name[0], "aws_") {
+					imports.add(pyModule("aws_cdk."+name[0]), name[1])
+				}
+			}
+		}
+	case "Java":
+		for _, match := range cdkJavaImportRe.FindAllStringSubmatch(content, -1) {
+			module := cdkModule{Import: match[1], Service: strings.TrimPrefix(match[1], "software.amazon.awscdk.services.")}
+			imports.add(module, "", match[2])
+		}
+	case "Go":
+		for _, match := range cdkGoImportRe.FindAllStringSubmatch(content, -1) {
+			alias := match[1]
+			if alias == "" {
+				alias = match[3]
+			}
+			imports.add(cdkModule{Import: match[2], Service: strings.TrimPrefix(match[3], "aws")}, alias)
+		}
+	}
+	return imports
+}
+
+// cdkAppLanguage infers the language of a CDK app from the command cdk.json
+// runs it with.
+func cdkAppLanguage(app string) string {
+	switch {
+	case strings.Contains(app, "ts-node") || strings.Contains(app, ".ts"):
+		return "TypeScript"
+	case strings.Contains(app, "python"):
+		return "Python"
+	case strings.Contains(app, "mvn") || strings.Contains(app, "gradle") || strings.Contains(app, "java"):
+		return "Java"
+	case strings.HasPrefix(app, "go ") || strings.Contains(app, "go run"):
+		return "Go"
+	case strings.Contains(app, "dotnet"):
+		return "C#"
+	case strings.Contains(app, "node"):
+		return "JavaScript"
+	}
+	return ""
+}
+
+// CDKProcessor reports AWS CDK apps from cdk.json, and the construct library
+// modules programs import and the constructs they instantiate, in
+// TypeScript, JavaScript, Python, Java and Go. Constructs are mapped to the
+// vendors and services of Terraform resources through the provider table.
+type CDKProcessor struct {
+	Providers []TerraformProvider
+}
+
+// NewCDKProcessor creates a CDKProcessor that names services from the
+// provider tables in f.
+func NewCDKProcessor(f fs.FS) *CDKProcessor {
+	providers, err := LoadTerraformProviders(f)
+	if err != nil {
+		log.Printf("Failed to load Terraform providers: %v", err)
+	}
+	return &CDKProcessor{Providers: providers}
+}
+
+func (c CDKProcessor) Supports(filePath string) bool {
+	if filepath.Base(filePath) == "cdk.json" {
+		return true
+	}
+	_, ok := infrastructureCodeLanguage(filePath)
+	return ok
+}
+
+func (c CDKProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	if filepath.Base(path) == "cdk.json" {
+		return c.processApp(path, repoName, content)
+	}
+	language, _ := infrastructureCodeLanguage(path)
+	// Programs using the CDK name it in their imports.
+	if !strings.Contains(content, "aws-cdk") && !strings.Contains(content, "aws_cdk") && !strings.Contains(content, "awscdk") {
+		return nil, nil
+	}
+	imports := parseCDKImports(language, content)
+
+	var matches []core.Finding
+	for _, name := range sortedKeys(imports.Modules) {
+		module := imports.Modules[name]
+		props, _, _ := terraformTypeProperties(c.Providers, terraformEquivalentType("aws", module.Service, ""))
+		props["language"] = language
+		matches = append(matches, core.Finding{
+			Name:       module.Import,
+			Type:       "CDK Module",
+			Category:   "CDK",
+			Properties: props,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+
+	for _, construct := range findCodeConstructs(content, cdkConstructPatterns[language]) {
+		module, ok := imports.Classes[construct.Class]
+		if construct.Qualifier != "" {
+			module, ok = imports.Aliases[construct.Qualifier]
+		}
+		if !ok {
+			continue
+		}
+		props := map[string]interface{}{
+			"construct": construct.Class,
+			"module":    module.Import,
+			"language":  language,
+		}
+		if construct.ID != "" {
+			props["construct_id"] = construct.ID
+		}
+		terraformType := terraformEquivalentType("aws", module.Service, construct.Class)
+		matches = append(matches, infrastructureCodeFinding(c.Providers, "CDK", terraformType, props, path, repoName))
+	}
+	return matches, nil
+}
+
+// processApp reports the app cdk.json describes and the feature flags it
+// sets in its context.
+func (c CDKProcessor) processApp(path string, repoName string, content string) ([]core.Finding, error) {
+	var config struct {
+		App     string                 `json:"app"`
+		Context map[string]interface{} `json:"context"`
+	}
+	if err := json.Unmarshal([]byte(content), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	props := map[string]interface{}{
+		"app": config.App,
+	}
+	if language := cdkAppLanguage(config.App); language != "" {
+		props["language"] = language
+	}
+	var flags []string
+	for _, key := range sortedKeys(config.Context) {
+		if strings.HasPrefix(key, "@aws-cdk") {
+			flags = append(flags, key)
+		}
+	}
+	if len(flags) > 0 {
+		props["feature_flags"] = flags
+	}
+	return []core.Finding{{
+		Name:       "CDK App",
+		Type:       "CDK App",
+		Category:   "CDK",
+		Properties: props,
+		Path:       path,
+		RepoName:   repoName,
+	}}, nil
+}
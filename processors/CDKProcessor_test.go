@@ -0,0 +1,185 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// cdkResources returns the CDK resources among findings keyed by construct ID.
+func cdkResources(t *testing.T, findings []core.Finding) map[string]core.Finding {
+	t.Helper()
+	resources := make(map[string]core.Finding)
+	for _, finding := range findingsByType(findings, "CDK Resource Use") {
+		resources[finding.Properties["construct_id"].(string)] = finding
+	}
+	return resources
+}
+
+func TestCDKProcessor_TypeScript(t *testing.T) {
+	content := `import * as cdk from 'aws-cdk-lib';
+import { Stack, StackProps, aws_sqs as sqs } from 'aws-cdk-lib';
+import * as s3 from 'aws-cdk-lib/aws-s3';
+import * as apigw from 'aws-cdk-lib/aws-apigateway';
+import { Function, Runtime, Code } from 'aws-cdk-lib/aws-lambda';
+import { Construct } from 'constructs';
+
+export class ApiStack extends Stack {
+  constructor(scope: Construct, id: string, props?: StackProps) {
+    super(scope, id, props);
+
+    const bucket = new s3.Bucket(this, 'Assets', {
+      encryption: s3.BucketEncryption.S3_MANAGED,
+    });
+    const queue = new sqs.Queue(this, "Jobs");
+    const handler = new Function(
+      this,
+      'Handler',
+      { runtime: Runtime.NODEJS_20_X, code: Code.fromAsset('lambda'), handler: 'index.handler' },
+    );
+    new apigw.LambdaRestApi(this, 'Api', { handler });
+    new cdk.CfnOutput(this, 'BucketName', { value: bucket.bucketName });
+  }
+}
+`
+	processor := NewCDKProcessor(terraformFS)
+	assert.True(t, processor.Supports("lib/api-stack.ts"))
+	assert.False(t, processor.Supports("node_modules/aws-cdk-lib/index.d.ts"))
+
+	findings, err := processor.Process("lib/api-stack.ts", "repo", content)
+	require.NoError(t, err)
+
+	modules := findingsOfType(findings, "CDK Module")
+	require.Len(t, modules, 4)
+	assert.Equal(t, "Amazon S3", modules["aws-cdk-lib/aws-s3"].Properties["service"])
+	assert.Equal(t, "Amazon SQS", modules["aws-cdk-lib/aws-sqs"].Properties["service"])
+	assert.Equal(t, "TypeScript", modules["aws-cdk-lib/aws-lambda"].Properties["language"])
+
+	resources := cdkResources(t, findings)
+	require.Len(t, resources, 4)
+	assert.Equal(t, "AWS Resource", resources["Assets"].Name)
+	assert.Equal(t, "Bucket", resources["Assets"].Properties["construct"])
+	assert.Equal(t, "aws-cdk-lib/aws-s3", resources["Assets"].Properties["module"])
+	assert.Equal(t, "Amazon S3", resources["Assets"].Properties["service"])
+	assert.Equal(t, "AWS", resources["Assets"].Properties["vendor"])
+	assert.Equal(t, "Amazon SQS", resources["Jobs"].Properties["service"])
+	assert.Equal(t, "Function", resources["Handler"].Properties["construct"])
+	assert.Equal(t, "AWS Lambda", resources["Handler"].Properties["service"])
+	assert.Equal(t, "Amazon API Gateway", resources["Api"].Properties["service"])
+}
+
+func TestCDKProcessor_Python(t *testing.T) {
+	content := `from aws_cdk import (
+    Stack,
+    aws_dynamodb as dynamodb,  # tables
+    aws_lambda as _lambda,
+)
+import aws_cdk.aws_sns as sns
+from aws_cdk.aws_ec2 import Vpc, SubnetConfiguration
+
+class DataStack(Stack):
+    def __init__(self, scope, construct_id, **kwargs):
+        super().__init__(scope, construct_id, **kwargs)
+        table = dynamodb.Table(self, "Orders", partition_key=dynamodb.Attribute(name="id", type=dynamodb.AttributeType.STRING))
+        fn = _lambda.Function(self, "Processor", runtime=_lambda.Runtime.PYTHON_3_12, handler="app.handler", code=_lambda.Code.from_asset("src"))
+        sns.Topic(self, "Events")
+        Vpc(self, "Network", subnet_configuration=[SubnetConfiguration(name="private", subnet_type=None)])
+`
+	findings, err := NewCDKProcessor(terraformFS).Process("stacks/data_stack.py", "repo", content)
+	require.NoError(t, err)
+
+	assert.Len(t, findingsOfType(findings, "CDK Module"), 4)
+	resources := cdkResources(t, findings)
+	require.Len(t, resources, 4)
+	assert.Equal(t, "Amazon DynamoDB", resources["Orders"].Properties["service"])
+	assert.Equal(t, "aws_cdk.aws_dynamodb", resources["Orders"].Properties["module"])
+	assert.Equal(t, "AWS Lambda", resources["Processor"].Properties["service"])
+	assert.Equal(t, "Amazon SNS", resources["Events"].Properties["service"])
+	assert.Equal(t, "Vpc", resources["Network"].Properties["construct"])
+	assert.Equal(t, "Python", resources["Network"].Properties["language"])
+}
+
+func TestCDKProcessor_JavaAndGo(t *testing.T) {
+	java := `package com.acme;
+
+import software.amazon.awscdk.Stack;
+import software.amazon.awscdk.services.s3.Bucket;
+import software.amazon.awscdk.services.lambda.Function;
+
+public class AppStack extends Stack {
+    public AppStack(final Construct scope, final String id) {
+        super(scope, id);
+        Bucket bucket = new Bucket(this, "Assets");
+        Function fn = Function.Builder.create(this, "Handler").build();
+    }
+}
+`
+	findings, err := NewCDKProcessor(terraformFS).Process("src/main/java/com/acme/AppStack.java", "repo", java)
+	require.NoError(t, err)
+	resources := cdkResources(t, findings)
+	require.Len(t, resources, 2)
+	assert.Equal(t, "Amazon S3", resources["Assets"].Properties["service"])
+	assert.Equal(t, "AWS Lambda", resources["Handler"].Properties["service"])
+
+	golang := `package main
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	ddb "github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func NewAppStack(scope constructs.Construct, id string) awscdk.Stack {
+	stack := awscdk.NewStack(scope, &id, nil)
+	awssqs.NewQueue(stack, jsii.String("Jobs"), &awssqs.QueueProps{})
+	ddb.NewTable(stack, jsii.String("Orders"), &ddb.TableProps{})
+	return stack
+}
+`
+	findings, err = NewCDKProcessor(terraformFS).Process("app.go", "repo", golang)
+	require.NoError(t, err)
+	assert.Len(t, findingsOfType(findings, "CDK Module"), 2)
+	resources = cdkResources(t, findings)
+	require.Len(t, resources, 2)
+	assert.Equal(t, "Amazon SQS", resources["Jobs"].Properties["service"])
+	assert.Equal(t, "Amazon DynamoDB", resources["Orders"].Properties["service"])
+	assert.Equal(t, "github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb", resources["Orders"].Properties["module"])
+}
+
+func TestCDKProcessor_App(t *testing.T) {
+	content := `{
+  "app": "npx ts-node --prefer-ts-exts bin/app.ts",
+  "watch": { "include": ["**"] },
+  "context": {
+    "@aws-cdk/aws-lambda:recognizeLayerVersion": true,
+    "@aws-cdk/core:checkSecretUsage": true,
+    "environment": "prod"
+  }
+}`
+	processor := NewCDKProcessor(terraformFS)
+	assert.True(t, processor.Supports("infra/cdk.json"))
+
+	findings, err := processor.Process("infra/cdk.json", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "CDK App", findings[0].Type)
+	assert.Equal(t, "TypeScript", findings[0].Properties["language"])
+	assert.Equal(t, []string{"@aws-cdk/aws-lambda:recognizeLayerVersion", "@aws-cdk/core:checkSecretUsage"}, findings[0].Properties["feature_flags"])
+
+	findings, err = processor.Process("src/index.ts", "repo", "import express from 'express';\nnew Server(app, 'x');\n")
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestSnakeCase(t *testing.T) {
+	assert.Equal(t, "bucket", snakeCase("Bucket"))
+	assert.Equal(t, "database_instance", snakeCase("DatabaseInstance"))
+	assert.Equal(t, "http_api", snakeCase("HTTPApi"))
+	assert.Equal(t, "bucket_v2", snakeCase("BucketV2"))
+	assert.Equal(t, "aws_api_gateway_rest_api", terraformEquivalentType("aws", "apigateway", "RestApi"))
+	assert.Equal(t, "aws_s3", terraformEquivalentType("aws", "s3", ""))
+}
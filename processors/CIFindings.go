@@ -0,0 +1,49 @@
+package processors
+
+import "github.com/reaandrew/techdetector/core"
+
+// CI processors share one taxonomy so the same queries work across platforms.
+// The category is the CI platform and the type is one of these.
+const (
+	ciPipeline   = "CI Pipeline"
+	ciStage      = "CI Stage"
+	ciJob        = "CI Job"
+	ciAction     = "CI Action"
+	ciInclude    = "CI Include"
+	ciImage      = "CI Image"
+	ciRunner     = "CI Runner"
+	ciTool       = "CI Tool"
+	ciPermission = "CI Permission"
+	ciSecret     = "CI Secret"
+)
+
+// ciFindingFunc builds a finding of the given CI type for one pipeline file.
+type ciFindingFunc func(name string, findingType string, properties map[string]interface{}) core.Finding
+
+// newCIFindingFunc returns a ciFindingFunc that tags every finding with the
+// platform and the pipeline it came from.
+func newCIFindingFunc(platform string, pipeline string, path string, repoName string) ciFindingFunc {
+	return func(name string, findingType string, properties map[string]interface{}) core.Finding {
+		properties["pipeline"] = pipeline
+		return core.Finding{
+			Name:       name,
+			Type:       findingType,
+			Category:   platform,
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		}
+	}
+}
+
+// ciImageFinding reports a container image used by a job, split into its parts.
+func ciImageFinding(newFinding ciFindingFunc, image string, job string, role string) core.Finding {
+	properties := parseImageReference(image).properties()
+	if job != "" {
+		properties["job"] = job
+	}
+	if role != "" {
+		properties["role"] = role
+	}
+	return newFinding(image, ciImage, properties)
+}
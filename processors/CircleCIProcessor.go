@@ -0,0 +1,214 @@
+package processors
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// circleciSemverRe matches a fully specified orb version, the only form that
+// does not float to newer releases.
+var circleciSemverRe = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// circleciExecutor holds the fields shared by executors and jobs that decide
+// where a job runs.
+type circleciExecutor struct {
+	Docker []struct {
+		Image string `yaml:"image"`
+	} `yaml:"docker"`
+	Machine interface{} `yaml:"machine"`
+	MacOS   *struct {
+		Xcode string `yaml:"xcode"`
+	} `yaml:"macos"`
+	ResourceClass string `yaml:"resource_class"`
+}
+
+// circleciJob is a job definition; executor is either a name or {name: ...}.
+type circleciJob struct {
+	circleciExecutor `yaml:",inline"`
+	Executor         interface{} `yaml:"executor"`
+}
+
+// circleciConfig is a .circleci/config.yml.
+type circleciConfig struct {
+	Version   interface{}                 `yaml:"version"`
+	Setup     bool                        `yaml:"setup"`
+	Orbs      map[string]interface{}      `yaml:"orbs"`
+	Executors map[string]circleciExecutor `yaml:"executors"`
+	Jobs      map[string]circleciJob      `yaml:"jobs"`
+	Workflows map[string]interface{}      `yaml:"workflows"`
+}
+
+// circleciWorkflowJobs returns the names of the jobs a workflow runs. Entries
+// are either a job name or a single-key map of the job name to its options.
+func circleciWorkflowJobs(workflow interface{}) []string {
+	fields, ok := workflow.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	entries, _ := fields["jobs"].([]interface{})
+	var jobs []string
+	for _, entry := range entries {
+		switch e := entry.(type) {
+		case string:
+			jobs = append(jobs, e)
+		case map[string]interface{}:
+			for name := range e {
+				jobs = append(jobs, name)
+			}
+		}
+	}
+	return jobs
+}
+
+// CircleCIProcessor reports the orbs, executors, images, jobs and workflows of
+// CircleCI configurations.
+type CircleCIProcessor struct {
+}
+
+func (c CircleCIProcessor) Supports(filePath string) bool {
+	base := filepath.Base(filePath)
+	return filepath.Base(filepath.Dir(filePath)) == ".circleci" && (base == "config.yml" || base == "config.yaml")
+}
+
+func (c CircleCIProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	var config circleciConfig
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse CircleCI config '%s': %w", path, err)
+	}
+
+	name := filepath.Base(path)
+	newFinding := newCIFindingFunc("CircleCI", name, path, repoName)
+
+	workflows := sortedKeys(config.Workflows)
+	// The "version" key of the workflows map is the legacy 2.0 syntax version.
+	workflowNames := make([]string, 0, len(workflows))
+	jobWorkflows := make(map[string][]string)
+	for _, workflow := range workflows {
+		if workflow == "version" {
+			continue
+		}
+		workflowNames = append(workflowNames, workflow)
+		for _, job := range circleciWorkflowJobs(config.Workflows[workflow]) {
+			jobWorkflows[job] = append(jobWorkflows[job], workflow)
+		}
+	}
+	matches := []core.Finding{newFinding(name, ciPipeline, map[string]interface{}{
+		"kind":      "config",
+		"version":   fmt.Sprint(config.Version),
+		"setup":     config.Setup,
+		"workflows": workflowNames,
+	})}
+
+	for _, alias := range sortedKeys(config.Orbs) {
+		orb, ok := config.Orbs[alias].(string)
+		if !ok {
+			// Inline orbs are defined in the config itself.
+			continue
+		}
+		reference, version, _ := strings.Cut(orb, "@")
+		namespace, orbName, _ := strings.Cut(reference, "/")
+		refType := "range"
+		switch {
+		case version == "volatile":
+			refType = "volatile"
+		case circleciSemverRe.MatchString(version):
+			refType = "tag"
+		}
+		matches = append(matches, newFinding(orb, ciAction, map[string]interface{}{
+			"alias":    alias,
+			"owner":    namespace,
+			"repo":     orbName,
+			"action":   reference,
+			"ref":      version,
+			"ref_type": refType,
+			"pinned":   refType == "tag",
+		}))
+	}
+
+	for _, executor := range sortedKeys(config.Executors) {
+		matches = append(matches, circleciExecutorFindings(newFinding, config.Executors[executor], "", executor)...)
+	}
+
+	for _, job := range sortedKeys(config.Jobs) {
+		definition := config.Jobs[job]
+		properties := map[string]interface{}{}
+		if workflows := jobWorkflows[job]; len(workflows) > 0 {
+			properties["workflows"] = workflows
+		}
+		executor := ""
+		switch e := definition.Executor.(type) {
+		case string:
+			executor = e
+		case map[string]interface{}:
+			executor = fmt.Sprint(e["name"])
+		}
+		if executor != "" {
+			properties["executor"] = executor
+		}
+		if definition.ResourceClass != "" {
+			properties["resource_class"] = definition.ResourceClass
+		}
+		matches = append(matches, newFinding(job, ciJob, properties))
+		matches = append(matches, circleciExecutorFindings(newFinding, definition.circleciExecutor, job, "")...)
+	}
+
+	return matches, nil
+}
+
+// circleciExecutorFindings reports the images and machines of an executor or
+// of a job that declares its own.
+func circleciExecutorFindings(newFinding ciFindingFunc, executor circleciExecutor, job string, name string) []core.Finding {
+	var matches []core.Finding
+	withExecutor := func(finding core.Finding) core.Finding {
+		if name != "" {
+			finding.Properties["executor"] = name
+		}
+		if executor.ResourceClass != "" {
+			finding.Properties["resource_class"] = executor.ResourceClass
+		}
+		return finding
+	}
+
+	for i, image := range executor.Docker {
+		role := "service"
+		if i == 0 {
+			// The first image is where steps run; the rest are service containers.
+			role = "container"
+		}
+		matches = append(matches, withExecutor(ciImageFinding(newFinding, image.Image, job, role)))
+	}
+
+	machine := ""
+	switch m := executor.Machine.(type) {
+	case bool:
+		if m {
+			machine = "machine"
+		}
+	case map[string]interface{}:
+		machine = "machine"
+		if image, ok := m["image"].(string); ok {
+			machine = image
+		}
+	}
+	if machine != "" {
+		properties := map[string]interface{}{"kind": "machine"}
+		if job != "" {
+			properties["job"] = job
+		}
+		matches = append(matches, withExecutor(newFinding(machine, ciRunner, properties)))
+	}
+	if executor.MacOS != nil {
+		properties := map[string]interface{}{"kind": "macos", "xcode": executor.MacOS.Xcode}
+		if job != "" {
+			properties["job"] = job
+		}
+		matches = append(matches, withExecutor(newFinding("macos", ciRunner, properties)))
+	}
+	return matches
+}
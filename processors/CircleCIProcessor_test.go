@@ -0,0 +1,96 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestCircleCIProcessor_Supports(t *testing.T) {
+	processor := CircleCIProcessor{}
+
+	assert.True(t, processor.Supports(".circleci/config.yml"))
+	assert.True(t, processor.Supports("repo/.circleci/config.yaml"))
+	assert.False(t, processor.Supports("config.yml"))
+}
+
+func TestCircleCIProcessor_Process(t *testing.T) {
+	processor := CircleCIProcessor{}
+
+	content := `version: 2.1
+orbs:
+  node: circleci/node@5.1.0
+  aws-cli: circleci/aws-cli@4
+  slack: circleci/slack@volatile
+executors:
+  python:
+    docker:
+      - image: cimg/python:3.12
+      - image: cimg/postgres:16.1
+    resource_class: large
+jobs:
+  test:
+    executor: python
+    steps: [checkout]
+  build:
+    machine:
+      image: ubuntu-2204:2024.01.1
+    steps: [checkout]
+  ios:
+    macos:
+      xcode: 15.2.0
+    steps: [checkout]
+workflows:
+  main:
+    jobs:
+      - test
+      - build:
+          requires: [test]
+      - node/test
+`
+
+	findings, err := processor.Process(".circleci/config.yml", "repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		assert.Equal(t, "CircleCI", finding.Category)
+		byType[finding.Type] = append(byType[finding.Type], finding)
+	}
+
+	require.Len(t, byType["CI Pipeline"], 1)
+	assert.Equal(t, []string{"main"}, byType["CI Pipeline"][0].Properties["workflows"])
+	assert.Equal(t, "2.1", byType["CI Pipeline"][0].Properties["version"])
+
+	orbs := findingsByName(byType["CI Action"])
+	require.Len(t, orbs, 3)
+	node := orbs["circleci/node@5.1.0"].Properties
+	assert.Equal(t, "circleci", node["owner"])
+	assert.Equal(t, "node", node["repo"])
+	assert.Equal(t, "tag", node["ref_type"])
+	assert.Equal(t, true, node["pinned"])
+	assert.Equal(t, "range", orbs["circleci/aws-cli@4"].Properties["ref_type"])
+	assert.Equal(t, "volatile", orbs["circleci/slack@volatile"].Properties["ref_type"])
+
+	images := findingsByName(byType["CI Image"])
+	require.Len(t, images, 2)
+	python := images["cimg/python:3.12"].Properties
+	assert.Equal(t, "container", python["role"])
+	assert.Equal(t, "python", python["executor"])
+	assert.Equal(t, "large", python["resource_class"])
+	assert.Equal(t, "service", images["cimg/postgres:16.1"].Properties["role"])
+
+	jobs := findingsByName(byType["CI Job"])
+	require.Len(t, jobs, 3)
+	assert.Equal(t, "python", jobs["test"].Properties["executor"])
+	assert.Equal(t, []string{"main"}, jobs["build"].Properties["workflows"])
+	assert.NotContains(t, jobs["ios"].Properties, "workflows")
+
+	runners := findingsByName(byType["CI Runner"])
+	require.Len(t, runners, 2)
+	assert.Equal(t, "build", runners["ubuntu-2204:2024.01.1"].Properties["job"])
+	assert.Equal(t, "15.2.0", runners["macos"].Properties["xcode"])
+}
@@ -1,6 +1,10 @@
 package processors
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -8,6 +12,21 @@ import (
 	"github.com/reaandrew/techdetector/core"
 )
 
+// maxDeploymentManagerNestingDepth bounds how deep templates using other
+// templates are followed.
+const maxDeploymentManagerNestingDepth = 5
+
+// deploymentManagerTypeRe matches the literal type keys in a template that
+// could not be rendered, such as "type: compute.v1.instance" in Jinja or
+// "'type': 'storage.v1.bucket'" in Python.
+var deploymentManagerTypeRe = regexp.MustCompile(`['"]?\btype['"]?\s*:\s*['"]?([A-Za-z][\w.-]*(?:/[\w.-]+)?(?::[\w./-]+)?)['"]?`)
+
+// deploymentManagerResourceTypeRe matches the values of type keys that are
+// resource types: "<api>.v<N>.<kind>", "<project>/<type provider>:<collection>"
+// (including gcp-types/...) or a template. Other type keys, such as a
+// disk's "type: PERSISTENT", are properties of a resource.
+var deploymentManagerResourceTypeRe = regexp.MustCompile(`^(?:[a-z][\w-]*\.(?:v\d+\w*|alpha|beta)\.[A-Za-z][\w.]*|[\w.-]+/[\w.-]+:[\w./-]+|[\w./-]+\.(?:jinja|py))$`)
+
 // CloudDeploymentManagerTemplate is a simplified representation of a Cloud Deployment Manager template.
 // Typically, you’ll see keys: "imports", "resources", etc.
 type CloudDeploymentManagerTemplate struct {
@@ -26,7 +45,18 @@ type CDMResource struct {
 	Properties map[string]interface{} `yaml:"properties,omitempty"`
 }
 
+// cdmTemplateSchema is the part of a template's .schema file that gives
+// defaults for the properties the template reads.
+type cdmTemplateSchema struct {
+	Properties map[string]struct {
+		Default interface{} `yaml:"default"`
+	} `yaml:"properties"`
+}
+
 // CloudDeploymentManagerProcessor attempts to detect and parse GCP Cloud Deployment Manager templates.
+// Resources whose type is a Jinja template the configuration imports are
+// rendered with their properties, and the resources the template defines
+// are attributed to the configuration.
 type CloudDeploymentManagerProcessor struct{}
 
 // Supports checks file extensions that are commonly used for Deployment Manager templates.
@@ -40,6 +70,14 @@ func (p CloudDeploymentManagerProcessor) Supports(filePath string) bool {
 // Process tries to parse the file as YAML (common for Deployment Manager).
 // If "resources" is present and non-empty, we produce a Finding for each resource.
 func (p CloudDeploymentManagerProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	if strings.HasSuffix(strings.ToLower(path), ".jinja") {
+		// A template on its own is rendered without properties, so that
+		// template syntax does not stop it parsing.
+		if rendered, _, err := renderJinja(content, deploymentManagerContext(path, "", "", nil)); err == nil {
+			content = rendered
+		}
+	}
+
 	var template CloudDeploymentManagerTemplate
 	err := yaml.Unmarshal([]byte(content), &template)
 	if err != nil {
@@ -52,9 +90,26 @@ func (p CloudDeploymentManagerProcessor) Process(path string, repoName string, c
 		return nil, nil
 	}
 
+	// Resources name a template by its import name, which defaults to its
+	// file name, or by its path.
+	imports := make(map[string]CDMImport)
+	for _, imported := range template.Imports {
+		imports[imported.Path] = imported
+		imports[imported.Path[strings.LastIndex(imported.Path, "/")+1:]] = imported
+		if imported.Name != "" {
+			imports[imported.Name] = imported
+		}
+	}
+	return p.resourceFindings(path, repoName, template.Resources, imports, map[string]bool{}), nil
+}
+
+// resourceFindings reports resources, followed by the resources of the
+// templates each one uses. imports are the configuration's imports by name
+// and path, and visited holds the templates being expanded.
+func (p CloudDeploymentManagerProcessor) resourceFindings(path string, repoName string, resources []CDMResource, imports map[string]CDMImport, visited map[string]bool) []core.Finding {
 	var findings []core.Finding
 
-	for _, resource := range template.Resources {
+	for _, resource := range resources {
 		finding := core.Finding{
 			Name:     resource.Name,
 			Type:     "Deployment Manager Resource",
@@ -67,7 +122,125 @@ func (p CloudDeploymentManagerProcessor) Process(path string, repoName string, c
 			RepoName: repoName,
 		}
 		findings = append(findings, finding)
+
+		if imported, ok := imports[resource.Type]; ok {
+			findings = append(findings, p.templateFindings(path, repoName, resource, imported, finding.Properties, imports, visited)...)
+		}
 	}
 
-	return findings, nil
+	return findings
+}
+
+// templateFindings reports the resources a template used by resource
+// defines. The template is read relative to the configuration at path, unless
+// that leads outside the repository, and its findings are attributed to the
+// configuration and name the resource and template they come from. Jinja
+// templates are rendered with the resource's properties; Python templates,
+// and Jinja the renderer does not support, report the literal resource types
+// they contain.
+func (p CloudDeploymentManagerProcessor) templateFindings(path string, repoName string, resource CDMResource, imported CDMImport, props map[string]interface{}, imports map[string]CDMImport, visited map[string]bool) []core.Finding {
+	templatePath := filepath.Clean(filepath.Join(filepath.Dir(path), imported.Path))
+	props["template"] = filepath.ToSlash(imported.Path)
+	props["resolved"] = false
+	if visited[templatePath] || len(visited) > maxDeploymentManagerNestingDepth || !withinRepository(path, templatePath) {
+		return nil
+	}
+	source, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil
+	}
+	props["resolved"] = true
+
+	var resources []CDMResource
+	rendered := false
+	if strings.HasSuffix(strings.ToLower(templatePath), ".jinja") {
+		props["template_type"] = "jinja"
+		vars := deploymentManagerContext(path, resource.Name, resource.Type, templateProperties(templatePath, resource.Properties))
+		output, unresolved, err := renderJinja(string(source), vars)
+		var template CloudDeploymentManagerTemplate
+		if err == nil && yaml.Unmarshal([]byte(output), &template) == nil {
+			resources, rendered = template.Resources, true
+			if len(unresolved) > 0 {
+				props["unresolved"] = unresolved
+			}
+		}
+	} else if strings.HasSuffix(strings.ToLower(templatePath), ".py") {
+		props["template_type"] = "python"
+	}
+	props["rendered"] = rendered
+
+	if !rendered {
+		var matches []core.Finding
+		for _, match := range deploymentManagerTypeRe.FindAllStringSubmatch(string(source), -1) {
+			if !deploymentManagerResourceTypeRe.MatchString(match[1]) {
+				continue
+			}
+			matches = append(matches, core.Finding{
+				Name:     resource.Name,
+				Type:     "Deployment Manager Resource",
+				Category: "GCP",
+				Properties: map[string]interface{}{
+					"resource_type":       match[1],
+					"deployment_resource": resource.Name,
+					"source_template":     filepath.ToSlash(imported.Path),
+					"rendered":            false,
+				},
+				Path:     path,
+				RepoName: repoName,
+			})
+		}
+		return matches
+	}
+
+	visited[templatePath] = true
+	defer delete(visited, templatePath)
+	matches := p.resourceFindings(path, repoName, resources, imports, visited)
+	for i := range matches {
+		if _, ok := matches[i].Properties["deployment_resource"]; !ok {
+			matches[i].Properties["deployment_resource"] = resource.Name
+			matches[i].Properties["source_template"] = filepath.ToSlash(imported.Path)
+		} else {
+			// Resources of deeper templates are named by their path from this one.
+			matches[i].Properties["deployment_resource"] = resource.Name + "/" + fmt.Sprint(matches[i].Properties["deployment_resource"])
+		}
+	}
+	return matches
+}
+
+// templateProperties returns the properties a template is rendered with:
+// those the resource sets, over the defaults in the template's schema file.
+func templateProperties(templatePath string, properties map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	if content, err := os.ReadFile(templatePath + ".schema"); err == nil {
+		var schema cdmTemplateSchema
+		if yaml.Unmarshal(content, &schema) == nil {
+			for name, property := range schema.Properties {
+				if property.Default != nil {
+					merged[name] = property.Default
+				}
+			}
+		}
+	}
+	for name, value := range properties {
+		merged[name] = value
+	}
+	return merged
+}
+
+// deploymentManagerContext returns the variables Deployment Manager gives a
+// template. The deployment is named after the configuration file, and the
+// project is not known.
+func deploymentManagerContext(path string, name string, resourceType string, properties map[string]interface{}) map[string]interface{} {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	deployment := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return map[string]interface{}{
+		"properties": properties,
+		"env": map[string]interface{}{
+			"deployment": deployment,
+			"name":       name,
+			"type":       resourceType,
+		},
+	}
 }
@@ -1,7 +1,12 @@
 package processors
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCloudDeploymentManagerProcessor_Supports(t *testing.T) {
@@ -128,3 +133,233 @@ func TestCloudDeploymentManagerProcessor_Process_InvalidYaml(t *testing.T) {
 		t.Errorf("Expected 0 findings for invalid YAML, got %d", len(findings))
 	}
 }
+
+func TestCloudDeploymentManagerProcessor_Process_RendersImportedTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "templates", "vm.jinja"), `{# A VM with optional disks #}
+{% set prefix = env["deployment"] ~ "-" ~ env["name"] %}
+resources:
+- name: {{ prefix }}-vm
+  type: compute.v1.instance
+  properties:
+    zone: {{ properties["zone"] }}
+    machineType: zones/{{ properties.zone }}/machineTypes/{{ properties["machineType"] | default("e2-small") }}
+{% for disk in properties["disks"] %}
+- name: {{ prefix }}-{{ disk }}
+  type: compute.v1.disk
+  properties:
+    sizeGb: {{ properties.diskSize }}
+{% endfor %}
+{% if properties["network"] %}
+- name: {{ prefix }}-network
+  type: network.jinja
+{% endif %}
+`)
+	writeTestFile(t, filepath.Join(dir, "templates", "vm.jinja.schema"), `properties:
+  diskSize:
+    type: integer
+    default: 20
+`)
+	writeTestFile(t, filepath.Join(dir, "templates", "network.jinja"), `resources:
+- name: {{ env["name"] }}
+  type: compute.v1.network
+`)
+	writeTestFile(t, filepath.Join(dir, "templates", "bucket.py"), `def GenerateConfig(context):
+    return {'resources': [{
+        'name': context.env['name'],
+        'type': 'storage.v1.bucket',
+    }]}
+`)
+	config := filepath.Join(dir, "prod-deployment.yaml")
+	content := `imports:
+- path: templates/vm.jinja
+  name: vm
+- path: templates/network.jinja
+- path: templates/bucket.py
+resources:
+- name: web
+  type: vm
+  properties:
+    zone: europe-west2-a
+    disks: [data, logs]
+    network: true
+- name: assets
+  type: templates/bucket.py
+- name: missing
+  type: templates/missing.jinja
+`
+	findings, err := CloudDeploymentManagerProcessor{}.Process(config, "repo", content)
+	require.NoError(t, err)
+
+	names := make([]string, len(findings))
+	for i, finding := range findings {
+		names[i] = finding.Name
+		assert.Equal(t, config, finding.Path)
+	}
+	assert.Equal(t, []string{"web", "prod-deployment-web-vm", "prod-deployment-web-data", "prod-deployment-web-logs",
+		"prod-deployment-web-network", "prod-deployment-web-network", "assets", "assets", "missing"}, names)
+
+	web := findings[0].Properties
+	assert.Equal(t, "templates/vm.jinja", web["template"])
+	assert.Equal(t, "jinja", web["template_type"])
+	assert.Equal(t, true, web["rendered"])
+
+	vm := findings[1].Properties
+	assert.Equal(t, "compute.v1.instance", vm["resource_type"])
+	assert.Equal(t, "web", vm["deployment_resource"])
+	assert.Equal(t, "templates/vm.jinja", vm["source_template"])
+	assert.Equal(t, "zones/europe-west2-a/machineTypes/e2-small", vm["properties"].(map[string]interface{})["machineType"])
+	assert.Equal(t, 20, findings[2].Properties["properties"].(map[string]interface{})["sizeGb"])
+
+	network := findings[5].Properties
+	assert.Equal(t, "compute.v1.network", network["resource_type"])
+	assert.Equal(t, "web/prod-deployment-web-network", network["deployment_resource"])
+	assert.Equal(t, "templates/network.jinja", network["source_template"])
+	assert.Equal(t, "templates/network.jinja", findings[4].Properties["template"])
+	assert.Equal(t, "templates/vm.jinja", findings[4].Properties["source_template"])
+
+	assert.Equal(t, "python", findings[6].Properties["template_type"])
+	assert.Equal(t, false, findings[6].Properties["rendered"])
+	assert.Equal(t, "storage.v1.bucket", findings[7].Properties["resource_type"])
+	assert.Equal(t, "assets", findings[7].Properties["deployment_resource"])
+
+	assert.Nil(t, findings[8].Properties["template"])
+}
+
+func TestCloudDeploymentManagerProcessor_Process_UnrenderableTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "db.jinja"), `{% import "helpers.jinja" as helpers %}
+resources:
+- name: {{ helpers.name(env) }}
+  type: sqladmin.v1beta4.instance
+`)
+	content := `imports:
+- path: db.jinja
+resources:
+- name: orders
+  type: db.jinja
+`
+	findings, err := CloudDeploymentManagerProcessor{}.Process(filepath.Join(dir, "deployment.yaml"), "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, true, findings[0].Properties["resolved"])
+	assert.Equal(t, false, findings[0].Properties["rendered"])
+	assert.Equal(t, "sqladmin.v1beta4.instance", findings[1].Properties["resource_type"])
+	assert.Equal(t, "orders", findings[1].Properties["deployment_resource"])
+}
+
+func TestCloudDeploymentManagerProcessor_Process_RenderLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "vm.jinja"), `resources:
+- name: {{ env["name"] }}-{{ "x" * (10 - properties.width | default(20)) }}
+  type: compute.v1.instance
+{% for i in range(10000000000) %}
+- name: disk-{{ i }}
+  type: compute.v1.disk
+{% endfor %}
+`)
+	content := `imports:
+- path: vm.jinja
+resources:
+- name: vm
+  type: vm.jinja
+`
+	findings, err := CloudDeploymentManagerProcessor{}.Process(filepath.Join(dir, "deployment.yaml"), "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+	assert.Equal(t, false, findings[0].Properties["rendered"])
+	assert.Equal(t, "compute.v1.instance", findings[1].Properties["resource_type"])
+	assert.Equal(t, "compute.v1.disk", findings[2].Properties["resource_type"])
+
+	findings, err = CloudDeploymentManagerProcessor{}.Process("main.jinja", "repo", `resources:
+- name: banner{{ "=" * (10 - properties.width | default(20)) }}
+  type: compute.v1.instance
+`)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "banner", findings[0].Name)
+}
+
+func TestCloudDeploymentManagerProcessor_Process_PythonTemplateTypes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "vm.py"), `COMPUTE_URL_BASE = 'https://www.googleapis.com/compute/v1/'
+
+
+def GenerateConfig(context):
+    resources = [{
+        'name': context.env['name'],
+        'type': 'compute.v1.instance',
+        'properties': {
+            'zone': context.properties['zone'],
+            'disks': [{
+                'deviceName': 'boot',
+                'type': 'PERSISTENT',
+                'boot': True,
+            }],
+            'networkInterfaces': [{
+                'network': COMPUTE_URL_BASE + 'projects/' + context.env['project'] + '/global/networks/default',
+                'accessConfigs': [{
+                    'name': 'External NAT',
+                    'type': 'ONE_TO_ONE_NAT'
+                }]
+            }]
+        }
+    }, {
+        'name': 'cluster',
+        'type': 'gcp-types/container-v1:projects.locations.clusters',
+    }, {
+        'name': 'topic',
+        'type': 'my-project/pubsub-provider:projects.topics',
+    }, {
+        'name': 'network',
+        'type': 'network.jinja',
+    }, {
+        'name': 'beta',
+        'type': "compute.beta.instance",
+    }, {
+        'name': 'copied',
+        'type': context.env['type'],
+    }]
+    return {'resources': resources}
+`)
+	content := `imports:
+- path: vm.py
+resources:
+- name: vm
+  type: vm.py
+`
+	findings, err := CloudDeploymentManagerProcessor{}.Process(filepath.Join(dir, "deployment.yaml"), "repo", content)
+	require.NoError(t, err)
+	var types []string
+	for _, finding := range findings[1:] {
+		types = append(types, finding.Properties["resource_type"].(string))
+	}
+	assert.Equal(t, []string{
+		"compute.v1.instance",
+		"gcp-types/container-v1:projects.locations.clusters",
+		"my-project/pubsub-provider:projects.topics",
+		"network.jinja",
+		"compute.beta.instance",
+	}, types)
+}
+
+func TestCloudDeploymentManagerProcessor_Process_ImportOutsideRepository(t *testing.T) {
+	parent := t.TempDir()
+	repo := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(parent, "secret.jinja"), `resources:
+- name: leaked
+  type: compute.v1.instance
+`)
+	content := `imports:
+- path: ../secret.jinja
+resources:
+- name: vm
+  type: ../secret.jinja
+`
+	findings, err := CloudDeploymentManagerProcessor{}.Process(filepath.Join(repo, "deployment.yaml"), "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "vm", findings[0].Name)
+	assert.Equal(t, false, findings[0].Properties["resolved"])
+}
@@ -2,6 +2,9 @@ package processors
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -9,70 +12,370 @@ import (
 	"github.com/reaandrew/techdetector/core"
 )
 
+// maxCloudFormationNestingDepth bounds how deep nested stacks are followed.
+const maxCloudFormationNestingDepth = 5
+
+// cloudFormationIntrinsics maps the YAML short forms of the intrinsic
+// functions to the names used in their long, JSON form.
+var cloudFormationIntrinsics = map[string]string{
+	"!Ref":          "Ref",
+	"!Condition":    "Condition",
+	"!Base64":       "Fn::Base64",
+	"!Cidr":         "Fn::Cidr",
+	"!FindInMap":    "Fn::FindInMap",
+	"!GetAtt":       "Fn::GetAtt",
+	"!GetAZs":       "Fn::GetAZs",
+	"!ImportValue":  "Fn::ImportValue",
+	"!Join":         "Fn::Join",
+	"!Length":       "Fn::Length",
+	"!Select":       "Fn::Select",
+	"!Split":        "Fn::Split",
+	"!Sub":          "Fn::Sub",
+	"!ToJsonString": "Fn::ToJsonString",
+	"!Transform":    "Fn::Transform",
+	"!And":          "Fn::And",
+	"!Equals":       "Fn::Equals",
+	"!If":           "Fn::If",
+	"!Not":          "Fn::Not",
+	"!Or":           "Fn::Or",
+}
+
 // CloudFormationTemplate is a partial representation of a CloudFormation template.
 type CloudFormationTemplate struct {
-	AWSTemplateFormatVersion string                            `yaml:"AWSTemplateFormatVersion,omitempty" json:"AWSTemplateFormatVersion,omitempty"`
-	Resources                map[string]CloudFormationResource `yaml:"Resources,omitempty" json:"Resources,omitempty"`
+	AWSTemplateFormatVersion string                             `yaml:"AWSTemplateFormatVersion,omitempty" json:"AWSTemplateFormatVersion,omitempty"`
+	Transform                interface{}                        `yaml:"Transform,omitempty" json:"Transform,omitempty"`
+	Globals                  map[string]map[string]interface{}  `yaml:"Globals,omitempty" json:"Globals,omitempty"`
+	Parameters               map[string]CloudFormationParameter `yaml:"Parameters,omitempty" json:"Parameters,omitempty"`
+	Resources                map[string]CloudFormationResource  `yaml:"Resources,omitempty" json:"Resources,omitempty"`
+	Outputs                  map[string]CloudFormationOutput    `yaml:"Outputs,omitempty" json:"Outputs,omitempty"`
 }
 
 // CloudFormationResource is a partial representation of a CloudFormation resource.
 type CloudFormationResource struct {
 	Type       string                 `yaml:"Type,omitempty" json:"Type,omitempty"`
+	Condition  string                 `yaml:"Condition,omitempty" json:"Condition,omitempty"`
 	Properties map[string]interface{} `yaml:"Properties,omitempty" json:"Properties,omitempty"`
 }
 
-// CloudFormationProcessor parses files with .yml, .yaml, or .json
-// and checks if they appear to be AWS CloudFormation templates.
-// It then emits a Finding for each resource.
+// CloudFormationParameter is a template parameter.
+type CloudFormationParameter struct {
+	Type          string        `yaml:"Type,omitempty" json:"Type,omitempty"`
+	Default       interface{}   `yaml:"Default,omitempty" json:"Default,omitempty"`
+	AllowedValues []interface{} `yaml:"AllowedValues,omitempty" json:"AllowedValues,omitempty"`
+	NoEcho        interface{}   `yaml:"NoEcho,omitempty" json:"NoEcho,omitempty"`
+	Description   string        `yaml:"Description,omitempty" json:"Description,omitempty"`
+}
+
+// CloudFormationOutput is a template output.
+type CloudFormationOutput struct {
+	Value       interface{} `yaml:"Value,omitempty" json:"Value,omitempty"`
+	Description string      `yaml:"Description,omitempty" json:"Description,omitempty"`
+	Condition   string      `yaml:"Condition,omitempty" json:"Condition,omitempty"`
+	Export      struct {
+		Name interface{} `yaml:"Name,omitempty" json:"Name,omitempty"`
+	} `yaml:"Export,omitempty" json:"Export,omitempty"`
+}
+
+// maxCloudFormationNodes bounds how many nodes decoding a document visits,
+// counting a node again each time an alias refers to it, so that nested
+// anchors cannot expand into an exponentially large document.
+const maxCloudFormationNodes = 1000000
+
+// decodeCloudFormationNode converts a YAML node to Go values, rewriting the
+// short-form intrinsic tags to their long form, so !GetAtt Bucket.Arn becomes
+// {"Fn::GetAtt": ["Bucket", "Arn"]} as it would be written in JSON. Aliases
+// are expanded, and an error is returned when the expanded document would be
+// larger than maxCloudFormationNodes.
+func decodeCloudFormationNode(node *yaml.Node) (interface{}, error) {
+	budget := maxCloudFormationNodes
+	return decodeCloudFormationNodeWithin(node, &budget)
+}
+
+func decodeCloudFormationNodeWithin(node *yaml.Node, budget *int) (interface{}, error) {
+	if *budget--; *budget < 0 {
+		return nil, fmt.Errorf("document has more than %d nodes once its aliases are expanded", maxCloudFormationNodes)
+	}
+	if function, ok := cloudFormationIntrinsics[node.Tag]; ok {
+		if node.Kind == yaml.ScalarNode {
+			if function == "Fn::GetAtt" {
+				resource, attribute, _ := strings.Cut(node.Value, ".")
+				return map[string]interface{}{function: []interface{}{resource, attribute}}, nil
+			}
+			return map[string]interface{}{function: node.Value}, nil
+		}
+		untagged := *node
+		untagged.Tag = ""
+		value, err := decodeCloudFormationNodeWithin(&untagged, budget)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{function: value}, nil
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return decodeCloudFormationNodeWithin(node.Content[0], budget)
+	case yaml.AliasNode:
+		return decodeCloudFormationNodeWithin(node.Alias, budget)
+	case yaml.MappingNode:
+		values := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := decodeCloudFormationNodeWithin(node.Content[i+1], budget)
+			if err != nil {
+				return nil, err
+			}
+			values[node.Content[i].Value] = value
+		}
+		return values, nil
+	case yaml.SequenceNode:
+		values := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := decodeCloudFormationNodeWithin(item, budget)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+
+	// Dates such as AWSTemplateFormatVersion: 2010-09-09 stay as written.
+	if node.Tag == "!!timestamp" || strings.HasPrefix(node.Tag, "!") && !strings.HasPrefix(node.Tag, "!!") {
+		return node.Value, nil
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// parseCloudFormationTemplate decodes a YAML or JSON template.
+func parseCloudFormationTemplate(content []byte) (CloudFormationTemplate, error) {
+	var template CloudFormationTemplate
+	var node yaml.Node
+	if yamlErr := yaml.Unmarshal(content, &node); yamlErr != nil {
+		// If YAML fails, attempt JSON parse
+		if jsonErr := json.Unmarshal(content, &template); jsonErr != nil {
+			return template, jsonErr
+		}
+		return template, nil
+	}
+	document, err := decodeCloudFormationNode(&node)
+	if err != nil {
+		return template, err
+	}
+	if _, ok := document.(map[string]interface{}); !ok {
+		return template, nil
+	}
+	// The document is plain maps and lists now, so it can go through JSON to
+	// fill in the typed template.
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return template, err
+	}
+	if err := json.Unmarshal(encoded, &template); err != nil {
+		return template, err
+	}
+	return template, nil
+}
+
+// isCloudFormationTemplateName reports whether a file is named like a
+// template, in which case it is parsed without looking at its content first.
+func isCloudFormationTemplateName(filePath string) bool {
+	lower := strings.ToLower(filePath)
+	return strings.HasSuffix(lower, "template.yml") || strings.HasSuffix(lower, "template.yaml") || strings.HasSuffix(lower, "template.json")
+}
+
+// CloudFormationProcessor parses YAML and JSON files that look like AWS
+// CloudFormation templates and reports their resources, parameters, outputs
+// and transforms, following nested stacks to templates in the repository.
 type CloudFormationProcessor struct {
 }
 
 func (c CloudFormationProcessor) Supports(filePath string) bool {
-	lower := strings.ToLower(filePath)
-	if !(strings.HasSuffix(lower, "template.yml") || strings.HasSuffix(lower, "template.yaml") || strings.HasSuffix(lower, "template.json")) {
-		return false
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yml", ".yaml", ".json", ".template":
+		// Compose files share the extensions but are never templates.
+		return !DockerComposeProcessor{}.Supports(filePath)
 	}
-	// Quick heuristic: we might read partial content to see if "AWSTemplateFormatVersion" or "Resources" exist
-	// but you can also attempt a parse in Process. We'll just let Process handle that.
-	return true
+	return false
 }
 
 func (c CloudFormationProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
-	// Attempt YAML parse first
-	var template CloudFormationTemplate
-	yamlErr := yaml.Unmarshal([]byte(content), &template)
-	if yamlErr != nil {
-		// If YAML fails, attempt JSON parse
-		jsonErr := json.Unmarshal([]byte(content), &template)
-		if jsonErr != nil {
+	named := isCloudFormationTemplateName(path)
+	// Files not named like templates are only parsed when they mention
+	// something only a template would.
+	if !named && !strings.Contains(content, "AWSTemplateFormatVersion") && !strings.Contains(content, "AWS::") {
+		return nil, nil
+	}
+
+	template, err := parseCloudFormationTemplate([]byte(content))
+	if err != nil {
+		if named {
 			// Not recognized as valid YAML or JSON for CloudFormation
-			return nil, jsonErr
+			return nil, err
 		}
+		return nil, nil
 	}
-	
-	if template.AWSTemplateFormatVersion == "" && len(template.Resources) == 0 {
+	if !isCloudFormation(template, named) {
 		return nil, nil // Doesn't look like a CloudFormation template
 	}
+	return c.templateFindings(path, repoName, template, map[string]bool{filepath.Clean(path): true}), nil
+}
+
+// isCloudFormation reports whether a parsed document is a template. Files
+// named like templates only need resources; others need the format version
+// or a resource of an AWS type.
+func isCloudFormation(template CloudFormationTemplate, named bool) bool {
+	if template.AWSTemplateFormatVersion != "" {
+		return true
+	}
+	if named {
+		return len(template.Resources) > 0
+	}
+	for _, resource := range template.Resources {
+		if strings.HasPrefix(resource.Type, "AWS::") || strings.HasPrefix(resource.Type, "Custom::") {
+			return true
+		}
+	}
+	return false
+}
+
+// templateFindings reports a template. visited holds the templates on the
+// current chain of nested stacks, which guards against cycles.
+func (c CloudFormationProcessor) templateFindings(path string, repoName string, template CloudFormationTemplate, visited map[string]bool) []core.Finding {
+	newFinding := func(name string, findingType string, properties map[string]interface{}) core.Finding {
+		return core.Finding{
+			Name:       name,
+			Type:       findingType,
+			Category:   "AWS",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		}
+	}
 
 	var matches []core.Finding
+	for _, transform := range cloudFormationTransforms(template.Transform) {
+		matches = append(matches, newFinding(transform, "CloudFormation Transform", map[string]interface{}{}))
+	}
 
-	for resourceName, resource := range template.Resources {
+	for _, name := range sortedKeys(template.Parameters) {
+		parameter := template.Parameters[name]
+		props := map[string]interface{}{
+			"type":    parameter.Type,
+			"no_echo": fmt.Sprint(parameter.NoEcho) == "true",
+		}
+		if parameter.Default != nil {
+			props["default"] = parameter.Default
+		}
+		if len(parameter.AllowedValues) > 0 {
+			props["allowed_values"] = parameter.AllowedValues
+		}
+		if parameter.Description != "" {
+			props["description"] = parameter.Description
+		}
+		matches = append(matches, newFinding(name, "CloudFormation Parameter", props))
+	}
 
+	for _, resourceName := range sortedKeys(template.Resources) {
+		resource := template.Resources[resourceName]
 		props := map[string]interface{}{
 			"resource_type": resource.Type,
 		}
 		for key, value := range resource.Properties {
 			props[key] = value
 		}
-		matches = append(matches, core.Finding{
-			Name:       resourceName,
-			Type:       "CloudFormation Resource",
-			Category:   "AWS",
-			Properties: props,
-			Path:       path,
-			RepoName:   repoName,
-		})
+		if resource.Condition != "" {
+			props["condition"] = resource.Condition
+		}
+		matches = append(matches, newFinding(resourceName, "CloudFormation Resource", props))
+
+		if resource.Type == "AWS::CloudFormation::Stack" {
+			matches = append(matches, c.nestedStackFindings(path, repoName, resourceName, resource, props, visited)...)
+		}
+	}
+
+	for _, name := range sortedKeys(template.Outputs) {
+		output := template.Outputs[name]
+		props := map[string]interface{}{
+			"value": output.Value,
+		}
+		if output.Export.Name != nil {
+			props["export"] = output.Export.Name
+		}
+		if output.Description != "" {
+			props["description"] = output.Description
+		}
+		if output.Condition != "" {
+			props["condition"] = output.Condition
+		}
+		matches = append(matches, newFinding(name, "CloudFormation Output", props))
+	}
+	return matches
+}
+
+// nestedStackFindings reports the template of a nested stack whose
+// TemplateURL is a path in the repository, as it is before
+// "aws cloudformation package" uploads it. Paths leading outside the
+// repository are not followed. Its findings are attributed to the
+// parent template and name the stack they belong to.
+func (c CloudFormationProcessor) nestedStackFindings(path string, repoName string, stack string, resource CloudFormationResource, props map[string]interface{}, visited map[string]bool) []core.Finding {
+	templateURL, ok := resource.Properties["TemplateURL"].(string)
+	if !ok || strings.Contains(templateURL, "://") {
+		return nil
+	}
+	nestedPath := filepath.Clean(filepath.Join(filepath.Dir(path), templateURL))
+	props["resolved"] = false
+	if visited[nestedPath] || len(visited) > maxCloudFormationNestingDepth || !withinRepository(path, nestedPath) {
+		return nil
+	}
+	content, err := os.ReadFile(nestedPath)
+	if err != nil {
+		return nil
 	}
+	template, err := parseCloudFormationTemplate(content)
+	if err != nil || !isCloudFormation(template, true) {
+		return nil
+	}
+	props["resolved"] = true
 
-	return matches, nil
+	visited[nestedPath] = true
+	defer delete(visited, nestedPath)
+	matches := c.templateFindings(nestedPath, repoName, template, visited)
+	for i := range matches {
+		if _, ok := matches[i].Properties["nested_stack"]; !ok {
+			matches[i].Properties["nested_stack"] = stack
+			matches[i].Properties["template"] = filepath.ToSlash(templateURL)
+		} else {
+			// Deeper stacks are named by their path from this one.
+			matches[i].Properties["nested_stack"] = stack + "/" + fmt.Sprint(matches[i].Properties["nested_stack"])
+		}
+		matches[i].Path = path
+	}
+	return matches
+}
+
+// cloudFormationTransforms returns the macros a Transform section applies,
+// which is a name, a list of names, or AWS::Include with its parameters.
+func cloudFormationTransforms(transform interface{}) []string {
+	switch t := transform.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var transforms []string
+		for _, item := range t {
+			transforms = append(transforms, cloudFormationTransforms(item)...)
+		}
+		return transforms
+	case map[string]interface{}:
+		if name, ok := t["Name"].(string); ok {
+			return []string{name}
+		}
+	}
+	return nil
 }
@@ -1,7 +1,17 @@
 package processors
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
 )
 
 func TestCloudFormationProcessor_Supports(t *testing.T) {
@@ -14,6 +24,8 @@ func TestCloudFormationProcessor_Supports(t *testing.T) {
 		{"template.yaml", true},
 		{"template.yml", true},
 		{"template.json", true},
+		{"infra/network.yaml", true},
+		{"stacks/vpc.template", true},
 		{"main.tf", false}, // not CF
 		{"docker-compose.yml", false},
 		{"config.txt", false},
@@ -147,3 +159,232 @@ func TestCloudFormationProcessor_Process_InvalidYamlOrJson(t *testing.T) {
 		t.Error("Expected an error for invalid YAML, got nil")
 	}
 }
+
+// billionLaughs returns YAML in which each of levels anchors refers to the
+// previous one ten times, so the document expands to 10^levels values.
+func billionLaughs(key string, levels int) string {
+	var doc strings.Builder
+	doc.WriteString("a0: &a0 [lol]\n")
+	for i := 1; i <= levels; i++ {
+		fmt.Fprintf(&doc, "a%d: &a%d [", i, i)
+		for j := 0; j < 10; j++ {
+			if j > 0 {
+				doc.WriteString(", ")
+			}
+			fmt.Fprintf(&doc, "*a%d", i-1)
+		}
+		doc.WriteString("]\n")
+	}
+	fmt.Fprintf(&doc, "%s: *a%d\n", key, levels)
+	return doc.String()
+}
+
+func TestCloudFormationProcessor_Process_BillionLaughs(t *testing.T) {
+	content := "AWSTemplateFormatVersion: 2010-09-09\n" + billionLaughs("Metadata", 9)
+	start := time.Now()
+	_, err := CloudFormationProcessor{}.Process("template.yaml", "repo", content)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "aliases are expanded")
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	findings, err := CloudFormationProcessor{}.Process("stack.yaml", "repo", content)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	// A few levels of anchors are fine.
+	_, err = CloudFormationProcessor{}.Process("template.yaml", "repo", "AWSTemplateFormatVersion: 2010-09-09\n"+billionLaughs("Metadata", 3))
+	require.NoError(t, err)
+}
+
+func TestCloudFormationProcessor_Process_SniffsContent(t *testing.T) {
+	processor := CloudFormationProcessor{}
+
+	findings, err := processor.Process("infra/network.yaml", "test-repo", `
+Resources:
+  Vpc:
+    Type: AWS::EC2::VPC
+    Properties:
+      CidrBlock: 10.0.0.0/16
+`)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Vpc", findings[0].Name)
+
+	// Files that only look like templates by extension are skipped quietly.
+	findings, err = processor.Process("k8s/deployment.yaml", "test-repo", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	findings, err = processor.Process("charts/app/templates/service.yaml", "test-repo", "{{- if .Values.aws }}\nType: AWS::S3::Bucket\n{{- end }}")
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestCloudFormationProcessor_Process_IntrinsicsParametersOutputs(t *testing.T) {
+	content := `
+AWSTemplateFormatVersion: 2010-09-09
+Transform: AWS::LanguageExtensions
+
+Parameters:
+  Environment:
+    Type: String
+    Default: dev
+    AllowedValues: [dev, prod]
+  DbPassword:
+    Type: String
+    NoEcho: true
+
+Conditions:
+  IsProd: !Equals [!Ref Environment, prod]
+
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Condition: IsProd
+    Properties:
+      BucketName: !Sub "${AWS::StackName}-assets"
+      Tags:
+        - Key: Env
+          Value: !Ref Environment
+  Policy:
+    Type: AWS::S3::BucketPolicy
+    Properties:
+      Bucket: !Ref Bucket
+      PolicyDocument:
+        Statement:
+          - Resource: !GetAtt Bucket.Arn
+            Condition: !If [IsProd, !Join ["", ["a", "b"]], !Ref AWS::NoValue]
+
+Outputs:
+  BucketArn:
+    Value: !GetAtt [Bucket, Arn]
+    Export:
+      Name: !Sub "${AWS::StackName}-BucketArn"
+`
+	findings, err := CloudFormationProcessor{}.Process("template.yaml", "test-repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		byType[finding.Type] = append(byType[finding.Type], finding)
+	}
+
+	require.Len(t, byType["CloudFormation Transform"], 1)
+	assert.Equal(t, "AWS::LanguageExtensions", byType["CloudFormation Transform"][0].Name)
+
+	params := findingsByName(byType["CloudFormation Parameter"])
+	require.Len(t, params, 2)
+	assert.Equal(t, "dev", params["Environment"].Properties["default"])
+	assert.Equal(t, []interface{}{"dev", "prod"}, params["Environment"].Properties["allowed_values"])
+	assert.Equal(t, true, params["DbPassword"].Properties["no_echo"])
+
+	resources := findingsByName(byType["CloudFormation Resource"])
+	require.Len(t, resources, 2)
+	bucket := resources["Bucket"].Properties
+	assert.Equal(t, map[string]interface{}{"Fn::Sub": "${AWS::StackName}-assets"}, bucket["BucketName"])
+	assert.Equal(t, "IsProd", bucket["condition"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"Key": "Env", "Value": map[string]interface{}{"Ref": "Environment"}}}, bucket["Tags"])
+
+	policy := resources["Policy"].Properties
+	assert.Equal(t, map[string]interface{}{"Ref": "Bucket"}, policy["Bucket"])
+	statement := policy["PolicyDocument"].(map[string]interface{})["Statement"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"Fn::GetAtt": []interface{}{"Bucket", "Arn"}}, statement["Resource"])
+	assert.Equal(t, map[string]interface{}{"Fn::If": []interface{}{
+		"IsProd",
+		map[string]interface{}{"Fn::Join": []interface{}{"", []interface{}{"a", "b"}}},
+		map[string]interface{}{"Ref": "AWS::NoValue"},
+	}}, statement["Condition"])
+
+	outputs := findingsByName(byType["CloudFormation Output"])
+	require.Len(t, outputs, 1)
+	assert.Equal(t, map[string]interface{}{"Fn::GetAtt": []interface{}{"Bucket", "Arn"}}, outputs["BucketArn"].Properties["value"])
+	assert.Equal(t, map[string]interface{}{"Fn::Sub": "${AWS::StackName}-BucketArn"}, outputs["BucketArn"].Properties["export"])
+}
+
+func TestCloudFormationProcessor_Process_NestedStacks(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "stacks", "network.yaml"), `
+AWSTemplateFormatVersion: "2010-09-09"
+Resources:
+  Vpc:
+    Type: AWS::EC2::VPC
+  Subnets:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: subnets.yaml
+`)
+	writeTestFile(t, filepath.Join(dir, "stacks", "subnets.yaml"), `
+Resources:
+  Subnet:
+    Type: AWS::EC2::Subnet
+`)
+	path := filepath.Join(dir, "main.yaml")
+	content := `
+AWSTemplateFormatVersion: "2010-09-09"
+Resources:
+  Network:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: ./stacks/network.yaml
+  Remote:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: https://s3.amazonaws.com/bucket/remote.yaml
+`
+	writeTestFile(t, path, content)
+
+	findings, err := CloudFormationProcessor{}.Process(path, "test-repo", content)
+	require.NoError(t, err)
+
+	resources := findingsByName(findingsByType(findings, "CloudFormation Resource"))
+	require.Len(t, resources, 5)
+	assert.Equal(t, true, resources["Network"].Properties["resolved"])
+	assert.Nil(t, resources["Remote"].Properties["resolved"])
+
+	assert.Equal(t, path, resources["Vpc"].Path)
+	assert.Equal(t, "Network", resources["Vpc"].Properties["nested_stack"])
+	assert.Equal(t, "./stacks/network.yaml", resources["Vpc"].Properties["template"])
+	assert.Equal(t, "Network/Subnets", resources["Subnet"].Properties["nested_stack"])
+	assert.Equal(t, path, resources["Subnet"].Path)
+}
+
+func findingsByType(findings []core.Finding, findingType string) []core.Finding {
+	var filtered []core.Finding
+	for _, finding := range findings {
+		if finding.Type == findingType {
+			filtered = append(filtered, finding)
+		}
+	}
+	return filtered
+}
+
+func TestCloudFormationProcessor_Process_NestedStackOutsideRepository(t *testing.T) {
+	parent := t.TempDir()
+	repo := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(parent, "outside.yaml"), `
+Resources:
+  Secret:
+    Type: AWS::SecretsManager::Secret
+`)
+	path := filepath.Join(repo, "template.yaml")
+	content := `
+AWSTemplateFormatVersion: "2010-09-09"
+Resources:
+  Outside:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: ../outside.yaml
+`
+	writeTestFile(t, path, content)
+	findings, err := CloudFormationProcessor{}.Process(path, "repo", content)
+	require.NoError(t, err)
+	resources := findingsByName(findingsByType(findings, "CloudFormation Resource"))
+	require.Len(t, resources, 1)
+	assert.Equal(t, false, resources["Outside"].Properties["resolved"])
+}
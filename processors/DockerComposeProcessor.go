@@ -1,27 +1,39 @@
 package processors
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/reaandrew/techdetector/core"
+	"github.com/reaandrew/techdetector/utils"
 )
 
-// DockerComposeService is a minimal struct to parse just enough from a docker-compose file.
-type DockerComposeService struct {
-	Image string `yaml:"image,omitempty"`
-}
+// composeFileRe matches Compose file names: the default names, their
+// override files and variants such as docker-compose.prod.yml.
+var composeFileRe = regexp.MustCompile(`^((?:docker-)?compose)(?:[.-]([\w.-]+?))?\.ya?ml$`)
 
-// DockerComposeFile represents the top-level structure of a docker-compose.yml file.
-type DockerComposeFile struct {
-	Services map[string]DockerComposeService `yaml:"services,omitempty"`
-}
+// composeModifiers are the modifiers a ${NAME...} reference can have, longest
+// first.
+var composeModifiers = []string{":-", ":?", ":+", "-", "?", "+"}
+
+// composeReplacedKeys are the service keys whose values an override
+// replaces rather than merges with.
+var composeReplacedKeys = []string{"command", "entrypoint", "test", "image"}
 
 // DockerComposeProcessor scans Docker Compose files (docker-compose.yml / .yaml)
 // and reports the discovered services/images.
+//
+// Files are loaded the way "docker compose" loads them. Variables are
+// interpolated from the .env file next to them. A compose.override.yaml is
+// merged into its base file, and a variant such as docker-compose.prod.yml
+// is merged over the base file as "-f docker-compose.yml -f
+// docker-compose.prod.yml" would.
 type DockerComposeProcessor struct {
 }
 
@@ -29,32 +41,652 @@ type DockerComposeProcessor struct {
 func (d DockerComposeProcessor) Supports(filePath string) bool {
 	base := filepath.Base(filePath)
 	lower := strings.ToLower(base)
-	// Common Docker Compose filenames
-	return lower == "docker-compose.yml" || lower == "docker-compose.yaml" ||
-		lower == "compose.yml" || lower == "compose.yaml"
+	// Common Docker Compose filenames, with their override and variant files
+	return composeFileRe.MatchString(lower)
+}
+
+// composeBaseFile returns the default Compose file in dir whose name starts
+// with prefix, such as docker-compose.yml, if there is one.
+func composeBaseFile(dir string, prefix string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		lower := strings.ToLower(entry.Name())
+		if lower == prefix+".yml" || lower == prefix+".yaml" {
+			return filepath.Join(dir, entry.Name()), true
+		}
+	}
+	return "", false
+}
+
+// readComposeEnv reads the KEY=VALUE lines of a .env file.
+func readComposeEnv(path string) map[string]string {
+	env := map[string]string{}
+	file, err := os.Open(path)
+	if err != nil {
+		return env
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if idx := strings.Index(value, " #"); idx >= 0 {
+			value = strings.TrimSpace(value[:idx])
+		}
+		env[strings.TrimSpace(name)] = value
+	}
+	return env
+}
+
+// interpolateCompose substitutes variables from env into s: $$, $NAME,
+// ${NAME} and ${NAME} with a :-, -, :?, ?, :+ or + modifier, whose argument
+// can itself hold references, as in ${A:-${B}}. Variables that are not set
+// and have no default are replaced by nothing and returned.
+func interpolateCompose(s string, env map[string]string) (string, []string) {
+	var out strings.Builder
+	var unresolved []string
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+		switch {
+		case s[i+1] == '$':
+			out.WriteByte('$')
+			i++
+		case s[i+1] == '{':
+			end := composeReferenceEnd(s, i+2)
+			if end < 0 {
+				// An unclosed reference is not substituted.
+				out.WriteString(s[i:])
+				return out.String(), unresolved
+			}
+			value, missing := expandComposeReference(s[i+2:end], env)
+			out.WriteString(value)
+			unresolved = append(unresolved, missing...)
+			i = end
+		case isComposeNameChar(s[i+1]):
+			end := i + 1
+			for end < len(s) && isComposeNameChar(s[end]) {
+				end++
+			}
+			value, set := env[s[i+1:end]]
+			if !set {
+				unresolved = append(unresolved, s[i+1:end])
+			}
+			out.WriteString(value)
+			i = end - 1
+		default:
+			out.WriteByte('$')
+		}
+	}
+	return out.String(), unresolved
+}
+
+// composeReferenceEnd returns the offset of the brace closing the reference
+// whose body starts at start, or -1 if it is not closed.
+func composeReferenceEnd(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// expandComposeReference returns the value of the body of a ${...} reference
+// and the variables it needed that are not set.
+func expandComposeReference(body string, env map[string]string) (string, []string) {
+	end := 0
+	for end < len(body) && isComposeNameChar(body[end]) {
+		end++
+	}
+	name, rest := body[:end], body[end:]
+	modifier, argument := "", ""
+	for _, candidate := range composeModifiers {
+		if strings.HasPrefix(rest, candidate) {
+			modifier, argument = candidate, rest[len(candidate):]
+			break
+		}
+	}
+	if name == "" || (rest != "" && modifier == "") {
+		// Compose rejects the reference, so its value cannot be known.
+		return "", []string{"${" + body + "}"}
+	}
+
+	value, set := env[name]
+	// The ":" forms treat an empty value as unset.
+	if strings.HasPrefix(modifier, ":") && value == "" {
+		set = false
+	}
+	switch strings.TrimPrefix(modifier, ":") {
+	case "-":
+		if !set {
+			return interpolateCompose(argument, env)
+		}
+	case "+":
+		if set {
+			return interpolateCompose(argument, env)
+		}
+		return "", nil
+	}
+	if !set {
+		return "", []string{name}
+	}
+	return value, nil
+}
+
+func isComposeNameChar(ch byte) bool {
+	return ch == '_' || ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ('0' <= ch && ch <= '9')
+}
+
+// interpolateComposeNode interpolates the values of a YAML document, adding
+// the variables that are not set to unresolved. Keys are left as they are.
+func interpolateComposeNode(node *yaml.Node, env map[string]string, unresolved *[]string) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			interpolateComposeNode(child, env, unresolved)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			interpolateComposeNode(node.Content[i], env, unresolved)
+		}
+	case yaml.ScalarNode:
+		if strings.Contains(node.Value, "$") {
+			value, missing := interpolateCompose(node.Value, env)
+			node.Value = value
+			for _, name := range missing {
+				if !utils.Contains(*unresolved, name) {
+					*unresolved = append(*unresolved, name)
+				}
+			}
+		}
+	}
+}
+
+// loadComposeFile parses a Compose file, interpolating its variables, and
+// returns its services with the variables each uses that are not set.
+func loadComposeFile(content string, env map[string]string) (map[string]interface{}, map[string][]string, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &node); err != nil {
+		return nil, nil, err
+	}
+	unresolved := map[string][]string{}
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
+		root := node.Content[0]
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value != "services" || root.Content[i+1].Kind != yaml.MappingNode {
+				continue
+			}
+			services := root.Content[i+1]
+			for j := 0; j+1 < len(services.Content); j += 2 {
+				var missing []string
+				interpolateComposeNode(services.Content[j+1], env, &missing)
+				if len(missing) > 0 {
+					unresolved[services.Content[j].Value] = missing
+				}
+			}
+		}
+	}
+	var file struct {
+		Services map[string]interface{} `yaml:"services"`
+	}
+	if err := node.Decode(&file); err != nil {
+		return nil, nil, err
+	}
+	services := map[string]interface{}{}
+	for name, service := range file.Services {
+		if definition, ok := service.(map[string]interface{}); ok {
+			services[name] = normalizeComposeService(definition)
+		} else {
+			services[name] = map[string]interface{}{}
+		}
+	}
+	return services, unresolved, nil
+}
+
+// mergeComposeUnresolved adds the unresolved variables of each service in
+// other to those in unresolved.
+func mergeComposeUnresolved(unresolved map[string][]string, other map[string][]string) {
+	for service, names := range other {
+		for _, name := range names {
+			if !utils.Contains(unresolved[service], name) {
+				unresolved[service] = append(unresolved[service], name)
+			}
+		}
+	}
+}
+
+// normalizeComposeService turns the list forms of environment and labels
+// into maps so that overrides merge them by key.
+func normalizeComposeService(service map[string]interface{}) map[string]interface{} {
+	for _, key := range []string{"environment", "labels"} {
+		list, ok := service[key].([]interface{})
+		if !ok {
+			continue
+		}
+		values := map[string]interface{}{}
+		for _, item := range list {
+			name, value, _ := strings.Cut(fmt.Sprint(item), "=")
+			values[name] = value
+		}
+		service[key] = values
+	}
+	return service
+}
+
+// mergeComposeServices merges the services of an override file into those
+// of a base file.
+func mergeComposeServices(base map[string]interface{}, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for name, service := range base {
+		merged[name] = service
+	}
+	for name, service := range override {
+		merged[name] = mergeCompose(merged[name], service, "")
+	}
+	return merged
+}
+
+// mergeCompose merges an override into a base definition. Mappings are
+// merged, sequences are appended, and scalars and the values of
+// composeReplacedKeys are replaced.
+func mergeCompose(base interface{}, override interface{}, key string) interface{} {
+	if utils.Contains(composeReplacedKeys, key) {
+		return override
+	}
+	switch o := override.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return o
+		}
+		merged := make(map[string]interface{}, len(b)+len(o))
+		for k, v := range b {
+			merged[k] = v
+		}
+		for k, v := range o {
+			if existing, ok := merged[k]; ok {
+				merged[k] = mergeCompose(existing, v, k)
+			} else {
+				merged[k] = v
+			}
+		}
+		return merged
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok {
+			return o
+		}
+		merged := append([]interface{}{}, b...)
+		for _, item := range o {
+			duplicate := false
+			for _, existing := range merged {
+				if fmt.Sprint(existing) == fmt.Sprint(item) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				merged = append(merged, item)
+			}
+		}
+		return merged
+	}
+	return override
 }
 
 func (d DockerComposeProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
-	var compose DockerComposeFile
-	err := yaml.Unmarshal([]byte(content), &compose)
+	dir := filepath.Dir(path)
+	match := composeFileRe.FindStringSubmatch(strings.ToLower(filepath.Base(path)))
+	prefix, variant := "", ""
+	if match != nil {
+		prefix, variant = match[1], match[2]
+	}
+	basePath, hasBase := "", false
+	if variant != "" {
+		basePath, hasBase = composeBaseFile(dir, prefix)
+	}
+	// An override file is reported as part of its base file.
+	if variant == "override" && hasBase {
+		return nil, nil
+	}
+
+	env := readComposeEnv(filepath.Join(dir, ".env"))
+	services, unresolved, err := loadComposeFile(content, env)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse docker-compose file '%s': %w", path, err)
 	}
+	defined := sortedKeys(services)
+
+	var mergedFiles []string
+	if hasBase {
+		// A variant is merged over its base file.
+		baseContent, err := os.ReadFile(basePath)
+		if err == nil {
+			if base, baseUnresolved, err := loadComposeFile(string(baseContent), env); err == nil {
+				services = mergeComposeServices(base, services)
+				mergeComposeUnresolved(unresolved, baseUnresolved)
+				mergedFiles = append(mergedFiles, filepath.Base(basePath))
+			}
+		}
+	} else if variant == "" {
+		for _, ext := range []string{".yml", ".yaml"} {
+			overridePath := filepath.Join(dir, prefix+".override"+ext)
+			overrideContent, err := os.ReadFile(overridePath)
+			if err != nil {
+				continue
+			}
+			if override, overrideUnresolved, err := loadComposeFile(string(overrideContent), env); err == nil {
+				services = mergeComposeServices(services, override)
+				mergeComposeUnresolved(unresolved, overrideUnresolved)
+				defined = sortedKeys(services)
+				mergedFiles = append(mergedFiles, filepath.Base(overridePath))
+			}
+		}
+	}
 
 	var matches []core.Finding
-	for serviceName, service := range compose.Services {
+	var edges []core.Finding
+	for _, serviceName := range defined {
+		service, _ := services[serviceName].(map[string]interface{})
+		properties := composeServiceProperties(service, dir)
+		if variant != "" && variant != "override" {
+			properties["variant"] = variant
+		}
+		if len(mergedFiles) > 0 {
+			properties["merged_files"] = mergedFiles
+		}
+		if missing := unresolved[serviceName]; len(missing) > 0 {
+			properties["unresolved"] = missing
+		}
 		// Create a Finding for each service
 		matches = append(matches, core.Finding{
-			Name:     serviceName,
-			Type:     "Docker Compose Service",
-			Category: "",
-			Properties: map[string]interface{}{
-				"image": service.Image,
-			},
-			Path:     path,
-			RepoName: repoName,
+			Name:       serviceName,
+			Type:       "Docker Compose Service",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+
+		for _, dependency := range composeDependencies(service) {
+			dependency.Properties["service"] = serviceName
+			dependency.Name = serviceName + " -> " + dependency.Name
+			dependency.Path, dependency.RepoName = path, repoName
+			edges = append(edges, dependency)
+		}
+	}
+
+	return append(matches, edges...), nil
+}
+
+// composeServiceProperties describes a service: its image, the build that
+// produces it, and how it is run.
+func composeServiceProperties(service map[string]interface{}, dir string) map[string]interface{} {
+	properties := map[string]interface{}{}
+	image, _ := service["image"].(string)
+	properties["image"] = image
+	if image != "" {
+		for key, value := range parseImageReference(image).properties() {
+			properties[key] = value
+		}
+	}
+	if build, ok := service["build"]; ok {
+		for key, value := range composeBuildProperties(build, dir) {
+			properties[key] = value
+		}
+	}
+
+	if ports := composePorts(service["ports"]); len(ports) > 0 {
+		properties["ports"] = ports
+	}
+	if environment, ok := service["environment"].(map[string]interface{}); ok && len(environment) > 0 {
+		// Only the names are reported, as values may be secrets.
+		properties["environment"] = sortedKeys(environment)
+	}
+	if envFiles := stringList(composeEnvFiles(service["env_file"])); len(envFiles) > 0 {
+		properties["env_files"] = envFiles
+	}
+	if volumes := composeVolumes(service["volumes"]); len(volumes) > 0 {
+		properties["volumes"] = volumes
+	}
+	switch networks := service["networks"].(type) {
+	case []interface{}:
+		properties["networks"] = stringList(networks)
+	case map[string]interface{}:
+		properties["networks"] = sortedKeys(networks)
+	}
+	if dependsOn := composeDependsOn(service["depends_on"]); len(dependsOn) > 0 {
+		properties["depends_on"] = sortedKeys(dependsOn)
+	}
+	for _, key := range []string{"container_name", "restart", "platform", "network_mode", "user"} {
+		if value, ok := service[key].(string); ok && value != "" {
+			properties[key] = value
+		}
+	}
+	if profiles := stringList(service["profiles"]); len(profiles) > 0 {
+		properties["profiles"] = profiles
+	}
+	return properties
+}
+
+// composeBuildProperties describes a service's build: its context and the
+// Dockerfile it uses. A Dockerfile in the repository is resolved to its
+// path, so that it matches the Path of the Dockerfile's own findings, and
+// the base image of the stage built is read from it. Dockerfiles outside the
+// repository are not read.
+func composeBuildProperties(build interface{}, dir string) map[string]interface{} {
+	context, dockerfile, target := "", "", ""
+	switch b := build.(type) {
+	case string:
+		context = b
+	case map[string]interface{}:
+		context, _ = b["context"].(string)
+		dockerfile, _ = b["dockerfile"].(string)
+		target, _ = b["target"].(string)
+		if _, inline := b["dockerfile_inline"]; inline {
+			dockerfile = "inline"
+		}
+	}
+	if context == "" {
+		context = "."
+	}
+	properties := map[string]interface{}{"build_context": context}
+	if target != "" {
+		properties["build_target"] = target
+	}
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	properties["dockerfile"] = dockerfile
+	if dockerfile == "inline" || strings.Contains(context, "://") || strings.HasPrefix(context, "git@") {
+		return properties
+	}
+
+	dockerfilePath := filepath.Clean(filepath.Join(dir, context, dockerfile))
+	if filepath.IsAbs(dockerfile) {
+		dockerfilePath = filepath.Clean(dockerfile)
+	}
+	properties["dockerfile_path"] = dockerfilePath
+	if !withinRepository(dir, dockerfilePath) {
+		properties["dockerfile_found"] = false
+		return properties
+	}
+	content, err := os.ReadFile(dockerfilePath)
+	properties["dockerfile_found"] = err == nil
+	if err != nil {
+		return properties
+	}
+	if parsed, err := LoadDockerfile(string(content)); err == nil {
+		stage, ok := parsed.Final()
+		for _, candidate := range parsed.Stages {
+			if target != "" && candidate.Name == strings.ToLower(target) {
+				stage, ok = candidate, true
+			}
+		}
+		// A stage built on other stages has the image at the root of its chain.
+		for ok && stage.BaseStage != "" {
+			ok = false
+			for _, candidate := range parsed.Stages {
+				if candidate.Name == stage.BaseStage {
+					stage, ok = candidate, true
+					break
+				}
+			}
+		}
+		if ok && stage.Image != "" {
+			properties["build_base_image"] = stage.Image
+		}
+	}
+	return properties
+}
+
+// composePorts returns ports in their short syntax, e.g. "8080:80/tcp".
+func composePorts(value interface{}) []string {
+	list, _ := value.([]interface{})
+	var ports []string
+	for _, item := range list {
+		switch port := item.(type) {
+		case map[string]interface{}:
+			short := fmt.Sprint(port["target"])
+			if published, ok := port["published"]; ok {
+				short = fmt.Sprint(published) + ":" + short
+				if hostIP, ok := port["host_ip"].(string); ok && hostIP != "" {
+					short = hostIP + ":" + short
+				}
+			}
+			if protocol, ok := port["protocol"].(string); ok && protocol != "" {
+				short += "/" + protocol
+			}
+			ports = append(ports, short)
+		default:
+			ports = append(ports, fmt.Sprint(port))
+		}
+	}
+	return ports
+}
+
+// composeVolumes returns volumes in their short syntax, e.g. "data:/var/lib/data".
+func composeVolumes(value interface{}) []string {
+	list, _ := value.([]interface{})
+	var volumes []string
+	for _, item := range list {
+		switch volume := item.(type) {
+		case map[string]interface{}:
+			short := fmt.Sprint(volume["target"])
+			if source, ok := volume["source"].(string); ok && source != "" {
+				short = source + ":" + short
+			}
+			if readOnly, ok := volume["read_only"].(bool); ok && readOnly {
+				short += ":ro"
+			}
+			volumes = append(volumes, short)
+		default:
+			volumes = append(volumes, fmt.Sprint(volume))
+		}
+	}
+	return volumes
+}
+
+// composeEnvFiles returns the paths of env_file, which is a path, a list of
+// paths or a list of {path, required} entries.
+func composeEnvFiles(value interface{}) interface{} {
+	list, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	var paths []interface{}
+	for _, item := range list {
+		if entry, ok := item.(map[string]interface{}); ok {
+			paths = append(paths, entry["path"])
+		} else {
+			paths = append(paths, item)
+		}
+	}
+	return paths
+}
+
+// composeDependsOn returns the services depends_on names with the condition
+// each is waited for, from the list or the long syntax.
+func composeDependsOn(value interface{}) map[string]string {
+	dependencies := map[string]string{}
+	switch d := value.(type) {
+	case []interface{}:
+		for _, name := range stringList(d) {
+			dependencies[name] = "service_started"
+		}
+	case map[string]interface{}:
+		for name, settings := range d {
+			condition := "service_started"
+			if s, ok := settings.(map[string]interface{}); ok {
+				if c, ok := s["condition"].(string); ok && c != "" {
+					condition = c
+				}
+			}
+			dependencies[name] = condition
+		}
+	}
+	return dependencies
+}
+
+// composeDependencies returns the edges from a service to the services it
+// depends on, links to, shares volumes with or shares a network namespace
+// with. Each edge is named after the service it points to.
+func composeDependencies(service map[string]interface{}) []core.Finding {
+	var edges []core.Finding
+	edge := func(target string, kind string, properties map[string]interface{}) {
+		properties["depends_on"] = target
+		properties["kind"] = kind
+		edges = append(edges, core.Finding{
+			Name:       target,
+			Type:       "Docker Compose Dependency",
+			Category:   "",
+			Properties: properties,
 		})
 	}
 
-	return matches, nil
+	dependsOn := composeDependsOn(service["depends_on"])
+	for _, name := range sortedKeys(dependsOn) {
+		edge(name, "depends_on", map[string]interface{}{"condition": dependsOn[name]})
+	}
+	for _, link := range stringList(service["links"]) {
+		name, _, _ := strings.Cut(link, ":")
+		edge(name, "links", map[string]interface{}{})
+	}
+	for _, source := range stringList(service["volumes_from"]) {
+		// Containers outside the project are not services.
+		if strings.HasPrefix(source, "container:") {
+			continue
+		}
+		name, _, _ := strings.Cut(source, ":")
+		edge(name, "volumes_from", map[string]interface{}{})
+	}
+	if mode, ok := service["network_mode"].(string); ok {
+		if name, ok := strings.CutPrefix(mode, "service:"); ok {
+			edge(name, "network_mode", map[string]interface{}{})
+		}
+	}
+	return edges
 }
@@ -1,7 +1,12 @@
 package processors
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDockerComposeProcessor_Supports(t *testing.T) {
@@ -19,6 +24,10 @@ func TestDockerComposeProcessor_Supports(t *testing.T) {
 		{"random.yaml", false},
 		{"config.yml", false},
 		{"docker-compose.txt", false},
+		{"docker-compose.override.yml", true},
+		{"docker-compose.prod.yaml", true},
+		{"compose-dev.yml", true},
+		{"my-compose.yml", false},
 	}
 
 	for _, tt := range tests {
@@ -100,3 +109,199 @@ func TestDockerComposeProcessor_Process_InvalidYaml(t *testing.T) {
 		t.Error("Expected error due to invalid YAML, got nil")
 	}
 }
+
+func TestDockerComposeProcessor_Process_FullModel(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, ".env"), `# Defaults for local runs
+REGISTRY=ghcr.io/acme
+TAG="1.4.2"
+export DB_PASSWORD=secret
+`)
+	writeTestFile(t, filepath.Join(dir, "api", "Dockerfile"), `FROM golang:1.22 AS build
+FROM gcr.io/distroless/static AS runtime
+COPY --from=build /app /app
+`)
+	compose := `services:
+  api:
+    image: ${REGISTRY}/api:${TAG:-latest}
+    build:
+      context: ./api
+      target: build
+    ports:
+      - "8080:8080"
+      - target: 9090
+        published: 9091
+        protocol: udp
+    environment:
+      - DB_HOST=db
+      - DB_PASSWORD=${DB_PASSWORD}
+      - FEATURE=${FEATURE_FLAG}
+    depends_on:
+      db:
+        condition: service_healthy
+      cache:
+        condition: service_started
+    networks: [backend]
+    command: ["serve"]
+  db:
+    image: postgres:16
+    volumes:
+      - data:/var/lib/postgresql/data
+    restart: always
+  cache:
+    image: redis:7
+    profiles: [full]
+  proxy:
+    image: nginx:1.25
+    links: ["api:backend"]
+    network_mode: service:api
+volumes:
+  data: {}
+`
+	writeTestFile(t, filepath.Join(dir, "docker-compose.override.yml"), `services:
+  api:
+    environment:
+      LOG_LEVEL: debug
+    ports:
+      - "2345:2345"
+    command: ["debug"]
+  debugger:
+    image: busybox
+    depends_on: [api]
+    command: ["--port", "${DEBUG_PORT}"]
+`)
+	path := filepath.Join(dir, "docker-compose.yml")
+	writeTestFile(t, path, compose)
+
+	findings, err := DockerComposeProcessor{}.Process(path, "repo", compose)
+	require.NoError(t, err)
+
+	services := findingsOfType(findings, "Docker Compose Service")
+	require.Len(t, services, 5)
+	api := services["api"].Properties
+	assert.Equal(t, "ghcr.io/acme/api:1.4.2", api["image"])
+	assert.Equal(t, "ghcr.io", api["registry"])
+	assert.Equal(t, "acme/api", api["repository"])
+	assert.Equal(t, "1.4.2", api["tag"])
+	assert.Equal(t, []string{"8080:8080", "9091:9090/udp", "2345:2345"}, api["ports"])
+	assert.Equal(t, []string{"DB_HOST", "DB_PASSWORD", "FEATURE", "LOG_LEVEL"}, api["environment"])
+	assert.Equal(t, []string{"cache", "db"}, api["depends_on"])
+	assert.Equal(t, []string{"backend"}, api["networks"])
+	assert.Equal(t, []string{"FEATURE_FLAG"}, api["unresolved"])
+	assert.Nil(t, services["db"].Properties["unresolved"])
+	assert.Equal(t, []string{"DEBUG_PORT"}, services["debugger"].Properties["unresolved"])
+	assert.Equal(t, []string{"docker-compose.override.yml"}, api["merged_files"])
+
+	assert.Equal(t, "./api", api["build_context"])
+	assert.Equal(t, "Dockerfile", api["dockerfile"])
+	assert.Equal(t, filepath.Join(dir, "api", "Dockerfile"), api["dockerfile_path"])
+	assert.Equal(t, true, api["dockerfile_found"])
+	assert.Equal(t, "build", api["build_target"])
+	assert.Equal(t, "golang:1.22", api["build_base_image"])
+
+	assert.Equal(t, []string{"data:/var/lib/postgresql/data"}, services["db"].Properties["volumes"])
+	assert.Equal(t, "always", services["db"].Properties["restart"])
+	assert.Equal(t, []string{"full"}, services["cache"].Properties["profiles"])
+	assert.Equal(t, "busybox", services["debugger"].Properties["image"])
+
+	var edges []string
+	conditions := map[string]interface{}{}
+	for _, edge := range findingsByType(findings, "Docker Compose Dependency") {
+		edges = append(edges, edge.Name+" ("+edge.Properties["kind"].(string)+")")
+		conditions[edge.Name] = edge.Properties["condition"]
+	}
+	assert.Equal(t, []string{
+		"api -> cache (depends_on)",
+		"api -> db (depends_on)",
+		"debugger -> api (depends_on)",
+		"proxy -> api (links)",
+		"proxy -> api (network_mode)",
+	}, edges)
+	assert.Equal(t, "service_healthy", conditions["api -> db"])
+
+	// The override is reported as part of the base file.
+	findings, err = DockerComposeProcessor{}.Process(filepath.Join(dir, "docker-compose.override.yml"), "repo", "services: {}\n")
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestDockerComposeProcessor_Process_Variant(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "compose.yaml"), `services:
+  web:
+    build: .
+    ports: ["80:80"]
+  worker:
+    image: acme/worker:1.0
+`)
+	variant := `services:
+  web:
+    image: acme/web:${VERSION:?set VERSION}
+    build:
+      dockerfile: Dockerfile.prod
+`
+	path := filepath.Join(dir, "compose.prod.yaml")
+	findings, err := DockerComposeProcessor{}.Process(path, "repo", variant)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+
+	web := findings[0].Properties
+	assert.Equal(t, "web", findings[0].Name)
+	assert.Equal(t, "prod", web["variant"])
+	assert.Equal(t, []string{"compose.yaml"}, web["merged_files"])
+	assert.Equal(t, []string{"80:80"}, web["ports"])
+	assert.Equal(t, "acme/web:", web["image"])
+	assert.Equal(t, []string{"VERSION"}, web["unresolved"])
+	assert.Equal(t, ".", web["build_context"])
+	assert.Equal(t, "Dockerfile.prod", web["dockerfile"])
+	assert.Equal(t, false, web["dockerfile_found"])
+}
+
+func TestDockerComposeProcessor_Process_BuildOutsideRepository(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(parent, "Dockerfile"), "FROM host/secret:1.0\n")
+	content := `services:
+  context:
+    build: ..
+  absolute:
+    build:
+      dockerfile: ` + filepath.Join(parent, "Dockerfile") + `
+`
+	findings, err := DockerComposeProcessor{}.Process(filepath.Join(dir, "compose.yaml"), "repo", content)
+	require.NoError(t, err)
+	services := findingsByName(findings)
+	require.Len(t, services, 2)
+	for name, service := range services {
+		assert.Equal(t, false, service.Properties["dockerfile_found"], name)
+		assert.Nil(t, service.Properties["build_base_image"], name)
+	}
+}
+
+func TestInterpolateCompose(t *testing.T) {
+	env := map[string]string{"SET": "value", "EMPTY": ""}
+	tests := []struct {
+		value      string
+		expected   string
+		unresolved []string
+	}{
+		{"$SET and ${SET}", "value and value", nil},
+		{"${EMPTY:-default}/${EMPTY-default}", "default/", nil},
+		{"${MISSING-default}", "default", nil},
+		{"${SET:+alt}${EMPTY:+alt}${EMPTY+alt}", "altalt", nil},
+		{"$$SET", "$SET", nil},
+		{"${MISSING:?required}", "", []string{"MISSING"}},
+		{"${MISSING:-${SET}}/${MISSING:-${OTHER:-x}}-y", "value/x-y", nil},
+		{"${MISSING:-${ALSO_MISSING}}", "", []string{"ALSO_MISSING"}},
+		{"${SET:+${SET}-${SET}}${SET:-${IGNORED}}", "value-valuevalue", nil},
+		{"$SET_2 ${SET}s $ 5$", " values $ 5$", []string{"SET_2"}},
+		{"${SET", "${SET", nil},
+		{"${SET/x}", "", []string{"${SET/x}"}},
+	}
+	for _, tt := range tests {
+		expanded, unresolved := interpolateCompose(tt.value, env)
+		assert.Equal(t, tt.expected, expanded, tt.value)
+		assert.Equal(t, tt.unresolved, unresolved, tt.value)
+	}
+}
@@ -13,20 +13,27 @@ import (
 type DockerInstruction struct {
 	Directive string
 	Arguments string
+	// Line is the line the instruction starts on.
+	Line int
 }
 
 func ParseDockerfile(reader io.Reader) ([]DockerInstruction, error) {
 	var instructions []DockerInstruction
 	var currentCommand strings.Builder
+	lineNumber, startLine := 0, 0
 
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNumber++
 		trimmedLine := strings.TrimSpace(line)
 
 		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
 			continue
 		}
+		if currentCommand.Len() == 0 {
+			startLine = lineNumber
+		}
 
 		if strings.HasSuffix(trimmedLine, "\\") {
 			trimmedLine = strings.TrimRight(trimmedLine, "\\")
@@ -40,6 +47,7 @@ func ParseDockerfile(reader io.Reader) ([]DockerInstruction, error) {
 				if err != nil {
 					return nil, err
 				}
+				instruction.Line = startLine
 				instructions = append(instructions, instruction)
 			}
 			currentCommand.Reset()
@@ -57,6 +65,7 @@ func ParseDockerfile(reader io.Reader) ([]DockerInstruction, error) {
 			if err != nil {
 				return nil, err
 			}
+			instruction.Line = startLine
 			instructions = append(instructions, instruction)
 		}
 	}
@@ -65,8 +74,9 @@ func ParseDockerfile(reader io.Reader) ([]DockerInstruction, error) {
 }
 
 func parseInstruction(line string) (DockerInstruction, error) {
-	commentIndex := strings.Index(line, "#")
-	if commentIndex != -1 {
+	// A "#" starts a trailing comment only after whitespace, so URLs with
+	// fragments and values such as ARG CHAR=# are kept.
+	if commentIndex := strings.Index(line, " #"); commentIndex != -1 {
 		line = strings.TrimSpace(line[:commentIndex])
 	}
 
@@ -85,7 +95,15 @@ func parseInstruction(line string) (DockerInstruction, error) {
 	}, nil
 }
 
+// DockerProcessor reports a Dockerfile's directives, its build stages with
+// the graph of stages they build on and copy from, and the packages its RUN
+// instructions install. It also checks the Dockerfile against
+// DockerfileRules and reports each issue found.
 type DockerProcessor struct {
+	// Rules are the IDs of the rules to run. All rules run when it is empty.
+	Rules []string
+	// IgnoredRules are the IDs of rules never to report.
+	IgnoredRules []string
 }
 
 func (d DockerProcessor) Supports(filePath string) bool {
@@ -95,88 +113,149 @@ func (d DockerProcessor) Supports(filePath string) bool {
 }
 
 func (d DockerProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
-	var matches []core.Finding
-	reader := strings.NewReader(content)
-	instructions, err := ParseDockerfile(reader)
+	dockerfile, err := LoadDockerfile(content)
 	if err != nil {
 		return nil, err
 	}
-	handledInstructions := []string{"MAINTAINER", "LABEL", "FROM", "EXPOSE"}
+	newFinding := func(name string, findingType string, properties map[string]interface{}) core.Finding {
+		return core.Finding{
+			Name:       name,
+			Type:       findingType,
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		}
+	}
+	handledInstructions := []string{"MAINTAINER", "LABEL", "EXPOSE", "USER", "HEALTHCHECK", "ENTRYPOINT", "CMD"}
 
-	for _, instruction := range instructions {
-		if instruction.Directive == "FROM" {
-			// Handle multi-stage FROM directives with AS
-			imageParts, alias := parseFromDirective(instruction.Arguments)
+	var matches []core.Finding
+	for _, stage := range dockerfile.Stages {
+		final := stage.Index == len(dockerfile.Stages)-1
+		matches = append(matches, newFinding("FROM", "Docker Directive", fromProperties(stage, final)))
+
+		for _, instruction := range stage.Instructions {
+			if !utils.Contains(handledInstructions, instruction.Directive) {
+				continue
+			}
 			properties := map[string]interface{}{
-				"owner":   imageParts["owner"],
-				"image":   imageParts["image"],
-				"version": imageParts["version"],
+				"arguments": instruction.Arguments,
+				"stage":     stage.stageName(),
+				"line":      instruction.Line,
 			}
-			if alias != "" {
-				properties["alias"] = alias
+			if instruction.Directive == "ENTRYPOINT" || instruction.Directive == "CMD" {
+				properties["form"] = "shell"
+				if _, ok := dockerExecForm(instruction.Arguments); ok {
+					properties["form"] = "exec"
+				}
 			}
+			matches = append(matches, newFinding(instruction.Directive, "Docker Directive", properties))
+		}
+
+		matches = append(matches, newFinding(stage.stageName(), "Docker Stage", stageProperties(stage, final)))
 
-			matches = append(matches, core.Finding{
-				Name:       instruction.Directive,
-				Type:       "Docker Directive",
-				Category:   "",
-				Properties: properties,
-				Path:       path,
-				RepoName:   repoName,
-			})
-		} else if utils.Contains(handledInstructions, instruction.Directive) {
-			matches = append(matches, core.Finding{
-				Name:     instruction.Directive,
-				Type:     "Docker Directive",
-				Category: "",
-				Properties: map[string]interface{}{
-					"arguments": instruction.Arguments,
-				},
-				Path:     path,
-				RepoName: repoName,
-			})
+		for _, pkg := range stage.Packages {
+			properties := map[string]interface{}{
+				"manager": pkg.Manager,
+				"stage":   stage.stageName(),
+				"line":    pkg.Line,
+			}
+			if pkg.Version != "" {
+				properties["version"] = pkg.Version
+			}
+			matches = append(matches, newFinding(pkg.Name, "Docker Package", properties))
 		}
 	}
+	matches = append(matches, d.issueFindings(path, content, dockerfile, newFinding)...)
 	return matches, nil
 }
 
-// Helper function to parse the FROM directive
-func parseFromDirective(arguments string) (map[string]string, string) {
-	alias := ""
-	if strings.Contains(arguments, " AS ") {
-		parts := strings.SplitN(arguments, " AS ", 2)
-		arguments = parts[0]
-		alias = strings.TrimSpace(parts[1])
+// fromProperties describes the base of a stage: an image split into its
+// parts, or the earlier stage it builds on.
+func fromProperties(stage DockerStage, final bool) map[string]interface{} {
+	var properties map[string]interface{}
+	switch {
+	case stage.BaseStage != "":
+		properties = map[string]interface{}{
+			"owner":      "",
+			"image":      stage.BaseStage,
+			"version":    "",
+			"base_stage": stage.BaseStage,
+		}
+	case stage.Image == "scratch":
+		properties = map[string]interface{}{
+			"owner":   "",
+			"image":   "scratch",
+			"version": "",
+		}
+	default:
+		ref := parseImageReference(stage.Image)
+		properties = ref.properties()
+		// owner, image and version are the namespace, the last path
+		// component and the tag, e.g. "bitnami", "redis" and "7.2".
+		properties["owner"] = ""
+		if idx := strings.LastIndex(ref.Repository, "/"); idx >= 0 {
+			properties["owner"] = ref.Repository[:idx]
+			properties["namespace"] = ref.Repository[:idx]
+		} else if ref.Registry == "docker.io" {
+			properties["namespace"] = "library"
+		}
+		properties["image"] = ref.Repository[strings.LastIndex(ref.Repository, "/")+1:]
+		properties["version"] = ref.Tag
+		if ref.Tag == "latest" && !strings.HasSuffix(stage.Image, ":latest") {
+			properties["version"] = ""
+		}
+		properties["reference"] = stage.Image
 	}
-
-	imageParts := splitDockerImage(arguments)
-	return imageParts, alias
+	if stage.Name != "" {
+		properties["alias"] = stage.Name
+	}
+	if stage.Platform != "" {
+		properties["platform"] = stage.Platform
+	}
+	if len(stage.Unresolved) > 0 {
+		properties["unresolved_args"] = stage.Unresolved
+	}
+	properties["stage"] = stage.stageName()
+	properties["index"] = stage.Index
+	properties["final"] = final
+	properties["line"] = stage.Line
+	return properties
 }
 
-// Helper function to split a Docker image string into components
-func splitDockerImage(image string) map[string]string {
-	parts := strings.Split(image, "/")
-	result := map[string]string{
-		"owner":   "",
-		"image":   "",
-		"version": "",
-	}
-
-	if len(parts) == 2 {
-		result["owner"] = parts[0]
-		imageWithVersion := parts[1]
-		imageVersionParts := strings.SplitN(imageWithVersion, ":", 2)
-		result["image"] = imageVersionParts[0]
-		if len(imageVersionParts) == 2 {
-			result["version"] = imageVersionParts[1]
-		}
-	} else if len(parts) == 1 {
-		imageVersionParts := strings.SplitN(parts[0], ":", 2)
-		result["image"] = imageVersionParts[0]
-		if len(imageVersionParts) == 2 {
-			result["version"] = imageVersionParts[1]
+// stageProperties describes a build stage and what it depends on.
+func stageProperties(stage DockerStage, final bool) map[string]interface{} {
+	properties := map[string]interface{}{
+		"index": stage.Index,
+		"final": final,
+		"line":  stage.Line,
+	}
+	if stage.BaseStage != "" {
+		properties["base_stage"] = stage.BaseStage
+	} else {
+		properties["base_image"] = stage.Image
+	}
+	if dependsOn := stage.DependsOn(); len(dependsOn) > 0 {
+		properties["depends_on"] = dependsOn
+	}
+	if len(stage.CopiesFromImages) > 0 {
+		properties["copies_from_images"] = stage.CopiesFromImages
+	}
+	if stage.Platform != "" {
+		properties["platform"] = stage.Platform
+	}
+	for key, value := range map[string]string{
+		"user":        stage.User,
+		"healthcheck": stage.Healthcheck,
+		"entrypoint":  stage.Entrypoint,
+		"cmd":         stage.Cmd,
+	} {
+		if value != "" {
+			properties[key] = value
 		}
 	}
-
-	return result
+	if len(stage.Packages) > 0 {
+		properties["packages"] = len(stage.Packages)
+	}
+	return properties
 }
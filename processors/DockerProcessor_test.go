@@ -1,9 +1,13 @@
 package processors
 
 import (
-	"github.com/reaandrew/techdetector/core"
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
 )
 
 func TestDockerProcessor_Supports(t *testing.T) {
@@ -68,9 +72,18 @@ ENTRYPOINT ["bash", "-c", "echo Hello World"]
 			Type:     "Docker Directive",
 			Category: "",
 			Properties: map[string]interface{}{
-				"image":   "ubuntu",
-				"version": "20.04",
-				"owner":   "",
+				"image":      "ubuntu",
+				"version":    "20.04",
+				"owner":      "",
+				"namespace":  "library",
+				"registry":   "docker.io",
+				"repository": "ubuntu",
+				"tag":        "20.04",
+				"reference":  "ubuntu:20.04",
+				"stage":      "0",
+				"index":      0,
+				"final":      true,
+				"line":       3,
 			},
 			Path:     path,
 			RepoName: repoName,
@@ -81,6 +94,101 @@ ENTRYPOINT ["bash", "-c", "echo Hello World"]
 			Category: "",
 			Properties: map[string]interface{}{
 				"arguments": "8080",
+				"stage":     "0",
+				"line":      14,
+			},
+			Path:     path,
+			RepoName: repoName,
+		},
+		{
+			Name:     "USER",
+			Type:     "Docker Directive",
+			Category: "",
+			Properties: map[string]interface{}{
+				"arguments": "appuser",
+				"stage":     "0",
+				"line":      20,
+			},
+			Path:     path,
+			RepoName: repoName,
+		},
+		{
+			Name:     "ENTRYPOINT",
+			Type:     "Docker Directive",
+			Category: "",
+			Properties: map[string]interface{}{
+				"arguments": `["bash", "-c", "echo Hello World"]`,
+				"form":      "exec",
+				"stage":     "0",
+				"line":      23,
+			},
+			Path:     path,
+			RepoName: repoName,
+		},
+		{
+			Name:     "0",
+			Type:     "Docker Stage",
+			Category: "",
+			Properties: map[string]interface{}{
+				"index":      0,
+				"final":      true,
+				"line":       3,
+				"base_image": "ubuntu:20.04",
+				"user":       "appuser",
+				"entrypoint": `["bash", "-c", "echo Hello World"]`,
+				"packages":   2,
+			},
+			Path:     path,
+			RepoName: repoName,
+		},
+		{
+			Name:     "curl",
+			Type:     "Docker Package",
+			Category: "",
+			Properties: map[string]interface{}{
+				"manager": "apt",
+				"stage":   "0",
+				"line":    6,
+			},
+			Path:     path,
+			RepoName: repoName,
+		},
+		{
+			Name:     "wget",
+			Type:     "Docker Package",
+			Category: "",
+			Properties: map[string]interface{}{
+				"manager": "apt",
+				"stage":   "0",
+				"line":    6,
+			},
+			Path:     path,
+			RepoName: repoName,
+		},
+		{
+			Name:     "TD002",
+			Type:     "Dockerfile Issue",
+			Category: "",
+			Properties: map[string]interface{}{
+				"rule":     "TD002",
+				"severity": "info",
+				"message":  "The final stage has no HEALTHCHECK",
+				"stage":    "0",
+				"line":     3,
+			},
+			Path:     path,
+			RepoName: repoName,
+		},
+		{
+			Name:     "DL3015",
+			Type:     "Dockerfile Issue",
+			Category: "",
+			Properties: map[string]interface{}{
+				"rule":     "DL3015",
+				"severity": "info",
+				"message":  "Avoid additional packages by specifying --no-install-recommends",
+				"stage":    "0",
+				"line":     6,
 			},
 			Path:     path,
 			RepoName: repoName,
@@ -96,3 +204,114 @@ ENTRYPOINT ["bash", "-c", "echo Hello World"]
 		t.Errorf("Process returned unexpected matches.\nGot:\n%v\nExpected:\n%v", matches, expectedMatches)
 	}
 }
+
+// dockerDirectives returns the directives among findings with the given name.
+func dockerDirectives(findings []core.Finding, directive string) []core.Finding {
+	var directives []core.Finding
+	for _, finding := range findingsByType(findings, "Docker Directive") {
+		if finding.Name == directive {
+			directives = append(directives, finding)
+		}
+	}
+	return directives
+}
+
+func TestDockerProcessor_Process_MultiStage(t *testing.T) {
+	content := `ARG REGISTRY=registry.example.com:5000
+ARG GO_VERSION=1.22
+ARG BASE_DIGEST
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION}-alpine as Build
+RUN apk add --no-cache git=2.43.0-r0 make
+RUN --mount=type=cache,target=/root/.cache go build -o /app ./cmd
+HEALTHCHECK CMD wget -q localhost
+
+FROM build AS test
+RUN go test ./...
+
+FROM ${REGISTRY}/team/runtime:${TAG:-stable}@sha256:abc AS final
+RUN apt-get update && apt-get install -y --no-install-recommends ca-certificates curl=7.88.1-10 \\
+ && pip install --no-cache-dir -r requirements.txt requests==2.31.0 "uvicorn[standard]>=0.29" \\
+ && npm install -g @angular/cli@17 typescript
+COPY --from=build /app /app
+COPY --from=1 /reports /reports
+COPY --from=nginx:1.25 /etc/nginx/nginx.conf /etc/nginx/
+USER 1000:1000
+ENTRYPOINT /app
+CMD ["--port", "8080"]
+`
+	findings, err := DockerProcessor{}.Process("Dockerfile", "repo", content)
+	require.NoError(t, err)
+
+	froms := dockerDirectives(findings, "FROM")
+	require.Len(t, froms, 3)
+	assert.Equal(t, "golang:1.22-alpine", froms[0].Properties["reference"])
+	assert.Equal(t, "1.22-alpine", froms[0].Properties["tag"])
+	assert.Equal(t, "build", froms[0].Properties["alias"])
+	assert.Equal(t, "$BUILDPLATFORM", froms[0].Properties["platform"])
+	assert.Equal(t, false, froms[0].Properties["final"])
+	assert.Equal(t, "build", froms[0].Properties["stage"])
+	assert.Equal(t, 0, froms[0].Properties["index"])
+
+	assert.Equal(t, "build", froms[1].Properties["base_stage"])
+	assert.Nil(t, froms[1].Properties["registry"])
+
+	final := froms[2].Properties
+	assert.Equal(t, "registry.example.com:5000", final["registry"])
+	assert.Equal(t, "team", final["namespace"])
+	assert.Equal(t, "team/runtime", final["repository"])
+	assert.Equal(t, "runtime", final["image"])
+	assert.Equal(t, "stable", final["tag"])
+	assert.Equal(t, "sha256:abc", final["digest"])
+	assert.Equal(t, true, final["final"])
+	assert.Equal(t, "final", final["stage"])
+	assert.Equal(t, 2, final["index"])
+	assert.Equal(t, 12, final["line"])
+
+	stages := findingsOfType(findings, "Docker Stage")
+	require.Len(t, stages, 3)
+	assert.Equal(t, "CMD wget -q localhost", stages["test"].Properties["healthcheck"])
+	assert.Equal(t, []string{"build"}, stages["test"].Properties["depends_on"])
+	assert.Equal(t, []string{"build", "test"}, stages["final"].Properties["depends_on"])
+	assert.Equal(t, []string{"nginx:1.25"}, stages["final"].Properties["copies_from_images"])
+	assert.Equal(t, "1000:1000", stages["final"].Properties["user"])
+	assert.Equal(t, "/app", stages["final"].Properties["entrypoint"])
+	assert.Equal(t, true, stages["final"].Properties["final"])
+	assert.Nil(t, stages["final"].Properties["healthcheck"])
+
+	packages := findingsOfType(findings, "Docker Package")
+	require.Len(t, packages, 8)
+	assert.Equal(t, "apk", packages["git"].Properties["manager"])
+	assert.Equal(t, "2.43.0-r0", packages["git"].Properties["version"])
+	assert.Equal(t, "build", packages["make"].Properties["stage"])
+	assert.Equal(t, "7.88.1-10", packages["curl"].Properties["version"])
+	assert.Equal(t, "apt", packages["ca-certificates"].Properties["manager"])
+	assert.Equal(t, "2.31.0", packages["requests"].Properties["version"])
+	assert.Equal(t, "pip", packages["uvicorn"].Properties["manager"])
+	assert.Equal(t, "17", packages["@angular/cli"].Properties["version"])
+	assert.Equal(t, "npm", packages["typescript"].Properties["manager"])
+	assert.Equal(t, 13, packages["typescript"].Properties["line"])
+
+	for _, finding := range dockerDirectives(findings, "CMD") {
+		assert.Equal(t, "exec", finding.Properties["form"])
+	}
+}
+
+func TestExpandDockerArgs(t *testing.T) {
+	args := map[string]string{"TAG": "1.0", "EMPTY": ""}
+	tests := []struct {
+		value      string
+		expected   string
+		unresolved []string
+	}{
+		{"app:$TAG", "app:1.0", nil},
+		{"app:${TAG}", "app:1.0", nil},
+		{"app:${EMPTY:-dev}", "app:dev", nil},
+		{"app${TAG:+-tagged}", "app-tagged", nil},
+		{"app:${MISSING}", "app:", []string{"MISSING"}},
+	}
+	for _, tt := range tests {
+		expanded, unresolved := expandDockerArgs(tt.value, args)
+		assert.Equal(t, tt.expected, expanded, tt.value)
+		assert.Equal(t, tt.unresolved, unresolved, tt.value)
+	}
+}
@@ -0,0 +1,400 @@
+package processors
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/reaandrew/techdetector/utils"
+)
+
+// dockerArgRe matches a variable reference: $NAME, ${NAME}, ${NAME:-default}
+// or ${NAME:+alternative}.
+var dockerArgRe = regexp.MustCompile(`\$(?:\{(\w+)(?::([-+])([^}]*))?\}|(\w+))`)
+
+// dockerShellSeparatorRe splits a shell command line into simple commands.
+var dockerShellSeparatorRe = regexp.MustCompile(`&&|\|\||;|\|`)
+
+// Dockerfile is a Dockerfile split into its build stages.
+type Dockerfile struct {
+	// Args are the ARGs declared before the first FROM, with their defaults,
+	// and Global the instructions before the first FROM.
+	Args   map[string]string
+	Global []DockerInstruction
+	Stages []DockerStage
+}
+
+// DockerStage is a build stage, from its FROM instruction to the next.
+type DockerStage struct {
+	Index int
+	// Name is the stage's AS name, lowercased as Docker does.
+	Name string
+	Line int
+	// Image is the base image with ARGs substituted. BaseStage is set instead
+	// when the stage builds on an earlier stage.
+	Image      string
+	BaseStage  string
+	Platform   string
+	Unresolved []string
+	// CopiesFrom are the earlier stages files are copied or mounted from,
+	// and CopiesFromImages the images.
+	CopiesFrom       []string
+	CopiesFromImages []string
+	// User, Healthcheck, Entrypoint and Cmd are the last values set in the
+	// stage or inherited from its base stage.
+	User         string
+	Healthcheck  string
+	Entrypoint   string
+	Cmd          string
+	Packages     []DockerPackage
+	Instructions []DockerInstruction
+}
+
+// DockerPackage is a package a RUN instruction installs.
+type DockerPackage struct {
+	Manager string
+	Name    string
+	Version string
+	Line    int
+}
+
+// Final returns the last stage, which is the one built by default.
+func (d Dockerfile) Final() (DockerStage, bool) {
+	if len(d.Stages) == 0 {
+		return DockerStage{}, false
+	}
+	return d.Stages[len(d.Stages)-1], true
+}
+
+// stageName returns the name a stage is referred to by: its AS name, or its
+// index.
+func (s DockerStage) stageName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return strconv.Itoa(s.Index)
+}
+
+// DependsOn returns the earlier stages a stage builds on or copies from.
+func (s DockerStage) DependsOn() []string {
+	var stages []string
+	for _, stage := range append([]string{s.BaseStage}, s.CopiesFrom...) {
+		if stage != "" && !utils.Contains(stages, stage) {
+			stages = append(stages, stage)
+		}
+	}
+	return stages
+}
+
+// LoadDockerfile parses a Dockerfile into its stages.
+func LoadDockerfile(content string) (Dockerfile, error) {
+	instructions, err := ParseDockerfile(strings.NewReader(content))
+	if err != nil {
+		return Dockerfile{}, err
+	}
+	return buildDockerfile(instructions), nil
+}
+
+func buildDockerfile(instructions []DockerInstruction) Dockerfile {
+	dockerfile := Dockerfile{Args: map[string]string{}}
+	stagesByName := map[string]int{}
+	var stage *DockerStage
+
+	for _, instruction := range instructions {
+		if instruction.Directive == "FROM" {
+			dockerfile.Stages = append(dockerfile.Stages, newDockerStage(len(dockerfile.Stages), instruction, dockerfile.Args, dockerfile.Stages, stagesByName))
+			stage = &dockerfile.Stages[len(dockerfile.Stages)-1]
+			if stage.Name != "" {
+				stagesByName[stage.Name] = stage.Index
+			}
+			continue
+		}
+		if stage == nil {
+			dockerfile.Global = append(dockerfile.Global, instruction)
+			if instruction.Directive == "ARG" {
+				for name, value := range parseDockerArgs(instruction.Arguments) {
+					dockerfile.Args[name] = value
+				}
+			}
+			continue
+		}
+
+		stage.Instructions = append(stage.Instructions, instruction)
+		flags, rest := dockerFlags(instruction.Arguments)
+		switch instruction.Directive {
+		case "USER":
+			stage.User = rest
+		case "HEALTHCHECK":
+			stage.Healthcheck = rest
+		case "ENTRYPOINT":
+			stage.Entrypoint = rest
+		case "CMD":
+			stage.Cmd = rest
+		case "COPY", "ADD":
+			if from, ok := flags["from"]; ok {
+				stage.addSource(from, stagesByName, dockerfile.Stages)
+			}
+		case "RUN":
+			for _, mount := range dockerFlagValues(instruction.Arguments, "mount") {
+				for _, option := range strings.Split(mount, ",") {
+					if from, ok := strings.CutPrefix(option, "from="); ok {
+						stage.addSource(from, stagesByName, dockerfile.Stages)
+					}
+				}
+			}
+			stage.Packages = append(stage.Packages, dockerPackages(rest, instruction.Line)...)
+		}
+	}
+	return dockerfile
+}
+
+// newDockerStage starts a stage from its FROM instruction, substituting the
+// global ARGs into the image and platform.
+func newDockerStage(index int, instruction DockerInstruction, args map[string]string, stages []DockerStage, stagesByName map[string]int) DockerStage {
+	stage := DockerStage{Index: index, Line: instruction.Line}
+	flags, rest := dockerFlags(instruction.Arguments)
+	fields := strings.Fields(rest)
+	if len(fields) >= 3 && strings.EqualFold(fields[1], "as") {
+		stage.Name = strings.ToLower(fields[2])
+	}
+	if len(fields) > 0 {
+		stage.Image, stage.Unresolved = expandDockerArgs(fields[0], args)
+	}
+	if platform, ok := flags["platform"]; ok {
+		// Automatic platform ARGs such as $BUILDPLATFORM are kept as written.
+		stage.Platform = platform
+		if expanded, unresolved := expandDockerArgs(platform, args); len(unresolved) == 0 {
+			stage.Platform = expanded
+		}
+	}
+
+	if base, ok := stagesByName[strings.ToLower(stage.Image)]; ok {
+		stage.BaseStage = strings.ToLower(stage.Image)
+		inherited := stages[base]
+		stage.User, stage.Healthcheck = inherited.User, inherited.Healthcheck
+		stage.Entrypoint, stage.Cmd = inherited.Entrypoint, inherited.Cmd
+	}
+	return stage
+}
+
+// addSource records a COPY --from or RUN --mount from source, which is the
+// name or index of an earlier stage or else an image.
+func (s *DockerStage) addSource(source string, stagesByName map[string]int, stages []DockerStage) {
+	if _, ok := stagesByName[strings.ToLower(source)]; ok {
+		source = strings.ToLower(source)
+	} else if index, err := strconv.Atoi(source); err == nil && index >= 0 && index < len(stages) {
+		source = stages[index].stageName()
+	} else {
+		if !utils.Contains(s.CopiesFromImages, source) {
+			s.CopiesFromImages = append(s.CopiesFromImages, source)
+		}
+		return
+	}
+	if !utils.Contains(s.CopiesFrom, source) {
+		s.CopiesFrom = append(s.CopiesFrom, source)
+	}
+}
+
+// parseDockerArgs returns the names and defaults an ARG instruction declares.
+func parseDockerArgs(arguments string) map[string]string {
+	args := map[string]string{}
+	for _, field := range strings.Fields(arguments) {
+		name, value, _ := strings.Cut(field, "=")
+		args[name] = strings.Trim(value, `"'`)
+	}
+	return args
+}
+
+// expandDockerArgs substitutes ARG values into s. References to ARGs without
+// a value are replaced by nothing and returned.
+func expandDockerArgs(s string, args map[string]string) (string, []string) {
+	var unresolved []string
+	expanded := dockerArgRe.ReplaceAllStringFunc(s, func(ref string) string {
+		match := dockerArgRe.FindStringSubmatch(ref)
+		name := match[1] + match[4]
+		value, ok := args[name]
+		set := ok && value != ""
+		switch match[2] {
+		case "-":
+			if !set {
+				return match[3]
+			}
+		case "+":
+			if set {
+				return match[3]
+			}
+			return ""
+		}
+		if !set {
+			unresolved = append(unresolved, name)
+		}
+		return value
+	})
+	return expanded, unresolved
+}
+
+// dockerFlags splits the leading --name=value flags off an instruction's
+// arguments. Repeated flags keep their last value.
+func dockerFlags(arguments string) (map[string]string, string) {
+	flags := map[string]string{}
+	rest := strings.TrimSpace(arguments)
+	for strings.HasPrefix(rest, "--") {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		name, value, _ := strings.Cut(rest[2:end], "=")
+		flags[strings.ToLower(name)] = value
+		rest = strings.TrimSpace(rest[end:])
+	}
+	return flags, rest
+}
+
+// dockerFlagValues returns every value of a leading flag, for flags such as
+// --mount that can be repeated.
+func dockerFlagValues(arguments string, flag string) []string {
+	var values []string
+	for _, field := range strings.Fields(arguments) {
+		if !strings.HasPrefix(field, "--") {
+			break
+		}
+		if value, ok := strings.CutPrefix(field, "--"+flag+"="); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// dockerExecForm returns the arguments of an instruction in exec form, a
+// JSON array such as ["nginx", "-g", "daemon off;"].
+func dockerExecForm(arguments string) ([]string, bool) {
+	var args []string
+	if !strings.HasPrefix(strings.TrimSpace(arguments), "[") || json.Unmarshal([]byte(arguments), &args) != nil {
+		return nil, false
+	}
+	return args, true
+}
+
+// dockerShellCommands splits a RUN command line into the words of each
+// simple command, without quotes.
+func dockerShellCommands(command string) [][]string {
+	var commands [][]string
+	for _, part := range dockerShellSeparatorRe.Split(command, -1) {
+		var words []string
+		for _, word := range strings.Fields(part) {
+			words = append(words, strings.Trim(word, `"'`))
+		}
+		// Skip leading environment assignments and sudo.
+		for len(words) > 0 && (words[0] == "sudo" || strings.Contains(words[0], "=") && !strings.HasPrefix(words[0], "-")) {
+			words = words[1:]
+		}
+		if len(words) > 0 {
+			commands = append(commands, words)
+		}
+	}
+	return commands
+}
+
+// dockerPackageCommands are the install subcommands of each package
+// manager, and how a version is attached to a package name.
+var dockerPackageCommands = map[string]struct {
+	Manager    string
+	Subcommand []string
+	Separator  string
+}{
+	"apt-get":  {"apt", []string{"install"}, "="},
+	"apt":      {"apt", []string{"install"}, "="},
+	"apk":      {"apk", []string{"add"}, "="},
+	"yum":      {"yum", []string{"install"}, ""},
+	"dnf":      {"yum", []string{"install"}, ""},
+	"microdnf": {"yum", []string{"install"}, ""},
+	"pip":      {"pip", []string{"install"}, "=="},
+	"pip3":     {"pip", []string{"install"}, "=="},
+	"npm":      {"npm", []string{"install", "i", "add"}, "@"},
+	"yarn":     {"npm", []string{"add"}, "@"},
+	"pnpm":     {"npm", []string{"add", "install", "i"}, "@"},
+	"pipx":     {"pip", []string{"install"}, "=="},
+	"gem":      {"gem", []string{"install"}, ""},
+}
+
+// dockerOptionsWithValues are package manager options whose value is the
+// next word rather than a package.
+var dockerOptionsWithValues = map[string]bool{
+	"-r": true, "--requirement": true, "-c": true, "--constraint": true, "-i": true, "--index-url": true,
+	"--extra-index-url": true, "-t": true, "--target": true, "--prefix": true, "-f": true, "--find-links": true,
+	"-X": true, "--repository": true, "--registry": true, "-v": true, "--version": true,
+	"--cache-dir": true, "--root": true, "--installroot": true, "--setopt": true, "-o": true,
+}
+
+// dockerPackages returns the packages a RUN command line installs with apt,
+// apk, yum, pip and npm. Packages given by variables or files are skipped.
+func dockerPackages(command string, line int) []DockerPackage {
+	var packages []DockerPackage
+	for _, words := range dockerShellCommands(command) {
+		program := words[0][strings.LastIndex(words[0], "/")+1:]
+		if strings.HasPrefix(program, "python") && len(words) > 2 && words[1] == "-m" && strings.HasPrefix(words[2], "pip") {
+			program, words = "pip", words[2:]
+		}
+		if strings.HasPrefix(program, "pip3.") {
+			program = "pip3"
+		}
+		manager, ok := dockerPackageCommands[program]
+		if !ok {
+			continue
+		}
+
+		args := words[1:]
+		// Skip options before the subcommand, e.g. "yum -y install", and
+		// "yarn global add".
+		for len(args) > 0 && (strings.HasPrefix(args[0], "-") || args[0] == "global") {
+			args = args[1:]
+		}
+		if len(args) == 0 || !utils.Contains(manager.Subcommand, args[0]) {
+			continue
+		}
+
+		skipNext := false
+		for _, arg := range args[1:] {
+			switch {
+			case skipNext:
+				skipNext = false
+			case dockerOptionsWithValues[arg]:
+				skipNext = true
+			case strings.HasPrefix(arg, "-") || strings.Contains(arg, "$") || strings.ContainsAny(arg, "/\\") && !strings.HasPrefix(arg, "@") || arg == ".":
+			default:
+				name, version := splitDockerPackage(arg, manager.Separator)
+				if name != "" {
+					packages = append(packages, DockerPackage{Manager: manager.Manager, Name: name, Version: version, Line: line})
+				}
+			}
+		}
+	}
+	return packages
+}
+
+// splitDockerPackage splits a package argument such as curl=7.88.1-10,
+// requests==2.31.0 or @types/node@20 into its name and version.
+func splitDockerPackage(arg string, separator string) (string, string) {
+	switch separator {
+	case "":
+		return arg, ""
+	case "@":
+		if idx := strings.LastIndex(arg, "@"); idx > 0 {
+			return arg[:idx], arg[idx+1:]
+		}
+		return arg, ""
+	case "==":
+		// Strip extras and version specifiers: pkg[extra]>=1.0
+		if idx := strings.IndexAny(arg, "=<>!~;["); idx >= 0 {
+			version := ""
+			if rest, ok := strings.CutPrefix(arg[idx:], "=="); ok {
+				version = rest
+			}
+			return arg[:idx], version
+		}
+		return arg, ""
+	}
+	name, version, _ := strings.Cut(arg, separator)
+	return name, version
+}
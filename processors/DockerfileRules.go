@@ -0,0 +1,434 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+	"github.com/reaandrew/techdetector/utils"
+)
+
+// maxDockerfileConfigDepth bounds how far up from a Dockerfile a
+// .hadolint.yaml is looked for.
+const maxDockerfileConfigDepth = 10
+
+var (
+	dockerfileIgnoreRe = regexp.MustCompile(`^\s*#\s*(?:hadolint|techdetector)\s+ignore=([\w,\s]+)`)
+	dockerEnvNameRe    = regexp.MustCompile(`(?:^|\s)([A-Za-z_][\w.-]*)=`)
+	dockerSecretNameRe = regexp.MustCompile(`(?i)passw(?:or)?d|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credential`)
+	dockerArchiveRe    = regexp.MustCompile(`\.(?:tar|tar\.\w+|tgz|tbz2?|txz|gz|bz2|xz)$`)
+)
+
+// dockerfileViolation is a place a rule is broken.
+type dockerfileViolation struct {
+	Line   int
+	Stage  string
+	Detail string
+}
+
+// DockerfileRule is a best-practice check on a Dockerfile. Rules that match
+// a hadolint rule share its ID, so hadolint configuration and ignore
+// comments apply to them.
+type DockerfileRule struct {
+	ID       string
+	Severity string
+	Message  string
+	Check    func(dockerfile Dockerfile) []dockerfileViolation
+}
+
+// DockerfileRules are the built-in rules.
+var DockerfileRules = []DockerfileRule{
+	{"DL3002", "warning", "Last USER should not be root", checkDockerRootUser},
+	{"DL3005", "error", "Do not use apt-get upgrade or dist-upgrade", checkDockerAptUpgrade},
+	{"DL3006", "warning", "Always tag the version of an image explicitly", checkDockerUntaggedImage},
+	{"DL3007", "warning", "Using latest is prone to errors if the image will ever update", checkDockerLatestImage},
+	{"DL3015", "info", "Avoid additional packages by specifying --no-install-recommends", checkDockerInstallRecommends},
+	{"DL3020", "error", "Use COPY instead of ADD for files and folders", checkDockerAddLocalFile},
+	{"TD001", "warning", "The final stage sets no USER, so the container runs as root", checkDockerNoUser},
+	{"TD002", "info", "The final stage has no HEALTHCHECK", checkDockerNoHealthcheck},
+	{"TD003", "error", "Secrets should not be set in ENV or ARG", checkDockerSecrets},
+	{"TD004", "warning", "Do not ADD files from remote URLs; download them with curl or wget in a RUN instead", checkDockerAddURL},
+}
+
+// hadolint reports whether the rule is one of hadolint's, whose
+// configuration applies to it.
+func (r DockerfileRule) hadolint() bool {
+	return strings.HasPrefix(r.ID, "DL")
+}
+
+// dockerfileConfig is the part of a .hadolint.yaml the rules read: the
+// rules to ignore and the rules whose severity is overridden. As in
+// hadolint, it applies only to hadolint's rules.
+type dockerfileConfig struct {
+	Ignored  []string            `yaml:"ignored"`
+	Override map[string][]string `yaml:"override"`
+}
+
+// repositoryConfig is the .techdetector.yaml at the root of a repository.
+// Its dockerfile section selects the Dockerfile rules run on the
+// repository, as DockerProcessor.Rules and IgnoredRules do.
+type repositoryConfig struct {
+	Dockerfile struct {
+		Rules   []string `yaml:"rules"`
+		Ignored []string `yaml:"ignored"`
+	} `yaml:"dockerfile"`
+}
+
+// findDockerfileConfig walks up from dir to a .hadolint.yaml, stopping at
+// the root of the repository.
+func findDockerfileConfig(dir string) (dockerfileConfig, bool) {
+	root, bounded := repositoryRoot(dir)
+	for i := 0; i < maxDockerfileConfigDepth && (!bounded || withinDirectory(root, dir)); i++ {
+		for _, name := range []string{".hadolint.yaml", ".hadolint.yml"} {
+			content, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			var config dockerfileConfig
+			if yaml.Unmarshal(content, &config) == nil {
+				return config, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return dockerfileConfig{}, false
+}
+
+// findRepositoryConfig reads the .techdetector.yaml at the root of the
+// repository path is in.
+func findRepositoryConfig(path string) (repositoryConfig, bool) {
+	root, ok := repositoryRoot(path)
+	if !ok {
+		return repositoryConfig{}, false
+	}
+	for _, name := range []string{".techdetector.yaml", ".techdetector.yml"} {
+		content, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			continue
+		}
+		var config repositoryConfig
+		if yaml.Unmarshal(content, &config) == nil {
+			return config, true
+		}
+	}
+	return repositoryConfig{}, false
+}
+
+// forRepository returns d with the rule selection of the repository path is
+// in: its rules replace d.Rules, and its ignored rules are added to
+// d.IgnoredRules.
+func (d DockerProcessor) forRepository(path string) DockerProcessor {
+	config, ok := findRepositoryConfig(path)
+	if !ok {
+		return d
+	}
+	if len(config.Dockerfile.Rules) > 0 {
+		d.Rules = config.Dockerfile.Rules
+	}
+	d.IgnoredRules = append(append([]string{}, d.IgnoredRules...), config.Dockerfile.Ignored...)
+	return d
+}
+
+// dockerfileIgnoredLines returns, for each line, the rules an ignore comment
+// on the line before it suppresses, e.g. "# hadolint ignore=DL3008,DL3015".
+func dockerfileIgnoredLines(content string) map[int][]string {
+	ignored := map[int][]string{}
+	var pending []string
+	for i, line := range strings.Split(content, "\n") {
+		if match := dockerfileIgnoreRe.FindStringSubmatch(line); match != nil {
+			for _, id := range strings.Split(match[1], ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					pending = append(pending, id)
+				}
+			}
+			continue
+		}
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if len(pending) > 0 {
+			ignored[i+1] = pending
+			pending = nil
+		}
+	}
+	return ignored
+}
+
+// issueFindings runs the rules selected by the processor and the
+// repository's .techdetector.yaml over a Dockerfile, and reports each
+// violation that is not ignored by them, the repository's .hadolint.yaml or a
+// comment.
+func (d DockerProcessor) issueFindings(path string, content string, dockerfile Dockerfile, newFinding func(string, string, map[string]interface{}) core.Finding) []core.Finding {
+	d = d.forRepository(path)
+	config, _ := findDockerfileConfig(filepath.Dir(path))
+	severities := map[string]string{}
+	for severity, ids := range config.Override {
+		for _, id := range ids {
+			severities[id] = severity
+		}
+	}
+	ignoredLines := dockerfileIgnoredLines(content)
+
+	var matches []core.Finding
+	for _, rule := range DockerfileRules {
+		if len(d.Rules) > 0 && !utils.Contains(d.Rules, rule.ID) {
+			continue
+		}
+		if utils.Contains(d.IgnoredRules, rule.ID) || rule.hadolint() && utils.Contains(config.Ignored, rule.ID) {
+			continue
+		}
+		severity := rule.Severity
+		if override, ok := severities[rule.ID]; ok && rule.hadolint() {
+			severity = override
+		}
+		for _, violation := range rule.Check(dockerfile) {
+			if utils.Contains(ignoredLines[violation.Line], rule.ID) {
+				continue
+			}
+			properties := map[string]interface{}{
+				"rule":     rule.ID,
+				"severity": severity,
+				"message":  rule.Message,
+				"line":     violation.Line,
+			}
+			if violation.Stage != "" {
+				properties["stage"] = violation.Stage
+			}
+			if violation.Detail != "" {
+				properties["detail"] = violation.Detail
+			}
+			matches = append(matches, newFinding(rule.ID, "Dockerfile Issue", properties))
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Properties["line"].(int) < matches[j].Properties["line"].(int)
+	})
+	return matches
+}
+
+// dockerBaseImages returns the stages built on an image, rather than on an
+// earlier stage or scratch, whose image is fully resolved.
+func dockerBaseImages(dockerfile Dockerfile) []DockerStage {
+	var stages []DockerStage
+	for _, stage := range dockerfile.Stages {
+		if stage.BaseStage == "" && stage.Image != "" && stage.Image != "scratch" && len(stage.Unresolved) == 0 {
+			stages = append(stages, stage)
+		}
+	}
+	return stages
+}
+
+func checkDockerUntaggedImage(dockerfile Dockerfile) []dockerfileViolation {
+	var violations []dockerfileViolation
+	for _, stage := range dockerBaseImages(dockerfile) {
+		ref := parseImageReference(stage.Image)
+		if ref.Tag == "latest" && ref.Digest == "" && !strings.HasSuffix(stage.Image, ":latest") {
+			violations = append(violations, dockerfileViolation{Line: stage.Line, Stage: stage.stageName(), Detail: stage.Image})
+		}
+	}
+	return violations
+}
+
+func checkDockerLatestImage(dockerfile Dockerfile) []dockerfileViolation {
+	var violations []dockerfileViolation
+	for _, stage := range dockerBaseImages(dockerfile) {
+		if ref := parseImageReference(stage.Image); ref.Digest == "" && strings.HasSuffix(stage.Image, ":latest") {
+			violations = append(violations, dockerfileViolation{Line: stage.Line, Stage: stage.stageName(), Detail: stage.Image})
+		}
+	}
+	return violations
+}
+
+// dockerLastInstruction returns the line of the last instruction of a
+// stage with the given directive, or the stage's FROM line.
+func dockerLastInstruction(stage DockerStage, directive string) int {
+	line := stage.Line
+	for _, instruction := range stage.Instructions {
+		if instruction.Directive == directive {
+			line = instruction.Line
+		}
+	}
+	return line
+}
+
+func checkDockerRootUser(dockerfile Dockerfile) []dockerfileViolation {
+	final, ok := dockerfile.Final()
+	if !ok {
+		return nil
+	}
+	user, _, _ := strings.Cut(final.User, ":")
+	if user != "root" && user != "0" {
+		return nil
+	}
+	return []dockerfileViolation{{Line: dockerLastInstruction(final, "USER"), Stage: final.stageName(), Detail: final.User}}
+}
+
+func checkDockerNoUser(dockerfile Dockerfile) []dockerfileViolation {
+	final, ok := dockerfile.Final()
+	if !ok || final.User != "" {
+		return nil
+	}
+	return []dockerfileViolation{{Line: final.Line, Stage: final.stageName()}}
+}
+
+func checkDockerNoHealthcheck(dockerfile Dockerfile) []dockerfileViolation {
+	final, ok := dockerfile.Final()
+	if !ok || final.Healthcheck != "" {
+		return nil
+	}
+	return []dockerfileViolation{{Line: final.Line, Stage: final.stageName()}}
+}
+
+// dockerAptCommands returns the apt-get and apt commands of each RUN
+// instruction, with the instruction and its stage.
+func dockerAptCommands(dockerfile Dockerfile, visit func(words []string, instruction DockerInstruction, stage DockerStage)) {
+	for _, stage := range dockerfile.Stages {
+		for _, instruction := range stage.Instructions {
+			if instruction.Directive != "RUN" {
+				continue
+			}
+			_, command := dockerFlags(instruction.Arguments)
+			for _, words := range dockerShellCommands(command) {
+				if program := filepath.Base(words[0]); program == "apt-get" || program == "apt" {
+					visit(words, instruction, stage)
+				}
+			}
+		}
+	}
+}
+
+func checkDockerInstallRecommends(dockerfile Dockerfile) []dockerfileViolation {
+	var violations []dockerfileViolation
+	dockerAptCommands(dockerfile, func(words []string, instruction DockerInstruction, stage DockerStage) {
+		if !utils.Contains(words, "install") || utils.Contains(words, "--no-install-recommends") {
+			return
+		}
+		for _, word := range words {
+			if strings.Contains(word, "Install-Recommends=") {
+				return
+			}
+		}
+		violations = append(violations, dockerfileViolation{Line: instruction.Line, Stage: stage.stageName()})
+	})
+	return violations
+}
+
+func checkDockerAptUpgrade(dockerfile Dockerfile) []dockerfileViolation {
+	var violations []dockerfileViolation
+	dockerAptCommands(dockerfile, func(words []string, instruction DockerInstruction, stage DockerStage) {
+		for _, word := range words[1:] {
+			if word == "upgrade" || word == "dist-upgrade" || word == "full-upgrade" {
+				violations = append(violations, dockerfileViolation{Line: instruction.Line, Stage: stage.stageName(), Detail: word})
+				return
+			}
+		}
+	})
+	return violations
+}
+
+// dockerAddSources returns the sources of an ADD instruction.
+func dockerAddSources(arguments string) []string {
+	_, rest := dockerFlags(arguments)
+	args, ok := dockerExecForm(rest)
+	if !ok {
+		args = strings.Fields(rest)
+	}
+	if len(args) < 2 {
+		return nil
+	}
+	return args[:len(args)-1]
+}
+
+func isRemoteDockerSource(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "git@")
+}
+
+// visitDockerAdds calls visit for each source of each ADD instruction.
+func visitDockerAdds(dockerfile Dockerfile, visit func(source string, instruction DockerInstruction, stage DockerStage)) {
+	for _, stage := range dockerfile.Stages {
+		for _, instruction := range stage.Instructions {
+			if instruction.Directive != "ADD" {
+				continue
+			}
+			for _, source := range dockerAddSources(instruction.Arguments) {
+				visit(source, instruction, stage)
+			}
+		}
+	}
+}
+
+func checkDockerAddURL(dockerfile Dockerfile) []dockerfileViolation {
+	var violations []dockerfileViolation
+	visitDockerAdds(dockerfile, func(source string, instruction DockerInstruction, stage DockerStage) {
+		if isRemoteDockerSource(source) && !strings.HasSuffix(source, ".git") {
+			violations = append(violations, dockerfileViolation{Line: instruction.Line, Stage: stage.stageName(), Detail: source})
+		}
+	})
+	return violations
+}
+
+// checkDockerAddLocalFile flags ADD of local files and folders. ADD is only
+// needed for URLs and for archives it extracts.
+func checkDockerAddLocalFile(dockerfile Dockerfile) []dockerfileViolation {
+	var violations []dockerfileViolation
+	visitDockerAdds(dockerfile, func(source string, instruction DockerInstruction, stage DockerStage) {
+		if isRemoteDockerSource(source) || strings.Contains(source, "$") || dockerArchiveRe.MatchString(source) {
+			return
+		}
+		violations = append(violations, dockerfileViolation{Line: instruction.Line, Stage: stage.stageName(), Detail: source})
+	})
+	return violations
+}
+
+// dockerEnvNames returns the names an ENV instruction sets, in either the
+// ENV KEY=value or the legacy ENV KEY value form.
+func dockerEnvNames(arguments string) []string {
+	fields := strings.Fields(arguments)
+	if len(fields) == 0 {
+		return nil
+	}
+	if !strings.Contains(fields[0], "=") {
+		return fields[:1]
+	}
+	var names []string
+	for _, match := range dockerEnvNameRe.FindAllStringSubmatch(arguments, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+func checkDockerSecrets(dockerfile Dockerfile) []dockerfileViolation {
+	var violations []dockerfileViolation
+	check := func(instruction DockerInstruction, stage string) {
+		var names []string
+		switch instruction.Directive {
+		case "ENV":
+			names = dockerEnvNames(instruction.Arguments)
+		case "ARG":
+			names = sortedKeys(parseDockerArgs(instruction.Arguments))
+		}
+		for _, name := range names {
+			if dockerSecretNameRe.MatchString(name) {
+				violations = append(violations, dockerfileViolation{Line: instruction.Line, Stage: stage, Detail: instruction.Directive + " " + name})
+			}
+		}
+	}
+	for _, instruction := range dockerfile.Global {
+		check(instruction, "")
+	}
+	for _, stage := range dockerfile.Stages {
+		for _, instruction := range stage.Instructions {
+			check(instruction, stage.stageName())
+		}
+	}
+	return violations
+}
@@ -0,0 +1,150 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// dockerfileIssues returns the rule IDs of the issues among findings, with
+// the line of each.
+func dockerfileIssues(findings []core.Finding) map[string][]int {
+	issues := make(map[string][]int)
+	for _, finding := range findingsByType(findings, "Dockerfile Issue") {
+		issues[finding.Name] = append(issues[finding.Name], finding.Properties["line"].(int))
+	}
+	return issues
+}
+
+const dockerfileWithIssues = `ARG NPM_TOKEN
+FROM node AS build
+RUN apt-get update && apt-get upgrade -y && apt-get install -y git
+ADD https://example.com/tool.tar.gz /tmp/
+ADD package.json /app/
+ADD vendor.tar.gz /app/
+
+FROM nginx:latest
+ENV API_KEY=abc LOG_LEVEL=debug
+# hadolint ignore=DL3015
+RUN apt-get install -y curl
+COPY --from=build /app /usr/share/nginx/html
+USER root
+`
+
+func TestDockerProcessor_Rules(t *testing.T) {
+	findings, err := DockerProcessor{}.Process("Dockerfile", "repo", dockerfileWithIssues)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]int{
+		"TD003":  {1, 9},
+		"DL3006": {2},
+		"DL3005": {3},
+		"DL3015": {3},
+		"TD004":  {4},
+		"DL3020": {5},
+		"DL3007": {8},
+		"TD002":  {8},
+		"DL3002": {13},
+	}, dockerfileIssues(findings))
+
+	for _, finding := range findingsByType(findings, "Dockerfile Issue") {
+		switch finding.Name {
+		case "DL3002":
+			assert.Equal(t, "warning", finding.Properties["severity"])
+			assert.Equal(t, "root", finding.Properties["detail"])
+			assert.Equal(t, "1", finding.Properties["stage"])
+		case "TD004":
+			assert.Equal(t, "https://example.com/tool.tar.gz", finding.Properties["detail"])
+		}
+	}
+
+	clean := `FROM golang:1.22 AS build
+FROM gcr.io/distroless/static@sha256:abc
+COPY --from=build /app /app
+USER nonroot
+HEALTHCHECK NONE
+`
+	findings, err = DockerProcessor{}.Process("Dockerfile", "repo", clean)
+	require.NoError(t, err)
+	assert.Empty(t, dockerfileIssues(findings))
+
+	findings, err = DockerProcessor{}.Process("Dockerfile", "repo", "FROM alpine:3.20\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int{"TD001": {1}, "TD002": {1}}, dockerfileIssues(findings))
+}
+
+func TestDockerProcessor_RuleSelection(t *testing.T) {
+	findings, err := DockerProcessor{Rules: []string{"DL3007", "DL3002", "TD003"}, IgnoredRules: []string{"TD003"}}.Process("Dockerfile", "repo", dockerfileWithIssues)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int{"DL3007": {8}, "DL3002": {13}}, dockerfileIssues(findings))
+
+	repo := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(repo, ".hadolint.yaml"), `ignored:
+  - DL3005
+  - TD002
+override:
+  error:
+    - DL3007
+    - TD004
+`)
+	path := filepath.Join(repo, "services", "web", "Dockerfile")
+	writeTestFile(t, path, dockerfileWithIssues)
+
+	findings, err = DockerProcessor{}.Process(path, "repo", dockerfileWithIssues)
+	require.NoError(t, err)
+	issues := dockerfileIssues(findings)
+	assert.NotContains(t, issues, "DL3005")
+	// hadolint configuration applies only to hadolint's rules.
+	assert.Contains(t, issues, "TD002")
+	for _, finding := range findingsByType(findings, "Dockerfile Issue") {
+		switch finding.Name {
+		case "DL3007":
+			assert.Equal(t, "error", finding.Properties["severity"])
+		case "TD004":
+			assert.Equal(t, "warning", finding.Properties["severity"])
+		}
+	}
+
+	// A .hadolint.yaml above the repository does not apply to it.
+	outer := t.TempDir()
+	writeTestFile(t, filepath.Join(outer, ".hadolint.yaml"), "ignored: [DL3007]\n")
+	inner := filepath.Join(outer, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(inner, ".git"), 0o755))
+	path = filepath.Join(inner, "Dockerfile")
+	writeTestFile(t, path, dockerfileWithIssues)
+	findings, err = DockerProcessor{}.Process(path, "repo", dockerfileWithIssues)
+	require.NoError(t, err)
+	assert.Contains(t, dockerfileIssues(findings), "DL3007")
+}
+
+func TestInitializeProcessors_DockerfileRulesFromRepoConfig(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(repo, ".techdetector.yaml"), `dockerfile:
+  rules:
+    - DL3007
+    - DL3002
+    - TD003
+  ignored:
+    - TD003
+`)
+	path := filepath.Join(repo, "Dockerfile")
+	writeTestFile(t, path, dockerfileWithIssues)
+
+	var findings []core.Finding
+	for _, processor := range InitializeProcessors() {
+		if !processor.Supports(path) {
+			continue
+		}
+		matches, err := processor.Process(path, "repo", dockerfileWithIssues)
+		require.NoError(t, err)
+		findings = append(findings, matches...)
+	}
+	assert.Equal(t, map[string][]int{"DL3007": {8}, "DL3002": {13}}, dockerfileIssues(findings))
+}
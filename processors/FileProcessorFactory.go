@@ -8,17 +8,43 @@ import (
 //go:embed data/patterns/*.json
 var patternsFS embed.FS
 
+//go:embed data/kubernetes/*.json
+var kubernetesFS embed.FS
+
+//go:embed data/terraform/*.json
+var terraformFS embed.FS
+
+//go:embed data/lambda/*.json
+var lambdaFS embed.FS
+
 // InitializeProcessors creates and returns a slice of FileProcessor implementations.
 func InitializeProcessors() []core.FileProcessor {
 	var processors []core.FileProcessor
+	terraform := NewTerraformProcessor()
 
 	processors = append(processors, NewFilePatternsProcessor(patternsFS))
 	processors = append(processors, NewLibrariesProcessor())
 	processors = append(processors, DockerProcessor{})
-	processors = append(processors, NewTerraformProcessor())
+	processors = append(processors, terraform)
+	processors = append(processors, NewTerragruntProcessor(terraform))
 	processors = append(processors, DockerComposeProcessor{})
 	processors = append(processors, CloudFormationProcessor{})
+	processors = append(processors, NewSAMProcessor(lambdaFS))
+	processors = append(processors, NewServerlessProcessor(lambdaFS))
+	processors = append(processors, NewCDKProcessor(terraformFS))
+	processors = append(processors, NewPulumiProcessor(terraformFS))
+	processors = append(processors, BicepProcessor{})
+	processors = append(processors, ARMTemplateProcessor{})
 	processors = append(processors, CloudDeploymentManagerProcessor{})
+	processors = append(processors, AndroidManifestProcessor{})
+	processors = append(processors, NewKubernetesProcessor(kubernetesFS, DefaultKubernetesTargetVersion))
+	processors = append(processors, HelmProcessor{})
+	processors = append(processors, KustomizeProcessor{})
+	processors = append(processors, GitHubActionsProcessor{})
+	processors = append(processors, GitLabCIProcessor{})
+	processors = append(processors, JenkinsfileProcessor{})
+	processors = append(processors, AzurePipelinesProcessor{})
+	processors = append(processors, CircleCIProcessor{})
 	processors = append(processors, LanguageProcessor{})
 	processors = append(processors, FilenameProcessor{})
 	return processors
@@ -2,14 +2,13 @@ package processors
 
 import (
 	"github.com/reaandrew/techdetector/core"
-	"strings"
 )
 
 type FilenameProcessor struct {
 }
 
 func (f FilenameProcessor) Supports(filePath string) bool {
-	return !strings.Contains(filePath, ".git")
+	return !inDirectory(filePath, ".git")
 }
 
 func (f FilenameProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
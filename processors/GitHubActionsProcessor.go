@@ -0,0 +1,342 @@
+package processors
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+var (
+	// githubSHARe matches a full commit SHA, the only ref that pins an action
+	// to immutable content.
+	githubSHARe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	// githubTagRe matches refs that look like release tags, e.g. "v4" or "1.2.3".
+	githubTagRe = regexp.MustCompile(`^v?\d+(\.\d+)*([-+][0-9A-Za-z.-]+)?$`)
+	// githubSecretRe matches secrets referenced in expressions, e.g.
+	// "${{ secrets.NPM_TOKEN }}" or "${{ secrets['NPM_TOKEN'] }}".
+	githubSecretRe = regexp.MustCompile(`secrets(?:\.([A-Za-z_][A-Za-z0-9_-]*)|\[\s*['"]([^'"]+)['"]\s*\])`)
+)
+
+// githubActionsStep is a step of a workflow job or composite action.
+type githubActionsStep struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	Uses string `yaml:"uses"`
+}
+
+// githubActionsJob is a workflow job. runs-on, permissions and container take
+// several shapes so they are decoded on demand.
+type githubActionsJob struct {
+	RunsOn      yaml.Node            `yaml:"runs-on"`
+	Permissions yaml.Node            `yaml:"permissions"`
+	Uses        string               `yaml:"uses"`
+	Secrets     yaml.Node            `yaml:"secrets"`
+	Container   yaml.Node            `yaml:"container"`
+	Services    map[string]yaml.Node `yaml:"services"`
+	Steps       []githubActionsStep  `yaml:"steps"`
+}
+
+// githubActionsWorkflow is a workflow file, or an action.yml whose steps live
+// under runs.
+type githubActionsWorkflow struct {
+	Name        string                      `yaml:"name"`
+	On          yaml.Node                   `yaml:"on"`
+	Permissions yaml.Node                   `yaml:"permissions"`
+	Jobs        map[string]githubActionsJob `yaml:"jobs"`
+	Runs        struct {
+		Using string              `yaml:"using"`
+		Image string              `yaml:"image"`
+		Steps []githubActionsStep `yaml:"steps"`
+	} `yaml:"runs"`
+}
+
+// githubActionRef is a "uses:" reference split into its parts.
+type githubActionRef struct {
+	Owner   string
+	Repo    string
+	Path    string
+	Ref     string
+	RefType string
+}
+
+// parseGitHubActionRef splits "owner/repo/path@ref". Local actions ("./...")
+// and Docker actions ("docker://...") have no owner or ref.
+func parseGitHubActionRef(uses string) githubActionRef {
+	switch {
+	case strings.HasPrefix(uses, "./"):
+		return githubActionRef{Path: uses, RefType: "local"}
+	case strings.HasPrefix(uses, "docker://"):
+		return githubActionRef{Path: strings.TrimPrefix(uses, "docker://"), RefType: "docker"}
+	}
+
+	var ref githubActionRef
+	name := uses
+	if idx := strings.LastIndex(uses, "@"); idx >= 0 {
+		name, ref.Ref = uses[:idx], uses[idx+1:]
+	}
+	parts := strings.SplitN(name, "/", 3)
+	ref.Owner = parts[0]
+	if len(parts) > 1 {
+		ref.Repo = parts[1]
+	}
+	if len(parts) > 2 {
+		ref.Path = parts[2]
+	}
+
+	switch {
+	case githubSHARe.MatchString(ref.Ref):
+		ref.RefType = "sha"
+	case githubTagRe.MatchString(ref.Ref):
+		ref.RefType = "tag"
+	case ref.Ref != "":
+		// Anything else is taken to be a branch, which can move under the workflow.
+		ref.RefType = "branch"
+	default:
+		ref.RefType = "none"
+	}
+	return ref
+}
+
+// githubRunnerLabels returns the labels of a runs-on value, which can be a
+// label, a list of labels or a {group, labels} map.
+func githubRunnerLabels(node yaml.Node) (labels []string, group string) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}, ""
+	case yaml.SequenceNode:
+		_ = node.Decode(&labels)
+		return labels, ""
+	case yaml.MappingNode:
+		var runsOn struct {
+			Group  string    `yaml:"group"`
+			Labels yaml.Node `yaml:"labels"`
+		}
+		_ = node.Decode(&runsOn)
+		labels, _ = githubRunnerLabels(runsOn.Labels)
+		return labels, runsOn.Group
+	}
+	return nil, ""
+}
+
+// githubPermissions returns the scopes granted by a permissions value. The
+// read-all and write-all shorthands are reported as an "all" scope.
+func githubPermissions(node yaml.Node) map[string]string {
+	permissions := make(map[string]string)
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if access, ok := strings.CutSuffix(node.Value, "-all"); ok {
+			permissions["all"] = access
+		}
+	case yaml.MappingNode:
+		_ = node.Decode(&permissions)
+	}
+	return permissions
+}
+
+// githubTriggers returns the event names of a workflow's "on" value.
+func githubTriggers(node yaml.Node) []string {
+	var triggers []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		triggers = append(triggers, node.Value)
+	case yaml.SequenceNode:
+		_ = node.Decode(&triggers)
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			triggers = append(triggers, node.Content[i].Value)
+		}
+	}
+	return triggers
+}
+
+// GitHubActionsProcessor reports the actions, runners, permissions, images and
+// secrets used by GitHub Actions workflows and composite actions.
+type GitHubActionsProcessor struct {
+}
+
+func (g GitHubActionsProcessor) Supports(filePath string) bool {
+	base := filepath.Base(filePath)
+	if base == "action.yml" || base == "action.yaml" {
+		return true
+	}
+	ext := filepath.Ext(base)
+	return (ext == ".yml" || ext == ".yaml") &&
+		strings.HasSuffix(filepath.ToSlash(filepath.Dir(filePath)), ".github/workflows")
+}
+
+func (g GitHubActionsProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	var workflow githubActionsWorkflow
+	if err := yaml.Unmarshal([]byte(content), &workflow); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub Actions workflow '%s': %w", path, err)
+	}
+
+	pipeline := workflow.Name
+	if pipeline == "" {
+		pipeline = filepath.Base(path)
+	}
+	newFinding := newCIFindingFunc("GitHub Actions", pipeline, path, repoName)
+
+	var matches []core.Finding
+	base := filepath.Base(path)
+	if base == "action.yml" || base == "action.yaml" {
+		matches = append(matches, newFinding(pipeline, ciPipeline, map[string]interface{}{
+			"kind":  "action",
+			"using": workflow.Runs.Using,
+		}))
+		if workflow.Runs.Using == "docker" && strings.HasPrefix(workflow.Runs.Image, "docker://") {
+			image := strings.TrimPrefix(workflow.Runs.Image, "docker://")
+			matches = append(matches, ciImageFinding(newFinding, image, "", ""))
+		}
+		for _, step := range workflow.Runs.Steps {
+			if step.Uses != "" {
+				matches = append(matches, newFinding(step.Uses, ciAction, githubActionProperties(step.Uses, "", step)))
+			}
+		}
+	} else {
+		matches = append(matches, newFinding(pipeline, ciPipeline, map[string]interface{}{
+			"kind":     "workflow",
+			"triggers": githubTriggers(workflow.On),
+		}))
+		matches = append(matches, githubPermissionFindings(workflow.Permissions, "", newFinding)...)
+
+		for _, id := range sortedKeys(workflow.Jobs) {
+			matches = append(matches, githubJobFindings(id, workflow.Jobs[id], newFinding)...)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, match := range githubSecretRe.FindAllStringSubmatch(content, -1) {
+		name := match[1] + match[2]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		matches = append(matches, newFinding(name, ciSecret, map[string]interface{}{
+			"builtin": name == "GITHUB_TOKEN",
+		}))
+	}
+
+	return matches, nil
+}
+
+func githubJobFindings(id string, job githubActionsJob, newFinding ciFindingFunc) []core.Finding {
+	var matches []core.Finding
+
+	labels, group := githubRunnerLabels(job.RunsOn)
+	// A job runs on a self-hosted runner if any of its labels says so, or if it
+	// targets a runner group.
+	selfHosted := group != ""
+	for _, label := range labels {
+		selfHosted = selfHosted || label == "self-hosted"
+	}
+	for _, label := range labels {
+		properties := map[string]interface{}{
+			"job":         id,
+			"self_hosted": selfHosted,
+		}
+		if group != "" {
+			properties["runner_group"] = group
+		}
+		matches = append(matches, newFinding(label, ciRunner, properties))
+	}
+
+	matches = append(matches, githubPermissionFindings(job.Permissions, id, newFinding)...)
+
+	if job.Uses != "" {
+		properties := githubActionProperties(job.Uses, id, githubActionsStep{})
+		properties["reusable_workflow"] = true
+		if job.Secrets.Kind == yaml.ScalarNode && job.Secrets.Value == "inherit" {
+			properties["secrets"] = "inherit"
+		}
+		matches = append(matches, newFinding(job.Uses, ciAction, properties))
+	}
+
+	container := job.Container.Value
+	if job.Container.Kind == yaml.MappingNode {
+		var spec struct {
+			Image string `yaml:"image"`
+		}
+		_ = job.Container.Decode(&spec)
+		container = spec.Image
+	}
+	if container != "" {
+		matches = append(matches, ciImageFinding(newFinding, container, id, "container"))
+	}
+
+	for _, name := range sortedKeys(job.Services) {
+		var service struct {
+			Image string `yaml:"image"`
+		}
+		node := job.Services[name]
+		if node.Decode(&service) != nil || service.Image == "" {
+			continue
+		}
+		finding := ciImageFinding(newFinding, service.Image, id, "service")
+		finding.Properties["service"] = name
+		matches = append(matches, finding)
+	}
+
+	for _, step := range job.Steps {
+		if step.Uses != "" {
+			matches = append(matches, newFinding(step.Uses, ciAction, githubActionProperties(step.Uses, id, step)))
+		}
+	}
+	return matches
+}
+
+func githubActionProperties(uses string, job string, step githubActionsStep) map[string]interface{} {
+	ref := parseGitHubActionRef(uses)
+	properties := map[string]interface{}{
+		"ref_type": ref.RefType,
+		"pinned":   ref.RefType == "sha",
+	}
+	if ref.Owner != "" {
+		properties["owner"] = ref.Owner
+		properties["repo"] = ref.Repo
+		properties["action"] = ref.Owner + "/" + ref.Repo
+	}
+	if ref.Path != "" {
+		properties["action_path"] = ref.Path
+	}
+	if ref.Ref != "" {
+		properties["ref"] = ref.Ref
+	}
+	if ref.RefType == "docker" {
+		for key, value := range parseImageReference(ref.Path).properties() {
+			properties[key] = value
+		}
+		delete(properties, "action_path")
+		properties["image"] = ref.Path
+	}
+	if job != "" {
+		properties["job"] = job
+	}
+	if step.Name != "" {
+		properties["step"] = step.Name
+	} else if step.ID != "" {
+		properties["step"] = step.ID
+	}
+	return properties
+}
+
+func githubPermissionFindings(node yaml.Node, job string, newFinding ciFindingFunc) []core.Finding {
+	var matches []core.Finding
+	permissions := githubPermissions(node)
+	for _, scope := range sortedKeys(permissions) {
+		properties := map[string]interface{}{
+			"access": permissions[scope],
+			"level":  "workflow",
+		}
+		if job != "" {
+			properties["level"] = "job"
+			properties["job"] = job
+		}
+		matches = append(matches, newFinding(scope, ciPermission, properties))
+	}
+	return matches
+}
@@ -0,0 +1,157 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestGitHubActionsProcessor_Supports(t *testing.T) {
+	processor := GitHubActionsProcessor{}
+
+	assert.True(t, processor.Supports(".github/workflows/ci.yml"))
+	assert.True(t, processor.Supports("repo/.github/workflows/release.yaml"))
+	assert.True(t, processor.Supports(".github/actions/setup/action.yml"))
+	assert.True(t, processor.Supports("action.yaml"))
+	assert.False(t, processor.Supports(".github/dependabot.yml"))
+	assert.False(t, processor.Supports("deploy/workflows/ci.yml"))
+}
+
+func TestInDirectory(t *testing.T) {
+	assert.True(t, inDirectory("repo/.git/config", ".git"))
+	assert.False(t, inDirectory("repo/.github/workflows/ci.yml", ".git"))
+	assert.False(t, inDirectory("repo/vendor-api/go.mod", "vendor"))
+	assert.True(t, inDirectory("repo/vendor/github.com/x/go.mod", "vendor"))
+}
+
+func TestGitHubActionsProcessor_Workflow(t *testing.T) {
+	processor := GitHubActionsProcessor{}
+
+	content := `name: CI
+on:
+  push:
+    branches: [main]
+  pull_request:
+permissions:
+  contents: read
+jobs:
+  build:
+    runs-on: [self-hosted, linux, x64]
+    permissions:
+      id-token: write
+      packages: write
+    container: node:20-alpine
+    services:
+      postgres:
+        image: postgres:16
+    steps:
+      - uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11
+      - name: Setup
+        uses: actions/setup-node@v4
+      - uses: org/tools/lint@main
+      - uses: ./.github/actions/setup
+      - uses: docker://alpine:3.19
+      - run: npm publish
+        env:
+          NODE_AUTH_TOKEN: ${{ secrets.NPM_TOKEN }}
+          GH: ${{ secrets['GITHUB_TOKEN'] }}
+  deploy:
+    uses: org/workflows/.github/workflows/deploy.yml@v2.1.0
+    secrets: inherit
+  test:
+    runs-on:
+      group: large
+      labels: ubuntu-latest
+    permissions: read-all
+    steps:
+      - run: echo ${{ secrets.NPM_TOKEN }}
+`
+
+	findings, err := processor.Process(".github/workflows/ci.yml", "repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		assert.Equal(t, "GitHub Actions", finding.Category)
+		assert.Equal(t, "CI", finding.Properties["pipeline"])
+		byType[finding.Type] = append(byType[finding.Type], finding)
+	}
+
+	require.Len(t, byType["CI Pipeline"], 1)
+	assert.Equal(t, []string{"push", "pull_request"}, byType["CI Pipeline"][0].Properties["triggers"])
+
+	actions := findingsByName(byType["CI Action"])
+	require.Len(t, actions, 6)
+	checkout := actions["actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11"].Properties
+	assert.Equal(t, "actions", checkout["owner"])
+	assert.Equal(t, "checkout", checkout["repo"])
+	assert.Equal(t, "sha", checkout["ref_type"])
+	assert.Equal(t, true, checkout["pinned"])
+	assert.Equal(t, "build", checkout["job"])
+	setup := actions["actions/setup-node@v4"].Properties
+	assert.Equal(t, "tag", setup["ref_type"])
+	assert.Equal(t, false, setup["pinned"])
+	assert.Equal(t, "Setup", setup["step"])
+	lint := actions["org/tools/lint@main"].Properties
+	assert.Equal(t, "branch", lint["ref_type"])
+	assert.Equal(t, "lint", lint["action_path"])
+	assert.Equal(t, "local", actions["./.github/actions/setup"].Properties["ref_type"])
+	assert.Equal(t, "3.19", actions["docker://alpine:3.19"].Properties["tag"])
+	deploy := actions["org/workflows/.github/workflows/deploy.yml@v2.1.0"].Properties
+	assert.Equal(t, true, deploy["reusable_workflow"])
+	assert.Equal(t, "inherit", deploy["secrets"])
+	assert.Equal(t, "tag", deploy["ref_type"])
+
+	runners := byType["CI Runner"]
+	require.Len(t, runners, 4)
+	assert.Equal(t, "self-hosted", runners[0].Name)
+	assert.Equal(t, true, runners[1].Properties["self_hosted"])
+	assert.Equal(t, "ubuntu-latest", runners[3].Name)
+	assert.Equal(t, "large", runners[3].Properties["runner_group"])
+
+	permissions := byType["CI Permission"]
+	require.Len(t, permissions, 4)
+	assert.Equal(t, "contents", permissions[0].Name)
+	assert.Equal(t, "workflow", permissions[0].Properties["level"])
+	assert.Equal(t, "id-token", permissions[1].Name)
+	assert.Equal(t, "write", permissions[1].Properties["access"])
+	assert.Equal(t, "all", permissions[3].Name)
+	assert.Equal(t, "read", permissions[3].Properties["access"])
+	assert.Equal(t, "test", permissions[3].Properties["job"])
+
+	images := findingsByName(byType["CI Image"])
+	require.Len(t, images, 2)
+	assert.Equal(t, "container", images["node:20-alpine"].Properties["role"])
+	assert.Equal(t, "postgres", images["postgres:16"].Properties["service"])
+
+	secrets := findingsByName(byType["CI Secret"])
+	require.Len(t, secrets, 2)
+	assert.Equal(t, false, secrets["NPM_TOKEN"].Properties["builtin"])
+	assert.Equal(t, true, secrets["GITHUB_TOKEN"].Properties["builtin"])
+}
+
+func TestGitHubActionsProcessor_CompositeAction(t *testing.T) {
+	processor := GitHubActionsProcessor{}
+
+	content := `name: Setup toolchain
+runs:
+  using: composite
+  steps:
+    - uses: actions/cache@v3.3.2
+    - run: echo "${{ secrets.CACHE_KEY }}"
+      shell: bash
+`
+
+	findings, err := processor.Process(".github/actions/setup/action.yml", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+	assert.Equal(t, "CI Pipeline", findings[0].Type)
+	assert.Equal(t, "composite", findings[0].Properties["using"])
+	assert.Equal(t, "action", findings[0].Properties["kind"])
+	assert.Equal(t, "actions/cache@v3.3.2", findings[1].Name)
+	assert.Equal(t, "tag", findings[1].Properties["ref_type"])
+	assert.Equal(t, "CACHE_KEY", findings[2].Name)
+}
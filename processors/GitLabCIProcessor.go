@@ -0,0 +1,464 @@
+package processors
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// maxGitLabIncludeDepth bounds how deep nested local includes are followed;
+// GitLab itself allows 100 includes in total.
+const maxGitLabIncludeDepth = 10
+
+// gitlabReservedKeys are the top-level keys of .gitlab-ci.yml that are not jobs.
+var gitlabReservedKeys = map[string]bool{
+	"default":       true,
+	"include":       true,
+	"stages":        true,
+	"variables":     true,
+	"workflow":      true,
+	"image":         true,
+	"services":      true,
+	"cache":         true,
+	"before_script": true,
+	"after_script":  true,
+	"spec":          true,
+}
+
+// gitlabInclude is an entry of "include:", normalised from its string and map
+// forms.
+type gitlabInclude struct {
+	Type    string
+	Source  string
+	Project string
+	Ref     string
+}
+
+// gitlabIncludes returns the entries of an include value, which can be a
+// string, a map or a list of either.
+func gitlabIncludes(value interface{}) []gitlabInclude {
+	var includes []gitlabInclude
+	switch v := value.(type) {
+	case string:
+		includeType := "local"
+		if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
+			includeType = "remote"
+		}
+		includes = append(includes, gitlabInclude{Type: includeType, Source: v})
+	case []interface{}:
+		for _, item := range v {
+			includes = append(includes, gitlabIncludes(item)...)
+		}
+	case map[string]interface{}:
+		ref, _ := v["ref"].(string)
+		switch {
+		case v["local"] != nil:
+			includes = append(includes, gitlabInclude{Type: "local", Source: fmt.Sprint(v["local"])})
+		case v["project"] != nil:
+			project := fmt.Sprint(v["project"])
+			files := stringList(v["file"])
+			if len(files) == 0 {
+				files = []string{""}
+			}
+			for _, file := range files {
+				includes = append(includes, gitlabInclude{Type: "project", Source: file, Project: project, Ref: ref})
+			}
+		case v["remote"] != nil:
+			includes = append(includes, gitlabInclude{Type: "remote", Source: fmt.Sprint(v["remote"])})
+		case v["template"] != nil:
+			includes = append(includes, gitlabInclude{Type: "template", Source: fmt.Sprint(v["template"])})
+		case v["component"] != nil:
+			component := fmt.Sprint(v["component"])
+			if idx := strings.LastIndex(component, "@"); idx >= 0 {
+				component, ref = component[:idx], component[idx+1:]
+			}
+			includes = append(includes, gitlabInclude{Type: "component", Source: component, Ref: ref})
+		}
+	}
+	return includes
+}
+
+// stringList returns a YAML value that may be a string or a list of strings
+// as a list.
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// gitlabRule describes a rules entry by its condition.
+func gitlabRule(rule interface{}) string {
+	fields, ok := rule.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	switch {
+	case fields["if"] != nil:
+		return fmt.Sprint(fields["if"])
+	case fields["changes"] != nil:
+		return "changes: " + strings.Join(stringList(fields["changes"]), ", ")
+	case fields["exists"] != nil:
+		return "exists: " + strings.Join(stringList(fields["exists"]), ", ")
+	case fields["when"] != nil:
+		return "when: " + fmt.Sprint(fields["when"])
+	}
+	return ""
+}
+
+// gitlabPipeline is a .gitlab-ci.yml with its local includes merged in.
+type gitlabPipeline struct {
+	Root     string
+	Stages   []string
+	Default  map[string]interface{}
+	Jobs     map[string]map[string]interface{}
+	Sources  map[string]string
+	Includes []gitlabIncludeFinding
+	visited  map[string]bool
+}
+
+// gitlabIncludeFinding is an include together with the file that declared it
+// and whether it could be resolved in the repository.
+type gitlabIncludeFinding struct {
+	gitlabInclude
+	From     string
+	Resolved bool
+}
+
+// load merges a pipeline file into p. GitLab merges the files a pipeline
+// includes in order and then the including file over them, so later files
+// override earlier ones and the including file overrides them all. Jobs and
+// defaults defined in several files are deep merged: mappings are merged
+// key by key, and other values, including lists such as script, are
+// replaced.
+func (p *gitlabPipeline) load(file string, content []byte, depth int) error {
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return err
+	}
+	p.visited[file] = true
+
+	for _, include := range gitlabIncludes(document["include"]) {
+		resolved := false
+		if include.Type == "local" && depth < maxGitLabIncludeDepth {
+			for _, path := range p.localIncludes(file, include.Source) {
+				resolved = true
+				if p.visited[path] {
+					continue
+				}
+				included, err := os.ReadFile(path)
+				if err != nil {
+					continue
+				}
+				// A broken include is reported as unresolved rather than failing the pipeline.
+				if err := p.load(path, included, depth+1); err != nil {
+					resolved = false
+				}
+			}
+		}
+		p.Includes = append(p.Includes, gitlabIncludeFinding{gitlabInclude: include, From: file, Resolved: resolved})
+	}
+
+	if stages, ok := document["stages"]; ok {
+		p.Stages = stringList(stages)
+	}
+	if defaults, ok := document["default"].(map[string]interface{}); ok {
+		p.Default = mergeGitLabHash(p.Default, defaults)
+	} else if document["image"] != nil || document["services"] != nil {
+		// Top-level image and services are the deprecated form of default.
+		deprecated := map[string]interface{}{}
+		for _, key := range []string{"image", "services"} {
+			if value, ok := document[key]; ok {
+				deprecated[key] = value
+			}
+		}
+		p.Default = mergeGitLabHash(p.Default, deprecated)
+	}
+	for name, value := range document {
+		job, ok := value.(map[string]interface{})
+		if !ok || gitlabReservedKeys[name] {
+			continue
+		}
+		p.Jobs[name] = mergeGitLabHash(p.Jobs[name], job)
+		p.Sources[name] = file
+	}
+	return nil
+}
+
+// mergeGitLabHash deep merges override into base, as GitLab merges a job or
+// default defined in more than one file.
+func mergeGitLabHash(base map[string]interface{}, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		overrideMap, ok := value.(map[string]interface{})
+		baseMap, baseOK := merged[key].(map[string]interface{})
+		if ok && baseOK {
+			merged[key] = mergeGitLabHash(baseMap, overrideMap)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// localIncludes returns the files a local include in file names. Sources
+// are relative to the pipeline root and can use * for any characters but /
+// and ** for any characters, as in /ci/**.yml or /ci/**/*.yml. Files outside
+// the pipeline root or its repository are never included.
+func (p *gitlabPipeline) localIncludes(file string, source string) []string {
+	pattern := filepath.Join(p.Root, strings.TrimPrefix(source, "/"))
+	var paths []string
+	if !strings.Contains(source, "**") {
+		paths, _ = filepath.Glob(pattern)
+	} else {
+		re, err := regexp.Compile("^" + gitlabGlobPattern(filepath.ToSlash(pattern)) + "$")
+		if err != nil {
+			return nil
+		}
+		// Only the directories above the first wildcard are walked.
+		walkRoot := filepath.Dir(pattern[:strings.Index(pattern, "*")] + "x")
+		_ = filepath.WalkDir(walkRoot, func(path string, entry fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				return nil
+			case entry.IsDir() && entry.Name() == ".git":
+				return filepath.SkipDir
+			case !entry.IsDir() && re.MatchString(filepath.ToSlash(path)):
+				paths = append(paths, path)
+			}
+			return nil
+		})
+	}
+	var included []string
+	for _, path := range paths {
+		if withinDirectory(p.Root, path) && withinRepository(file, path) {
+			included = append(included, path)
+		}
+	}
+	return included
+}
+
+// gitlabGlobPattern turns an include glob into a regular expression: "**/"
+// matches any number of directories, "**" any characters, "*" any characters
+// but "/" and "?" one character but "/".
+func gitlabGlobPattern(glob string) string {
+	var pattern strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			pattern.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			pattern.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			pattern.WriteString("[^/]*")
+		case glob[i] == '?':
+			pattern.WriteString("[^/]")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return pattern.String()
+}
+
+// extendsChain returns every template a job inherits from, nearest first, in
+// the order GitLab applies them: later entries in "extends" override earlier
+// ones, and each template's own parents come after it.
+func (p *gitlabPipeline) extendsChain(name string, seen map[string]bool) []string {
+	var chain []string
+	parents := stringList(p.Jobs[name]["extends"])
+	for i := len(parents) - 1; i >= 0; i-- {
+		parent := parents[i]
+		if seen[parent] {
+			continue
+		}
+		seen[parent] = true
+		chain = append(chain, parent)
+		chain = append(chain, p.extendsChain(parent, seen)...)
+	}
+	return chain
+}
+
+// lookup returns the effective value of key for a job, following its extends
+// chain and falling back to the pipeline defaults.
+func (p *gitlabPipeline) lookup(name string, chain []string, key string) interface{} {
+	for _, job := range append([]string{name}, chain...) {
+		if value, ok := p.Jobs[job][key]; ok {
+			return value
+		}
+	}
+	return p.Default[key]
+}
+
+// GitLabCIProcessor reports the jobs, images, includes, stages and rules of
+// GitLab CI pipelines.
+type GitLabCIProcessor struct {
+}
+
+func (g GitLabCIProcessor) Supports(filePath string) bool {
+	base := filepath.Base(filePath)
+	return base == ".gitlab-ci.yml" || base == ".gitlab-ci.yaml"
+}
+
+func (g GitLabCIProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	pipeline := &gitlabPipeline{
+		Root:    filepath.Dir(path),
+		Jobs:    make(map[string]map[string]interface{}),
+		Sources: make(map[string]string),
+		visited: make(map[string]bool),
+	}
+	if err := pipeline.load(path, []byte(content), 0); err != nil {
+		return nil, fmt.Errorf("failed to parse GitLab CI pipeline '%s': %w", path, err)
+	}
+
+	name := filepath.Base(path)
+	newFinding := newCIFindingFunc("GitLab CI", name, path, repoName)
+
+	stages := pipeline.Stages
+	if stages == nil {
+		// GitLab's default stages when none are declared.
+		stages = []string{".pre", "build", "test", "deploy", ".post"}
+	}
+	matches := []core.Finding{newFinding(name, ciPipeline, map[string]interface{}{
+		"kind":   "pipeline",
+		"stages": stages,
+	})}
+	for i, stage := range stages {
+		matches = append(matches, newFinding(stage, ciStage, map[string]interface{}{
+			"order": i,
+		}))
+	}
+
+	for _, include := range pipeline.Includes {
+		properties := map[string]interface{}{
+			"include_type": include.Type,
+			"resolved":     include.Resolved,
+		}
+		if include.Project != "" {
+			properties["project"] = include.Project
+		}
+		if include.Ref != "" {
+			properties["ref"] = include.Ref
+		}
+		if include.From != path {
+			properties["included_from"] = pipeline.relative(include.From)
+		}
+		findingName := include.Source
+		if include.Type == "project" {
+			findingName = include.Project + ":" + include.Source
+		}
+		matches = append(matches, newFinding(findingName, ciInclude, properties))
+	}
+
+	for _, job := range sortedKeys(pipeline.Jobs) {
+		matches = append(matches, pipeline.jobFindings(job, path, newFinding)...)
+	}
+	return matches, nil
+}
+
+// relative returns a path of an included file relative to the pipeline root.
+func (p *gitlabPipeline) relative(path string) string {
+	if rel, err := filepath.Rel(p.Root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func (p *gitlabPipeline) jobFindings(job string, path string, newFinding ciFindingFunc) []core.Finding {
+	chain := p.extendsChain(job, map[string]bool{job: true})
+	hidden := strings.HasPrefix(job, ".")
+
+	properties := map[string]interface{}{
+		"template": hidden,
+	}
+	if stage := p.lookup(job, chain, "stage"); stage != nil {
+		properties["stage"] = fmt.Sprint(stage)
+	} else if !hidden {
+		properties["stage"] = "test"
+	}
+	if extends := stringList(p.Jobs[job]["extends"]); len(extends) > 0 {
+		properties["extends"] = extends
+		properties["extends_chain"] = chain
+	}
+	if rules, ok := p.lookup(job, chain, "rules").([]interface{}); ok {
+		var conditions []string
+		for _, rule := range rules {
+			if condition := gitlabRule(rule); condition != "" {
+				conditions = append(conditions, condition)
+			}
+		}
+		properties["rules"] = conditions
+	}
+	if when := p.lookup(job, chain, "when"); when != nil {
+		properties["when"] = fmt.Sprint(when)
+	}
+	if trigger := p.Jobs[job]["trigger"]; trigger != nil {
+		properties["trigger"] = true
+	}
+	if p.Sources[job] != path {
+		properties["source"] = p.relative(p.Sources[job])
+	}
+	matches := []core.Finding{newFinding(job, ciJob, properties)}
+
+	// Templates only contribute to the jobs that extend them.
+	if hidden {
+		return matches
+	}
+
+	if image := gitlabImageName(p.lookup(job, chain, "image")); image != "" {
+		matches = append(matches, ciImageFinding(newFinding, image, job, "container"))
+	}
+	if services, ok := p.lookup(job, chain, "services").([]interface{}); ok {
+		for _, service := range services {
+			image := gitlabImageName(service)
+			if image == "" {
+				continue
+			}
+			finding := ciImageFinding(newFinding, image, job, "service")
+			if fields, ok := service.(map[string]interface{}); ok && fields["alias"] != nil {
+				finding.Properties["service"] = fmt.Sprint(fields["alias"])
+			}
+			matches = append(matches, finding)
+		}
+	}
+	for _, tag := range stringList(p.lookup(job, chain, "tags")) {
+		matches = append(matches, newFinding(tag, ciRunner, map[string]interface{}{
+			"job": job,
+		}))
+	}
+	return matches
+}
+
+// gitlabImageName returns the image of an image or services entry, which can
+// be a string or a map with a name.
+func gitlabImageName(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if name, ok := v["name"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestGitLabCIProcessor_Supports(t *testing.T) {
+	processor := GitLabCIProcessor{}
+
+	assert.True(t, processor.Supports("repo/.gitlab-ci.yml"))
+	assert.True(t, processor.Supports(".gitlab-ci.yaml"))
+	assert.False(t, processor.Supports("ci/build.yml"))
+}
+
+func TestGitLabCIProcessor_Process(t *testing.T) {
+	processor := GitLabCIProcessor{}
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "ci", "templates.yml"), `.node:
+  image: node:20
+  tags: [docker, linux]
+include:
+  - local: /ci/deploy.yml
+`)
+	writeTestFile(t, filepath.Join(dir, "ci", "deploy.yml"), `.deploy:
+  stage: deploy
+  rules:
+    - if: $CI_COMMIT_BRANCH == $CI_DEFAULT_BRANCH
+    - changes: [k8s/**/*]
+      when: manual
+`)
+
+	content := `stages: [build, test, deploy]
+default:
+  image: alpine:3.19
+include:
+  - local: ci/templates.yml
+  - project: platform/ci-templates
+    ref: v1.4.0
+    file: [/jobs/sast.yml, /jobs/lint.yml]
+  - remote: https://example.com/ci/common.yml
+  - template: Security/SAST.gitlab-ci.yml
+  - component: gitlab.com/org/components/scan@1.0.0
+  - local: ci/missing.yml
+build:
+  extends: .node
+  stage: build
+  services:
+    - name: postgres:16
+      alias: db
+    - redis:7
+  script: npm run build
+deploy:
+  extends: [.node, .deploy]
+  script: ./deploy.sh
+test:
+  script: make test
+`
+
+	path := filepath.Join(dir, ".gitlab-ci.yml")
+	findings, err := processor.Process(path, "repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		assert.Equal(t, "GitLab CI", finding.Category)
+		byType[finding.Type] = append(byType[finding.Type], finding)
+	}
+
+	require.Len(t, byType["CI Pipeline"], 1)
+	assert.Equal(t, []string{"build", "test", "deploy"}, byType["CI Pipeline"][0].Properties["stages"])
+	require.Len(t, byType["CI Stage"], 3)
+	assert.Equal(t, 2, byType["CI Stage"][2].Properties["order"])
+
+	includes := findingsByName(byType["CI Include"])
+	require.Len(t, includes, 8)
+	assert.Equal(t, true, includes["ci/templates.yml"].Properties["resolved"])
+	assert.Equal(t, "ci/templates.yml", includes["/ci/deploy.yml"].Properties["included_from"])
+	assert.Equal(t, false, includes["ci/missing.yml"].Properties["resolved"])
+	sast := includes["platform/ci-templates:/jobs/sast.yml"].Properties
+	assert.Equal(t, "project", sast["include_type"])
+	assert.Equal(t, "v1.4.0", sast["ref"])
+	assert.Equal(t, "remote", includes["https://example.com/ci/common.yml"].Properties["include_type"])
+	assert.Equal(t, "template", includes["Security/SAST.gitlab-ci.yml"].Properties["include_type"])
+	assert.Equal(t, "1.0.0", includes["gitlab.com/org/components/scan"].Properties["ref"])
+
+	jobs := findingsByName(byType["CI Job"])
+	require.Len(t, jobs, 5)
+	assert.Equal(t, "build", jobs["build"].Properties["stage"])
+	assert.Equal(t, "test", jobs["test"].Properties["stage"])
+	deploy := jobs["deploy"].Properties
+	assert.Equal(t, "deploy", deploy["stage"])
+	assert.Equal(t, []string{".node", ".deploy"}, deploy["extends"])
+	assert.Equal(t, []string{".deploy", ".node"}, deploy["extends_chain"])
+	assert.Equal(t, []string{"$CI_COMMIT_BRANCH == $CI_DEFAULT_BRANCH", "changes: k8s/**/*"}, deploy["rules"])
+	assert.Equal(t, true, jobs[".deploy"].Properties["template"])
+	assert.Equal(t, "ci/deploy.yml", jobs[".deploy"].Properties["source"])
+
+	images := make(map[string]core.Finding)
+	for _, image := range byType["CI Image"] {
+		images[image.Properties["job"].(string)+"|"+image.Name] = image
+	}
+	require.Len(t, images, 5)
+	assert.Equal(t, "container", images["build|node:20"].Properties["role"])
+	assert.Equal(t, "db", images["build|postgres:16"].Properties["service"])
+	assert.Contains(t, images, "build|redis:7")
+	assert.Contains(t, images, "deploy|node:20")
+	assert.Contains(t, images, "test|alpine:3.19")
+
+	assert.Len(t, byType["CI Runner"], 4)
+}
+
+func TestGitLabCIProcessor_IncludesMergeAndStayInRepository(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(parent, "secrets.yml"), `leak:
+  script: cat /etc/passwd
+`)
+	writeTestFile(t, filepath.Join(dir, "ci", "base.yml"), `default:
+  image: alpine:3.19
+  tags: [docker]
+build:
+  stage: build
+  image: node:18
+  variables:
+    NODE_ENV: production
+    CACHE: "true"
+  script: [npm ci, npm run build]
+`)
+	writeTestFile(t, filepath.Join(dir, "ci", "jobs", "lint.yml"), `lint:
+  script: make lint
+`)
+	writeTestFile(t, filepath.Join(dir, "ci", "jobs", "nested", "audit.yml"), `audit:
+  script: make audit
+`)
+	content := `include:
+  - local: /ci/base.yml
+  - local: /ci/**.yml
+  - local: ../secrets.yml
+build:
+  image: node:20
+  variables:
+    NODE_ENV: test
+  script: [make]
+`
+	path := filepath.Join(dir, ".gitlab-ci.yml")
+	findings, err := GitLabCIProcessor{}.Process(path, "repo", content)
+	require.NoError(t, err)
+
+	includes := findingsByName(findingsByType(findings, "CI Include"))
+	assert.Equal(t, true, includes["/ci/**.yml"].Properties["resolved"])
+	assert.Equal(t, false, includes["../secrets.yml"].Properties["resolved"])
+
+	jobs := findingsByName(findingsByType(findings, "CI Job"))
+	assert.Contains(t, jobs, "lint")
+	assert.Contains(t, jobs, "audit")
+	assert.NotContains(t, jobs, "leak")
+
+	build := jobs["build"].Properties
+	assert.Equal(t, "build", build["stage"])
+	assert.Nil(t, build["source"])
+	assert.Equal(t, "ci/jobs/lint.yml", jobs["lint"].Properties["source"])
+
+	images := make(map[string]string)
+	for _, image := range findingsByType(findings, "CI Image") {
+		images[image.Properties["job"].(string)] = image.Name
+	}
+	assert.Equal(t, "node:20", images["build"])
+	assert.Equal(t, "alpine:3.19", images["lint"])
+}
+
+func TestGitLabGlobPattern(t *testing.T) {
+	tests := []struct {
+		glob    string
+		path    string
+		matches bool
+	}{
+		{"ci/*.yml", "ci/build.yml", true},
+		{"ci/*.yml", "ci/jobs/build.yml", false},
+		{"ci/**.yml", "ci/jobs/build.yml", true},
+		{"ci/**/*.yml", "ci/build.yml", true},
+		{"ci/**/*.yml", "ci/a/b/build.yml", true},
+		{"ci/**/*.yml", "other/build.yml", false},
+		{"ci/job?.yml", "ci/job1.yml", true},
+		{"ci/job?.yml", "ci/job/.yml", false},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile("^" + gitlabGlobPattern(tt.glob) + "$")
+		assert.Equal(t, tt.matches, re.MatchString(tt.path), tt.glob+" "+tt.path)
+	}
+}
+
+func TestMergeGitLabHash(t *testing.T) {
+	merged := mergeGitLabHash(map[string]interface{}{
+		"stage":     "build",
+		"script":    []interface{}{"a", "b"},
+		"variables": map[string]interface{}{"A": "1", "B": "2"},
+	}, map[string]interface{}{
+		"script":    []interface{}{"c"},
+		"variables": map[string]interface{}{"B": "3"},
+	})
+	assert.Equal(t, map[string]interface{}{
+		"stage":     "build",
+		"script":    []interface{}{"c"},
+		"variables": map[string]interface{}{"A": "1", "B": "3"},
+	}, merged)
+}
@@ -0,0 +1,251 @@
+package processors
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// maxHelmChartDepth bounds how far up the tree a values file looks for the
+// Chart.yaml it belongs to.
+const maxHelmChartDepth = 5
+
+// HelmChart is the subset of Chart.yaml and Chart.lock used to report charts
+// and their dependencies.
+type HelmChart struct {
+	APIVersion   string `yaml:"apiVersion"`
+	Name         string `yaml:"name"`
+	Version      string `yaml:"version"`
+	AppVersion   string `yaml:"appVersion"`
+	Type         string `yaml:"type"`
+	KubeVersion  string `yaml:"kubeVersion"`
+	Digest       string `yaml:"digest"`
+	Dependencies []struct {
+		Name       string `yaml:"name"`
+		Version    string `yaml:"version"`
+		Repository string `yaml:"repository"`
+		Condition  string `yaml:"condition"`
+		Alias      string `yaml:"alias"`
+	} `yaml:"dependencies"`
+}
+
+func readHelmChart(path string) (HelmChart, bool) {
+	var chart HelmChart
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return chart, false
+	}
+	if err := yaml.Unmarshal(content, &chart); err != nil || chart.Name == "" {
+		return chart, false
+	}
+	return chart, true
+}
+
+// findHelmChart walks up from dir to the Chart.yaml of the chart a file
+// belongs to.
+func findHelmChart(dir string) (HelmChart, bool) {
+	for i := 0; i < maxHelmChartDepth; i++ {
+		if chart, ok := readHelmChart(filepath.Join(dir, "Chart.yaml")); ok {
+			return chart, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return HelmChart{}, false
+}
+
+// HelmProcessor reports Helm charts, their dependencies and the container
+// images configured in their values files.
+type HelmProcessor struct {
+}
+
+func (h HelmProcessor) Supports(filePath string) bool {
+	base := filepath.Base(filePath)
+	if base == "Chart.yaml" || base == "Chart.lock" {
+		return true
+	}
+	ext := filepath.Ext(base)
+	return strings.HasPrefix(base, "values") && (ext == ".yaml" || ext == ".yml")
+}
+
+func (h HelmProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	switch filepath.Base(path) {
+	case "Chart.yaml":
+		return h.processChart(path, repoName, content)
+	case "Chart.lock":
+		return h.processLock(path, repoName, content)
+	default:
+		return h.processValues(path, repoName, content)
+	}
+}
+
+func (h HelmProcessor) processChart(path string, repoName string, content string) ([]core.Finding, error) {
+	var chart HelmChart
+	if err := yaml.Unmarshal([]byte(content), &chart); err != nil {
+		return nil, fmt.Errorf("failed to parse Helm chart '%s': %w", path, err)
+	}
+	if chart.Name == "" {
+		return nil, nil
+	}
+
+	properties := map[string]interface{}{
+		"version":     chart.Version,
+		"api_version": chart.APIVersion,
+	}
+	if chart.AppVersion != "" {
+		properties["app_version"] = chart.AppVersion
+	}
+	if chart.Type != "" {
+		properties["chart_type"] = chart.Type
+	}
+	if chart.KubeVersion != "" {
+		properties["kube_version"] = chart.KubeVersion
+	}
+	matches := []core.Finding{{
+		Name:       chart.Name,
+		Type:       "Helm Chart",
+		Category:   "Kubernetes",
+		Properties: properties,
+		Path:       path,
+		RepoName:   repoName,
+	}}
+	return append(matches, helmDependencyFindings(chart, chart.Name, false, path, repoName)...), nil
+}
+
+func (h HelmProcessor) processLock(path string, repoName string, content string) ([]core.Finding, error) {
+	var lock HelmChart
+	if err := yaml.Unmarshal([]byte(content), &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse Helm chart lock '%s': %w", path, err)
+	}
+	chartName := ""
+	if chart, ok := readHelmChart(filepath.Join(filepath.Dir(path), "Chart.yaml")); ok {
+		chartName = chart.Name
+	}
+	return helmDependencyFindings(lock, chartName, true, path, repoName), nil
+}
+
+func helmDependencyFindings(chart HelmChart, chartName string, locked bool, path string, repoName string) []core.Finding {
+	var matches []core.Finding
+	for _, dep := range chart.Dependencies {
+		properties := map[string]interface{}{
+			"version":    dep.Version,
+			"repository": dep.Repository,
+		}
+		if chartName != "" {
+			properties["chart"] = chartName
+		}
+		if dep.Condition != "" {
+			properties["condition"] = dep.Condition
+		}
+		if dep.Alias != "" {
+			properties["alias"] = dep.Alias
+		}
+		if locked {
+			properties["locked"] = true
+			if chart.Digest != "" {
+				properties["digest"] = chart.Digest
+			}
+		}
+		matches = append(matches, core.Finding{
+			Name:       dep.Name,
+			Type:       "Helm Chart Dependency",
+			Category:   "Kubernetes",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	return matches
+}
+
+// helmValuesImage is an image found in a values file and the key it was set at.
+type helmValuesImage struct {
+	Key   string
+	Image string
+}
+
+// findHelmValuesImages walks a values tree for the two common ways of setting
+// an image: "image: repo:tag" and "image: {registry, repository, tag, digest}".
+func findHelmValuesImages(value interface{}, key string, appVersion string) []helmValuesImage {
+	var images []helmValuesImage
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if repository, ok := v["repository"].(string); ok && repository != "" {
+			image := repository
+			if registry, ok := v["registry"].(string); ok && registry != "" {
+				image = registry + "/" + image
+			}
+			tag := fmt.Sprint(v["tag"])
+			if v["tag"] == nil || tag == "" {
+				// Charts conventionally default the tag to the appVersion.
+				tag = appVersion
+			}
+			if tag != "" {
+				image += ":" + tag
+			}
+			if digest, ok := v["digest"].(string); ok && digest != "" {
+				image += "@" + digest
+			}
+			return append(images, helmValuesImage{Key: key, Image: image})
+		}
+		keys := make([]string, 0, len(v))
+		for child := range v {
+			keys = append(keys, child)
+		}
+		sort.Strings(keys)
+		for _, child := range keys {
+			childKey := child
+			if key != "" {
+				childKey = key + "." + child
+			}
+			if image, ok := v[child].(string); ok && (child == "image" || strings.HasSuffix(child, "Image")) {
+				if image != "" {
+					images = append(images, helmValuesImage{Key: childKey, Image: image})
+				}
+				continue
+			}
+			images = append(images, findHelmValuesImages(v[child], childKey, appVersion)...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			images = append(images, findHelmValuesImages(item, fmt.Sprintf("%s[%d]", key, i), appVersion)...)
+		}
+	}
+	return images
+}
+
+func (h HelmProcessor) processValues(path string, repoName string, content string) ([]core.Finding, error) {
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &values); err != nil {
+		return nil, fmt.Errorf("failed to parse Helm values '%s': %w", path, err)
+	}
+
+	chart, inChart := findHelmChart(filepath.Dir(path))
+	var matches []core.Finding
+	for _, image := range findHelmValuesImages(values, "", chart.AppVersion) {
+		properties := parseImageReference(image.Image).properties()
+		properties["key"] = image.Key
+		properties["values_file"] = filepath.Base(path)
+		if inChart {
+			properties["chart"] = chart.Name
+		}
+		matches = append(matches, core.Finding{
+			Name:       image.Image,
+			Type:       "Helm Image",
+			Category:   "Kubernetes",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	return matches, nil
+}
@@ -0,0 +1,123 @@
+package processors
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmProcessor_Supports(t *testing.T) {
+	processor := HelmProcessor{}
+
+	assert.True(t, processor.Supports("charts/web/Chart.yaml"))
+	assert.True(t, processor.Supports("charts/web/Chart.lock"))
+	assert.True(t, processor.Supports("charts/web/values.yaml"))
+	assert.True(t, processor.Supports("charts/web/values-prod.yml"))
+	assert.False(t, processor.Supports("charts/web/templates/deployment.yaml"))
+	assert.False(t, processor.Supports("charts/web/values.json"))
+}
+
+func TestHelmProcessor_Chart(t *testing.T) {
+	processor := HelmProcessor{}
+
+	content := `apiVersion: v2
+name: web
+version: 1.4.0
+appVersion: "2.1.0"
+type: application
+kubeVersion: ">=1.25.0"
+dependencies:
+  - name: redis
+    version: 18.x.x
+    repository: https://charts.bitnami.com/bitnami
+    condition: redis.enabled
+  - name: common
+    version: 2.0.0
+    repository: oci://registry-1.docker.io/bitnamicharts
+    alias: shared
+`
+
+	findings, err := processor.Process("charts/web/Chart.yaml", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+
+	assert.Equal(t, "Helm Chart", findings[0].Type)
+	assert.Equal(t, "web", findings[0].Name)
+	assert.Equal(t, "1.4.0", findings[0].Properties["version"])
+	assert.Equal(t, "2.1.0", findings[0].Properties["app_version"])
+	assert.Equal(t, "application", findings[0].Properties["chart_type"])
+	assert.Equal(t, ">=1.25.0", findings[0].Properties["kube_version"])
+
+	deps := findingsByName(findings[1:])
+	assert.Equal(t, "https://charts.bitnami.com/bitnami", deps["redis"].Properties["repository"])
+	assert.Equal(t, "redis.enabled", deps["redis"].Properties["condition"])
+	assert.Equal(t, "web", deps["redis"].Properties["chart"])
+	assert.Equal(t, "shared", deps["common"].Properties["alias"])
+}
+
+func TestHelmProcessor_Lock(t *testing.T) {
+	processor := HelmProcessor{}
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: web\nversion: 1.0.0\n")
+
+	content := `dependencies:
+- name: redis
+  repository: https://charts.bitnami.com/bitnami
+  version: 18.6.1
+digest: sha256:abc
+generated: "2024-01-01T00:00:00Z"
+`
+
+	findings, err := processor.Process(filepath.Join(dir, "Chart.lock"), "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Helm Chart Dependency", findings[0].Type)
+	assert.Equal(t, "18.6.1", findings[0].Properties["version"])
+	assert.Equal(t, true, findings[0].Properties["locked"])
+	assert.Equal(t, "sha256:abc", findings[0].Properties["digest"])
+	assert.Equal(t, "web", findings[0].Properties["chart"])
+}
+
+func TestHelmProcessor_Values(t *testing.T) {
+	processor := HelmProcessor{}
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: web\nversion: 1.0.0\nappVersion: 2.1.0\n")
+
+	content := `image:
+  repository: org/web
+  pullPolicy: IfNotPresent
+worker:
+  image:
+    registry: ghcr.io
+    repository: org/worker
+    tag: 3.0.0
+    digest: sha256:def
+sidecars:
+  - name: proxy
+    image: envoyproxy/envoy:v1.29.0
+metrics:
+  exporterImage: quay.io/prometheus/node-exporter:v1.7.0
+replicaCount: 2
+`
+
+	findings, err := processor.Process(filepath.Join(dir, "values-prod.yaml"), "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 4)
+
+	images := findingsByName(findings)
+	web := images["org/web:2.1.0"].Properties
+	assert.Equal(t, "image", web["key"])
+	assert.Equal(t, "2.1.0", web["tag"])
+	assert.Equal(t, "web", web["chart"])
+	assert.Equal(t, "values-prod.yaml", web["values_file"])
+
+	worker := images["ghcr.io/org/worker:3.0.0@sha256:def"].Properties
+	assert.Equal(t, "ghcr.io", worker["registry"])
+	assert.Equal(t, "sha256:def", worker["digest"])
+	assert.Equal(t, "worker.image", worker["key"])
+
+	assert.Equal(t, "sidecars[0].image", images["envoyproxy/envoy:v1.29.0"].Properties["key"])
+	assert.Equal(t, "quay.io", images["quay.io/prometheus/node-exporter:v1.7.0"].Properties["registry"])
+}
@@ -0,0 +1,53 @@
+package processors
+
+import "strings"
+
+// imageReference is a container image reference split into its parts, e.g.
+// "ghcr.io/org/app:1.2.3@sha256:..." has registry "ghcr.io", repository
+// "org/app", tag "1.2.3" and the digest.
+type imageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// parseImageReference splits an image reference the way Docker resolves it:
+// the first path component is a registry only if it looks like a host name,
+// and images without a tag or digest use "latest".
+func parseImageReference(image string) imageReference {
+	var ref imageReference
+	image = strings.TrimSpace(image)
+	if idx := strings.Index(image, "@"); idx >= 0 {
+		image, ref.Digest = image[:idx], image[idx+1:]
+	}
+	if idx := strings.LastIndex(image, ":"); idx >= 0 && !strings.Contains(image[idx:], "/") {
+		image, ref.Tag = image[:idx], image[idx+1:]
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	ref.Registry = "docker.io"
+	if idx := strings.Index(image, "/"); idx >= 0 {
+		host := image[:idx]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry, image = host, image[idx+1:]
+		}
+	}
+	ref.Repository = image
+	return ref
+}
+
+// properties returns the parts of the reference as finding properties.
+func (r imageReference) properties() map[string]interface{} {
+	properties := map[string]interface{}{
+		"registry":   r.Registry,
+		"repository": r.Repository,
+		"tag":        r.Tag,
+	}
+	if r.Digest != "" {
+		properties["digest"] = r.Digest
+	}
+	return properties
+}
@@ -0,0 +1,145 @@
+package processors
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// infrastructureCodeLanguages maps the extensions of the languages CDK and
+// Pulumi programs are written in to the language.
+var infrastructureCodeLanguages = map[string]string{
+	".ts":   "TypeScript",
+	".js":   "JavaScript",
+	".mjs":  "JavaScript",
+	".cjs":  "JavaScript",
+	".py":   "Python",
+	".java": "Java",
+	".go":   "Go",
+}
+
+// infrastructureCodeLanguage returns the language of a source file that may
+// define infrastructure. Type declarations, dependencies and synthesized
+// output are skipped.
+func infrastructureCodeLanguage(filePath string) (string, bool) {
+	if strings.HasSuffix(filePath, ".d.ts") || inDirectory(filePath, "node_modules", "cdk.out", "vendor", ".venv", "venv") {
+		return "", false
+	}
+	language, ok := infrastructureCodeLanguages[strings.ToLower(filepath.Ext(filePath))]
+	return language, ok
+}
+
+// awsServiceAliases maps AWS service module names, lower-cased without
+// separators, to the service prefix of the Terraform resource types when the
+// two differ.
+var awsServiceAliases = map[string]string{
+	"apigateway":               "api_gateway",
+	"apigatewayv2integrations": "apigatewayv2",
+	"applicationautoscaling":   "autoscaling",
+	"certificatemanager":       "acm",
+	"cloudfrontorigins":        "cloudfront",
+	"cloudwatchactions":        "cloudwatch",
+	"codepipelineactions":      "codepipeline",
+	"ecrassets":                "ecr",
+	"ecspatterns":              "ecs",
+	"elasticbeanstalk":         "elastic_beanstalk",
+	"elasticloadbalancing":     "elb",
+	"elasticloadbalancingv2":   "lb",
+	"events":                   "cloudwatch_event",
+	"eventstargets":            "cloudwatch_event",
+	"kinesisfirehose":          "kinesis",
+	"lambdaeventsources":       "lambda",
+	"lambdanodejs":             "lambda",
+	"logs":                     "cloudwatch",
+	"opensearchservice":        "opensearch",
+	"route53targets":           "route53",
+	"s3deployment":             "s3",
+	"s3notifications":          "s3",
+	"snssubscriptions":         "sns",
+	"stepfunctions":            "sfn",
+	"stepfunctionstasks":       "sfn",
+}
+
+// codeConstruct is a class instantiated in infrastructure code: the alias it
+// is qualified by, if any, the class and the literal name or ID it is given.
+type codeConstruct struct {
+	Qualifier string
+	Class     string
+	ID        string
+}
+
+// findCodeConstructs returns the instantiations matched by patterns, each of
+// which captures the qualifier, class and ID in that order.
+func findCodeConstructs(content string, patterns []*regexp.Regexp) []codeConstruct {
+	var constructs []codeConstruct
+	for _, pattern := range patterns {
+		for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+			constructs = append(constructs, codeConstruct{Qualifier: match[1], Class: match[2], ID: match[3]})
+		}
+	}
+	return constructs
+}
+
+// snakeCase converts a class name such as "DatabaseInstance" or "HTTPApi" to
+// the form Terraform types use, "database_instance" or "http_api".
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) || (unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// terraformEquivalentType names the Terraform resource type a class of a
+// provider module corresponds to, such as aws_s3_bucket for s3.Bucket, so it
+// can be looked up in the provider table. Without a class it names the
+// module's type prefix.
+func terraformEquivalentType(prefix string, module string, class string) string {
+	module = strings.ToLower(strings.NewReplacer("-", "", "_", "", ".", "", "/", "").Replace(module))
+	if prefix == "aws" {
+		if alias, ok := awsServiceAliases[module]; ok {
+			module = alias
+		}
+	}
+	parts := []string{prefix}
+	if module != "" && module != "index" {
+		parts = append(parts, module)
+	}
+	if class != "" {
+		parts = append(parts, snakeCase(class))
+	}
+	return strings.Join(parts, "_")
+}
+
+// infrastructureCodeFinding reports a resource declared by a program. Like
+// Terraform resources, it is named after the vendor of its provider and
+// carries the service from the provider table.
+func infrastructureCodeFinding(providers []TerraformProvider, tool string, terraformType string, properties map[string]interface{}, path string, repoName string) core.Finding {
+	typeProps, provider, known := terraformTypeProperties(providers, terraformType)
+	for key, value := range typeProps {
+		properties[key] = value
+	}
+	name, category := tool+" Resource", tool
+	if known {
+		name, category = provider.Vendor+" Resource", provider.Category
+	}
+	return core.Finding{
+		Name:       name,
+		Type:       tool + " Resource Use",
+		Category:   category,
+		Properties: properties,
+		Path:       path,
+		RepoName:   repoName,
+	}
+}
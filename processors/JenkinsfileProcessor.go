@@ -0,0 +1,242 @@
+package processors
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+var (
+	// jenkinsLibraryAnnotationRe matches @Library('lib@ref') and
+	// @Library(['a', 'b@ref']).
+	jenkinsLibraryAnnotationRe = regexp.MustCompile(`@Library\s*\(\s*\[?([^)\]]*)\]?\s*\)`)
+	// jenkinsLibraryStepRe matches the dynamic form: library 'lib@ref'.
+	jenkinsLibraryStepRe = regexp.MustCompile(`(?m)^\s*library\s*\(?\s*(?:identifier\s*:\s*)?['"]([^'"]+)['"]`)
+	jenkinsQuotedRe      = regexp.MustCompile(`['"]([^'"]+)['"]`)
+
+	jenkinsDeclarativeRe = regexp.MustCompile(`\bpipeline\s*\{`)
+	jenkinsStageRe       = regexp.MustCompile(`\bstage\s*\(\s*['"]([^'"]+)['"]\s*\)`)
+	jenkinsAgentRe       = regexp.MustCompile(`\bagent\s+(any|none)\b|\bagent\s*\{`)
+	jenkinsToolsRe       = regexp.MustCompile(`\btools\s*\{`)
+	jenkinsToolRe        = regexp.MustCompile(`(?m)^\s*(\w+)\s*\(?\s*['"]([^'"]+)['"]`)
+	jenkinsLabelRe       = regexp.MustCompile(`\blabel\s*\(?\s*['"]([^'"]+)['"]`)
+	jenkinsAgentImageRe  = regexp.MustCompile(`\b(?:image|docker)\s*\(?\s*['"]([^'"]+)['"]`)
+	jenkinsAgentKindRe   = regexp.MustCompile(`\b(docker|dockerfile|kubernetes|node)\b`)
+	// jenkinsNodeRe and jenkinsDockerImageRe match scripted pipelines:
+	// node('label') { ... } and docker.image('image').inside { ... }.
+	jenkinsNodeRe        = regexp.MustCompile(`\bnode\s*\(\s*['"]([^'"]+)['"]\s*\)`)
+	jenkinsDockerImageRe = regexp.MustCompile(`\bdocker\.image\s*\(\s*['"]([^'"]+)['"]\s*\)`)
+)
+
+// stripGroovyComments removes the // and /* */ comments from Groovy source.
+// Comment markers inside single, double and triple quoted strings and /slashy/
+// strings, such as the glob in 'src/**/*.java', are left alone. Block
+// comments are replaced by their line breaks so that lines stay where they
+// were.
+func stripGroovyComments(content string) string {
+	var out strings.Builder
+	// previous is the last character of code outside comments that was not
+	// a space, which tells a slashy string from a division.
+	previous := byte(0)
+	for i := 0; i < len(content); {
+		ch := content[i]
+		switch {
+		case strings.HasPrefix(content[i:], "//"):
+			end := strings.IndexByte(content[i:], '\n')
+			if end < 0 {
+				return out.String()
+			}
+			i += end
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			comment := content[i:]
+			if end >= 0 {
+				comment = content[i : i+2+end+2]
+			}
+			out.WriteString(strings.Repeat("\n", strings.Count(comment, "\n")))
+			i += len(comment)
+		case ch == '\'' || ch == '"' || (ch == '/' && groovySlashyStart(previous)):
+			quote := string(ch)
+			if ch != '/' && strings.HasPrefix(content[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+			}
+			end := groovyStringEnd(content, i, quote)
+			out.WriteString(content[i:end])
+			previous = content[end-1]
+			i = end
+		default:
+			out.WriteByte(ch)
+			if ch != ' ' && ch != '\t' && ch != '\n' && ch != '\r' {
+				previous = ch
+			}
+			i++
+		}
+	}
+	return out.String()
+}
+
+// groovySlashyStart reports whether a / after the code character previous
+// opens a slashy string rather than dividing.
+func groovySlashyStart(previous byte) bool {
+	return previous == 0 || strings.IndexByte("(=,[{:;!&|?+~", previous) >= 0
+}
+
+// groovyStringEnd returns the offset just past the string opened by quote at
+// content[start]. Backslashes escape the next character, and strings other
+// than triple-quoted ones end at a line break if they are not closed.
+func groovyStringEnd(content string, start int, quote string) int {
+	for i := start + len(quote); i < len(content); i++ {
+		switch {
+		case content[i] == '\\':
+			i++
+		case content[i] == '\n' && len(quote) == 1:
+			return i
+		case strings.HasPrefix(content[i:], quote):
+			return i + len(quote)
+		}
+	}
+	return len(content)
+}
+
+// groovyBlock returns the body of the brace block opened at content[open].
+func groovyBlock(content string, open int) string {
+	depth := 0
+	for i := open; i < len(content); i++ {
+		switch content[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return content[open+1 : i]
+			}
+		}
+	}
+	return content[open+1:]
+}
+
+// jenkinsStageAt returns the stage declared most recently before offset, which
+// is the stage an agent or image at offset belongs to in a declarative
+// pipeline.
+func jenkinsStageAt(stages [][]int, content string, offset int) string {
+	stage := ""
+	for _, match := range stages {
+		if match[0] > offset {
+			break
+		}
+		stage = content[match[2]:match[3]]
+	}
+	return stage
+}
+
+// JenkinsfileProcessor reports the agents, docker images, shared libraries,
+// tools and stages of Jenkins pipelines.
+type JenkinsfileProcessor struct {
+}
+
+func (j JenkinsfileProcessor) Supports(filePath string) bool {
+	base := filepath.Base(filePath)
+	return base == "Jenkinsfile" || strings.HasPrefix(base, "Jenkinsfile.") ||
+		strings.HasSuffix(strings.ToLower(base), ".jenkinsfile")
+}
+
+func (j JenkinsfileProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	content = stripGroovyComments(content)
+
+	newFinding := newCIFindingFunc("Jenkins", filepath.Base(path), path, repoName)
+	kind := "scripted"
+	if jenkinsDeclarativeRe.MatchString(content) {
+		kind = "declarative"
+	}
+	matches := []core.Finding{newFinding(filepath.Base(path), ciPipeline, map[string]interface{}{
+		"kind": kind,
+	})}
+
+	var libraries []string
+	for _, match := range jenkinsLibraryAnnotationRe.FindAllStringSubmatch(content, -1) {
+		for _, quoted := range jenkinsQuotedRe.FindAllStringSubmatch(match[1], -1) {
+			libraries = append(libraries, quoted[1])
+		}
+	}
+	for _, match := range jenkinsLibraryStepRe.FindAllStringSubmatch(content, -1) {
+		libraries = append(libraries, match[1])
+	}
+	for _, library := range libraries {
+		name, ref, _ := strings.Cut(library, "@")
+		properties := map[string]interface{}{
+			"include_type": "library",
+		}
+		if ref != "" {
+			properties["ref"] = ref
+		}
+		matches = append(matches, newFinding(name, ciInclude, properties))
+	}
+
+	stages := jenkinsStageRe.FindAllStringSubmatchIndex(content, -1)
+	for i, match := range stages {
+		matches = append(matches, newFinding(content[match[2]:match[3]], ciStage, map[string]interface{}{
+			"order": i,
+		}))
+	}
+
+	for _, match := range jenkinsAgentRe.FindAllStringSubmatchIndex(content, -1) {
+		stage := jenkinsStageAt(stages, content, match[0])
+		withStage := func(properties map[string]interface{}) map[string]interface{} {
+			if stage != "" {
+				properties["stage"] = stage
+			}
+			return properties
+		}
+		if match[2] >= 0 {
+			if content[match[2]:match[3]] == "any" {
+				matches = append(matches, newFinding("any", ciRunner, withStage(map[string]interface{}{
+					"agent": "any",
+				})))
+			}
+			continue
+		}
+
+		body := groovyBlock(content, match[1]-1)
+		agent := "label"
+		if kindMatch := jenkinsAgentKindRe.FindStringSubmatch(body); kindMatch != nil {
+			agent = kindMatch[1]
+		}
+		for _, label := range jenkinsLabelRe.FindAllStringSubmatch(body, -1) {
+			matches = append(matches, newFinding(label[1], ciRunner, withStage(map[string]interface{}{
+				"agent": agent,
+			})))
+		}
+		if agent == "docker" {
+			for _, image := range jenkinsAgentImageRe.FindAllStringSubmatch(body, -1) {
+				finding := ciImageFinding(newFinding, image[1], "", "agent")
+				withStage(finding.Properties)
+				matches = append(matches, finding)
+			}
+		} else if agent == "kubernetes" {
+			matches = append(matches, newFinding("kubernetes", ciRunner, withStage(map[string]interface{}{
+				"agent": agent,
+			})))
+		}
+	}
+
+	for _, match := range jenkinsNodeRe.FindAllStringSubmatch(content, -1) {
+		matches = append(matches, newFinding(match[1], ciRunner, map[string]interface{}{
+			"agent": "node",
+		}))
+	}
+	for _, match := range jenkinsDockerImageRe.FindAllStringSubmatch(content, -1) {
+		matches = append(matches, ciImageFinding(newFinding, match[1], "", "inside"))
+	}
+
+	for _, match := range jenkinsToolsRe.FindAllStringIndex(content, -1) {
+		for _, tool := range jenkinsToolRe.FindAllStringSubmatch(groovyBlock(content, match[1]-1), -1) {
+			matches = append(matches, newFinding(tool[1], ciTool, map[string]interface{}{
+				"installation": tool[2],
+			}))
+		}
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,186 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestJenkinsfileProcessor_Supports(t *testing.T) {
+	processor := JenkinsfileProcessor{}
+
+	assert.True(t, processor.Supports("Jenkinsfile"))
+	assert.True(t, processor.Supports("ci/Jenkinsfile.release"))
+	assert.True(t, processor.Supports("ci/deploy.jenkinsfile"))
+	assert.False(t, processor.Supports("Jenkinsfile-notes.md.txt/readme"))
+}
+
+func TestJenkinsfileProcessor_Declarative(t *testing.T) {
+	processor := JenkinsfileProcessor{}
+
+	content := `@Library(['shared-pipeline@v2.3', 'utils']) _
+library 'dynamic-lib@main'
+
+// agent { label 'commented-out' }
+pipeline {
+    agent { label 'linux && docker' }
+    tools {
+        maven 'Maven 3.9'
+        jdk 'temurin-17'
+    }
+    stages {
+        stage('Build') {
+            agent {
+                docker {
+                    image 'maven:3.9-eclipse-temurin-17'
+                    label 'docker'
+                }
+            }
+            steps {
+                sh 'mvn -B package'
+            }
+        }
+        stage('Deploy') {
+            agent {
+                kubernetes {
+                    yamlFile 'pod.yaml'
+                }
+            }
+            steps {
+                sh './deploy.sh'
+            }
+        }
+    }
+}
+`
+
+	findings, err := processor.Process("Jenkinsfile", "repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		assert.Equal(t, "Jenkins", finding.Category)
+		byType[finding.Type] = append(byType[finding.Type], finding)
+	}
+
+	require.Len(t, byType["CI Pipeline"], 1)
+	assert.Equal(t, "declarative", byType["CI Pipeline"][0].Properties["kind"])
+
+	libraries := findingsByName(byType["CI Include"])
+	require.Len(t, libraries, 3)
+	assert.Equal(t, "v2.3", libraries["shared-pipeline"].Properties["ref"])
+	assert.NotContains(t, libraries["utils"].Properties, "ref")
+	assert.Equal(t, "main", libraries["dynamic-lib"].Properties["ref"])
+
+	require.Len(t, byType["CI Stage"], 2)
+	assert.Equal(t, "Build", byType["CI Stage"][0].Name)
+
+	runners := byType["CI Runner"]
+	require.Len(t, runners, 3)
+	assert.Equal(t, "linux && docker", runners[0].Name)
+	assert.NotContains(t, runners[0].Properties, "stage")
+	assert.Equal(t, "docker", runners[1].Name)
+	assert.Equal(t, "Build", runners[1].Properties["stage"])
+	assert.Equal(t, "kubernetes", runners[2].Name)
+	assert.Equal(t, "Deploy", runners[2].Properties["stage"])
+
+	images := byType["CI Image"]
+	require.Len(t, images, 1)
+	assert.Equal(t, "maven", images[0].Properties["repository"])
+	assert.Equal(t, "3.9-eclipse-temurin-17", images[0].Properties["tag"])
+	assert.Equal(t, "Build", images[0].Properties["stage"])
+
+	tools := findingsByName(byType["CI Tool"])
+	require.Len(t, tools, 2)
+	assert.Equal(t, "Maven 3.9", tools["maven"].Properties["installation"])
+	assert.Equal(t, "temurin-17", tools["jdk"].Properties["installation"])
+}
+
+func TestJenkinsfileProcessor_Scripted(t *testing.T) {
+	processor := JenkinsfileProcessor{}
+
+	content := `node('build-agents') {
+    stage('Test') {
+        docker.image('node:20').inside {
+            sh 'npm test'
+        }
+    }
+}
+`
+
+	findings, err := processor.Process("Jenkinsfile", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 4)
+	assert.Equal(t, "scripted", findings[0].Properties["kind"])
+	assert.Equal(t, "Test", findings[1].Name)
+	assert.Equal(t, "build-agents", findings[2].Name)
+	assert.Equal(t, "CI Runner", findings[2].Type)
+	assert.Equal(t, "node:20", findings[3].Name)
+	assert.Equal(t, "CI Image", findings[3].Type)
+}
+
+func TestJenkinsfileProcessor_CommentsAndStrings(t *testing.T) {
+	processor := JenkinsfileProcessor{}
+
+	content := `pipeline {
+    agent { docker { image 'maven:3.9' } } // agent { label 'trailing' }
+    stages {
+        stage('Build') {
+            steps {
+                junit 'target/**/*.xml'
+                archiveArtifacts "**/target/*.jar"
+            }
+        }
+        /* stage('Commented') {
+        } */
+        stage('Test') {
+            steps {
+                sh '''
+                    find src/**/*.java -name '*Test.java'
+                '''
+                sh(/echo "http:\/\/example.com"/) // stage('Trailing')
+                echo 10 / 2 // stage('Division')
+            }
+        }
+    }
+}
+`
+
+	findings, err := processor.Process("Jenkinsfile", "repo", content)
+	require.NoError(t, err)
+
+	var stages []string
+	var images []string
+	for _, finding := range findings {
+		switch finding.Type {
+		case ciStage:
+			stages = append(stages, finding.Name)
+		case ciImage:
+			images = append(images, finding.Name)
+		}
+	}
+	assert.Equal(t, []string{"Build", "Test"}, stages)
+	assert.Equal(t, []string{"maven:3.9"}, images)
+}
+
+func TestStripGroovyComments(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"a // comment\nb", "a \nb"},
+		{"a /* one\ntwo */ b", "a \n b"},
+		{`x = 'src/**/*.java' // glob`, `x = 'src/**/*.java' `},
+		{`x = "a // b" /* c */`, `x = "a // b" `},
+		{"x = '''\n/* kept */\n''' // gone", "x = '''\n/* kept */\n''' "},
+		{`x = "say \"//\"" // gone`, `x = "say \"//\"" `},
+		{`x = ~/a\/*b/ // gone`, `x = ~/a\/*b/ `},
+		{`y = 4 / 2 // gone`, `y = 4 / 2 `},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, stripGroovyComments(tt.source), tt.source)
+	}
+}
@@ -0,0 +1,1600 @@
+package processors
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// This file renders the subset of Jinja that Deployment Manager templates
+// use: output expressions with filters, if, for and set. Templates using
+// anything else, such as macros or includes, fail to parse and are left
+// unrendered.
+
+// Rendering stops with an error rather than exhaust memory when a template
+// builds more than maxJinjaItems list items, runs more than
+// maxJinjaIterations loop iterations, or builds or writes a string of more
+// than maxJinjaOutput bytes.
+const (
+	maxJinjaItems      = 10000
+	maxJinjaIterations = 100000
+	maxJinjaOutput     = 1 << 20
+)
+
+// jinjaUndefined is the value of a name, attribute or key the context does
+// not have. It renders as an empty string.
+type jinjaUndefined struct {
+	Name string
+}
+
+// jinjaCallable is a function or bound method a template can call.
+type jinjaCallable func(args []interface{}) (interface{}, error)
+
+type jinjaExpr func(scope *jinjaScope) (interface{}, error)
+
+type jinjaNode interface{}
+
+type jinjaText string
+
+type jinjaOutput struct {
+	Expr   jinjaExpr
+	Source string
+}
+
+type jinjaIf struct {
+	Conditions []jinjaExpr
+	Bodies     [][]jinjaNode
+	Else       []jinjaNode
+}
+
+type jinjaFor struct {
+	Vars   []string
+	Iter   jinjaExpr
+	Filter jinjaExpr
+	Body   []jinjaNode
+	Else   []jinjaNode
+}
+
+type jinjaSet struct {
+	Names []string
+	Expr  jinjaExpr
+}
+
+// jinjaScope holds the variables visible to part of a template.
+type jinjaScope struct {
+	vars   map[string]interface{}
+	parent *jinjaScope
+}
+
+func (s *jinjaScope) lookup(name string) interface{} {
+	for scope := s; scope != nil; scope = scope.parent {
+		if value, ok := scope.vars[name]; ok {
+			return value
+		}
+	}
+	if name == "range" {
+		return jinjaCallable(jinjaRange)
+	}
+	return jinjaUndefined{Name: name}
+}
+
+// jinjaSegment is a piece of template source: text, or the inside of an
+// output, statement or comment tag.
+type jinjaSegment struct {
+	Kind byte
+	Text string
+}
+
+// splitJinja splits template source into text and tags, applying the "-"
+// whitespace control markers.
+func splitJinja(src string) ([]jinjaSegment, error) {
+	var segments []jinjaSegment
+	trimNext := false
+	for len(src) > 0 {
+		start := -1
+		for i := 0; i+1 < len(src); i++ {
+			if src[i] == '{' && (src[i+1] == '{' || src[i+1] == '%' || src[i+1] == '#') {
+				start = i
+				break
+			}
+		}
+		text := src
+		if start >= 0 {
+			text = src[:start]
+		}
+		if trimNext {
+			text = strings.TrimLeftFunc(text, unicode.IsSpace)
+			trimNext = false
+		}
+		if start < 0 {
+			segments = append(segments, jinjaSegment{Kind: 't', Text: text})
+			break
+		}
+
+		kind := src[start+1]
+		closer := map[byte]string{'{': "}}", '%': "%}", '#': "#}"}[kind]
+		end := strings.Index(src[start+2:], closer)
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed tag at offset %d", start)
+		}
+		inner := src[start+2 : start+2+end]
+		if strings.HasPrefix(inner, "-") {
+			text = strings.TrimRightFunc(text, unicode.IsSpace)
+			inner = inner[1:]
+		}
+		if strings.HasSuffix(inner, "-") {
+			trimNext = true
+			inner = inner[:len(inner)-1]
+		}
+		segments = append(segments, jinjaSegment{Kind: 't', Text: text})
+		if kind != '#' {
+			segments = append(segments, jinjaSegment{Kind: kind, Text: strings.TrimSpace(inner)})
+		}
+		src = src[start+2+end+2:]
+	}
+	return segments, nil
+}
+
+// parseJinja parses template source into nodes.
+func parseJinja(src string) ([]jinjaNode, error) {
+	segments, err := splitJinja(src)
+	if err != nil {
+		return nil, err
+	}
+	parser := &jinjaBlockParser{segments: segments}
+	nodes, end, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+	if end != "" {
+		return nil, fmt.Errorf("unexpected {%% %s %%}", end)
+	}
+	return nodes, nil
+}
+
+type jinjaBlockParser struct {
+	segments []jinjaSegment
+	pos      int
+}
+
+// parse reads nodes up to the end of the template or a tag that closes or
+// continues a block, which is returned.
+func (p *jinjaBlockParser) parse() ([]jinjaNode, string, error) {
+	var nodes []jinjaNode
+	for p.pos < len(p.segments) {
+		segment := p.segments[p.pos]
+		p.pos++
+		switch segment.Kind {
+		case 't':
+			if segment.Text != "" {
+				nodes = append(nodes, jinjaText(segment.Text))
+			}
+		case '{':
+			expr, err := parseJinjaExpression(segment.Text)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, jinjaOutput{Expr: expr, Source: segment.Text})
+		case '%':
+			keyword, rest, _ := strings.Cut(segment.Text, " ")
+			rest = strings.TrimSpace(rest)
+			switch keyword {
+			case "if":
+				node, err := p.parseIf(rest)
+				if err != nil {
+					return nil, "", err
+				}
+				nodes = append(nodes, node)
+			case "for":
+				node, err := p.parseFor(rest)
+				if err != nil {
+					return nil, "", err
+				}
+				nodes = append(nodes, node)
+			case "set":
+				node, err := parseJinjaSet(rest)
+				if err != nil {
+					return nil, "", err
+				}
+				nodes = append(nodes, node)
+			case "elif", "else", "endif", "endfor":
+				return nodes, segment.Text, nil
+			default:
+				return nil, "", fmt.Errorf("unsupported tag {%% %s %%}", keyword)
+			}
+		}
+	}
+	return nodes, "", nil
+}
+
+func (p *jinjaBlockParser) parseIf(condition string) (jinjaNode, error) {
+	node := jinjaIf{}
+	for {
+		expr, err := parseJinjaExpression(condition)
+		if err != nil {
+			return nil, err
+		}
+		body, end, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		node.Conditions = append(node.Conditions, expr)
+		node.Bodies = append(node.Bodies, body)
+		switch {
+		case strings.HasPrefix(end, "elif "):
+			condition = strings.TrimSpace(strings.TrimPrefix(end, "elif "))
+		case end == "else":
+			node.Else, end, err = p.parse()
+			if err != nil {
+				return nil, err
+			}
+			if end != "endif" {
+				return nil, fmt.Errorf("expected endif, found %q", end)
+			}
+			return node, nil
+		case end == "endif":
+			return node, nil
+		default:
+			return nil, fmt.Errorf("expected endif, found %q", end)
+		}
+	}
+}
+
+func (p *jinjaBlockParser) parseFor(header string) (jinjaNode, error) {
+	tokens, err := tokenizeJinja(header)
+	if err != nil {
+		return nil, err
+	}
+	parser := &jinjaExprParser{tokens: tokens}
+	node := jinjaFor{}
+	for {
+		token := parser.next()
+		if token.Kind != 'n' {
+			return nil, fmt.Errorf("invalid for loop %q", header)
+		}
+		node.Vars = append(node.Vars, token.Text)
+		if !parser.accept(",") {
+			break
+		}
+	}
+	if !parser.accept("in") {
+		return nil, fmt.Errorf("invalid for loop %q", header)
+	}
+	if node.Iter, err = parser.parseOr(); err != nil {
+		return nil, err
+	}
+	if parser.accept("if") {
+		if node.Filter, err = parser.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+	if !parser.done() {
+		return nil, fmt.Errorf("invalid for loop %q", header)
+	}
+
+	body, end, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	node.Body = body
+	if end == "else" {
+		node.Else, end, err = p.parse()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if end != "endfor" {
+		return nil, fmt.Errorf("expected endfor, found %q", end)
+	}
+	return node, nil
+}
+
+func parseJinjaSet(statement string) (jinjaNode, error) {
+	names, value, ok := strings.Cut(statement, "=")
+	if !ok {
+		return nil, fmt.Errorf("unsupported block set %q", statement)
+	}
+	node := jinjaSet{}
+	for _, name := range strings.Split(names, ",") {
+		node.Names = append(node.Names, strings.TrimSpace(name))
+	}
+	expr, err := parseJinjaExpression(value)
+	if err != nil {
+		return nil, err
+	}
+	node.Expr = expr
+	return node, nil
+}
+
+// jinjaRenderer renders parsed templates, recording the output expressions
+// whose values were not known and counting loop iterations.
+type jinjaRenderer struct {
+	Unresolved []string
+	Iterations int
+}
+
+// renderJinja renders template source with vars. It returns the output and
+// the expressions that rendered as undefined.
+func renderJinja(src string, vars map[string]interface{}) (string, []string, error) {
+	nodes, err := parseJinja(src)
+	if err != nil {
+		return "", nil, err
+	}
+	renderer := &jinjaRenderer{}
+	var out strings.Builder
+	if err := renderer.render(&out, nodes, &jinjaScope{vars: vars}); err != nil {
+		return "", nil, err
+	}
+	return out.String(), renderer.Unresolved, nil
+}
+
+func (r *jinjaRenderer) render(out *strings.Builder, nodes []jinjaNode, scope *jinjaScope) error {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case jinjaText:
+			out.WriteString(string(n))
+			if out.Len() > maxJinjaOutput {
+				return fmt.Errorf("output is longer than %d bytes", maxJinjaOutput)
+			}
+		case jinjaOutput:
+			value, err := n.Expr(scope)
+			if err != nil {
+				return err
+			}
+			if _, undefined := value.(jinjaUndefined); undefined {
+				r.Unresolved = append(r.Unresolved, n.Source)
+			}
+			out.WriteString(jinjaString(value))
+			if out.Len() > maxJinjaOutput {
+				return fmt.Errorf("output is longer than %d bytes", maxJinjaOutput)
+			}
+		case jinjaSet:
+			value, err := n.Expr(scope)
+			if err != nil {
+				return err
+			}
+			if err := assignJinja(scope, n.Names, value); err != nil {
+				return err
+			}
+		case jinjaIf:
+			body := n.Else
+			for i, condition := range n.Conditions {
+				value, err := condition(scope)
+				if err != nil {
+					return err
+				}
+				if jinjaTruthy(value) {
+					body = n.Bodies[i]
+					break
+				}
+			}
+			if err := r.render(out, body, scope); err != nil {
+				return err
+			}
+		case jinjaFor:
+			if err := r.renderFor(out, n, scope); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *jinjaRenderer) renderFor(out *strings.Builder, loop jinjaFor, scope *jinjaScope) error {
+	iterable, err := loop.Iter(scope)
+	if err != nil {
+		return err
+	}
+	items, err := jinjaItems(iterable)
+	if err != nil {
+		return err
+	}
+
+	var selected []*jinjaScope
+	for _, item := range items {
+		child := &jinjaScope{vars: map[string]interface{}{}, parent: scope}
+		if err := assignJinja(child, loop.Vars, item); err != nil {
+			return err
+		}
+		if loop.Filter != nil {
+			keep, err := loop.Filter(child)
+			if err != nil {
+				return err
+			}
+			if !jinjaTruthy(keep) {
+				continue
+			}
+		}
+		selected = append(selected, child)
+	}
+	if len(selected) == 0 {
+		return r.render(out, loop.Else, scope)
+	}
+	for i, child := range selected {
+		r.Iterations++
+		if r.Iterations > maxJinjaIterations {
+			return fmt.Errorf("loops ran more than %d times", maxJinjaIterations)
+		}
+		child.vars["loop"] = map[string]interface{}{
+			"index":  i + 1,
+			"index0": i,
+			"first":  i == 0,
+			"last":   i == len(selected)-1,
+			"length": len(selected),
+		}
+		if err := r.render(out, loop.Body, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignJinja binds names to a value, unpacking it when there are several.
+func assignJinja(scope *jinjaScope, names []string, value interface{}) error {
+	if len(names) == 1 {
+		scope.vars[names[0]] = value
+		return nil
+	}
+	list, ok := value.([]interface{})
+	if !ok || len(list) != len(names) {
+		return fmt.Errorf("cannot unpack %s into %d names", jinjaString(value), len(names))
+	}
+	for i, name := range names {
+		scope.vars[name] = list[i]
+	}
+	return nil
+}
+
+// jinjaItems returns the values a for loop visits: list items, map keys in
+// order, or the characters of a string.
+func jinjaItems(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, nil
+	case map[string]interface{}:
+		var keys []interface{}
+		for _, key := range sortedKeys(v) {
+			keys = append(keys, key)
+		}
+		return keys, nil
+	case string:
+		var chars []interface{}
+		for _, char := range v {
+			chars = append(chars, string(char))
+		}
+		return chars, nil
+	case jinjaUndefined:
+		return nil, fmt.Errorf("cannot iterate over undefined %s", v.Name)
+	}
+	return nil, fmt.Errorf("cannot iterate over %s", jinjaString(value))
+}
+
+// jinjaToken is a token of an expression: a name ('n'), string ('s'),
+// number ('d') or operator ('o').
+type jinjaToken struct {
+	Kind byte
+	Text string
+}
+
+var jinjaOperators = []string{"==", "!=", "<=", ">=", "//", "**", "+", "-", "*", "/", "%", "~", "<", ">", "|", ".", "[", "]", "(", ")", ",", ":", "{", "}", "="}
+
+func tokenizeJinja(src string) ([]jinjaToken, error) {
+	var tokens []jinjaToken
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '\'' || ch == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != ch; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+					switch src[j] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					default:
+						b.WriteByte(src[j])
+					}
+					continue
+				}
+				b.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string in %q", src)
+			}
+			tokens = append(tokens, jinjaToken{Kind: 's', Text: b.String()})
+			i = j + 1
+		case ch >= '0' && ch <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.' && j+1 < len(src) && src[j+1] >= '0' && src[j+1] <= '9') {
+				j++
+			}
+			tokens = append(tokens, jinjaToken{Kind: 'd', Text: src[i:j]})
+			i = j
+		case ch == '_' || unicode.IsLetter(rune(ch)):
+			j := i
+			for j < len(src) && (src[j] == '_' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			tokens = append(tokens, jinjaToken{Kind: 'n', Text: src[i:j]})
+			i = j
+		default:
+			matched := false
+			for _, op := range jinjaOperators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, jinjaToken{Kind: 'o', Text: op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected %q in %q", ch, src)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+type jinjaExprParser struct {
+	tokens []jinjaToken
+	pos    int
+}
+
+func (p *jinjaExprParser) peek() jinjaToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return jinjaToken{}
+}
+
+func (p *jinjaExprParser) next() jinjaToken {
+	token := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return token
+}
+
+func (p *jinjaExprParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// accept consumes the next token if it is the operator or keyword text.
+func (p *jinjaExprParser) accept(text string) bool {
+	token := p.peek()
+	if (token.Kind == 'o' || token.Kind == 'n') && token.Text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *jinjaExprParser) expect(text string) error {
+	if !p.accept(text) {
+		return fmt.Errorf("expected %q, found %q", text, p.peek().Text)
+	}
+	return nil
+}
+
+// parseJinjaExpression compiles an expression.
+func parseJinjaExpression(src string) (jinjaExpr, error) {
+	tokens, err := tokenizeJinja(src)
+	if err != nil {
+		return nil, err
+	}
+	parser := &jinjaExprParser{tokens: tokens}
+	expr, err := parser.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if !parser.done() {
+		return nil, fmt.Errorf("unexpected %q in %q", parser.peek().Text, src)
+	}
+	return expr, nil
+}
+
+func (p *jinjaExprParser) parseConditional() (jinjaExpr, error) {
+	value, err := p.parseOr()
+	if err != nil || !p.accept("if") {
+		return value, err
+	}
+	condition, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	otherwise := jinjaExpr(func(*jinjaScope) (interface{}, error) { return jinjaUndefined{Name: "else"}, nil })
+	if p.accept("else") {
+		if otherwise, err = p.parseConditional(); err != nil {
+			return nil, err
+		}
+	}
+	return func(s *jinjaScope) (interface{}, error) {
+		test, err := condition(s)
+		if err != nil {
+			return nil, err
+		}
+		if jinjaTruthy(test) {
+			return value(s)
+		}
+		return otherwise(s)
+	}, nil
+}
+
+func (p *jinjaExprParser) parseOr() (jinjaExpr, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("or") {
+		var right jinjaExpr
+		if right, err = p.parseAnd(); err == nil {
+			l, r := left, right
+			left = func(s *jinjaScope) (interface{}, error) {
+				value, err := l(s)
+				if err != nil || jinjaTruthy(value) {
+					return value, err
+				}
+				return r(s)
+			}
+		}
+	}
+	return left, err
+}
+
+func (p *jinjaExprParser) parseAnd() (jinjaExpr, error) {
+	left, err := p.parseNot()
+	for err == nil && p.accept("and") {
+		var right jinjaExpr
+		if right, err = p.parseNot(); err == nil {
+			l, r := left, right
+			left = func(s *jinjaScope) (interface{}, error) {
+				value, err := l(s)
+				if err != nil || !jinjaTruthy(value) {
+					return value, err
+				}
+				return r(s)
+			}
+		}
+	}
+	return left, err
+}
+
+func (p *jinjaExprParser) parseNot() (jinjaExpr, error) {
+	if p.accept("not") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(s *jinjaScope) (interface{}, error) {
+			value, err := operand(s)
+			return !jinjaTruthy(value), err
+		}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *jinjaExprParser) parseCompare() (jinjaExpr, error) {
+	left, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token := p.peek()
+		var op string
+		switch {
+		case token.Kind == 'o' && (token.Text == "==" || token.Text == "!=" || token.Text == "<" || token.Text == ">" || token.Text == "<=" || token.Text == ">="):
+			op = token.Text
+			p.pos++
+		case token.Kind == 'n' && token.Text == "in":
+			op = "in"
+			p.pos++
+		case token.Kind == 'n' && token.Text == "not" && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].Text == "in":
+			op = "not in"
+			p.pos += 2
+		case token.Kind == 'n' && token.Text == "is":
+			p.pos++
+			negate := p.accept("not")
+			test := p.next()
+			if test.Kind != 'n' {
+				return nil, fmt.Errorf("expected a test after is")
+			}
+			l := left
+			left = func(s *jinjaScope) (interface{}, error) {
+				value, err := l(s)
+				if err != nil {
+					return nil, err
+				}
+				result, err := jinjaTest(test.Text, value)
+				return result != negate, err
+			}
+			continue
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(s *jinjaScope) (interface{}, error) {
+			a, err := l(s)
+			if err != nil {
+				return nil, err
+			}
+			b, err := r(s)
+			if err != nil {
+				return nil, err
+			}
+			return jinjaCompare(op, a, b)
+		}
+	}
+}
+
+func (p *jinjaExprParser) parseConcat() (jinjaExpr, error) {
+	left, err := p.parseAdditive()
+	for err == nil && p.accept("~") {
+		var right jinjaExpr
+		if right, err = p.parseAdditive(); err == nil {
+			l, r := left, right
+			left = func(s *jinjaScope) (interface{}, error) {
+				a, err := l(s)
+				if err != nil {
+					return nil, err
+				}
+				b, err := r(s)
+				if err != nil {
+					return nil, err
+				}
+				if undefined, ok := jinjaFirstUndefined(a, b); ok {
+					return undefined, nil
+				}
+				x, y := jinjaString(a), jinjaString(b)
+				if err := jinjaCheckLength(float64(len(x)) + float64(len(y))); err != nil {
+					return nil, err
+				}
+				return x + y, nil
+			}
+		}
+	}
+	return left, err
+}
+
+func (p *jinjaExprParser) parseAdditive() (jinjaExpr, error) {
+	left, err := p.parseMultiplicative()
+	for err == nil {
+		op := p.peek().Text
+		if p.peek().Kind != 'o' || (op != "+" && op != "-") {
+			break
+		}
+		p.pos++
+		var right jinjaExpr
+		if right, err = p.parseMultiplicative(); err == nil {
+			left = jinjaBinary(op, left, right)
+		}
+	}
+	return left, err
+}
+
+func (p *jinjaExprParser) parseMultiplicative() (jinjaExpr, error) {
+	left, err := p.parseUnary()
+	for err == nil {
+		op := p.peek().Text
+		if p.peek().Kind != 'o' || (op != "*" && op != "/" && op != "//" && op != "%") {
+			break
+		}
+		p.pos++
+		var right jinjaExpr
+		if right, err = p.parseUnary(); err == nil {
+			left = jinjaBinary(op, left, right)
+		}
+	}
+	return left, err
+}
+
+func (p *jinjaExprParser) parseUnary() (jinjaExpr, error) {
+	if p.accept("-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		zero := jinjaExpr(func(*jinjaScope) (interface{}, error) { return 0, nil })
+		return jinjaBinary("-", zero, operand), nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *jinjaExprParser) parsePostfix() (jinjaExpr, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			name := p.next()
+			if name.Kind != 'n' && name.Kind != 'd' {
+				return nil, fmt.Errorf("expected an attribute name")
+			}
+			base := expr
+			expr = func(s *jinjaScope) (interface{}, error) {
+				value, err := base(s)
+				if err != nil {
+					return nil, err
+				}
+				return jinjaAttribute(value, name.Text), nil
+			}
+		case p.accept("["):
+			key, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			base := expr
+			expr = func(s *jinjaScope) (interface{}, error) {
+				value, err := base(s)
+				if err != nil {
+					return nil, err
+				}
+				k, err := key(s)
+				if err != nil {
+					return nil, err
+				}
+				return jinjaIndex(value, k), nil
+			}
+		case p.accept("("):
+			args, err := p.parseArgs(")")
+			if err != nil {
+				return nil, err
+			}
+			base := expr
+			expr = func(s *jinjaScope) (interface{}, error) {
+				value, err := base(s)
+				if err != nil {
+					return nil, err
+				}
+				values, err := evaluateJinjaArgs(args, s)
+				if err != nil {
+					return nil, err
+				}
+				switch callable := value.(type) {
+				case jinjaCallable:
+					return callable(values)
+				case jinjaUndefined:
+					return callable, nil
+				}
+				return nil, fmt.Errorf("%s is not callable", jinjaString(value))
+			}
+		case p.accept("|"):
+			name := p.next()
+			if name.Kind != 'n' || !jinjaFilters[name.Text] {
+				return nil, fmt.Errorf("unsupported filter %q", name.Text)
+			}
+			var args []jinjaExpr
+			if p.accept("(") {
+				if args, err = p.parseArgs(")"); err != nil {
+					return nil, err
+				}
+			}
+			base := expr
+			expr = func(s *jinjaScope) (interface{}, error) {
+				value, err := base(s)
+				if err != nil {
+					return nil, err
+				}
+				values, err := evaluateJinjaArgs(args, s)
+				if err != nil {
+					return nil, err
+				}
+				return jinjaFilter(name.Text, value, values)
+			}
+		default:
+			return expr, nil
+		}
+	}
+}
+
+// parseArgs reads a comma separated list up to closer. Keyword arguments
+// are accepted and passed by position.
+func (p *jinjaExprParser) parseArgs(closer string) ([]jinjaExpr, error) {
+	var args []jinjaExpr
+	for !p.accept(closer) {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			if p.accept(closer) {
+				break
+			}
+		}
+		if p.peek().Kind == 'n' && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].Text == "=" {
+			p.pos += 2
+		}
+		arg, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func (p *jinjaExprParser) parsePrimary() (jinjaExpr, error) {
+	token := p.next()
+	constant := func(value interface{}) jinjaExpr {
+		return func(*jinjaScope) (interface{}, error) { return value, nil }
+	}
+	switch token.Kind {
+	case 's':
+		// Adjacent strings are joined, as in Python.
+		text := token.Text
+		for p.peek().Kind == 's' {
+			text += p.next().Text
+		}
+		return constant(text), nil
+	case 'd':
+		if strings.Contains(token.Text, ".") {
+			f, err := strconv.ParseFloat(token.Text, 64)
+			return constant(f), err
+		}
+		n, err := strconv.Atoi(token.Text)
+		return constant(n), err
+	case 'n':
+		switch token.Text {
+		case "true", "True":
+			return constant(true), nil
+		case "false", "False":
+			return constant(false), nil
+		case "none", "None":
+			return constant(nil), nil
+		}
+		name := token.Text
+		return func(s *jinjaScope) (interface{}, error) { return s.lookup(name), nil }, nil
+	case 'o':
+		switch token.Text {
+		case "(":
+			expr, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			if p.accept(",") {
+				// A tuple, which behaves like a list here.
+				rest, err := p.parseArgs(")")
+				if err != nil {
+					return nil, err
+				}
+				return jinjaList(append([]jinjaExpr{expr}, rest...)), nil
+			}
+			return expr, p.expect(")")
+		case "[":
+			items, err := p.parseArgs("]")
+			if err != nil {
+				return nil, err
+			}
+			return jinjaList(items), nil
+		case "{":
+			var keys, values []jinjaExpr
+			for !p.accept("}") {
+				if len(keys) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+					if p.accept("}") {
+						break
+					}
+				}
+				key, err := p.parseConditional()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				value, err := p.parseConditional()
+				if err != nil {
+					return nil, err
+				}
+				keys, values = append(keys, key), append(values, value)
+			}
+			return func(s *jinjaScope) (interface{}, error) {
+				dict := make(map[string]interface{}, len(keys))
+				for i := range keys {
+					key, err := keys[i](s)
+					if err != nil {
+						return nil, err
+					}
+					value, err := values[i](s)
+					if err != nil {
+						return nil, err
+					}
+					dict[jinjaString(key)] = value
+				}
+				return dict, nil
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q", token.Text)
+}
+
+func jinjaList(items []jinjaExpr) jinjaExpr {
+	return func(s *jinjaScope) (interface{}, error) {
+		return evaluateJinjaArgs(items, s)
+	}
+}
+
+func evaluateJinjaArgs(args []jinjaExpr, s *jinjaScope) ([]interface{}, error) {
+	values := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		value, err := arg(s)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func jinjaFirstUndefined(values ...interface{}) (jinjaUndefined, bool) {
+	for _, value := range values {
+		if undefined, ok := value.(jinjaUndefined); ok {
+			return undefined, true
+		}
+	}
+	return jinjaUndefined{}, false
+}
+
+// jinjaNumber returns a numeric value as a float, and whether it is one.
+func jinjaNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// jinjaNumberValue returns f as an int when it is whole.
+func jinjaNumberValue(f float64, integral bool) interface{} {
+	if integral && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int(f)
+	}
+	return f
+}
+
+func jinjaBinary(op string, left jinjaExpr, right jinjaExpr) jinjaExpr {
+	return func(s *jinjaScope) (interface{}, error) {
+		a, err := left(s)
+		if err != nil {
+			return nil, err
+		}
+		b, err := right(s)
+		if err != nil {
+			return nil, err
+		}
+		if undefined, ok := jinjaFirstUndefined(a, b); ok {
+			return undefined, nil
+		}
+		if op == "+" {
+			if x, ok := a.(string); ok {
+				if y, ok := b.(string); ok {
+					if err := jinjaCheckLength(float64(len(x)) + float64(len(y))); err != nil {
+						return nil, err
+					}
+					return x + y, nil
+				}
+			}
+			if x, ok := a.([]interface{}); ok {
+				if y, ok := b.([]interface{}); ok {
+					if len(x)+len(y) > maxJinjaItems {
+						return nil, fmt.Errorf("list has more than %d items", maxJinjaItems)
+					}
+					return append(append([]interface{}{}, x...), y...), nil
+				}
+			}
+		}
+		if op == "*" {
+			if x, ok := a.(string); ok {
+				if n, ok := jinjaNumber(b); ok {
+					// Jinja repeats a string a negative number of times as "".
+					count := math.Max(math.Trunc(n), 0)
+					if err := jinjaCheckLength(float64(len(x)) * count); err != nil {
+						return nil, err
+					}
+					return strings.Repeat(x, int(count)), nil
+				}
+			}
+		}
+		x, okA := jinjaNumber(a)
+		y, okB := jinjaNumber(b)
+		if !okA || !okB {
+			return nil, fmt.Errorf("unsupported operands for %s: %s and %s", op, jinjaString(a), jinjaString(b))
+		}
+		_, floatA := a.(float64)
+		_, floatB := b.(float64)
+		integral := !floatA && !floatB
+		switch op {
+		case "+":
+			return jinjaNumberValue(x+y, integral), nil
+		case "-":
+			return jinjaNumberValue(x-y, integral), nil
+		case "*":
+			return jinjaNumberValue(x*y, integral), nil
+		case "/":
+			if y == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return x / y, nil
+		case "//":
+			if y == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return jinjaNumberValue(math.Floor(x/y), integral), nil
+		case "%":
+			if y == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return jinjaNumberValue(x-y*math.Floor(x/y), integral), nil
+		}
+		return nil, fmt.Errorf("unsupported operator %s", op)
+	}
+}
+
+func jinjaEqual(a interface{}, b interface{}) bool {
+	x, okA := jinjaNumber(a)
+	y, okB := jinjaNumber(b)
+	if okA && okB {
+		return x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func jinjaCompare(op string, a interface{}, b interface{}) (interface{}, error) {
+	switch op {
+	case "==":
+		return jinjaEqual(a, b), nil
+	case "!=":
+		return !jinjaEqual(a, b), nil
+	case "in", "not in":
+		found := false
+		switch container := b.(type) {
+		case []interface{}:
+			for _, item := range container {
+				if jinjaEqual(a, item) {
+					found = true
+					break
+				}
+			}
+		case map[string]interface{}:
+			_, found = container[jinjaString(a)]
+		case string:
+			found = strings.Contains(container, jinjaString(a))
+		}
+		return found == (op == "in"), nil
+	}
+
+	if undefined, ok := jinjaFirstUndefined(a, b); ok {
+		return nil, fmt.Errorf("cannot compare undefined %s", undefined.Name)
+	}
+	var cmp int
+	if x, ok := jinjaNumber(a); ok {
+		y, ok := jinjaNumber(b)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %s and %s", jinjaString(a), jinjaString(b))
+		}
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(jinjaString(a), jinjaString(b))
+	}
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	}
+	return cmp >= 0, nil
+}
+
+func jinjaTest(name string, value interface{}) (bool, error) {
+	_, undefined := value.(jinjaUndefined)
+	switch name {
+	case "defined":
+		return !undefined, nil
+	case "undefined":
+		return undefined, nil
+	case "none":
+		return value == nil, nil
+	case "string":
+		_, ok := value.(string)
+		return ok, nil
+	case "number":
+		_, ok := jinjaNumber(value)
+		return ok, nil
+	case "mapping":
+		_, ok := value.(map[string]interface{})
+		return ok, nil
+	case "sequence", "iterable":
+		switch value.(type) {
+		case []interface{}, map[string]interface{}, string:
+			return true, nil
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unsupported test %q", name)
+}
+
+func jinjaTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil, jinjaUndefined:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	if n, ok := jinjaNumber(value); ok {
+		return n != 0
+	}
+	return true
+}
+
+// jinjaString renders a value as Python would print it, so lists and dicts
+// come out in a form YAML also reads.
+func jinjaString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "None"
+	case jinjaUndefined:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e16 {
+			return strconv.FormatFloat(v, 'f', 1, 64)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []interface{}:
+		var items []string
+		length := 0
+		for _, item := range v {
+			// Past the limit the string is cut short; it is still too long
+			// to be written, so rendering fails rather than use it.
+			if length > maxJinjaOutput {
+				items = append(items, "...")
+				break
+			}
+			items = append(items, jinjaRepr(item))
+			length += len(items[len(items)-1])
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		var items []string
+		length := 0
+		for _, key := range sortedKeys(v) {
+			if length > maxJinjaOutput {
+				items = append(items, "...")
+				break
+			}
+			items = append(items, jinjaRepr(key)+": "+jinjaRepr(v[key]))
+			length += len(items[len(items)-1])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+	return fmt.Sprint(value)
+}
+
+// jinjaCheckLength returns an error when a string of length bytes would be
+// longer than a template may build. Lengths are projected before the string
+// is built, so they are floats that cannot overflow.
+func jinjaCheckLength(length float64) error {
+	if length > maxJinjaOutput {
+		return fmt.Errorf("string is longer than %d bytes", maxJinjaOutput)
+	}
+	return nil
+}
+
+func jinjaRepr(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return "'" + strings.ReplaceAll(s, "'", "\\'") + "'"
+	}
+	return jinjaString(value)
+}
+
+// jinjaAttribute looks up name on a value: a key of a dict, or a method of a
+// dict or string.
+func jinjaAttribute(value interface{}, name string) interface{} {
+	switch v := value.(type) {
+	case jinjaUndefined:
+		return jinjaUndefined{Name: v.Name + "." + name}
+	case map[string]interface{}:
+		if item, ok := v[name]; ok {
+			return item
+		}
+		switch name {
+		case "items":
+			return jinjaCallable(func([]interface{}) (interface{}, error) {
+				var items []interface{}
+				for _, key := range sortedKeys(v) {
+					items = append(items, []interface{}{key, v[key]})
+				}
+				return items, nil
+			})
+		case "keys":
+			return jinjaCallable(func([]interface{}) (interface{}, error) { return jinjaItems(v) })
+		case "values":
+			return jinjaCallable(func([]interface{}) (interface{}, error) {
+				var values []interface{}
+				for _, key := range sortedKeys(v) {
+					values = append(values, v[key])
+				}
+				return values, nil
+			})
+		case "get":
+			return jinjaCallable(func(args []interface{}) (interface{}, error) {
+				if len(args) == 0 {
+					return nil, fmt.Errorf("get needs a key")
+				}
+				if item, ok := v[jinjaString(args[0])]; ok {
+					return item, nil
+				}
+				if len(args) > 1 {
+					return args[1], nil
+				}
+				return nil, nil
+			})
+		}
+	case string:
+		if method, ok := jinjaStringMethods[name]; ok {
+			return jinjaCallable(func(args []interface{}) (interface{}, error) { return method(v, args) })
+		}
+	case []interface{}:
+		if index, err := strconv.Atoi(name); err == nil {
+			return jinjaIndex(v, index)
+		}
+	}
+	return jinjaUndefined{Name: name}
+}
+
+var jinjaStringMethods = map[string]func(string, []interface{}) (interface{}, error){
+	"lower": func(s string, _ []interface{}) (interface{}, error) { return strings.ToLower(s), nil },
+	"upper": func(s string, _ []interface{}) (interface{}, error) { return strings.ToUpper(s), nil },
+	"strip": func(s string, _ []interface{}) (interface{}, error) { return strings.TrimSpace(s), nil },
+	"startswith": func(s string, args []interface{}) (interface{}, error) {
+		return len(args) > 0 && strings.HasPrefix(s, jinjaString(args[0])), nil
+	},
+	"endswith": func(s string, args []interface{}) (interface{}, error) {
+		return len(args) > 0 && strings.HasSuffix(s, jinjaString(args[0])), nil
+	},
+	"replace": func(s string, args []interface{}) (interface{}, error) {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("replace needs two arguments")
+		}
+		old, replacement := jinjaString(args[0]), jinjaString(args[1])
+		if err := jinjaCheckLength(float64(len(s)) + float64(strings.Count(s, old))*float64(len(replacement)-len(old))); err != nil {
+			return nil, err
+		}
+		return strings.ReplaceAll(s, old, replacement), nil
+	},
+	"split": func(s string, args []interface{}) (interface{}, error) {
+		var parts []string
+		if len(args) == 0 {
+			parts = strings.Fields(s)
+		} else {
+			parts = strings.Split(s, jinjaString(args[0]))
+		}
+		items := make([]interface{}, len(parts))
+		for i, part := range parts {
+			items[i] = part
+		}
+		return items, nil
+	},
+}
+
+func jinjaIndex(value interface{}, key interface{}) interface{} {
+	switch v := value.(type) {
+	case jinjaUndefined:
+		return jinjaUndefined{Name: v.Name + "[" + jinjaString(key) + "]"}
+	case map[string]interface{}:
+		if item, ok := v[jinjaString(key)]; ok {
+			return item
+		}
+		return jinjaUndefined{Name: jinjaString(key)}
+	case []interface{}:
+		n, ok := jinjaNumber(key)
+		if !ok {
+			break
+		}
+		i := int(n)
+		if i < 0 {
+			i += len(v)
+		}
+		if i >= 0 && i < len(v) {
+			return v[i]
+		}
+	case string:
+		n, ok := jinjaNumber(key)
+		if !ok {
+			break
+		}
+		i := int(n)
+		if i < 0 {
+			i += len(v)
+		}
+		if i >= 0 && i < len(v) {
+			return string(v[i])
+		}
+	}
+	return jinjaUndefined{Name: jinjaString(key)}
+}
+
+func jinjaRange(args []interface{}) (interface{}, error) {
+	bounds := make([]float64, len(args))
+	for i, arg := range args {
+		n, ok := jinjaNumber(arg)
+		if !ok {
+			return nil, fmt.Errorf("range needs numbers")
+		}
+		bounds[i] = math.Trunc(n)
+	}
+	start, stop, step := 0.0, 0.0, 1.0
+	switch len(bounds) {
+	case 1:
+		stop = bounds[0]
+	case 2:
+		start, stop = bounds[0], bounds[1]
+	case 3:
+		start, stop, step = bounds[0], bounds[1], bounds[2]
+	default:
+		return nil, fmt.Errorf("range needs one to three arguments")
+	}
+	if step == 0 {
+		return nil, fmt.Errorf("range step cannot be zero")
+	}
+	if count := math.Ceil((stop - start) / step); count > maxJinjaItems {
+		return nil, fmt.Errorf("range has more than %d items", maxJinjaItems)
+	}
+	var items []interface{}
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		items = append(items, int(i))
+	}
+	return items, nil
+}
+
+// jinjaFilters are the filters jinjaFilter applies.
+var jinjaFilters = map[string]bool{
+	"default": true, "d": true, "lower": true, "upper": true, "trim": true, "title": true, "capitalize": true,
+	"replace": true, "string": true, "int": true, "float": true, "length": true, "count": true, "join": true,
+	"first": true, "last": true, "list": true, "sort": true, "tojson": true,
+}
+
+func jinjaFilter(name string, value interface{}, args []interface{}) (interface{}, error) {
+	switch name {
+	case "default", "d":
+		if _, undefined := value.(jinjaUndefined); undefined || (len(args) > 1 && jinjaTruthy(args[1]) && !jinjaTruthy(value)) {
+			if len(args) > 0 {
+				return args[0], nil
+			}
+			return "", nil
+		}
+		return value, nil
+	}
+	if undefined, ok := value.(jinjaUndefined); ok {
+		return undefined, nil
+	}
+	switch name {
+	case "lower", "upper", "trim":
+		method := map[string]string{"lower": "lower", "upper": "upper", "trim": "strip"}[name]
+		return jinjaStringMethods[method](jinjaString(value), nil)
+	case "title":
+		words := strings.Fields(jinjaString(value))
+		for i, word := range words {
+			words[i] = strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+		}
+		return strings.Join(words, " "), nil
+	case "capitalize":
+		s := jinjaString(value)
+		if s == "" {
+			return s, nil
+		}
+		return strings.ToUpper(s[:1]) + strings.ToLower(s[1:]), nil
+	case "replace":
+		return jinjaStringMethods["replace"](jinjaString(value), args)
+	case "string":
+		return jinjaString(value), nil
+	case "int":
+		if n, ok := jinjaNumber(value); ok {
+			return int(n), nil
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(jinjaString(value)), 64)
+		if err != nil {
+			return 0, nil
+		}
+		return int(n), nil
+	case "float":
+		if n, ok := jinjaNumber(value); ok {
+			return n, nil
+		}
+		n, _ := strconv.ParseFloat(strings.TrimSpace(jinjaString(value)), 64)
+		return n, nil
+	case "length", "count":
+		switch v := value.(type) {
+		case []interface{}:
+			return len(v), nil
+		case map[string]interface{}:
+			return len(v), nil
+		}
+		return len(jinjaString(value)), nil
+	case "join":
+		items, err := jinjaItems(value)
+		if err != nil {
+			return nil, err
+		}
+		separator := ""
+		if len(args) > 0 {
+			separator = jinjaString(args[0])
+		}
+		parts := make([]string, len(items))
+		length := float64(len(separator)) * float64(len(items)-1)
+		for i, item := range items {
+			parts[i] = jinjaString(item)
+			length += float64(len(parts[i]))
+			if err := jinjaCheckLength(length); err != nil {
+				return nil, err
+			}
+		}
+		return strings.Join(parts, separator), nil
+	case "first", "last":
+		items, err := jinjaItems(value)
+		if err != nil || len(items) == 0 {
+			return jinjaUndefined{Name: name}, err
+		}
+		if name == "first" {
+			return items[0], nil
+		}
+		return items[len(items)-1], nil
+	case "list":
+		return jinjaItems(value)
+	case "sort":
+		items, err := jinjaItems(value)
+		if err != nil {
+			return nil, err
+		}
+		sorted := append([]interface{}{}, items...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			less, _ := jinjaCompare("<", sorted[i], sorted[j])
+			return less == true
+		})
+		return sorted, nil
+	case "tojson":
+		return jinjaRepr(value), nil
+	}
+	return nil, fmt.Errorf("unsupported filter %q", name)
+}
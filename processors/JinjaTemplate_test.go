@@ -0,0 +1,83 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderJinja(t *testing.T) {
+	vars := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"name":  "web",
+			"count": 2,
+			"zones": []interface{}{"a", "b"},
+			"tags":  map[string]interface{}{"env": "prod", "team": "core"},
+		},
+		"env": map[string]interface{}{"deployment": "prod"},
+	}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{`{{ properties["name"] }}-{{ properties.name | upper }}`, "web-WEB"},
+		{`{{ env["deployment"] ~ "-" ~ properties.count + 1 }}`, "prod-3"},
+		{`{% for zone in properties.zones %}{{ loop.index }}{{ zone }}{% if not loop.last %},{% endif %}{% endfor %}`, "1a,2b"},
+		{`{% for key, value in properties.tags.items() %}{{ key }}={{ value }} {% endfor %}`, "env=prod team=core "},
+		{`{% set size = properties.size | default(10) %}{{ size * properties.count }}`, "20"},
+		{`{% if "b" in properties.zones and properties.count > 1 %}many{% elif properties.count %}one{% else %}none{% endif %}`, "many"},
+		{`{{ properties.zones }} {{ properties.zones | join("/") }} {{ properties.zones | length }}`, "['a', 'b'] a/b 2"},
+		{`{{ "yes" if properties.missing is defined else "no" }}{# comment #}`, "no"},
+		{"a\n  {%- for i in range(3) -%}\n {{ i }}\n{%- endfor %}", "a012"},
+		{`{{ "=" * 3 }}|{{ "=" * 0 }}|{{ "=" * -2 }}|{{ "=" * (10 - properties.width | default(20)) }}`, "===|||"},
+		{`{{ range(5, 0, -2) | join(",") }}|{{ range(0) | length }}|{{ range(3, 1) | length }}`, "5,3,1|0|0"},
+		{`{{ 7 // 2 }} {{ -7 % 3 }} {{ 7 / 2 }} {{ 2 * 1.5 }}`, "3 2 3.5 3.0"},
+		{`{{ properties.zones[-1] }}{{ properties.zones[5] }}{{ properties.name[0] }}`, "bw"},
+		{`{% for zone in properties.zones %}{% for i in range(2) %}{% if i %}{{ zone }}{{ loop.index }}{% endif %}{% endfor %}{{ loop.index }}{% endfor %}`, "a21b22"},
+		{`{% set x = 1 %}{% for zone in properties.zones %}{% set x = zone %}{{ x }}{% endfor %}{{ x }}`, "ab1"},
+		{`{% if properties.count %}{% set y = "set" %}{% endif %}{{ y }}`, "set"},
+		{`{% for zone in properties.zones if zone != "a" %}{{ zone }}{{ loop.length }}{% else %}none{% endfor %}`, "b1"},
+		{`{% for zone in [] %}{{ zone }}{% else %}none{% endfor %}`, "none"},
+		{`{{ "a-b-c".replace("-", "_") }} {{ properties.zones | join("/") }} {{ properties.zones ~ "" }}`, "a_b_c a/b ['a', 'b']"},
+	}
+	for _, tt := range tests {
+		got, _, err := renderJinja(tt.template, vars)
+		require.NoError(t, err, tt.template)
+		assert.Equal(t, tt.want, got, tt.template)
+	}
+
+	_, unresolved, err := renderJinja(`{{ properties.network }}-{{ env["project"] }}-{{ properties.zones[5] }}`, vars)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"properties.network", `env["project"]`, "properties.zones[5]"}, unresolved)
+
+	for _, template := range []string{
+		`{% macro x() %}{% endmacro %}`,
+		`{% for x in properties.missing %}{% endfor %}`,
+		`{% if x %}`,
+		`{{ x | unknown }}`,
+		`{{ properties.count / 0 }}`,
+		`{{ properties.count // 0 }}`,
+		`{{ properties.count % 0 }}`,
+		`{{ 2 ** 3 }}`,
+		`{{ properties.zones[0:1] }}`,
+		`{{ properties.name[1:] }}`,
+		`{{ range(10000000000) | length }}`,
+		`{% for i in range(10000000000) %}{% endfor %}`,
+		`{% for i in range(1000) %}{% for j in range(1000) %}{% endfor %}{% endfor %}`,
+		`{{ "x" * 10000000000 }}`,
+		`{% for i in range(2000) %}{{ "x" * 1000 }}{% endfor %}`,
+		`{% for i in range(0, 1) %}{{ range(i, 2, 0) }}{% endfor %}`,
+		`{{ ("x" * 1000000).replace("x", "xx") }}`,
+		`{{ "xxxxxxxxxx".replace("x", "xxxxxxxxxx").replace("x", "xxxxxxxxxx").replace("x", "xxxxxxxxxx").replace("x", "xxxxxxxxxx").replace("x", "xxxxxxxxxx").replace("x", "xxxxxxxxxx").replace("x", "xxxxxxxxxx").replace("x", "xxxxxxxxxx") }}`,
+		`{{ ("x" * 1000000) | replace("", "y") }}`,
+		`{{ ("x" * 600000) ~ ("x" * 600000) }}`,
+		`{{ ("x" * 600000) + ("x" * 600000) }}`,
+		`{{ ["x" * 600000, "x" * 600000] | join }}`,
+		`{{ range(3) | join("x" * 1000000) }}`,
+		`{{ ["x" * 600000, "x" * 600000] ~ "" }}`,
+	} {
+		_, _, err := renderJinja(template, vars)
+		assert.Error(t, err, template)
+	}
+}
@@ -0,0 +1,108 @@
+package processors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// DefaultKubernetesTargetVersion is the Kubernetes version manifests are
+// checked against when no other target is configured.
+const DefaultKubernetesTargetVersion = "1.32"
+
+// KubernetesDeprecation is an entry of data/kubernetes/deprecations.json: an
+// apiVersion/kind pair with the release it was deprecated in, the release it
+// was (or will be) removed in and the API that replaces it.
+type KubernetesDeprecation struct {
+	APIVersion   string `json:"api_version"`
+	Kind         string `json:"kind"`
+	DeprecatedIn string `json:"deprecated_in"`
+	RemovedIn    string `json:"removed_in,omitempty"`
+	Replacement  string `json:"replacement,omitempty"`
+	Note         string `json:"note,omitempty"`
+}
+
+// LoadKubernetesDeprecations reads the deprecation table from f.
+func LoadKubernetesDeprecations(f fs.FS) ([]KubernetesDeprecation, error) {
+	content, err := fs.ReadFile(f, "data/kubernetes/deprecations.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Kubernetes deprecations: %w", err)
+	}
+	var deprecations []KubernetesDeprecation
+	if err := json.Unmarshal(content, &deprecations); err != nil {
+		return nil, fmt.Errorf("failed to parse Kubernetes deprecations: %w", err)
+	}
+	return deprecations, nil
+}
+
+// kubernetesMinorVersion returns the minor release of a Kubernetes version
+// such as "1.25", "v1.25" or "1.25.3".
+func kubernetesMinorVersion(version string) (int, bool) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	if len(parts) < 2 || parts[0] != "1" {
+		return 0, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	return minor, err == nil
+}
+
+// kubernetesVersionReached reports whether target is at or past version. An
+// empty target is treated as the newest release, so every entry applies.
+func kubernetesVersionReached(target string, version string) bool {
+	wanted, ok := kubernetesMinorVersion(version)
+	if !ok {
+		return false
+	}
+	if target == "" {
+		return true
+	}
+	current, ok := kubernetesMinorVersion(target)
+	return ok && current >= wanted
+}
+
+// deprecationFinding returns the finding for a manifest using an API that is
+// deprecated or removed in the target version.
+func (k KubernetesProcessor) deprecationFinding(manifest KubernetesManifest, path string, repoName string) (core.Finding, bool) {
+	for _, deprecation := range k.Deprecations {
+		if deprecation.APIVersion != manifest.APIVersion || deprecation.Kind != manifest.Kind {
+			continue
+		}
+		removed := deprecation.RemovedIn != "" && kubernetesVersionReached(k.TargetVersion, deprecation.RemovedIn)
+		if !removed && !kubernetesVersionReached(k.TargetVersion, deprecation.DeprecatedIn) {
+			return core.Finding{}, false
+		}
+
+		properties := map[string]interface{}{
+			"kind":          manifest.Kind,
+			"api_version":   manifest.APIVersion,
+			"resource":      manifest.Metadata.Name,
+			"deprecated_in": deprecation.DeprecatedIn,
+			"removed":       removed,
+		}
+		if deprecation.RemovedIn != "" {
+			properties["removed_in"] = deprecation.RemovedIn
+		}
+		if deprecation.Replacement != "" {
+			properties["replacement"] = deprecation.Replacement
+		}
+		if deprecation.Note != "" {
+			properties["note"] = deprecation.Note
+		}
+		if k.TargetVersion != "" {
+			properties["target_version"] = k.TargetVersion
+		}
+		return core.Finding{
+			Name:       manifest.APIVersion + " " + manifest.Kind,
+			Type:       "Kubernetes Deprecated API",
+			Category:   "Kubernetes",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		}, true
+	}
+	return core.Finding{}, false
+}
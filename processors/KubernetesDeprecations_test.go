@@ -0,0 +1,95 @@
+package processors
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadKubernetesDeprecations(t *testing.T) {
+	deprecations, err := LoadKubernetesDeprecations(os.DirFS("."))
+	require.NoError(t, err)
+	require.NotEmpty(t, deprecations)
+
+	for _, deprecation := range deprecations {
+		assert.NotEmpty(t, deprecation.APIVersion)
+		assert.NotEmpty(t, deprecation.Kind)
+		_, ok := kubernetesMinorVersion(deprecation.DeprecatedIn)
+		assert.True(t, ok, "%s %s has an invalid deprecated_in", deprecation.APIVersion, deprecation.Kind)
+	}
+}
+
+func TestKubernetesVersionReached(t *testing.T) {
+	assert.True(t, kubernetesVersionReached("1.25", "1.25"))
+	assert.True(t, kubernetesVersionReached("v1.29.3", "1.25"))
+	assert.False(t, kubernetesVersionReached("1.24", "1.25"))
+	assert.True(t, kubernetesVersionReached("", "1.25"))
+	assert.False(t, kubernetesVersionReached("1.25", ""))
+}
+
+func TestKubernetesProcessor_DeprecatedAPIs(t *testing.T) {
+	content := `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+---
+apiVersion: policy/v1beta1
+kind: PodSecurityPolicy
+metadata:
+  name: restricted
+---
+apiVersion: flowcontrol.apiserver.k8s.io/v1beta3
+kind: FlowSchema
+metadata:
+  name: batch
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: current
+`
+
+	tests := []struct {
+		target   string
+		expected map[string]bool
+	}{
+		{"1.21", map[string]bool{"extensions/v1beta1 Ingress": false, "policy/v1beta1 PodSecurityPolicy": false}},
+		{"1.29", map[string]bool{"extensions/v1beta1 Ingress": true, "policy/v1beta1 PodSecurityPolicy": true, "flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema": false}},
+		{"", map[string]bool{"extensions/v1beta1 Ingress": true, "policy/v1beta1 PodSecurityPolicy": true, "flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run("target "+tt.target, func(t *testing.T) {
+			processor := NewKubernetesProcessor(os.DirFS("."), tt.target)
+			findings, err := processor.Process("deploy/legacy.yaml", "repo", content)
+			require.NoError(t, err)
+
+			removed := make(map[string]bool)
+			for _, finding := range findings {
+				if finding.Type == "Kubernetes Deprecated API" {
+					removed[finding.Name] = finding.Properties["removed"].(bool)
+				}
+			}
+			assert.Equal(t, tt.expected, removed)
+		})
+	}
+
+	processor := NewKubernetesProcessor(os.DirFS("."), "1.25")
+	findings, err := processor.Process("deploy/legacy.yaml", "repo", content)
+	require.NoError(t, err)
+	deprecated := make(map[string]map[string]interface{})
+	for _, finding := range findings {
+		if finding.Type == "Kubernetes Deprecated API" {
+			deprecated[finding.Name] = finding.Properties
+		}
+	}
+	ingress := deprecated["extensions/v1beta1 Ingress"]
+	assert.Equal(t, "networking.k8s.io/v1", ingress["replacement"])
+	assert.Equal(t, "1.22", ingress["removed_in"])
+	assert.Equal(t, "web", ingress["resource"])
+	assert.Equal(t, "1.25", ingress["target_version"])
+	assert.NotContains(t, deprecated["policy/v1beta1 PodSecurityPolicy"], "replacement")
+	assert.NotEmpty(t, deprecated["policy/v1beta1 PodSecurityPolicy"]["note"])
+}
@@ -0,0 +1,417 @@
+package processors
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// kubernetesTechnologies maps the API groups of well known custom resources
+// to the technology they signal.
+var kubernetesTechnologies = map[string]string{
+	"argoproj.io":                  "Argo",
+	"bitnami.com":                  "Sealed Secrets",
+	"cert-manager.io":              "cert-manager",
+	"acme.cert-manager.io":         "cert-manager",
+	"cilium.io":                    "Cilium",
+	"crd.projectcalico.org":        "Calico",
+	"external-secrets.io":          "External Secrets",
+	"gateway.networking.k8s.io":    "Gateway API",
+	"helm.toolkit.fluxcd.io":       "Flux",
+	"kustomize.toolkit.fluxcd.io":  "Flux",
+	"source.toolkit.fluxcd.io":     "Flux",
+	"karpenter.sh":                 "Karpenter",
+	"kafka.strimzi.io":             "Strimzi",
+	"keda.sh":                      "KEDA",
+	"kyverno.io":                   "Kyverno",
+	"linkerd.io":                   "Linkerd",
+	"policy.linkerd.io":            "Linkerd",
+	"monitoring.coreos.com":        "Prometheus Operator",
+	"networking.istio.io":          "Istio",
+	"security.istio.io":            "Istio",
+	"telemetry.istio.io":           "Istio",
+	"postgresql.cnpg.io":           "CloudNativePG",
+	"secrets-store.csi.x-k8s.io":   "Secrets Store CSI Driver",
+	"serving.knative.dev":          "Knative",
+	"eventing.knative.dev":         "Knative",
+	"tekton.dev":                   "Tekton",
+	"traefik.containo.us":          "Traefik",
+	"traefik.io":                   "Traefik",
+	"velero.io":                    "Velero",
+	"pkg.crossplane.io":            "Crossplane",
+	"templates.gatekeeper.sh":      "Gatekeeper",
+	"elbv2.k8s.aws":                "AWS Load Balancer Controller",
+	"route.openshift.io":           "OpenShift",
+	"configuration.konghq.com":     "Kong",
+	"elasticsearch.k8s.elastic.co": "Elastic Cloud on Kubernetes",
+	"opentelemetry.io":             "OpenTelemetry Operator",
+}
+
+// kubernetesWorkloadKinds are the kinds whose pod template is reported.
+var kubernetesWorkloadKinds = map[string]bool{
+	"Pod":                   true,
+	"Deployment":            true,
+	"StatefulSet":           true,
+	"DaemonSet":             true,
+	"ReplicaSet":            true,
+	"ReplicationController": true,
+	"Job":                   true,
+	"CronJob":               true,
+}
+
+// KubernetesManifest is the part of a Kubernetes object common to every kind.
+type KubernetesManifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Spec  yaml.Node   `yaml:"spec"`
+	Items []yaml.Node `yaml:"items"`
+}
+
+// group returns the API group of the manifest, which is empty for the core group.
+func (m KubernetesManifest) group() string {
+	if idx := strings.LastIndex(m.APIVersion, "/"); idx >= 0 {
+		return m.APIVersion[:idx]
+	}
+	return ""
+}
+
+type kubernetesProbe struct {
+	HTTPGet   interface{} `yaml:"httpGet"`
+	TCPSocket interface{} `yaml:"tcpSocket"`
+	Exec      interface{} `yaml:"exec"`
+	GRPC      interface{} `yaml:"grpc"`
+}
+
+func (p *kubernetesProbe) handler() string {
+	switch {
+	case p == nil:
+		return ""
+	case p.HTTPGet != nil:
+		return "httpGet"
+	case p.TCPSocket != nil:
+		return "tcpSocket"
+	case p.Exec != nil:
+		return "exec"
+	case p.GRPC != nil:
+		return "grpc"
+	}
+	return ""
+}
+
+type kubernetesContainer struct {
+	Name      string `yaml:"name"`
+	Image     string `yaml:"image"`
+	Resources struct {
+		Requests map[string]string `yaml:"requests"`
+		Limits   map[string]string `yaml:"limits"`
+	} `yaml:"resources"`
+	LivenessProbe  *kubernetesProbe `yaml:"livenessProbe"`
+	ReadinessProbe *kubernetesProbe `yaml:"readinessProbe"`
+	StartupProbe   *kubernetesProbe `yaml:"startupProbe"`
+}
+
+type kubernetesPodSpec struct {
+	ServiceAccountName string                `yaml:"serviceAccountName"`
+	ServiceAccount     string                `yaml:"serviceAccount"`
+	Containers         []kubernetesContainer `yaml:"containers"`
+	InitContainers     []kubernetesContainer `yaml:"initContainers"`
+}
+
+type kubernetesPodTemplate struct {
+	Spec kubernetesPodSpec `yaml:"spec"`
+}
+
+type kubernetesWorkloadSpec struct {
+	Replicas    *int                  `yaml:"replicas"`
+	Schedule    string                `yaml:"schedule"`
+	Template    kubernetesPodTemplate `yaml:"template"`
+	JobTemplate struct {
+		Spec struct {
+			Template kubernetesPodTemplate `yaml:"template"`
+		} `yaml:"spec"`
+	} `yaml:"jobTemplate"`
+}
+
+type kubernetesIngressSpec struct {
+	IngressClassName string `yaml:"ingressClassName"`
+	Rules            []struct {
+		Host string `yaml:"host"`
+	} `yaml:"rules"`
+	TLS []struct {
+		Hosts []string `yaml:"hosts"`
+	} `yaml:"tls"`
+	// Gateway API routes list their hosts as hostnames.
+	Hostnames []string `yaml:"hostnames"`
+}
+
+// KubernetesProcessor reports the workloads, container images, ingress hosts
+// and custom resources found in Kubernetes manifests, and flags APIs that are
+// deprecated or removed in TargetVersion.
+type KubernetesProcessor struct {
+	Deprecations  []KubernetesDeprecation
+	TargetVersion string
+}
+
+// NewKubernetesProcessor creates a KubernetesProcessor that checks manifests
+// against the deprecation table in f.
+func NewKubernetesProcessor(f fs.FS, targetVersion string) *KubernetesProcessor {
+	deprecations, err := LoadKubernetesDeprecations(f)
+	if err != nil {
+		log.Printf("Failed to load Kubernetes deprecations: %v", err)
+	}
+	return &KubernetesProcessor{Deprecations: deprecations, TargetVersion: targetVersion}
+}
+
+// Supports accepts any YAML file, since Kubernetes manifests have no fixed
+// name; Process sniffs the content for apiVersion and kind.
+func (k KubernetesProcessor) Supports(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// decodeKubernetesManifests reads every document in content that has an
+// apiVersion and kind, expanding List objects into their items. Files that are
+// not valid YAML, such as Helm templates, yield no manifests.
+func decodeKubernetesManifests(content string) []KubernetesManifest {
+	if !strings.Contains(content, "apiVersion") || !strings.Contains(content, "kind") {
+		return nil
+	}
+
+	var manifests []KubernetesManifest
+	var add func(manifest KubernetesManifest)
+	add = func(manifest KubernetesManifest) {
+		if manifest.APIVersion == "" || manifest.Kind == "" {
+			return
+		}
+		if strings.HasSuffix(manifest.Kind, "List") && len(manifest.Items) > 0 {
+			for _, item := range manifest.Items {
+				var child KubernetesManifest
+				if err := item.Decode(&child); err == nil {
+					add(child)
+				}
+			}
+			return
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(content)))
+	for {
+		var manifest KubernetesManifest
+		err := decoder.Decode(&manifest)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// The YAML decoder cannot resume after a syntax error.
+			if _, isTypeError := err.(*yaml.TypeError); !isTypeError {
+				break
+			}
+			continue
+		}
+		add(manifest)
+	}
+	return manifests
+}
+
+// podSpec returns the pod template of a workload manifest.
+func (m KubernetesManifest) podSpec() (kubernetesPodSpec, kubernetesWorkloadSpec, bool) {
+	var workload kubernetesWorkloadSpec
+	if m.Spec.Kind == 0 {
+		return kubernetesPodSpec{}, workload, false
+	}
+	if m.Kind == "Pod" {
+		var pod kubernetesPodSpec
+		err := m.Spec.Decode(&pod)
+		return pod, workload, err == nil
+	}
+	if err := m.Spec.Decode(&workload); err != nil {
+		return kubernetesPodSpec{}, workload, false
+	}
+	if m.Kind == "CronJob" {
+		return workload.JobTemplate.Spec.Template.Spec, workload, true
+	}
+	// Custom workloads such as Argo Rollouts also carry a pod template.
+	pod := workload.Template.Spec
+	return pod, workload, kubernetesWorkloadKinds[m.Kind] || len(pod.Containers) > 0
+}
+
+func (k KubernetesProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	var matches []core.Finding
+	technologies := make(map[string]KubernetesManifest)
+
+	for _, manifest := range decodeKubernetesManifests(content) {
+		group := manifest.group()
+		if group == "kustomize.config.k8s.io" {
+			continue
+		}
+		if technology := kubernetesTechnologies[group]; technology != "" {
+			if _, seen := technologies[technology]; !seen {
+				technologies[technology] = manifest
+			}
+		}
+
+		properties := map[string]interface{}{
+			"kind":        manifest.Kind,
+			"api_version": manifest.APIVersion,
+		}
+		if manifest.Metadata.Namespace != "" {
+			properties["namespace"] = manifest.Metadata.Namespace
+		}
+
+		findingType := "Kubernetes Resource"
+		pod, workload, isWorkload := manifest.podSpec()
+		if isWorkload {
+			findingType = "Kubernetes Workload"
+			if workload.Replicas != nil {
+				properties["replicas"] = *workload.Replicas
+			}
+			if workload.Schedule != "" {
+				properties["schedule"] = workload.Schedule
+			}
+			serviceAccount := pod.ServiceAccountName
+			if serviceAccount == "" {
+				serviceAccount = pod.ServiceAccount
+			}
+			if serviceAccount != "" {
+				properties["service_account"] = serviceAccount
+			}
+		}
+
+		matches = append(matches, core.Finding{
+			Name:       manifest.Metadata.Name,
+			Type:       findingType,
+			Category:   "Kubernetes",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+
+		if isWorkload {
+			matches = append(matches, kubernetesContainerFindings(manifest, pod.InitContainers, true, path, repoName)...)
+			matches = append(matches, kubernetesContainerFindings(manifest, pod.Containers, false, path, repoName)...)
+		}
+		if manifest.Kind == "Ingress" || manifest.Kind == "HTTPRoute" || manifest.Kind == "GRPCRoute" {
+			matches = append(matches, kubernetesIngressFindings(manifest, path, repoName)...)
+		}
+		if finding, ok := k.deprecationFinding(manifest, path, repoName); ok {
+			matches = append(matches, finding)
+		}
+	}
+
+	names := make([]string, 0, len(technologies))
+	for name := range technologies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		manifest := technologies[name]
+		matches = append(matches, core.Finding{
+			Name:     name,
+			Type:     "Kubernetes Technology",
+			Category: "Kubernetes",
+			Properties: map[string]interface{}{
+				"kind":        manifest.Kind,
+				"api_version": manifest.APIVersion,
+			},
+			Path:     path,
+			RepoName: repoName,
+		})
+	}
+
+	return matches, nil
+}
+
+func kubernetesContainerFindings(manifest KubernetesManifest, containers []kubernetesContainer, init bool, path string, repoName string) []core.Finding {
+	var matches []core.Finding
+	for _, container := range containers {
+		properties := parseImageReference(container.Image).properties()
+		properties["image"] = container.Image
+		properties["workload"] = manifest.Metadata.Name
+		properties["kind"] = manifest.Kind
+		if init {
+			properties["init"] = true
+		}
+		for resource, quantity := range container.Resources.Requests {
+			properties["requests_"+resource] = quantity
+		}
+		for resource, quantity := range container.Resources.Limits {
+			properties["limits_"+resource] = quantity
+		}
+		for name, probe := range map[string]*kubernetesProbe{
+			"liveness_probe":  container.LivenessProbe,
+			"readiness_probe": container.ReadinessProbe,
+			"startup_probe":   container.StartupProbe,
+		} {
+			if handler := probe.handler(); handler != "" {
+				properties[name] = handler
+			}
+		}
+		matches = append(matches, core.Finding{
+			Name:       container.Name,
+			Type:       "Kubernetes Container",
+			Category:   "Kubernetes",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	return matches
+}
+
+func kubernetesIngressFindings(manifest KubernetesManifest, path string, repoName string) []core.Finding {
+	var spec kubernetesIngressSpec
+	if manifest.Spec.Kind == 0 || manifest.Spec.Decode(&spec) != nil {
+		return nil
+	}
+
+	tls := make(map[string]bool)
+	for _, entry := range spec.TLS {
+		for _, host := range entry.Hosts {
+			tls[host] = true
+		}
+	}
+	hosts := append([]string(nil), spec.Hostnames...)
+	for _, rule := range spec.Rules {
+		hosts = append(hosts, rule.Host)
+	}
+
+	var matches []core.Finding
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		properties := map[string]interface{}{
+			"ingress": manifest.Metadata.Name,
+			"kind":    manifest.Kind,
+			"tls":     tls[host],
+		}
+		if spec.IngressClassName != "" {
+			properties["ingress_class"] = spec.IngressClassName
+		}
+		matches = append(matches, core.Finding{
+			Name:       host,
+			Type:       "Kubernetes Ingress Host",
+			Category:   "Kubernetes",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	return matches
+}
@@ -0,0 +1,193 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected imageReference
+	}{
+		{"nginx", imageReference{Registry: "docker.io", Repository: "nginx", Tag: "latest"}},
+		{"bitnami/redis:7.2", imageReference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7.2"}},
+		{"ghcr.io/org/app:1.2.3@sha256:abc", imageReference{Registry: "ghcr.io", Repository: "org/app", Tag: "1.2.3", Digest: "sha256:abc"}},
+		{"localhost:5000/app", imageReference{Registry: "localhost:5000", Repository: "app", Tag: "latest"}},
+		{"registry.k8s.io/pause@sha256:def", imageReference{Registry: "registry.k8s.io", Repository: "pause", Digest: "sha256:def"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseImageReference(tt.image))
+		})
+	}
+}
+
+func TestKubernetesProcessor_Supports(t *testing.T) {
+	processor := KubernetesProcessor{}
+
+	assert.True(t, processor.Supports("deploy/app.yaml"))
+	assert.True(t, processor.Supports("deploy/app.YML"))
+	assert.False(t, processor.Supports("deploy/app.json"))
+}
+
+func TestKubernetesProcessor_Process(t *testing.T) {
+	processor := KubernetesProcessor{}
+
+	content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  replicas: 3
+  template:
+    spec:
+      serviceAccountName: web-sa
+      initContainers:
+        - name: migrate
+          image: ghcr.io/org/migrate:1.0
+      containers:
+        - name: app
+          image: ghcr.io/org/web:2.1.0@sha256:abc
+          resources:
+            requests:
+              cpu: 100m
+              memory: 128Mi
+            limits:
+              memory: 256Mi
+          livenessProbe:
+            httpGet:
+              path: /healthz
+              port: 8080
+          readinessProbe:
+            tcpSocket:
+              port: 8080
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: report
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: report
+              image: busybox
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  ingressClassName: nginx
+  tls:
+    - hosts: [shop.example.com]
+  rules:
+    - host: shop.example.com
+    - host: admin.example.com
+---
+apiVersion: networking.istio.io/v1beta1
+kind: VirtualService
+metadata:
+  name: web
+---
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: web-tls
+`
+
+	findings, err := processor.Process("deploy/web.yaml", "repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		byType[finding.Type] = append(byType[finding.Type], finding)
+	}
+
+	workloads := findingsByName(byType["Kubernetes Workload"])
+	require.Len(t, workloads, 2)
+	assert.Equal(t, 3, workloads["web"].Properties["replicas"])
+	assert.Equal(t, "web-sa", workloads["web"].Properties["service_account"])
+	assert.Equal(t, "shop", workloads["web"].Properties["namespace"])
+	assert.Equal(t, "0 * * * *", workloads["report"].Properties["schedule"])
+
+	containers := findingsByName(byType["Kubernetes Container"])
+	require.Len(t, containers, 3)
+	app := containers["app"].Properties
+	assert.Equal(t, "ghcr.io", app["registry"])
+	assert.Equal(t, "org/web", app["repository"])
+	assert.Equal(t, "2.1.0", app["tag"])
+	assert.Equal(t, "sha256:abc", app["digest"])
+	assert.Equal(t, "100m", app["requests_cpu"])
+	assert.Equal(t, "256Mi", app["limits_memory"])
+	assert.Equal(t, "httpGet", app["liveness_probe"])
+	assert.Equal(t, "tcpSocket", app["readiness_probe"])
+	assert.NotContains(t, app, "startup_probe")
+	assert.Equal(t, true, containers["migrate"].Properties["init"])
+	assert.Equal(t, "docker.io", containers["report"].Properties["registry"])
+	assert.Equal(t, "CronJob", containers["report"].Properties["kind"])
+
+	hosts := findingsByName(byType["Kubernetes Ingress Host"])
+	require.Len(t, hosts, 2)
+	assert.Equal(t, true, hosts["shop.example.com"].Properties["tls"])
+	assert.Equal(t, false, hosts["admin.example.com"].Properties["tls"])
+	assert.Equal(t, "nginx", hosts["admin.example.com"].Properties["ingress_class"])
+
+	technologies := findingsByName(byType["Kubernetes Technology"])
+	require.Len(t, technologies, 2)
+	assert.Equal(t, "VirtualService", technologies["Istio"].Properties["kind"])
+	assert.Equal(t, "Certificate", technologies["cert-manager"].Properties["kind"])
+
+	assert.Len(t, byType["Kubernetes Resource"], 3)
+}
+
+func TestKubernetesProcessor_IgnoresOtherYAML(t *testing.T) {
+	processor := KubernetesProcessor{}
+
+	tests := map[string]string{
+		"compose":       "services:\n  web:\n    image: nginx\n",
+		"helm template": "apiVersion: v1\nkind: Service\nmetadata:\n  name: {{ .Release.Name }}\n  labels:\n    {{- include \"labels\" . | nindent 4 }}\n",
+		"chart":         "apiVersion: v2\nname: chart\nversion: 1.0.0\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			findings, err := processor.Process("file.yaml", "repo", content)
+			require.NoError(t, err)
+			assert.Empty(t, findings)
+		})
+	}
+}
+
+func TestKubernetesProcessor_List(t *testing.T) {
+	processor := KubernetesProcessor{}
+
+	content := `apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Pod
+    metadata:
+      name: debug
+    spec:
+      containers:
+        - name: shell
+          image: alpine:3.19
+`
+
+	findings, err := processor.Process("list.yaml", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "Kubernetes Workload", findings[0].Type)
+	assert.Equal(t, "3.19", findings[1].Properties["tag"])
+}
@@ -0,0 +1,168 @@
+package processors
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// Kustomization is the subset of kustomization.yaml used to report an overlay.
+type Kustomization struct {
+	Namespace  string   `yaml:"namespace"`
+	NamePrefix string   `yaml:"namePrefix"`
+	Resources  []string `yaml:"resources"`
+	Bases      []string `yaml:"bases"`
+	Components []string `yaml:"components"`
+	Images     []struct {
+		Name    string `yaml:"name"`
+		NewName string `yaml:"newName"`
+		NewTag  string `yaml:"newTag"`
+		Digest  string `yaml:"digest"`
+	} `yaml:"images"`
+	HelmCharts []struct {
+		Name        string `yaml:"name"`
+		Repo        string `yaml:"repo"`
+		Version     string `yaml:"version"`
+		ReleaseName string `yaml:"releaseName"`
+		Namespace   string `yaml:"namespace"`
+		ValuesFile  string `yaml:"valuesFile"`
+	} `yaml:"helmCharts"`
+}
+
+// isRemoteKustomizeResource reports whether a resource or base is fetched from
+// a git repository or URL rather than the local tree.
+func isRemoteKustomizeResource(resource string) bool {
+	return strings.Contains(resource, "://") || strings.HasPrefix(resource, "git@") ||
+		strings.HasPrefix(resource, "github.com/") || strings.Contains(resource, "?ref=")
+}
+
+// kustomizeRef returns the ref a remote resource is pinned to, if any.
+func kustomizeRef(resource string) string {
+	if idx := strings.Index(resource, "?"); idx >= 0 {
+		for _, param := range strings.Split(resource[idx+1:], "&") {
+			if strings.HasPrefix(param, "ref=") || strings.HasPrefix(param, "version=") {
+				return param[strings.Index(param, "=")+1:]
+			}
+		}
+	}
+	return ""
+}
+
+// KustomizeProcessor reports Kustomize overlays with the resources, image
+// overrides and Helm charts they pull in.
+type KustomizeProcessor struct {
+}
+
+func (k KustomizeProcessor) Supports(filePath string) bool {
+	base := filepath.Base(filePath)
+	return base == "kustomization.yaml" || base == "kustomization.yml" || base == "Kustomization"
+}
+
+func (k KustomizeProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	var kustomization Kustomization
+	if err := yaml.Unmarshal([]byte(content), &kustomization); err != nil {
+		return nil, fmt.Errorf("failed to parse kustomization '%s': %w", path, err)
+	}
+
+	overlay := repositoryPath(filepath.Dir(path))
+	overlayProperties := map[string]interface{}{
+		"directory": filepath.ToSlash(filepath.Dir(path)),
+	}
+	if kustomization.Namespace != "" {
+		overlayProperties["namespace"] = kustomization.Namespace
+	}
+	if kustomization.NamePrefix != "" {
+		overlayProperties["name_prefix"] = kustomization.NamePrefix
+	}
+	matches := []core.Finding{{
+		Name:       overlay,
+		Type:       "Kustomize Overlay",
+		Category:   "Kubernetes",
+		Properties: overlayProperties,
+		Path:       path,
+		RepoName:   repoName,
+	}}
+
+	addResources := func(resources []string, kind string) {
+		for _, resource := range resources {
+			properties := map[string]interface{}{
+				"overlay":       overlay,
+				"resource_type": kind,
+				"remote":        isRemoteKustomizeResource(resource),
+			}
+			if ref := kustomizeRef(resource); ref != "" {
+				properties["ref"] = ref
+			}
+			matches = append(matches, core.Finding{
+				Name:       resource,
+				Type:       "Kustomize Resource",
+				Category:   "Kubernetes",
+				Properties: properties,
+				Path:       path,
+				RepoName:   repoName,
+			})
+		}
+	}
+	addResources(kustomization.Resources, "resource")
+	addResources(kustomization.Bases, "base")
+	addResources(kustomization.Components, "component")
+
+	for _, image := range kustomization.Images {
+		// The override replaces the name, tag and digest of matching images.
+		effective := image.Name
+		if image.NewName != "" {
+			effective = image.NewName
+		}
+		if image.NewTag != "" {
+			effective += ":" + image.NewTag
+		}
+		if image.Digest != "" {
+			effective += "@" + image.Digest
+		}
+		properties := parseImageReference(effective).properties()
+		properties["overlay"] = overlay
+		properties["image"] = effective
+		if image.NewName != "" {
+			properties["new_name"] = image.NewName
+		}
+		matches = append(matches, core.Finding{
+			Name:       image.Name,
+			Type:       "Kustomize Image",
+			Category:   "Kubernetes",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+
+	for _, chart := range kustomization.HelmCharts {
+		properties := map[string]interface{}{
+			"overlay":    overlay,
+			"repository": chart.Repo,
+			"version":    chart.Version,
+		}
+		if chart.ReleaseName != "" {
+			properties["release_name"] = chart.ReleaseName
+		}
+		if chart.Namespace != "" {
+			properties["namespace"] = chart.Namespace
+		}
+		if chart.ValuesFile != "" {
+			properties["values_file"] = chart.ValuesFile
+		}
+		matches = append(matches, core.Finding{
+			Name:       chart.Name,
+			Type:       "Helm Chart Dependency",
+			Category:   "Kubernetes",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+
+	return matches, nil
+}
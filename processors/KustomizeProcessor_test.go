@@ -0,0 +1,100 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestKustomizeProcessor_Supports(t *testing.T) {
+	processor := KustomizeProcessor{}
+
+	assert.True(t, processor.Supports("overlays/prod/kustomization.yaml"))
+	assert.True(t, processor.Supports("overlays/prod/kustomization.yml"))
+	assert.True(t, processor.Supports("overlays/prod/Kustomization"))
+	assert.False(t, processor.Supports("overlays/prod/deployment.yaml"))
+}
+
+func TestKustomizeProcessor_Process(t *testing.T) {
+	processor := KustomizeProcessor{}
+
+	content := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namespace: shop-prod
+namePrefix: prod-
+resources:
+  - ../../base
+  - github.com/org/platform//deploy/monitoring?ref=v1.2.0
+  - https://raw.githubusercontent.com/org/repo/main/crd.yaml
+components:
+  - ../../components/tls
+images:
+  - name: web
+    newName: ghcr.io/org/web
+    newTag: 2.1.0
+  - name: redis
+    digest: sha256:abc
+helmCharts:
+  - name: ingress-nginx
+    repo: https://kubernetes.github.io/ingress-nginx
+    version: 4.9.0
+    releaseName: ingress
+`
+
+	findings, err := processor.Process("overlays/prod/kustomization.yaml", "repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		byType[finding.Type] = append(byType[finding.Type], finding)
+	}
+
+	require.Len(t, byType["Kustomize Overlay"], 1)
+	overlay := byType["Kustomize Overlay"][0]
+	assert.Equal(t, "overlays/prod", overlay.Name)
+	assert.Equal(t, "shop-prod", overlay.Properties["namespace"])
+	assert.Equal(t, "prod-", overlay.Properties["name_prefix"])
+
+	resources := findingsByName(byType["Kustomize Resource"])
+	require.Len(t, resources, 4)
+	assert.Equal(t, false, resources["../../base"].Properties["remote"])
+	remote := resources["github.com/org/platform//deploy/monitoring?ref=v1.2.0"].Properties
+	assert.Equal(t, true, remote["remote"])
+	assert.Equal(t, "v1.2.0", remote["ref"])
+	assert.Equal(t, true, resources["https://raw.githubusercontent.com/org/repo/main/crd.yaml"].Properties["remote"])
+	assert.Equal(t, "component", resources["../../components/tls"].Properties["resource_type"])
+	assert.Equal(t, "overlays/prod", resources["../../base"].Properties["overlay"])
+
+	images := findingsByName(byType["Kustomize Image"])
+	require.Len(t, images, 2)
+	web := images["web"].Properties
+	assert.Equal(t, "ghcr.io/org/web:2.1.0", web["image"])
+	assert.Equal(t, "ghcr.io", web["registry"])
+	assert.Equal(t, "2.1.0", web["tag"])
+	assert.Equal(t, "sha256:abc", images["redis"].Properties["digest"])
+
+	charts := byType["Helm Chart Dependency"]
+	require.Len(t, charts, 1)
+	assert.Equal(t, "ingress-nginx", charts[0].Name)
+	assert.Equal(t, "4.9.0", charts[0].Properties["version"])
+	assert.Equal(t, "ingress", charts[0].Properties["release_name"])
+	assert.Equal(t, "overlays/prod", charts[0].Properties["overlay"])
+}
+
+func TestKustomizeProcessor_OverlayNameIsRelativeToRepository(t *testing.T) {
+	repo := filepath.Join(t.TempDir(), "clone-1234")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	path := filepath.Join(repo, "overlays", "prod", "kustomization.yaml")
+
+	findings, err := KustomizeProcessor{}.Process(path, "repo", "resources:\n  - ../../base\n")
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "overlays/prod", findings[0].Name)
+	assert.Equal(t, filepath.ToSlash(filepath.Dir(path)), findings[0].Properties["directory"])
+	assert.Equal(t, "overlays/prod", findings[1].Properties["overlay"])
+}
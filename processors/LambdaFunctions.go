@@ -0,0 +1,324 @@
+package processors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// defaultLambdaArchitecture is the instruction set functions run on when
+// their configuration does not choose one.
+const defaultLambdaArchitecture = "x86_64"
+
+// LambdaRuntime is an entry of data/lambda/runtimes.json: a Lambda runtime
+// identifier, the language and version it provides and, once announced, the
+// date AWS deprecates it on.
+type LambdaRuntime struct {
+	Runtime      string `json:"runtime"`
+	Language     string `json:"language"`
+	Version      string `json:"version"`
+	DeprecatedOn string `json:"deprecated_on,omitempty"`
+}
+
+// LoadLambdaRuntimes reads the runtime table from f, keyed by identifier.
+func LoadLambdaRuntimes(f fs.FS) (map[string]LambdaRuntime, error) {
+	content, err := fs.ReadFile(f, "data/lambda/runtimes.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Lambda runtimes: %w", err)
+	}
+	var entries []LambdaRuntime
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse Lambda runtimes: %w", err)
+	}
+	runtimes := make(map[string]LambdaRuntime, len(entries))
+	for _, entry := range entries {
+		runtimes[entry.Runtime] = entry
+	}
+	return runtimes, nil
+}
+
+// lambdaEventTypes maps the event source names used by SAM and the
+// Serverless Framework, lower-cased, to a common name.
+var lambdaEventTypes = map[string]string{
+	"api":              "api",
+	"http":             "api",
+	"httpapi":          "http_api",
+	"sqs":              "sqs",
+	"s3":               "s3",
+	"schedule":         "schedule",
+	"schedulev2":       "schedule",
+	"sns":              "sns",
+	"dynamodb":         "dynamodb",
+	"kinesis":          "kinesis",
+	"stream":           "stream",
+	"eventbridgerule":  "eventbridge",
+	"eventbridge":      "eventbridge",
+	"cloudwatchevent":  "eventbridge",
+	"cloudwatchlogs":   "cloudwatch_logs",
+	"cloudwatchlog":    "cloudwatch_logs",
+	"iotrule":          "iot",
+	"iot":              "iot",
+	"msk":              "kafka",
+	"selfmanagedkafka": "kafka",
+	"kafka":            "kafka",
+	"mq":               "mq",
+	"activemq":         "mq",
+	"rabbitmq":         "mq",
+	"cognito":          "cognito",
+	"cognitouserpool":  "cognito",
+	"alexaskill":       "alexa",
+	"alb":              "alb",
+	"websocket":        "websocket",
+	"documentdb":       "documentdb",
+}
+
+// lambdaEventKeys maps the settings of an event source, lower-cased, to the
+// properties it is reported with.
+var lambdaEventKeys = map[string]string{
+	"path":               "path",
+	"method":             "method",
+	"queue":              "queue",
+	"bucket":             "bucket",
+	"events":             "s3_events",
+	"event":              "s3_events",
+	"schedule":           "schedule",
+	"rate":               "schedule",
+	"scheduleexpression": "schedule",
+	"topic":              "topic",
+	"topicname":          "topic",
+	"stream":             "stream",
+	"route":              "route",
+	"loggroup":           "log_group",
+	"loggroupname":       "log_group",
+}
+
+// lambdaEventShorthands names the property a Serverless Framework event
+// written as a single string, or as an arn, sets.
+var lambdaEventShorthands = map[string]string{
+	"sqs":             "queue",
+	"s3":              "bucket",
+	"schedule":        "schedule",
+	"sns":             "topic",
+	"dynamodb":        "stream",
+	"kinesis":         "stream",
+	"stream":          "stream",
+	"websocket":       "route",
+	"cloudwatch_logs": "log_group",
+}
+
+// lambdaEvent is an event source that invokes a function.
+type lambdaEvent struct {
+	Name       string
+	Properties map[string]interface{}
+}
+
+// lambdaFunction is a function as SAM and the Serverless Framework describe
+// it, with the template's or provider's defaults applied.
+type lambdaFunction struct {
+	Name         string
+	Runtime      string
+	Handler      string
+	Architecture string
+	MemorySize   interface{}
+	Timeout      interface{}
+	PackageType  string
+	Code         interface{}
+	Layers       []interface{}
+	Events       []lambdaEvent
+}
+
+// lambdaReference renders a value that names another resource, such as a
+// layer ARN, {"Ref": "Layer"} or {"Fn::GetAtt": ["Queue", "Arn"]}, as a string.
+func lambdaReference(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if ref, ok := v["Ref"].(string); ok {
+			return ref
+		}
+		if sub, ok := v["Fn::Sub"].(string); ok {
+			return sub
+		}
+		if attribute, ok := v["Fn::GetAtt"].([]interface{}); ok {
+			parts := make([]string, len(attribute))
+			for i, part := range attribute {
+				parts[i] = fmt.Sprint(part)
+			}
+			return strings.Join(parts, ".")
+		}
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+// lambdaValue keeps scalars and lists as they are and renders references.
+func lambdaValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return lambdaReference(v)
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = lambdaValue(item)
+		}
+		return values
+	}
+	return value
+}
+
+// lambdaEventProperties reports an event source's type and the settings that
+// say what invokes the function: an API path and method, a queue, bucket,
+// topic or stream, or a schedule expression.
+func lambdaEventProperties(trigger string, settings interface{}) map[string]interface{} {
+	eventType, ok := lambdaEventTypes[strings.ToLower(trigger)]
+	if !ok {
+		eventType = strings.ToLower(trigger)
+	}
+	props := map[string]interface{}{}
+
+	switch s := settings.(type) {
+	case string:
+		if eventType == "api" || eventType == "http_api" {
+			if method, path, ok := strings.Cut(s, " "); ok {
+				props["method"] = method
+				props["path"] = strings.TrimSpace(path)
+			} else {
+				props["path"] = s
+			}
+		} else if key, ok := lambdaEventShorthands[eventType]; ok {
+			props[key] = s
+		}
+	case map[string]interface{}:
+		for name, value := range s {
+			lower := strings.ToLower(name)
+			if key, ok := lambdaEventKeys[lower]; ok {
+				props[key] = lambdaValue(value)
+			} else if lower == "arn" {
+				if key, ok := lambdaEventShorthands[eventType]; ok {
+					props[key] = lambdaReference(value)
+				}
+			} else if lower == "type" && eventType == "stream" {
+				eventType = strings.ToLower(fmt.Sprint(value))
+			}
+		}
+	}
+
+	// Serverless Framework streams name their service in the ARN.
+	if eventType == "stream" {
+		stream := fmt.Sprint(props["stream"])
+		switch {
+		case strings.Contains(stream, ":dynamodb:"):
+			eventType = "dynamodb"
+		case strings.Contains(stream, ":kinesis:"):
+			eventType = "kinesis"
+		}
+	}
+	props["event_type"] = eventType
+	props["trigger"] = trigger
+	return props
+}
+
+// lambdaFunctionFindings reports a function, its event sources and layers,
+// and the runtime it runs on. Runtimes are reported like the runtimes of
+// package manifests, flagged when AWS has deprecated them by today.
+func lambdaFunctionFindings(function lambdaFunction, framework string, runtimes map[string]LambdaRuntime, today string, path string, repoName string) []core.Finding {
+	newFinding := func(name string, findingType string, properties map[string]interface{}) core.Finding {
+		properties["framework"] = framework
+		return core.Finding{
+			Name:       name,
+			Type:       findingType,
+			Category:   "AWS",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		}
+	}
+
+	props := map[string]interface{}{}
+	for key, value := range map[string]interface{}{
+		"runtime":      function.Runtime,
+		"handler":      function.Handler,
+		"architecture": function.Architecture,
+		"package_type": function.PackageType,
+	} {
+		if value != "" {
+			props[key] = value
+		}
+	}
+	if function.MemorySize != nil {
+		props["memory_size"] = function.MemorySize
+	}
+	if function.Timeout != nil {
+		props["timeout"] = function.Timeout
+	}
+	if function.Code != nil {
+		props["code"] = lambdaValue(function.Code)
+	}
+
+	var layers []string
+	for _, layer := range function.Layers {
+		layers = append(layers, lambdaReference(layer))
+	}
+	if len(layers) > 0 {
+		props["layers"] = layers
+	}
+
+	var events []core.Finding
+	var eventTypes []string
+	for _, event := range function.Events {
+		eventProps := event.Properties
+		eventProps["function"] = function.Name
+		if event.Name != "" {
+			eventProps["event"] = event.Name
+		}
+		eventType := fmt.Sprint(eventProps["event_type"])
+		eventTypes = append(eventTypes, eventType)
+		events = append(events, newFinding(eventType, "Lambda Event Source", eventProps))
+	}
+	if len(eventTypes) > 0 {
+		props["events"] = eventTypes
+	}
+
+	matches := []core.Finding{newFinding(function.Name, "Lambda Function", props)}
+	matches = append(matches, events...)
+	for _, layer := range layers {
+		matches = append(matches, newFinding(layer, "Lambda Layer", map[string]interface{}{
+			"function": function.Name,
+		}))
+	}
+
+	// Runtimes set through variables are not known until deployment.
+	if function.Runtime == "" || strings.Contains(function.Runtime, "${") {
+		return matches
+	}
+	runtime, known := runtimes[function.Runtime]
+	runtimeProps := map[string]interface{}{
+		"Platform":  "AWS Lambda",
+		"Runtime":   function.Runtime,
+		"Function":  function.Name,
+		"Framework": framework,
+	}
+	name := function.Runtime
+	if known {
+		name = runtime.Language
+		runtimeProps["Language"] = runtime.Language
+		runtimeProps["Version"] = runtime.Version
+		runtimeProps["Deprecated"] = runtime.DeprecatedOn != "" && runtime.DeprecatedOn <= today
+		if runtime.DeprecatedOn != "" {
+			runtimeProps["DeprecatedOn"] = runtime.DeprecatedOn
+		}
+	}
+	matches = append(matches, core.Finding{
+		Name:       name,
+		Type:       "Runtime",
+		Category:   "",
+		Properties: runtimeProps,
+		Path:       path,
+		RepoName:   repoName,
+	})
+	return matches
+}
@@ -3,14 +3,13 @@ package processors
 import (
 	"github.com/go-enry/go-enry/v2"
 	"github.com/reaandrew/techdetector/core"
-	"strings"
 )
 
 type LanguageProcessor struct {
 }
 
 func (l LanguageProcessor) Supports(filePath string) bool {
-	return !strings.Contains(filePath, ".git")
+	return !inDirectory(filePath, ".git")
 }
 
 func (l LanguageProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
@@ -27,21 +27,55 @@ func NewLibrariesProcessor() *LibrariesProcessor {
 }
 
 func (mp *LibrariesProcessor) Supports(filePath string) bool {
-	ignoredDirs := []string{"node_modules", "vendor", ".git"}
-	for _, dir := range ignoredDirs {
-		if strings.Contains(filePath, dir) {
-			return false
-		}
+	if inDirectory(filePath, "node_modules", "vendor", ".git") {
+		return false
 	}
 	base := filepath.Base(filePath)
 	supportedFiles := []string{
-		"pom.xml",          // Java (Maven)
-		"build.gradle",     // Java (Gradle) - Optional
-		"go.mod",           // Go
-		"package.json",     // Node.js
-		"requirements.txt", // Python
-		"pyproject.toml",   // Python
-		"*.csproj",         // C#
+		"pom.xml",             // Java (Maven)
+		"build.gradle",        // Java (Gradle) - Optional
+		"go.mod",              // Go
+		"go.work",             // Go (workspaces)
+		"package.json",        // Node.js
+		"package-lock.json",   // Node.js (npm)
+		"npm-shrinkwrap.json", // Node.js (npm)
+		"yarn.lock",           // Node.js (Yarn)
+		"pnpm-lock.yaml",      // Node.js (pnpm)
+		"requirements.txt",    // Python
+		"pyproject.toml",      // Python
+		"Pipfile",             // Python (Pipenv)
+		"Pipfile.lock",        // Python (Pipenv)
+		"poetry.lock",         // Python (Poetry)
+		"uv.lock",             // Python (uv)
+		"setup.cfg",           // Python (setuptools)
+		"setup.py",            // Python (setuptools)
+		"environment.yml",     // Python (conda)
+		"environment.yaml",    // Python (conda)
+		"*.csproj",            // C#
+		"*.fsproj",            // F#
+		"*.vbproj",            // VB.NET
+		"packages.config",     // .NET (NuGet)
+		"packages.lock.json",  // .NET (NuGet)
+		"paket.dependencies",  // .NET (Paket)
+		"paket.lock",          // .NET (Paket)
+		"global.json",         // .NET SDK
+		"Cargo.toml",          // Rust
+		"Cargo.lock",          // Rust
+		"Gemfile",             // Ruby
+		"Gemfile.lock",        // Ruby
+		"composer.json",       // PHP
+		"composer.lock",       // PHP
+		"pubspec.yaml",        // Dart (Flutter)
+		"pubspec.lock",        // Dart (Flutter)
+		"Podfile",             // Swift/Objective-C (CocoaPods)
+		"Podfile.lock",        // Swift/Objective-C (CocoaPods)
+		"Package.swift",       // Swift (SwiftPM)
+		"Package.resolved",    // Swift (SwiftPM)
+		"Cartfile.resolved",   // Swift/Objective-C (Carthage)
+	}
+
+	if isRequirementsFile(filePath) {
+		return true
 	}
 
 	for _, pattern := range supportedFiles {
@@ -73,16 +107,34 @@ func (mp *LibrariesProcessor) Process(path string, repoName string, content stri
 			return nil, fmt.Errorf("failed to parse go.mod: %w", err)
 		}
 		matches = append(matches, fs...)
+	case "go.work":
+		fs, err := mp.parseGoWork(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse go.work: %w", err)
+		}
+		matches = append(matches, fs...)
 	case "package.json":
 		fs, err := mp.parsePackageJSON(content, repoName, path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse package.json: %w", err)
 		}
 		matches = append(matches, fs...)
-	case "requirements.txt":
-		fs, err := mp.parseRequirementsTXT(content, repoName, path)
+	case "package-lock.json", "npm-shrinkwrap.json":
+		fs, err := mp.parsePackageLockJSON(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", base, err)
+		}
+		matches = append(matches, fs...)
+	case "yarn.lock":
+		fs, err := mp.parseYarnLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse yarn.lock: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "pnpm-lock.yaml":
+		fs, err := mp.parsePnpmLock(content, repoName, path)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse requirements.txt: %w", err)
+			return nil, fmt.Errorf("failed to parse pnpm-lock.yaml: %w", err)
 		}
 		matches = append(matches, fs...)
 	case "pyproject.toml":
@@ -91,11 +143,167 @@ func (mp *LibrariesProcessor) Process(path string, repoName string, content stri
 			return nil, fmt.Errorf("failed to parse pyproject.toml: %w", err)
 		}
 		matches = append(matches, fs...)
+	case "Pipfile":
+		fs, err := mp.parsePipfile(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Pipfile: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "Pipfile.lock":
+		fs, err := mp.parsePipfileLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Pipfile.lock: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "poetry.lock":
+		fs, err := mp.parsePoetryLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse poetry.lock: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "uv.lock":
+		fs, err := mp.parseUvLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse uv.lock: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "setup.cfg":
+		fs, err := mp.parseSetupCfg(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse setup.cfg: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "setup.py":
+		fs, err := mp.parseSetupPy(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse setup.py: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "environment.yml", "environment.yaml":
+		fs, err := mp.parseCondaEnvironment(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", base, err)
+		}
+		matches = append(matches, fs...)
+	case "Cargo.toml":
+		fs, err := mp.parseCargoToml(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Cargo.toml: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "Cargo.lock":
+		fs, err := mp.parseCargoLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Cargo.lock: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "Gemfile":
+		fs, err := mp.parseGemfile(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Gemfile: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "Gemfile.lock":
+		fs, err := mp.parseGemfileLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Gemfile.lock: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "composer.json":
+		fs, err := mp.parseComposerJSON(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse composer.json: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "composer.lock":
+		fs, err := mp.parseComposerLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse composer.lock: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "pubspec.yaml":
+		fs, err := mp.parsePubspecYAML(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pubspec.yaml: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "pubspec.lock":
+		fs, err := mp.parsePubspecLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pubspec.lock: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "Podfile":
+		fs, err := mp.parsePodfile(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Podfile: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "Podfile.lock":
+		fs, err := mp.parsePodfileLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Podfile.lock: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "Package.swift":
+		fs, err := mp.parsePackageSwift(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Package.swift: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "Package.resolved":
+		fs, err := mp.parsePackageResolved(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Package.resolved: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "Cartfile.resolved":
+		fs, err := mp.parseCartfileResolved(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Cartfile.resolved: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "packages.config":
+		fs, err := mp.parsePackagesConfig(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse packages.config: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "packages.lock.json":
+		fs, err := mp.parseNuGetLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse packages.lock.json: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "paket.dependencies":
+		fs, err := mp.parsePaketDependencies(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse paket.dependencies: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "paket.lock":
+		fs, err := mp.parsePaketLock(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse paket.lock: %w", err)
+		}
+		matches = append(matches, fs...)
+	case "global.json":
+		fs, err := mp.parseGlobalJSON(content, repoName, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse global.json: %w", err)
+		}
+		matches = append(matches, fs...)
 	default:
-		if strings.HasSuffix(base, ".csproj") {
+		if isRequirementsFile(path) {
+			fs, err := mp.parseRequirementsTXT(content, repoName, path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", base, err)
+			}
+			matches = append(matches, fs...)
+		} else if ext := filepath.Ext(base); ext == ".csproj" || ext == ".fsproj" || ext == ".vbproj" {
 			fs, err := mp.parseCsProj(content, repoName, path)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse .csproj: %w", err)
+				return nil, fmt.Errorf("failed to parse %s: %w", ext, err)
 			}
 			matches = append(matches, fs...)
 		} else {
@@ -107,89 +315,168 @@ func (mp *LibrariesProcessor) Process(path string, repoName string, content stri
 }
 
 func (mp *LibrariesProcessor) parsePomXML(content string, repoName string, path string) ([]core.Finding, error) {
-	type Dependency struct {
-		GroupID    string `xml:"groupId"`
-		ArtifactID string `xml:"artifactId"`
-		Version    string `xml:"version"`
-	}
-
-	type Project struct {
-		XMLName      xml.Name     `xml:"project"`
-		Dependencies []Dependency `xml:"dependencies>dependency"`
-	}
-
-	var project Project
+	var project mavenPOM
 	err := xml.Unmarshal([]byte(content), &project)
 	if err != nil {
 		return nil, err
 	}
 
+	model := newMavenModel(project, path)
+	reactor, inReactor := findMavenReactor(path)
+
+	// addReactor attributes a finding to its module within a multi-module
+	// build so the reactor can be reported as a single component tree.
+	addReactor := func(properties map[string]interface{}) {
+		if !inReactor {
+			return
+		}
+		properties["Module"] = project.groupID() + ":" + project.ArtifactID
+		properties["Reactor"] = reactor.Root
+	}
+
 	var matches []core.Finding
 	for _, dep := range project.Dependencies {
-		libraryName := fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)
+		groupID := model.interpolate(dep.GroupID)
+		artifactID := model.interpolate(dep.ArtifactID)
+		libraryName := fmt.Sprintf("%s:%s", groupID, artifactID)
+		managed, isManaged := model.Managed[libraryName]
+
+		version := dep.Version
+		if version == "" && isManaged {
+			version = managed.Version
+		}
+		version = model.interpolate(version)
+		scope := dep.Scope
+		if scope == "" && isManaged {
+			scope = managed.Scope
+		}
+		if scope == "" {
+			scope = "compile"
+		}
+
+		properties := map[string]interface{}{
+			"Language": "Java",
+			"Version":  version,
+			"Scope":    scope,
+		}
+		if version == "" {
+			properties["Version"] = "N/A"
+			if len(model.Unresolved) > 0 {
+				properties["ManagedBy"] = strings.Join(model.Unresolved, ",")
+			}
+		}
+		if strings.TrimSpace(dep.Optional) == "true" {
+			properties["Optional"] = true
+		}
+		addReactor(properties)
+		if _, internal := reactor.Modules[libraryName]; internal {
+			properties["Internal"] = true
+		}
+
 		match := core.Finding{
-			Name:     libraryName,
-			Type:     "Library",
-			Category: "",
-			Properties: map[string]interface{}{
-				"Language": "Java",
-				"Version":  dep.Version,
-			},
-			Path:     path,
-			RepoName: repoName,
+			Name:       libraryName,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
 		}
 		matches = append(matches, match)
 	}
 
+	for _, plugin := range project.Plugins {
+		pluginName := model.interpolate(mavenPluginName(plugin))
+		version := plugin.Version
+		if version == "" {
+			version = model.Plugins[mavenPluginName(plugin)]
+		}
+		version = model.interpolate(version)
+		if version == "" {
+			version = "N/A"
+		}
+		properties := map[string]interface{}{
+			"Language": "Java",
+			"Version":  version,
+			"Tool":     "Maven",
+		}
+		addReactor(properties)
+		matches = append(matches, core.Finding{
+			Name:       pluginName,
+			Type:       "Build Plugin",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+
 	return matches, nil
 }
 
 func (mp *LibrariesProcessor) parseGoMod(content string, repoName string, path string) ([]core.Finding, error) {
-	lines := strings.Split(content, "\n")
+	file := parseGoModFile(content)
 	var matches []core.Finding
-	var inRequireBlock bool
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+	// A go.work file that uses this module overrides its replace directives
+	// and resolves requirements on the other modules in the workspace.
+	workspace, inWorkspace := findGoWorkspace(filepath.Dir(path))
+	sums, haveSums := readGoSum(filepath.Join(filepath.Dir(path), "go.sum"))
 
-		// Check for start and end of "require" block
-		if strings.HasPrefix(line, "require (") {
-			inRequireBlock = true
-			continue
+	for _, req := range file.Requires {
+		properties := map[string]interface{}{
+			"Language": "Go",
+			"Version":  req.Version,
+			"Direct":   !req.Indirect,
 		}
-		if inRequireBlock && strings.HasPrefix(line, ")") {
-			inRequireBlock = false
-			continue
+		if file.excluded(req.goModuleVersion) {
+			properties["Excluded"] = true
 		}
 
-		if inRequireBlock || strings.HasPrefix(line, "require ") {
-			// Remove the "require" keyword for single-line entries
-			if !inRequireBlock {
-				line = strings.TrimPrefix(line, "require")
-				line = strings.TrimSpace(line)
+		effective := req.goModuleVersion
+		replace, replaced := file.replacement(req.goModuleVersion)
+		if inWorkspace {
+			if workReplace, ok := workspace.File.replacement(req.goModuleVersion); ok {
+				replace, replaced = workReplace, true
+			}
+			if use, ok := workspace.Modules[req.Path]; ok {
+				replace, replaced = goReplace{Old: req.goModuleVersion, New: goModuleVersion{Path: use}}, true
+				properties["Internal"] = true
 			}
+		}
+		if replaced {
+			properties["Replace"] = replace.New.Path
+			properties["RequiredVersion"] = req.Version
+			if replace.New.Version == "" {
+				properties["Source"] = "local"
+				properties["Version"] = "N/A"
+			} else {
+				properties["Source"] = "module"
+				properties["Version"] = replace.New.Version
+				effective = replace.New
+			}
+		}
 
-			// Split the line to extract library name and version
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				libraryName := parts[0]
-				version := parts[1]
-				match := core.Finding{
-					Name:     libraryName,
-					Type:     "Library",
-					Category: "",
-					Properties: map[string]interface{}{
-						"Language": "Go",
-						"Version":  version,
-					},
-					Path:     path,
-					RepoName: repoName,
-				}
-				matches = append(matches, match)
+		if haveSums && properties["Source"] != "local" {
+			if sum, ok := sums[effective.Path+" "+effective.Version]; ok {
+				properties["Integrity"] = sum
+			} else {
+				properties["GoSum"] = "missing"
 			}
 		}
+
+		match := core.Finding{
+			Name:       req.Path,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		}
+		matches = append(matches, match)
 	}
 
+	matches = append(matches, goRuntimeFindings(file, repoName, path)...)
+
 	return matches, nil
 }
 
@@ -234,43 +521,21 @@ func (mp *LibrariesProcessor) parsePackageJSON(content string, repoName string,
 }
 
 func (mp *LibrariesProcessor) parseRequirementsTXT(content string, repoName string, path string) ([]core.Finding, error) {
-	lines := strings.Split(content, "\n")
-	matches := make([]core.Finding, 0, len(lines))
+	requirements := readRequirements(content, path, "", map[string]bool{path: true})
+	matches := make([]core.Finding, 0, len(requirements))
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		var libraryName, version string
-		specifiers := []string{"==", ">=", "<=", "~=", ">", "<"}
-		found := false
-
-		for _, spec := range specifiers {
-			if strings.Contains(line, spec) {
-				parts := strings.SplitN(line, spec, 2)
-				libraryName = strings.TrimSpace(parts[0])
-				version = spec + strings.TrimSpace(parts[1])
-				found = true
-				break
-			}
-		}
-
-		if !found {
-			libraryName = line
-			version = "N/A"
+	for _, req := range requirements {
+		properties := req.properties()
+		if req.Include != "" {
+			properties["Include"] = req.Include
 		}
 		match := core.Finding{
-			Name:     libraryName,
-			Type:     "Library",
-			Category: "",
-			Properties: map[string]interface{}{
-				"Language": "Python",
-				"Version":  version,
-			},
-			Path:     path,
-			RepoName: repoName,
+			Name:       req.Name,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
 		}
 		matches = append(matches, match)
 	}
@@ -298,12 +563,12 @@ func (mp *LibrariesProcessor) parsePyProjectToml(content string, repoName string
 	combined := make(map[string]string)
 	for k, v := range py.Tool.Poetry.Dependencies {
 		if ver, ok := v.(string); ok {
-			combined[k] = ver
+			combined[normalizePythonName(k)] = ver
 		}
 	}
 	for k, v := range py.Tool.Poetry.DevDependencies {
 		if ver, ok := v.(string); ok {
-			combined[k] = ver
+			combined[normalizePythonName(k)] = ver
 		}
 	}
 
@@ -325,57 +590,63 @@ func (mp *LibrariesProcessor) parsePyProjectToml(content string, repoName string
 	return matches, nil
 }
 
+// parseCsProj parses .csproj, .fsproj and .vbproj projects, applying the
+// Directory.Build.props and Directory.Packages.props files above them.
 func (mp *LibrariesProcessor) parseCsProj(content string, repoName string, path string) ([]core.Finding, error) {
-	type PackageReference struct {
-		Include string `xml:"Include,attr"`
-		Version string `xml:"Version,attr"`
-	}
-
-	type Reference struct {
-		Include string `xml:"Include,attr"`
-		Version string `xml:"Version,attr,omitempty"` // Version is optional
-	}
-
-	type Project struct {
-		XMLName           xml.Name           `xml:"Project"`
-		PackageReferences []PackageReference `xml:"ItemGroup>PackageReference"`
-		References        []Reference        `xml:"ItemGroup>Reference"`
-	}
-
-	var project Project
+	var project msbuildProject
 	err := xml.Unmarshal([]byte(content), &project)
 	if err != nil {
 		return nil, err
 	}
 
+	model := newMSBuildModel(project, path)
+	language := dotnetLanguage(path)
 	var matches []core.Finding
 
-	for _, pr := range project.PackageReferences {
+	addReference := func(pr msbuildPackageReference, source string) {
 		if strings.TrimSpace(pr.Include) == "" {
-			continue
+			return
 		}
 
-		libraryName := pr.Include
-		version := pr.Version
-
+		properties := map[string]interface{}{
+			"Language": language,
+		}
+		version := model.interpolate(pr.version())
+		if version == "" {
+			if central, ok := model.Central[strings.ToLower(pr.Include)]; ok {
+				version = model.interpolate(central)
+				properties["ManagedBy"] = "Directory.Packages.props"
+			}
+		}
 		if strings.TrimSpace(version) == "" {
 			version = "N/A" // Or any default value you prefer
 		}
+		properties["Version"] = version
+		if strings.EqualFold(pr.PrivateAssets, "all") {
+			properties["Scope"] = "dev"
+		}
+		if source != "" {
+			properties["Include"] = source
+		}
 
 		match := core.Finding{
-			Name:     libraryName,
-			Type:     "Library",
-			Category: "",
-			Properties: map[string]interface{}{
-				"Language": "C#",
-				"Version":  version,
-			},
-			Path:     path,
-			RepoName: repoName,
+			Name:       pr.Include,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
 		}
 		matches = append(matches, match)
 	}
 
+	for _, pr := range project.PackageReferences {
+		addReference(pr, "")
+	}
+	for _, inherited := range model.Inherited {
+		addReference(inherited.Reference, inherited.Source)
+	}
+
 	for _, ref := range project.References {
 		if strings.TrimSpace(ref.Include) == "" {
 			continue
@@ -394,7 +665,7 @@ func (mp *LibrariesProcessor) parseCsProj(content string, repoName string, path
 			Type:     "Library",
 			Category: "",
 			Properties: map[string]interface{}{
-				"Language": "C#",
+				"Language": language,
 				"Version":  version,
 			},
 			Path:     path,
@@ -403,6 +674,8 @@ func (mp *LibrariesProcessor) parseCsProj(content string, repoName string, path
 		matches = append(matches, match)
 	}
 
+	matches = append(matches, dotnetRuntimeFindings(model.targetFrameworks(), language, repoName, path)...)
+
 	return matches, nil
 }
 
@@ -0,0 +1,567 @@
+package processors
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+var (
+	podfilePodRe        = regexp.MustCompile(`^pod\s*\(?\s*['"]([^'"]+)['"]\s*(.*)$`)
+	podfileTargetRe     = regexp.MustCompile(`^(?:abstract_)?target\s*\(?\s*['":]([^'"\s]+)['"]?`)
+	podfileBlockRe      = regexp.MustCompile(`\bdo(\s*\|[^|]*\|)?\s*$`)
+	podfilePlatformRe   = regexp.MustCompile(`^platform\s*:(\w+)(?:\s*,\s*['"]([^'"]+)['"])?`)
+	podfileStringRe     = regexp.MustCompile(`^['"]([^'"]*)['"]`)
+	podfileOptionRe     = regexp.MustCompile(`:(git|path|podspec|branch|tag|commit)\s*=>\s*['"]([^'"]+)['"]`)
+	podfileLockEntryRe  = regexp.MustCompile(`^- "?([^"(]+?)"?(?: \(([^)]*)\))?:?$`)
+	swiftToolsVersionRe = regexp.MustCompile(`swift-tools-version\s*:\s*([0-9.]+)`)
+	swiftLocationRe     = regexp.MustCompile(`\b(url|path|id)\s*:\s*"([^"]+)"`)
+	swiftRequirementRe  = regexp.MustCompile(`\b(from|exact|branch|revision)\s*:\s*"([^"]+)"`)
+	swiftExactRe        = regexp.MustCompile(`\.exact\s*\(\s*"([^"]+)"`)
+	swiftRangeRe        = regexp.MustCompile(`"([^"]+)"\s*(\.\.\.|\.\.<)\s*"([^"]+)"`)
+	swiftPlatformsRe    = regexp.MustCompile(`platforms\s*:\s*\[`)
+	swiftPlatformRe     = regexp.MustCompile(`\.(iOS|macOS|tvOS|watchOS|visionOS|macCatalyst|driverKit)\s*\(\s*(?:\.v(\d+(?:_\d+)*)|"([^"]+)")`)
+	cartfileEntryRe     = regexp.MustCompile(`^(github|git|binary)\s+"([^"]+)"\s+"([^"]+)"`)
+)
+
+// podfilePlatforms maps Podfile platform symbols to the name of the platform.
+var podfilePlatforms = map[string]string{
+	"ios":      "iOS",
+	"osx":      "macOS",
+	"macos":    "macOS",
+	"tvos":     "tvOS",
+	"watchos":  "watchOS",
+	"visionos": "visionOS",
+}
+
+func appleRuntimeFinding(platform string, version string, language string, repoName string, path string) core.Finding {
+	if version == "" {
+		version = "N/A"
+	}
+	return core.Finding{
+		Name:     platform,
+		Type:     "Runtime",
+		Category: "",
+		Properties: map[string]interface{}{
+			"Language": language,
+			"Version":  version,
+		},
+		Path:     path,
+		RepoName: repoName,
+	}
+}
+
+// podfilePod is a pod declared in a Podfile with the targets it belongs to.
+type podfilePod struct {
+	Name       string
+	Constraint string
+	Source     string
+	Targets    []string
+}
+
+func (p podfilePod) test() bool {
+	if len(p.Targets) == 0 {
+		return false
+	}
+	return strings.HasSuffix(p.Targets[len(p.Targets)-1], "Tests")
+}
+
+// parsePodfileDeclarations reads the pods and platform declared in a Podfile,
+// tracking the target blocks that enclose each pod.
+func parsePodfileDeclarations(content string) ([]podfilePod, [][2]string) {
+	var pods []podfilePod
+	var platforms [][2]string
+	var stack []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if podfileBlockRe.MatchString(line) {
+			target := ""
+			if match := podfileTargetRe.FindStringSubmatch(line); match != nil {
+				target = match[1]
+			}
+			stack = append(stack, target)
+			continue
+		}
+		if line == "end" {
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+		if match := podfilePlatformRe.FindStringSubmatch(line); match != nil {
+			platforms = append(platforms, [2]string{match[1], match[2]})
+			continue
+		}
+
+		match := podfilePodRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		pod := podfilePod{Name: match[1]}
+		rest := strings.TrimSpace(match[2])
+		var constraints []string
+		for strings.HasPrefix(rest, ",") {
+			rest = strings.TrimSpace(rest[1:])
+			constraint := podfileStringRe.FindStringSubmatch(rest)
+			if constraint == nil {
+				break
+			}
+			constraints = append(constraints, constraint[1])
+			rest = strings.TrimSpace(rest[len(constraint[0]):])
+		}
+		pod.Constraint = strings.Join(constraints, ", ")
+		var source []string
+		for _, option := range podfileOptionRe.FindAllStringSubmatch(rest, -1) {
+			switch option[1] {
+			case "git", "path", "podspec":
+				source = append([]string{option[2]}, source...)
+			default:
+				source = append(source, option[2])
+			}
+		}
+		pod.Source = strings.Join(source, "#")
+		for _, target := range stack {
+			if target != "" {
+				pod.Targets = append(pod.Targets, target)
+			}
+		}
+		pods = append(pods, pod)
+	}
+	return pods, platforms
+}
+
+func (mp *LibrariesProcessor) parsePodfile(content string, repoName string, path string) ([]core.Finding, error) {
+	pods, platforms := parsePodfileDeclarations(content)
+
+	var matches []core.Finding
+	for _, pod := range pods {
+		version := pod.Constraint
+		if version == "" {
+			version = "N/A"
+		}
+		scope := "prod"
+		if pod.test() {
+			scope = "dev"
+		}
+		properties := map[string]interface{}{
+			"Language": "Swift",
+			"Version":  version,
+			"Direct":   true,
+			"Scope":    scope,
+		}
+		if pod.Source != "" {
+			properties["Source"] = pod.Source
+		}
+		if len(pod.Targets) > 0 {
+			properties["Target"] = pod.Targets[len(pod.Targets)-1]
+		}
+		matches = append(matches, core.Finding{
+			Name:       pod.Name,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	for _, platform := range platforms {
+		name, ok := podfilePlatforms[platform[0]]
+		if !ok {
+			name = platform[0]
+		}
+		matches = append(matches, appleRuntimeFinding(name, platform[1], "Swift", repoName, path))
+	}
+	return matches, nil
+}
+
+func (mp *LibrariesProcessor) parsePodfileLock(content string, repoName string, path string) ([]core.Finding, error) {
+	graph := newLockfileGraph()
+	checksums := make(map[string]string)
+	sources := make(map[string]string)
+	var direct []string
+
+	section := ""
+	current := ""
+	external := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		trimmed := strings.TrimSpace(line)
+
+		if indent == 0 {
+			section = strings.TrimSuffix(strings.SplitN(trimmed, ":", 2)[0], ":")
+			continue
+		}
+
+		switch section {
+		case "PODS":
+			entry := podfileLockEntryRe.FindStringSubmatch(trimmed)
+			if entry == nil {
+				continue
+			}
+			switch indent {
+			case 2:
+				current = entry[1]
+				graph.Packages[current] = &lockedPackage{Name: current, Version: entry[2]}
+			case 4:
+				if pkg := graph.Packages[current]; pkg != nil {
+					pkg.Dependencies = append(pkg.Dependencies, entry[1])
+				}
+			}
+		case "DEPENDENCIES":
+			if entry := podfileLockEntryRe.FindStringSubmatch(trimmed); entry != nil {
+				direct = append(direct, entry[1])
+			}
+		case "EXTERNAL SOURCES", "CHECKOUT OPTIONS":
+			if indent == 2 {
+				external = strings.Trim(strings.TrimSuffix(trimmed, ":"), `"`)
+			} else if parts := strings.SplitN(trimmed, ": ", 2); len(parts) == 2 && sources[external] == "" {
+				sources[external] = strings.Trim(parts[1], `"`)
+			}
+		case "SPEC CHECKSUMS":
+			if parts := strings.SplitN(trimmed, ": ", 2); len(parts) == 2 {
+				checksums[strings.Trim(parts[0], `"`)] = parts[1]
+			}
+		}
+	}
+
+	// Checksums and external sources are recorded against the root pod, so
+	// subspecs such as "Firebase/Core" take them from "Firebase".
+	for _, pkg := range graph.Packages {
+		root := strings.SplitN(pkg.Name, "/", 2)[0]
+		pkg.Integrity = checksums[root]
+		pkg.Resolved = sources[root]
+	}
+
+	devPods := make(map[string]bool)
+	if podfile, err := os.ReadFile(filepath.Join(filepath.Dir(path), "Podfile")); err == nil {
+		pods, _ := parsePodfileDeclarations(string(podfile))
+		for _, pod := range pods {
+			if _, seen := devPods[pod.Name]; !seen || !pod.test() {
+				devPods[pod.Name] = pod.test()
+			}
+		}
+	}
+	for _, name := range direct {
+		graph.Roots = append(graph.Roots, lockfileRoot{Key: name, Dev: devPods[name]})
+	}
+
+	return graph.findings("Swift", repoName, path), nil
+}
+
+// swiftStripComments removes // and /* */ comments outside string literals.
+func swiftStripComments(content string) string {
+	var out strings.Builder
+	inString := false
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case inString:
+			if c == '\\' && i+1 < len(content) {
+				out.WriteByte(c)
+				i++
+				c = content[i]
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case strings.HasPrefix(content[i:], "//"):
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			if i < len(content) {
+				out.WriteByte('\n')
+			}
+			continue
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				return out.String()
+			}
+			i += end + 3
+			continue
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+// swiftCallArguments returns the text between the parentheses of every call
+// to fn, e.g. the arguments of each ".package(" in a package manifest.
+func swiftCallArguments(content string, fn string) []string {
+	var calls []string
+	for offset := 0; ; {
+		idx := strings.Index(content[offset:], fn+"(")
+		if idx < 0 {
+			return calls
+		}
+		start := offset + idx + len(fn) + 1
+		depth := 1
+		inString := false
+		end := start
+		for ; end < len(content) && depth > 0; end++ {
+			switch c := content[end]; {
+			case inString:
+				if c == '\\' {
+					end++
+				} else if c == '"' {
+					inString = false
+				}
+			case c == '"':
+				inString = true
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+			}
+		}
+		if depth > 0 {
+			return calls
+		}
+		calls = append(calls, content[start:end-1])
+		offset = end
+	}
+}
+
+// swiftPackageIdentity derives the SwiftPM identity of a package from its
+// location: the last path component, lowercased, without a ".git" suffix.
+func swiftPackageIdentity(location string) string {
+	location = strings.TrimSuffix(strings.TrimRight(location, "/"), ".git")
+	if idx := strings.LastIndexAny(location, "/:"); idx >= 0 {
+		location = location[idx+1:]
+	}
+	return strings.ToLower(location)
+}
+
+// swiftPackageDependency is a dependency declared in Package.swift.
+type swiftPackageDependency struct {
+	Identity string
+	Location string
+	Kind     string
+	Version  string
+	Ref      string
+}
+
+func parseSwiftPackageDependencies(content string) []swiftPackageDependency {
+	var deps []swiftPackageDependency
+	for _, args := range swiftCallArguments(content, ".package") {
+		location := swiftLocationRe.FindStringSubmatch(args)
+		if location == nil {
+			continue
+		}
+		dep := swiftPackageDependency{Location: location[2], Kind: location[1], Identity: swiftPackageIdentity(location[2])}
+		if dep.Kind == "id" {
+			// Registry identities are written "scope.name".
+			dep.Identity = strings.ToLower(location[2])
+		}
+		if rng := swiftRangeRe.FindStringSubmatch(args); rng != nil {
+			upper := "<"
+			if rng[2] == "..." {
+				upper = "<="
+			}
+			dep.Version = ">=" + rng[1] + ", " + upper + rng[3]
+		} else if req := swiftRequirementRe.FindStringSubmatch(args); req != nil {
+			switch req[1] {
+			case "from":
+				dep.Version = "^" + req[2]
+				if strings.Contains(args, "upToNextMinor") {
+					dep.Version = "~" + req[2]
+				}
+			case "exact":
+				dep.Version = req[2]
+			default:
+				dep.Ref = req[2]
+			}
+		} else if exact := swiftExactRe.FindStringSubmatch(args); exact != nil {
+			dep.Version = exact[1]
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+func (mp *LibrariesProcessor) parsePackageSwift(content string, repoName string, path string) ([]core.Finding, error) {
+	var matches []core.Finding
+	code := swiftStripComments(content)
+	for _, dep := range parseSwiftPackageDependencies(code) {
+		version := dep.Version
+		if version == "" {
+			version = "N/A"
+		}
+		properties := map[string]interface{}{
+			"Language": "Swift",
+			"Version":  version,
+			"Direct":   true,
+		}
+		source := dep.Location
+		if dep.Ref != "" {
+			source += "#" + dep.Ref
+		}
+		switch dep.Kind {
+		case "url":
+			properties["URL"] = source
+		case "path":
+			properties["Source"] = "local"
+			properties["Resolved"] = dep.Location
+		case "id":
+			properties["Source"] = "registry"
+		}
+		matches = append(matches, core.Finding{
+			Name:       dep.Identity,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+
+	// The tools version is a comment on the first line, so it is read from
+	// the original content.
+	if match := swiftToolsVersionRe.FindStringSubmatch(content); match != nil {
+		matches = append(matches, appleRuntimeFinding("Swift", match[1], "Swift", repoName, path))
+	}
+	for _, match := range swiftPlatformRe.FindAllStringSubmatch(swiftPlatformsSection(code), -1) {
+		version := match[3]
+		if match[2] != "" {
+			version = strings.ReplaceAll(match[2], "_", ".")
+		}
+		matches = append(matches, appleRuntimeFinding(match[1], version, "Swift", repoName, path))
+	}
+	return matches, nil
+}
+
+// swiftPlatformsSection returns the "platforms: [...]" argument of a package manifest.
+func swiftPlatformsSection(code string) string {
+	idx := swiftPlatformsRe.FindStringIndex(code)
+	if idx == nil {
+		return ""
+	}
+	end := strings.Index(code[idx[1]:], "]")
+	if end < 0 {
+		return code[idx[1]:]
+	}
+	return code[idx[1] : idx[1]+end]
+}
+
+func (mp *LibrariesProcessor) parsePackageResolved(content string, repoName string, path string) ([]core.Finding, error) {
+	type swiftPinState struct {
+		Branch   *string `json:"branch"`
+		Revision string  `json:"revision"`
+		Version  *string `json:"version"`
+	}
+
+	type swiftPin struct {
+		// Version 1 files
+		Package       string `json:"package"`
+		RepositoryURL string `json:"repositoryURL"`
+		// Version 2 and 3 files
+		Identity string        `json:"identity"`
+		Kind     string        `json:"kind"`
+		Location string        `json:"location"`
+		State    swiftPinState `json:"state"`
+	}
+
+	type packageResolved struct {
+		Version int        `json:"version"`
+		Pins    []swiftPin `json:"pins"`
+		Object  struct {
+			Pins []swiftPin `json:"pins"`
+		} `json:"object"`
+	}
+
+	var resolved packageResolved
+	if err := json.Unmarshal([]byte(content), &resolved); err != nil {
+		return nil, err
+	}
+	pins := resolved.Pins
+	if resolved.Version == 1 || len(pins) == 0 {
+		pins = append(pins, resolved.Object.Pins...)
+	}
+
+	// Package.resolved does not say which packages the manifest asked for,
+	// so the Package.swift beside it is used when present.
+	var declared map[string]bool
+	if manifest, err := os.ReadFile(filepath.Join(filepath.Dir(path), "Package.swift")); err == nil {
+		declared = make(map[string]bool)
+		for _, dep := range parseSwiftPackageDependencies(swiftStripComments(string(manifest))) {
+			declared[dep.Identity] = true
+		}
+	}
+
+	var matches []core.Finding
+	for _, pin := range pins {
+		location := pin.Location
+		if location == "" {
+			location = pin.RepositoryURL
+		}
+		identity := pin.Identity
+		if identity == "" {
+			identity = swiftPackageIdentity(location)
+		}
+		version := pin.State.Revision
+		if pin.State.Version != nil {
+			version = *pin.State.Version
+		} else if pin.State.Branch != nil {
+			version = *pin.State.Branch
+		}
+		properties := map[string]interface{}{
+			"Language": "Swift",
+			"Version":  version,
+			"Resolved": location,
+		}
+		if pin.State.Revision != "" {
+			properties["Integrity"] = pin.State.Revision
+		}
+		if declared != nil {
+			properties["Direct"] = declared[identity]
+		}
+		matches = append(matches, core.Finding{
+			Name:       identity,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	return matches, nil
+}
+
+func (mp *LibrariesProcessor) parseCartfileResolved(content string, repoName string, path string) ([]core.Finding, error) {
+	var matches []core.Finding
+	for _, line := range strings.Split(content, "\n") {
+		match := cartfileEntryRe.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		matches = append(matches, core.Finding{
+			Name:     match[2],
+			Type:     "Library",
+			Category: "",
+			Properties: map[string]interface{}{
+				"Language": "Swift",
+				"Version":  match[3],
+				"Source":   match[1],
+			},
+			Path:     path,
+			RepoName: repoName,
+		})
+	}
+	return matches, nil
+}
@@ -0,0 +1,192 @@
+package processors
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePodfileAndLock(t *testing.T) {
+	dir := t.TempDir()
+	podfile := `platform :ios, '13.0'
+use_frameworks!
+
+target 'App' do
+  pod 'Alamofire', '~> 5.8'
+  pod 'Firebase/Core'
+  pod 'MyKit', :path => '../MyKit'
+
+  target 'AppTests' do
+    inherit! :search_paths
+    pod 'Quick', '~> 7.0'
+  end
+end
+
+post_install do |installer|
+  installer.pods_project.targets.each do |target|
+    puts target.name
+  end
+end
+`
+	writeTestFile(t, filepath.Join(dir, "Podfile"), podfile)
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "Podfile"), "repo", podfile)
+	require.NoError(t, err)
+
+	libraries := findingsOfType(findings, "Library")
+	require.Len(t, libraries, 4)
+	assert.Equal(t, "~> 5.8", libraries["Alamofire"].Properties["Version"])
+	assert.Equal(t, "App", libraries["Alamofire"].Properties["Target"])
+	assert.Equal(t, "../MyKit", libraries["MyKit"].Properties["Source"])
+	assert.Equal(t, "dev", libraries["Quick"].Properties["Scope"])
+	assert.Equal(t, "AppTests", libraries["Quick"].Properties["Target"])
+	assert.Equal(t, "13.0", findingsOfType(findings, "Runtime")["iOS"].Properties["Version"])
+
+	lock := `PODS:
+  - Alamofire (5.8.1)
+  - Firebase/Core (10.18.0):
+    - Firebase/CoreOnly
+    - FirebaseAnalytics (~> 10.18.0)
+  - Firebase/CoreOnly (10.18.0)
+  - FirebaseAnalytics (10.18.0)
+  - MyKit (1.0.0)
+  - Nimble (13.0.0)
+  - Quick (7.3.0):
+    - Nimble
+
+DEPENDENCIES:
+  - Alamofire (~> 5.8)
+  - Firebase/Core
+  - MyKit (from ` + "`../MyKit`" + `)
+  - Quick (~> 7.0)
+
+EXTERNAL SOURCES:
+  MyKit:
+    :path: "../MyKit"
+
+SPEC CHECKSUMS:
+  Alamofire: sum-alamofire
+  Firebase: sum-firebase
+
+PODFILE CHECKSUM: abc
+
+COCOAPODS: 1.14.3
+`
+	findings, err = processor.Process(filepath.Join(dir, "Podfile.lock"), "repo", lock)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 7)
+	assert.Equal(t, "5.8.1", byName["Alamofire"].Properties["Version"])
+	assert.Equal(t, true, byName["Alamofire"].Properties["Direct"])
+	assert.Equal(t, "sum-alamofire", byName["Alamofire"].Properties["Integrity"])
+	assert.Equal(t, "sum-firebase", byName["Firebase/Core"].Properties["Integrity"])
+	assert.Equal(t, false, byName["FirebaseAnalytics"].Properties["Direct"])
+	assert.Equal(t, "../MyKit", byName["MyKit"].Properties["Resolved"])
+	assert.Equal(t, "dev", byName["Quick"].Properties["Scope"])
+	assert.Equal(t, "dev", byName["Nimble"].Properties["Scope"])
+	assert.Equal(t, "prod", byName["FirebaseAnalytics"].Properties["Scope"])
+}
+
+func TestParsePackageSwiftAndResolved(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "App",
+    platforms: [.iOS(.v15), .macOS("12.0")],
+    dependencies: [
+        // https://github.com/commented/out.git
+        .package(url: "https://github.com/apple/swift-argument-parser.git", from: "1.2.0"),
+        .package(url: "https://github.com/Alamofire/Alamofire.git", .upToNextMinor(from: "5.8.0")),
+        .package(url: "https://github.com/pointfreeco/swift-snapshot-testing", "1.10.0"..<"2.0.0"),
+        .package(url: "https://github.com/org/Fork.git", branch: "main"),
+        .package(path: "../LocalKit"),
+    ],
+    targets: [.executableTarget(name: "App")]
+)
+`
+	writeTestFile(t, filepath.Join(dir, "Package.swift"), manifest)
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "Package.swift"), "repo", manifest)
+	require.NoError(t, err)
+
+	libraries := findingsOfType(findings, "Library")
+	require.Len(t, libraries, 5)
+	assert.Equal(t, "^1.2.0", libraries["swift-argument-parser"].Properties["Version"])
+	assert.Equal(t, "~5.8.0", libraries["alamofire"].Properties["Version"])
+	assert.Equal(t, ">=1.10.0, <2.0.0", libraries["swift-snapshot-testing"].Properties["Version"])
+	assert.Equal(t, "https://github.com/org/Fork.git#main", libraries["fork"].Properties["URL"])
+	assert.Equal(t, "local", libraries["localkit"].Properties["Source"])
+
+	runtimes := findingsOfType(findings, "Runtime")
+	require.Len(t, runtimes, 3)
+	assert.Equal(t, "5.9", runtimes["Swift"].Properties["Version"])
+	assert.Equal(t, "15", runtimes["iOS"].Properties["Version"])
+	assert.Equal(t, "12.0", runtimes["macOS"].Properties["Version"])
+
+	resolved := `{
+  "pins" : [
+    {
+      "identity" : "alamofire",
+      "kind" : "remoteSourceControl",
+      "location" : "https://github.com/Alamofire/Alamofire.git",
+      "state" : {"revision" : "3dc6a42", "version" : "5.8.1"}
+    },
+    {
+      "identity" : "swift-collections",
+      "kind" : "remoteSourceControl",
+      "location" : "https://github.com/apple/swift-collections.git",
+      "state" : {"revision" : "94cf62b", "version" : "1.0.5"}
+    }
+  ],
+  "version" : 2
+}`
+	findings, err = processor.Process(filepath.Join(dir, "Package.resolved"), "repo", resolved)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 2)
+	assert.Equal(t, "5.8.1", byName["alamofire"].Properties["Version"])
+	assert.Equal(t, true, byName["alamofire"].Properties["Direct"])
+	assert.Equal(t, "3dc6a42", byName["alamofire"].Properties["Integrity"])
+	assert.Equal(t, false, byName["swift-collections"].Properties["Direct"])
+
+	legacy := `{
+  "object": {
+    "pins": [
+      {
+        "package": "Kingfisher",
+        "repositoryURL": "https://github.com/onevcat/Kingfisher.git",
+        "state": {"branch": null, "revision": "abc", "version": "7.10.0"}
+      }
+    ]
+  },
+  "version": 1
+}`
+	findings, err = processor.Process(filepath.Join(t.TempDir(), "Package.resolved"), "repo", legacy)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "kingfisher", findings[0].Name)
+	assert.Equal(t, "7.10.0", findings[0].Properties["Version"])
+	assert.NotContains(t, findings[0].Properties, "Direct")
+}
+
+func TestParseCartfileResolved(t *testing.T) {
+	content := `binary "https://dl.google.com/firebase.json" "10.18.0"
+github "ReactiveX/RxSwift" "6.6.0"
+git "https://gitlab.com/org/kit.git" "a1b2c3"
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process("Cartfile.resolved", "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 3)
+	assert.Equal(t, "6.6.0", byName["ReactiveX/RxSwift"].Properties["Version"])
+	assert.Equal(t, "github", byName["ReactiveX/RxSwift"].Properties["Source"])
+	assert.Equal(t, "binary", byName["https://dl.google.com/firebase.json"].Properties["Source"])
+}
@@ -0,0 +1,197 @@
+package processors
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// pubspecManifest is the subset of pubspec.yaml used to report dependencies.
+type pubspecManifest struct {
+	Environment         map[string]string      `yaml:"environment"`
+	Dependencies        map[string]interface{} `yaml:"dependencies"`
+	DevDependencies     map[string]interface{} `yaml:"dev_dependencies"`
+	DependencyOverrides map[string]interface{} `yaml:"dependency_overrides"`
+}
+
+// pubspecSDKRuntime names the runtime behind an environment or sdks entry.
+func pubspecSDKRuntime(sdk string) string {
+	switch sdk {
+	case "sdk", "dart":
+		return "Dart"
+	case "flutter":
+		return "Flutter"
+	default:
+		return sdk
+	}
+}
+
+func pubspecRuntimeFindings(sdks map[string]string, repoName string, path string) []core.Finding {
+	var matches []core.Finding
+	for _, sdk := range sortedKeys(sdks) {
+		matches = append(matches, core.Finding{
+			Name:     pubspecSDKRuntime(sdk),
+			Type:     "Runtime",
+			Category: "",
+			Properties: map[string]interface{}{
+				"Language": "Dart",
+				"Version":  sdks[sdk],
+			},
+			Path:     path,
+			RepoName: repoName,
+		})
+	}
+	return matches
+}
+
+// parsePubspecDependency reads a dependency that is either a version
+// constraint or a map describing a git, path, hosted or SDK source.
+func parsePubspecDependency(value interface{}) (version string, source string, location string) {
+	switch v := value.(type) {
+	case string:
+		return v, "", ""
+	case map[string]interface{}:
+		if constraint, ok := v["version"].(string); ok {
+			version = constraint
+		}
+		switch {
+		case v["sdk"] != nil:
+			return version, "sdk", fmt.Sprint(v["sdk"])
+		case v["path"] != nil:
+			return version, "path", fmt.Sprint(v["path"])
+		case v["git"] != nil:
+			switch git := v["git"].(type) {
+			case string:
+				location = git
+			case map[string]interface{}:
+				location = fmt.Sprint(git["url"])
+				if ref, ok := git["ref"].(string); ok {
+					location += "#" + ref
+				}
+			}
+			return version, "git", location
+		case v["hosted"] != nil:
+			switch hosted := v["hosted"].(type) {
+			case string:
+				location = hosted
+			case map[string]interface{}:
+				location = fmt.Sprint(hosted["url"])
+			}
+			return version, "hosted", location
+		}
+	}
+	return version, "", ""
+}
+
+func (mp *LibrariesProcessor) parsePubspecYAML(content string, repoName string, path string) ([]core.Finding, error) {
+	var manifest pubspecManifest
+	if err := yaml.Unmarshal([]byte(content), &manifest); err != nil {
+		return nil, err
+	}
+
+	var matches []core.Finding
+	add := func(deps map[string]interface{}, scope string, overridden bool) {
+		for _, name := range sortedKeys(deps) {
+			version, source, location := parsePubspecDependency(deps[name])
+			if version == "" {
+				version = "N/A"
+			}
+			properties := map[string]interface{}{
+				"Language": "Dart",
+				"Version":  version,
+				"Direct":   true,
+				"Scope":    scope,
+			}
+			if source != "" {
+				properties["Source"] = source
+			}
+			if location != "" {
+				properties["Resolved"] = location
+			}
+			if overridden {
+				properties["Overridden"] = true
+			}
+			matches = append(matches, core.Finding{
+				Name:       name,
+				Type:       "Library",
+				Category:   "",
+				Properties: properties,
+				Path:       path,
+				RepoName:   repoName,
+			})
+		}
+	}
+	add(manifest.Dependencies, "prod", false)
+	add(manifest.DevDependencies, "dev", false)
+	add(manifest.DependencyOverrides, "prod", true)
+
+	return append(matches, pubspecRuntimeFindings(manifest.Environment, repoName, path)...), nil
+}
+
+func (mp *LibrariesProcessor) parsePubspecLock(content string, repoName string, path string) ([]core.Finding, error) {
+	type pubspecLockedPackage struct {
+		Dependency  string      `yaml:"dependency"`
+		Description interface{} `yaml:"description"`
+		Source      string      `yaml:"source"`
+		Version     string      `yaml:"version"`
+	}
+
+	type pubspecLock struct {
+		Packages map[string]pubspecLockedPackage `yaml:"packages"`
+		SDKs     map[string]string               `yaml:"sdks"`
+	}
+
+	var lock pubspecLock
+	if err := yaml.Unmarshal([]byte(content), &lock); err != nil {
+		return nil, err
+	}
+
+	// pubspec.lock records how each package is depended upon but not the
+	// edges between packages, so transitive packages have no scope.
+	var matches []core.Finding
+	for _, name := range sortedKeys(lock.Packages) {
+		pkg := lock.Packages[name]
+		properties := map[string]interface{}{
+			"Language": "Dart",
+			"Version":  pkg.Version,
+			"Direct":   strings.HasPrefix(pkg.Dependency, "direct"),
+		}
+		switch pkg.Dependency {
+		case "direct main":
+			properties["Scope"] = "prod"
+		case "direct dev":
+			properties["Scope"] = "dev"
+		case "direct overridden":
+			properties["Overridden"] = true
+		}
+		if pkg.Source != "" {
+			properties["Source"] = pkg.Source
+		}
+		if description, ok := pkg.Description.(map[string]interface{}); ok {
+			if sha, ok := description["sha256"].(string); ok {
+				properties["Integrity"] = sha
+			}
+			if url, ok := description["url"].(string); ok {
+				properties["Resolved"] = url
+				if ref, ok := description["resolved-ref"].(string); ok {
+					properties["Resolved"] = url + "#" + ref
+				}
+			} else if local, ok := description["path"].(string); ok {
+				properties["Resolved"] = local
+			}
+		}
+		matches = append(matches, core.Finding{
+			Name:       name,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+
+	return append(matches, pubspecRuntimeFindings(lock.SDKs, repoName, path)...), nil
+}
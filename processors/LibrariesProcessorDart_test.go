@@ -0,0 +1,107 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePubspecYAML(t *testing.T) {
+	content := `name: app
+environment:
+  sdk: ">=3.0.0 <4.0.0"
+  flutter: ">=3.10.0"
+
+dependencies:
+  flutter:
+    sdk: flutter
+  http: ^1.1.0
+  shared:
+    path: ../shared
+  forked:
+    git:
+      url: https://github.com/org/forked.git
+      ref: main
+  cupertino_icons:
+
+dev_dependencies:
+  flutter_test:
+    sdk: flutter
+  lints: ^3.0.0
+
+dependency_overrides:
+  meta: 1.10.0
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process("app/pubspec.yaml", "repo", content)
+	require.NoError(t, err)
+
+	libraries := findingsOfType(findings, "Library")
+	require.Len(t, libraries, 8)
+	assert.Equal(t, "^1.1.0", libraries["http"].Properties["Version"])
+	assert.Equal(t, "sdk", libraries["flutter"].Properties["Source"])
+	assert.Equal(t, "../shared", libraries["shared"].Properties["Resolved"])
+	assert.Equal(t, "https://github.com/org/forked.git#main", libraries["forked"].Properties["Resolved"])
+	assert.Equal(t, "N/A", libraries["cupertino_icons"].Properties["Version"])
+	assert.Equal(t, "dev", libraries["lints"].Properties["Scope"])
+	assert.Equal(t, true, libraries["meta"].Properties["Overridden"])
+
+	runtimes := findingsOfType(findings, "Runtime")
+	require.Len(t, runtimes, 2)
+	assert.Equal(t, ">=3.0.0 <4.0.0", runtimes["Dart"].Properties["Version"])
+	assert.Equal(t, ">=3.10.0", runtimes["Flutter"].Properties["Version"])
+}
+
+func TestParsePubspecLock(t *testing.T) {
+	content := `packages:
+  http:
+    dependency: "direct main"
+    description:
+      name: http
+      sha256: "abc123"
+      url: "https://pub.dev"
+    source: hosted
+    version: "1.1.0"
+  lints:
+    dependency: "direct dev"
+    description:
+      name: lints
+      sha256: "def456"
+      url: "https://pub.dev"
+    source: hosted
+    version: "3.0.0"
+  http_parser:
+    dependency: transitive
+    description:
+      name: http_parser
+      sha256: "789"
+      url: "https://pub.dev"
+    source: hosted
+    version: "4.0.2"
+  flutter:
+    dependency: "direct main"
+    description: flutter
+    source: sdk
+    version: "0.0.0"
+sdks:
+  dart: ">=3.0.0 <4.0.0"
+  flutter: ">=3.10.0"
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process("app/pubspec.lock", "repo", content)
+	require.NoError(t, err)
+
+	libraries := findingsOfType(findings, "Library")
+	require.Len(t, libraries, 4)
+	assert.Equal(t, true, libraries["http"].Properties["Direct"])
+	assert.Equal(t, "prod", libraries["http"].Properties["Scope"])
+	assert.Equal(t, "abc123", libraries["http"].Properties["Integrity"])
+	assert.Equal(t, "dev", libraries["lints"].Properties["Scope"])
+	assert.Equal(t, false, libraries["http_parser"].Properties["Direct"])
+	assert.NotContains(t, libraries["http_parser"].Properties, "Scope")
+	assert.Equal(t, "sdk", libraries["flutter"].Properties["Source"])
+	assert.Len(t, findingsOfType(findings, "Runtime"), 2)
+}
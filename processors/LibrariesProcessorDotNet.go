@@ -0,0 +1,602 @@
+package processors
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+var (
+	msbuildPropertyRe = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_.-]*)\)`)
+	dotnetFrameworkRe = regexp.MustCompile(`^net(\d+)$`)
+	dotnetVersionRe   = regexp.MustCompile(`^(net|netcoreapp|netstandard)(\d+(?:\.\d+)*)$`)
+	paketLockEntryRe  = regexp.MustCompile(`^(\S+)(?: \(([^)]*)\))?`)
+)
+
+// maxMSBuildImportDepth bounds how far Directory.*.props files are followed up
+// the directory tree.
+const maxMSBuildImportDepth = 10
+
+type msbuildPackageReference struct {
+	Include         string `xml:"Include,attr"`
+	Version         string `xml:"Version,attr"`
+	VersionElement  string `xml:"Version"`
+	VersionOverride string `xml:"VersionOverride,attr"`
+	PrivateAssets   string `xml:"PrivateAssets,attr"`
+}
+
+func (r msbuildPackageReference) version() string {
+	if r.VersionOverride != "" {
+		return r.VersionOverride
+	}
+	if r.Version != "" {
+		return r.Version
+	}
+	return strings.TrimSpace(r.VersionElement)
+}
+
+// msbuildProject is the subset of an MSBuild project, Directory.Build.props or
+// Directory.Packages.props file used to report dependencies.
+type msbuildProject struct {
+	XMLName        xml.Name `xml:"Project"`
+	PropertyGroups []struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"PropertyGroup"`
+	PackageReferences       []msbuildPackageReference `xml:"ItemGroup>PackageReference"`
+	GlobalPackageReferences []msbuildPackageReference `xml:"ItemGroup>GlobalPackageReference"`
+	PackageVersions         []msbuildPackageReference `xml:"ItemGroup>PackageVersion"`
+	References              []struct {
+		Include string `xml:"Include,attr"`
+		Version string `xml:"Version,attr,omitempty"`
+	} `xml:"ItemGroup>Reference"`
+	Imports []struct {
+		Project string `xml:"Project,attr"`
+	} `xml:"Import"`
+}
+
+func readMSBuildProject(path string) (msbuildProject, bool) {
+	var project msbuildProject
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return project, false
+	}
+	if err := xml.Unmarshal(content, &project); err != nil {
+		return project, false
+	}
+	return project, true
+}
+
+// importsAbove reports whether project imports the next file of the same name
+// further up the tree, as in
+// <Import Project="$([MSBuild]::GetPathOfFileAbove('Directory.Build.props', '$(MSBuildThisFileDirectory)../'))" />.
+func (p msbuildProject) importsAbove(name string) bool {
+	for _, imp := range p.Imports {
+		if strings.Contains(imp.Project, "GetPathOfFileAbove") && strings.Contains(imp.Project, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// msbuildFile is a Directory.*.props file together with the path it was read from.
+type msbuildFile struct {
+	Path    string
+	Project msbuildProject
+}
+
+// findMSBuildFiles returns the nearest file called name in dir or above it,
+// followed by any files further up that it explicitly imports, outermost first.
+// Directories above the root of the repository are not searched.
+func findMSBuildFiles(dir string, name string) []msbuildFile {
+	var files []msbuildFile
+	start := dir
+	for i := 0; i < maxMSBuildImportDepth && withinRepository(start, dir); i++ {
+		candidate := filepath.Join(dir, name)
+		if project, ok := readMSBuildProject(candidate); ok {
+			files = append([]msbuildFile{{Path: candidate, Project: project}}, files...)
+			if !project.importsAbove(name) {
+				break
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return files
+}
+
+// msbuildModel is a project with the properties, central package versions and
+// inherited package references of the Directory.*.props files that apply to it.
+type msbuildModel struct {
+	Properties map[string]string
+	Central    map[string]string
+	Inherited  []msbuildInheritedReference
+}
+
+type msbuildInheritedReference struct {
+	Reference msbuildPackageReference
+	Source    string
+}
+
+func newMSBuildModel(project msbuildProject, path string) *msbuildModel {
+	model := &msbuildModel{
+		Properties: make(map[string]string),
+		Central:    make(map[string]string),
+	}
+	dir := filepath.Dir(path)
+	for _, file := range findMSBuildFiles(dir, "Directory.Build.props") {
+		model.addProperties(file.Project)
+		for _, ref := range file.Project.PackageReferences {
+			model.Inherited = append(model.Inherited, msbuildInheritedReference{Reference: ref, Source: "Directory.Build.props"})
+		}
+	}
+	model.addProperties(project)
+	for _, file := range findMSBuildFiles(dir, "Directory.Packages.props") {
+		model.addProperties(file.Project)
+		for _, version := range file.Project.PackageVersions {
+			model.Central[strings.ToLower(version.Include)] = version.version()
+		}
+		for _, ref := range file.Project.GlobalPackageReferences {
+			model.Inherited = append(model.Inherited, msbuildInheritedReference{Reference: ref, Source: "Directory.Packages.props"})
+		}
+	}
+	return model
+}
+
+// addProperties merges the properties a file defines. Later definitions win,
+// and conditions are not evaluated.
+func (m *msbuildModel) addProperties(project msbuildProject) {
+	for _, group := range project.PropertyGroups {
+		for _, entry := range group.Entries {
+			m.Properties[strings.ToLower(entry.XMLName.Local)] = strings.TrimSpace(entry.Value)
+		}
+	}
+}
+
+// interpolate replaces $(Property) references, leaving unknown ones intact.
+func (m *msbuildModel) interpolate(value string) string {
+	for i := 0; i < maxMSBuildImportDepth && strings.Contains(value, "$("); i++ {
+		replaced := msbuildPropertyRe.ReplaceAllStringFunc(value, func(ref string) string {
+			if resolved, ok := m.Properties[strings.ToLower(ref[2:len(ref)-1])]; ok {
+				return resolved
+			}
+			return ref
+		})
+		if replaced == value {
+			break
+		}
+		value = replaced
+	}
+	return strings.TrimSpace(value)
+}
+
+// targetFrameworks returns the target framework monikers the project builds for.
+func (m *msbuildModel) targetFrameworks() []string {
+	value := m.interpolate(m.Properties["targetframeworks"])
+	if value == "" {
+		value = m.interpolate(m.Properties["targetframework"])
+	}
+	var frameworks []string
+	for _, tfm := range strings.Split(value, ";") {
+		if tfm = strings.TrimSpace(tfm); tfm != "" && !strings.Contains(tfm, "$(") {
+			frameworks = append(frameworks, tfm)
+		}
+	}
+	// Classic projects use <TargetFrameworkVersion>v4.7.2</TargetFrameworkVersion>.
+	if version := m.interpolate(m.Properties["targetframeworkversion"]); len(frameworks) == 0 && version != "" {
+		frameworks = append(frameworks, version)
+	}
+	return frameworks
+}
+
+// dotnetLanguage returns the language of an MSBuild project from its extension.
+func dotnetLanguage(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".fsproj":
+		return "F#"
+	case ".vbproj":
+		return "VB.NET"
+	default:
+		return "C#"
+	}
+}
+
+// dotnetDirectoryLanguage guesses the language of the project that a
+// packages.config, packages.lock.json or paket file belongs to from the
+// project files beside it.
+func dotnetDirectoryLanguage(path string) string {
+	for _, pattern := range []string{"*.fsproj", "*.vbproj"} {
+		if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), pattern)); len(matches) > 0 {
+			return dotnetLanguage(matches[0])
+		}
+	}
+	return "C#"
+}
+
+// dotnetRuntime maps a target framework moniker such as "net48", "net8.0",
+// "netcoreapp3.1", "netstandard2.0" or "v4.7.2" to the runtime it targets.
+func dotnetRuntime(tfm string) (name string, version string, platform string, ok bool) {
+	moniker := strings.ToLower(tfm)
+	if idx := strings.Index(moniker, "-"); idx >= 0 {
+		moniker, platform = moniker[:idx], moniker[idx+1:]
+	}
+
+	if strings.HasPrefix(moniker, "v") {
+		return ".NET Framework", moniker[1:], platform, true
+	}
+	if match := dotnetFrameworkRe.FindStringSubmatch(moniker); match != nil {
+		// net48 and net472 spell the version without dots.
+		return ".NET Framework", strings.Join(strings.Split(match[1], ""), "."), platform, true
+	}
+	match := dotnetVersionRe.FindStringSubmatch(moniker)
+	if match == nil {
+		return "", "", "", false
+	}
+	switch match[1] {
+	case "netcoreapp":
+		return ".NET Core", match[2], platform, true
+	case "netstandard":
+		return ".NET Standard", match[2], platform, true
+	default:
+		return ".NET", match[2], platform, true
+	}
+}
+
+func dotnetRuntimeFindings(frameworks []string, language string, repoName string, path string) []core.Finding {
+	var matches []core.Finding
+	seen := make(map[string]bool)
+	for _, tfm := range frameworks {
+		name, version, platform, ok := dotnetRuntime(tfm)
+		if !ok || seen[tfm] {
+			continue
+		}
+		seen[tfm] = true
+		properties := map[string]interface{}{
+			"Language":        language,
+			"Version":         version,
+			"TargetFramework": tfm,
+		}
+		if platform != "" {
+			properties["Platform"] = platform
+		}
+		matches = append(matches, core.Finding{
+			Name:       name,
+			Type:       "Runtime",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	return matches
+}
+
+func (mp *LibrariesProcessor) parsePackagesConfig(content string, repoName string, path string) ([]core.Finding, error) {
+	type packagesConfig struct {
+		XMLName  xml.Name `xml:"packages"`
+		Packages []struct {
+			ID                    string `xml:"id,attr"`
+			Version               string `xml:"version,attr"`
+			TargetFramework       string `xml:"targetFramework,attr"`
+			DevelopmentDependency bool   `xml:"developmentDependency,attr"`
+		} `xml:"package"`
+	}
+
+	var config packagesConfig
+	if err := xml.Unmarshal([]byte(content), &config); err != nil {
+		return nil, err
+	}
+
+	language := dotnetDirectoryLanguage(path)
+	var matches []core.Finding
+	var frameworks []string
+	for _, pkg := range config.Packages {
+		if strings.TrimSpace(pkg.ID) == "" {
+			continue
+		}
+		version := pkg.Version
+		if version == "" {
+			version = "N/A"
+		}
+		scope := "prod"
+		if pkg.DevelopmentDependency {
+			scope = "dev"
+		}
+		properties := map[string]interface{}{
+			"Language": language,
+			"Version":  version,
+			"Direct":   true,
+			"Scope":    scope,
+		}
+		if pkg.TargetFramework != "" {
+			properties["TargetFramework"] = pkg.TargetFramework
+			frameworks = append(frameworks, pkg.TargetFramework)
+		}
+		matches = append(matches, core.Finding{
+			Name:       pkg.ID,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+
+	return append(matches, dotnetRuntimeFindings(frameworks, language, repoName, path)...), nil
+}
+
+func (mp *LibrariesProcessor) parseNuGetLock(content string, repoName string, path string) ([]core.Finding, error) {
+	type nugetLockedPackage struct {
+		Type         string            `json:"type"`
+		Resolved     string            `json:"resolved"`
+		ContentHash  string            `json:"contentHash"`
+		Dependencies map[string]string `json:"dependencies"`
+	}
+
+	type nugetLock struct {
+		Version      int                                      `json:"version"`
+		Dependencies map[string]map[string]nugetLockedPackage `json:"dependencies"`
+	}
+
+	var lock nugetLock
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil, err
+	}
+
+	// Each target framework is resolved separately, so packages are keyed by
+	// version and dependencies are looked up within the same framework.
+	graph := newLockfileGraph()
+	for _, tfm := range sortedKeys(lock.Dependencies) {
+		packages := lock.Dependencies[tfm]
+		resolved := make(map[string]string)
+		for name, pkg := range packages {
+			resolved[strings.ToLower(name)] = strings.ToLower(name) + "@" + pkg.Resolved
+		}
+		for _, name := range sortedKeys(packages) {
+			pkg := packages[name]
+			// Project references are other projects in the same solution.
+			if pkg.Type == "Project" {
+				continue
+			}
+			key := resolved[strings.ToLower(name)]
+			if graph.Packages[key] == nil {
+				graph.Packages[key] = &lockedPackage{
+					Name:      name,
+					Version:   pkg.Resolved,
+					Integrity: pkg.ContentHash,
+				}
+				for _, dep := range sortedKeys(pkg.Dependencies) {
+					if depKey, ok := resolved[strings.ToLower(dep)]; ok {
+						graph.Packages[key].Dependencies = append(graph.Packages[key].Dependencies, depKey)
+					}
+				}
+			}
+			if pkg.Type == "Direct" {
+				graph.Roots = append(graph.Roots, lockfileRoot{Key: key})
+			}
+		}
+	}
+
+	language := dotnetDirectoryLanguage(path)
+	return append(graph.findings(language, repoName, path), dotnetRuntimeFindings(sortedKeys(lock.Dependencies), language, repoName, path)...), nil
+}
+
+// paketScope returns the scope of the packages in a paket group.
+func paketScope(group string) string {
+	switch strings.ToLower(group) {
+	case "build":
+		return "build"
+	case "test", "tests", "dev", "development":
+		return "dev"
+	default:
+		return "prod"
+	}
+}
+
+// paketDependency is a package declared in a paket.dependencies file.
+type paketDependency struct {
+	Name    string
+	Version string
+	Group   string
+	Source  string
+}
+
+func parsePaketDependencies(content string) []paketDependency {
+	var deps []paketDependency
+	group := "Main"
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if idx := strings.Index(line, "//"); idx >= 0 && !strings.Contains(line[:idx], ":") {
+			line = strings.TrimSpace(line[:idx])
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch strings.ToLower(fields[0]) {
+		case "group":
+			group = fields[1]
+		case "nuget":
+			dep := paketDependency{Name: fields[1], Group: group}
+			var constraint []string
+			for _, field := range fields[2:] {
+				// Options such as "framework: net8.0" or "redirects: on"
+				// follow the version constraint.
+				if strings.HasSuffix(field, ":") {
+					break
+				}
+				constraint = append(constraint, field)
+			}
+			dep.Version = strings.Join(constraint, " ")
+			deps = append(deps, dep)
+		case "github", "git", "http", "gist":
+			dep := paketDependency{Name: fields[1], Group: group, Source: strings.ToLower(fields[0])}
+			if idx := strings.LastIndex(dep.Name, ":"); idx >= 0 && fields[0] != "http" && fields[0] != "git" {
+				dep.Name, dep.Version = dep.Name[:idx], dep.Name[idx+1:]
+			}
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+func (mp *LibrariesProcessor) parsePaketDependencies(content string, repoName string, path string) ([]core.Finding, error) {
+	language := dotnetDirectoryLanguage(path)
+	var matches []core.Finding
+	for _, dep := range parsePaketDependencies(content) {
+		version := dep.Version
+		if version == "" {
+			version = "N/A"
+		}
+		properties := map[string]interface{}{
+			"Language": language,
+			"Version":  version,
+			"Direct":   true,
+			"Scope":    paketScope(dep.Group),
+		}
+		if dep.Group != "Main" {
+			properties["Group"] = dep.Group
+		}
+		if dep.Source != "" {
+			properties["Source"] = dep.Source
+		}
+		matches = append(matches, core.Finding{
+			Name:       dep.Name,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	return matches, nil
+}
+
+func (mp *LibrariesProcessor) parsePaketLock(content string, repoName string, path string) ([]core.Finding, error) {
+	graph := newLockfileGraph()
+	group := "Main"
+	section := ""
+	remote := ""
+	current := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		trimmed := strings.TrimSpace(line)
+
+		if indent == 0 {
+			if strings.HasPrefix(trimmed, "GROUP ") {
+				group = strings.TrimSpace(strings.TrimPrefix(trimmed, "GROUP "))
+			}
+			section = trimmed
+			continue
+		}
+		if section != "NUGET" && section != "GITHUB" && section != "GIT" && section != "HTTP" {
+			continue
+		}
+		if indent == 2 && strings.HasPrefix(trimmed, "remote:") {
+			remote = strings.TrimSpace(strings.TrimPrefix(trimmed, "remote:"))
+			continue
+		}
+		entry := paketLockEntryRe.FindStringSubmatch(trimmed)
+		if entry == nil {
+			continue
+		}
+		switch indent {
+		case 4:
+			dev := paketScope(group) != "prod"
+			if section == "NUGET" {
+				current = group + "/" + strings.ToLower(entry[1])
+				graph.Packages[current] = &lockedPackage{
+					Name:     entry[1],
+					Version:  entry[2],
+					Resolved: remote,
+					Dev:      &dev,
+				}
+				continue
+			}
+			// Files taken from GitHub and other remotes are pinned to a commit.
+			current = group + "/" + strings.ToLower(remote)
+			if graph.Packages[current] == nil {
+				graph.Packages[current] = &lockedPackage{
+					Name:    remote,
+					Version: entry[2],
+					Dev:     &dev,
+				}
+			}
+		case 6:
+			if pkg := graph.Packages[current]; pkg != nil && section == "NUGET" {
+				pkg.Dependencies = append(pkg.Dependencies, group+"/"+strings.ToLower(entry[1]))
+			}
+		}
+	}
+
+	if manifest, err := os.ReadFile(filepath.Join(filepath.Dir(path), "paket.dependencies")); err == nil {
+		for _, dep := range parsePaketDependencies(string(manifest)) {
+			graph.Roots = append(graph.Roots, lockfileRoot{Key: dep.Group + "/" + strings.ToLower(dep.Name)})
+		}
+	}
+
+	return graph.findings(dotnetDirectoryLanguage(path), repoName, path), nil
+}
+
+func (mp *LibrariesProcessor) parseGlobalJSON(content string, repoName string, path string) ([]core.Finding, error) {
+	type globalJSON struct {
+		SDK struct {
+			Version     string `json:"version"`
+			RollForward string `json:"rollForward"`
+		} `json:"sdk"`
+		MSBuildSDKs map[string]string `json:"msbuild-sdks"`
+	}
+
+	var global globalJSON
+	if err := json.Unmarshal([]byte(content), &global); err != nil {
+		return nil, err
+	}
+
+	var matches []core.Finding
+	if global.SDK.Version != "" {
+		properties := map[string]interface{}{
+			"Version": global.SDK.Version,
+		}
+		if global.SDK.RollForward != "" {
+			properties["RollForward"] = global.SDK.RollForward
+		}
+		matches = append(matches, core.Finding{
+			Name:       ".NET SDK",
+			Type:       "Runtime",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	for _, name := range sortedKeys(global.MSBuildSDKs) {
+		matches = append(matches, core.Finding{
+			Name:     name,
+			Type:     "Library",
+			Category: "",
+			Properties: map[string]interface{}{
+				"Language": "C#",
+				"Version":  global.MSBuildSDKs[name],
+				"Direct":   true,
+				"Scope":    "build",
+			},
+			Path:     path,
+			RepoName: repoName,
+		})
+	}
+	return matches, nil
+}
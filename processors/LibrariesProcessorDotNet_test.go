@@ -0,0 +1,286 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func findingsOfType(findings []core.Finding, findingType string) map[string]core.Finding {
+	filtered := make([]core.Finding, 0, len(findings))
+	for _, finding := range findings {
+		if finding.Type == findingType {
+			filtered = append(filtered, finding)
+		}
+	}
+	return findingsByName(filtered)
+}
+
+func TestDotnetRuntime(t *testing.T) {
+	tests := []struct {
+		tfm      string
+		name     string
+		version  string
+		platform string
+	}{
+		{"net48", ".NET Framework", "4.8", ""},
+		{"net472", ".NET Framework", "4.7.2", ""},
+		{"v4.6.1", ".NET Framework", "4.6.1", ""},
+		{"net8.0", ".NET", "8.0", ""},
+		{"net8.0-windows10.0.19041", ".NET", "8.0", "windows10.0.19041"},
+		{"netcoreapp3.1", ".NET Core", "3.1", ""},
+		{"netstandard2.0", ".NET Standard", "2.0", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tfm, func(t *testing.T) {
+			name, version, platform, ok := dotnetRuntime(tt.tfm)
+			require.True(t, ok)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.version, version)
+			assert.Equal(t, tt.platform, platform)
+		})
+	}
+
+	_, _, _, ok := dotnetRuntime("monoandroid10")
+	assert.False(t, ok)
+}
+
+func TestParseCsProjDirectoryPropsAndCentralVersions(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "Directory.Build.props"), `<Project>
+  <PropertyGroup>
+    <SerilogVersion>3.1.1</SerilogVersion>
+    <TargetFrameworks>net48;net8.0</TargetFrameworks>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="StyleCop.Analyzers" Version="1.1.118" PrivateAssets="all" />
+  </ItemGroup>
+</Project>`)
+	writeTestFile(t, filepath.Join(dir, "Directory.Packages.props"), `<Project>
+  <PropertyGroup>
+    <ManagePackageVersionsCentrally>true</ManagePackageVersionsCentrally>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageVersion Include="Newtonsoft.Json" Version="13.0.3" />
+    <PackageVersion Include="Dapper" Version="2.1.24" />
+    <GlobalPackageReference Include="Nerdbank.GitVersioning" Version="3.6.133" />
+  </ItemGroup>
+</Project>`)
+
+	content := `<Project Sdk="Microsoft.NET.Sdk">
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" />
+    <PackageReference Include="Dapper" VersionOverride="2.0.151" />
+    <PackageReference Include="Serilog" Version="$(SerilogVersion)" />
+  </ItemGroup>
+</Project>`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "src", "App", "App.fsproj"), "repo", content)
+	require.NoError(t, err)
+
+	libraries := findingsOfType(findings, "Library")
+	require.Len(t, libraries, 5)
+	assert.Equal(t, "F#", libraries["Newtonsoft.Json"].Properties["Language"])
+	assert.Equal(t, "13.0.3", libraries["Newtonsoft.Json"].Properties["Version"])
+	assert.Equal(t, "Directory.Packages.props", libraries["Newtonsoft.Json"].Properties["ManagedBy"])
+	assert.Equal(t, "2.0.151", libraries["Dapper"].Properties["Version"])
+	assert.Equal(t, "3.1.1", libraries["Serilog"].Properties["Version"])
+	assert.Equal(t, "Directory.Build.props", libraries["StyleCop.Analyzers"].Properties["Include"])
+	assert.Equal(t, "dev", libraries["StyleCop.Analyzers"].Properties["Scope"])
+	assert.Equal(t, "Directory.Packages.props", libraries["Nerdbank.GitVersioning"].Properties["Include"])
+
+	runtimes := findings[len(findings)-2:]
+	assert.Equal(t, ".NET Framework", runtimes[0].Name)
+	assert.Equal(t, "4.8", runtimes[0].Properties["Version"])
+	assert.Equal(t, ".NET", runtimes[1].Name)
+	assert.Equal(t, "net8.0", runtimes[1].Properties["TargetFramework"])
+}
+
+func TestParseCsProjDirectoryPropsStayInRepository(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(parent, "Directory.Build.props"), `<Project>
+  <ItemGroup>
+    <PackageReference Include="Host.Analyzers" Version="1.0.0" />
+  </ItemGroup>
+</Project>`)
+	writeTestFile(t, filepath.Join(parent, "Directory.Packages.props"), `<Project>
+  <ItemGroup>
+    <PackageVersion Include="Newtonsoft.Json" Version="1.0.0" />
+  </ItemGroup>
+</Project>`)
+
+	content := `<Project Sdk="Microsoft.NET.Sdk">
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" Version="13.0.3" />
+  </ItemGroup>
+</Project>`
+	findings, err := NewLibrariesProcessor().Process(filepath.Join(dir, "src", "App", "App.csproj"), "repo", content)
+	require.NoError(t, err)
+	libraries := findingsOfType(findings, "Library")
+	require.Len(t, libraries, 1)
+	assert.Equal(t, "13.0.3", libraries["Newtonsoft.Json"].Properties["Version"])
+	assert.Nil(t, libraries["Newtonsoft.Json"].Properties["ManagedBy"])
+}
+
+func TestParseVbProjTargetFrameworkVersion(t *testing.T) {
+	content := `<?xml version="1.0" encoding="utf-8"?>
+<Project ToolsVersion="15.0" xmlns="http://schemas.microsoft.com/developer/msbuild/2003">
+  <PropertyGroup>
+    <TargetFrameworkVersion>v4.7.2</TargetFrameworkVersion>
+  </PropertyGroup>
+  <ItemGroup>
+    <Reference Include="System.Data" />
+  </ItemGroup>
+</Project>`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(t.TempDir(), "Legacy.vbproj"), "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "VB.NET", findings[0].Properties["Language"])
+	assert.Equal(t, "Runtime", findings[1].Type)
+	assert.Equal(t, ".NET Framework", findings[1].Name)
+	assert.Equal(t, "4.7.2", findings[1].Properties["Version"])
+}
+
+func TestParsePackagesConfig(t *testing.T) {
+	content := `<?xml version="1.0" encoding="utf-8"?>
+<packages>
+  <package id="EntityFramework" version="6.4.4" targetFramework="net472" />
+  <package id="Microsoft.CodeDom.Providers.DotNetCompilerPlatform" version="2.0.1" targetFramework="net472" developmentDependency="true" />
+</packages>`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(t.TempDir(), "packages.config"), "repo", content)
+	require.NoError(t, err)
+
+	libraries := findingsOfType(findings, "Library")
+	require.Len(t, libraries, 2)
+	assert.Equal(t, "6.4.4", libraries["EntityFramework"].Properties["Version"])
+	assert.Equal(t, "prod", libraries["EntityFramework"].Properties["Scope"])
+	assert.Equal(t, "dev", libraries["Microsoft.CodeDom.Providers.DotNetCompilerPlatform"].Properties["Scope"])
+
+	runtimes := findingsOfType(findings, "Runtime")
+	require.Len(t, runtimes, 1)
+	assert.Equal(t, "4.7.2", runtimes[".NET Framework"].Properties["Version"])
+}
+
+func TestParseNuGetLock(t *testing.T) {
+	content := `{
+  "version": 1,
+  "dependencies": {
+    "net8.0": {
+      "Serilog.Sinks.Console": {
+        "type": "Direct",
+        "requested": "[5.0.1, )",
+        "resolved": "5.0.1",
+        "contentHash": "console-hash",
+        "dependencies": {"Serilog": "3.1.1"}
+      },
+      "Serilog": {
+        "type": "Transitive",
+        "resolved": "3.1.1",
+        "contentHash": "serilog-hash"
+      },
+      "shared": {
+        "type": "Project",
+        "dependencies": {"Serilog": "[3.1.1, )"}
+      }
+    }
+  }
+}`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(t.TempDir(), "packages.lock.json"), "repo", content)
+	require.NoError(t, err)
+
+	libraries := findingsOfType(findings, "Library")
+	require.Len(t, libraries, 2)
+	assert.Equal(t, true, libraries["Serilog.Sinks.Console"].Properties["Direct"])
+	assert.Equal(t, "console-hash", libraries["Serilog.Sinks.Console"].Properties["Integrity"])
+	assert.Equal(t, false, libraries["Serilog"].Properties["Direct"])
+	assert.Equal(t, "3.1.1", libraries["Serilog"].Properties["Version"])
+	assert.Equal(t, "8.0", findingsOfType(findings, "Runtime")[".NET"].Properties["Version"])
+}
+
+func TestParsePaketDependenciesAndLock(t *testing.T) {
+	dir := t.TempDir()
+	dependencies := `source https://api.nuget.org/v3/index.json
+framework: net8.0
+
+nuget FSharp.Core >= 8.0
+nuget Argu 6.1.1
+github fsharp/FAKE:0341a2e src/app/FakeLib/Globbing/Globbing.fs
+
+group Test
+  source https://api.nuget.org/v3/index.json
+  nuget Expecto
+`
+	writeTestFile(t, filepath.Join(dir, "paket.dependencies"), dependencies)
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "paket.dependencies"), "repo", dependencies)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 4)
+	assert.Equal(t, ">= 8.0", byName["FSharp.Core"].Properties["Version"])
+	assert.Equal(t, "0341a2e", byName["fsharp/FAKE"].Properties["Version"])
+	assert.Equal(t, "github", byName["fsharp/FAKE"].Properties["Source"])
+	assert.Equal(t, "dev", byName["Expecto"].Properties["Scope"])
+	assert.Equal(t, "Test", byName["Expecto"].Properties["Group"])
+
+	lock := `RESTRICTION: == net8.0
+NUGET
+  remote: https://api.nuget.org/v3/index.json
+    Argu (6.1.1)
+      FSharp.Core (>= 4.3.2)
+    FSharp.Core (8.0.100)
+GITHUB
+  remote: fsharp/FAKE
+    src/app/FakeLib/Globbing/Globbing.fs (0341a2e614eb2a7f34607cec914eb0ed83ce9add)
+
+GROUP Test
+NUGET
+  remote: https://api.nuget.org/v3/index.json
+    Expecto (10.1.0)
+      FSharp.Core (>= 7.0.200)
+    FSharp.Core (8.0.100)
+`
+	findings, err = processor.Process(filepath.Join(dir, "paket.lock"), "repo", lock)
+	require.NoError(t, err)
+	require.Len(t, findings, 4)
+	byName = findingsByName(findings)
+	assert.Equal(t, "6.1.1", byName["Argu"].Properties["Version"])
+	assert.Equal(t, true, byName["Argu"].Properties["Direct"])
+	assert.Equal(t, "https://api.nuget.org/v3/index.json", byName["Argu"].Properties["Resolved"])
+	assert.Equal(t, "0341a2e614eb2a7f34607cec914eb0ed83ce9add", byName["fsharp/FAKE"].Properties["Version"])
+	assert.Equal(t, "10.1.0", byName["Expecto"].Properties["Version"])
+	assert.Equal(t, "dev", byName["Expecto"].Properties["Scope"])
+}
+
+func TestParseGlobalJSON(t *testing.T) {
+	content := `{
+  "sdk": {"version": "8.0.100", "rollForward": "latestFeature"},
+  "msbuild-sdks": {"Microsoft.Build.Traversal": "4.1.0"}
+}`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process("global.json", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, ".NET SDK", findings[0].Name)
+	assert.Equal(t, "Runtime", findings[0].Type)
+	assert.Equal(t, "8.0.100", findings[0].Properties["Version"])
+	assert.Equal(t, "latestFeature", findings[0].Properties["RollForward"])
+	assert.Equal(t, "Microsoft.Build.Traversal", findings[1].Name)
+	assert.Equal(t, "build", findings[1].Properties["Scope"])
+}
@@ -0,0 +1,276 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// maxGoWorkDepth bounds how far up the tree a go.work file is looked for.
+const maxGoWorkDepth = 10
+
+type goModuleVersion struct {
+	Path    string
+	Version string
+}
+
+type goRequire struct {
+	goModuleVersion
+	Indirect bool
+}
+
+// goReplace is a replace directive. Old.Version is empty when every version of
+// the module is replaced, and New.Version is empty for local directories.
+type goReplace struct {
+	Old goModuleVersion
+	New goModuleVersion
+}
+
+// goModFile is the parsed content of a go.mod or go.work file.
+type goModFile struct {
+	Module    string
+	Go        string
+	Toolchain string
+	Requires  []goRequire
+	Replaces  []goReplace
+	Excludes  []goModuleVersion
+	Uses      []string
+}
+
+// parseGoModFile reads the directives of a go.mod or go.work file. Both
+// single line directives and parenthesised blocks are handled.
+func parseGoModFile(content string) goModFile {
+	var file goModFile
+	block := ""
+	for _, line := range strings.Split(content, "\n") {
+		comment := ""
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line, comment = line[:idx], strings.TrimSpace(line[idx+2:])
+		}
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			fields[i] = strings.Trim(field, "\"`")
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		verb := block
+		args := fields
+		if block == "" {
+			verb, args = fields[0], fields[1:]
+			if len(args) == 1 && args[0] == "(" {
+				block = verb
+				continue
+			}
+		} else if fields[0] == ")" {
+			block = ""
+			continue
+		}
+
+		switch verb {
+		case "module":
+			if len(args) > 0 {
+				file.Module = args[0]
+			}
+		case "go":
+			if len(args) > 0 {
+				file.Go = args[0]
+			}
+		case "toolchain":
+			if len(args) > 0 {
+				file.Toolchain = args[0]
+			}
+		case "require":
+			if len(args) >= 2 {
+				file.Requires = append(file.Requires, goRequire{
+					goModuleVersion: goModuleVersion{Path: args[0], Version: args[1]},
+					Indirect:        strings.HasPrefix(comment, "indirect"),
+				})
+			}
+		case "exclude":
+			if len(args) >= 2 {
+				file.Excludes = append(file.Excludes, goModuleVersion{Path: args[0], Version: args[1]})
+			}
+		case "replace":
+			arrow := -1
+			for i, arg := range args {
+				if arg == "=>" {
+					arrow = i
+				}
+			}
+			if arrow < 1 || arrow == len(args)-1 {
+				continue
+			}
+			replace := goReplace{
+				Old: goModuleVersion{Path: args[0]},
+				New: goModuleVersion{Path: args[arrow+1]},
+			}
+			if arrow == 2 {
+				replace.Old.Version = args[1]
+			}
+			if len(args) > arrow+2 {
+				replace.New.Version = args[arrow+2]
+			}
+			file.Replaces = append(file.Replaces, replace)
+		case "use":
+			if len(args) > 0 {
+				file.Uses = append(file.Uses, args[0])
+			}
+		}
+	}
+	return file
+}
+
+// replacement returns the replace directive that applies to mod, preferring
+// one that names the exact version over one that covers every version.
+func (f goModFile) replacement(mod goModuleVersion) (goReplace, bool) {
+	var found goReplace
+	ok := false
+	for _, replace := range f.Replaces {
+		if replace.Old.Path != mod.Path {
+			continue
+		}
+		if replace.Old.Version == mod.Version {
+			return replace, true
+		}
+		if replace.Old.Version == "" {
+			found, ok = replace, true
+		}
+	}
+	return found, ok
+}
+
+func (f goModFile) excluded(mod goModuleVersion) bool {
+	for _, exclude := range f.Excludes {
+		if exclude == mod {
+			return true
+		}
+	}
+	return false
+}
+
+// goWorkspace is the go.work file a module belongs to.
+type goWorkspace struct {
+	File    goModFile
+	Modules map[string]string
+}
+
+// findGoWorkspace walks up from dir, no further than the root of its
+// repository, to a go.work file that uses dir, and reads the module path of
+// every module in the workspace that is in the repository.
+func findGoWorkspace(dir string) (goWorkspace, bool) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return goWorkspace{}, false
+	}
+	current := absDir
+	for i := 0; i < maxGoWorkDepth && withinRepository(absDir, current); i++ {
+		content, err := os.ReadFile(filepath.Join(current, "go.work"))
+		if err == nil {
+			workspace := goWorkspace{File: parseGoModFile(string(content)), Modules: make(map[string]string)}
+			member := false
+			for _, use := range workspace.File.Uses {
+				useDir := filepath.Join(current, filepath.FromSlash(use))
+				if useDir == absDir {
+					member = true
+				}
+				if !withinRepository(absDir, useDir) {
+					continue
+				}
+				if modContent, err := os.ReadFile(filepath.Join(useDir, "go.mod")); err == nil {
+					if module := parseGoModFile(string(modContent)).Module; module != "" {
+						workspace.Modules[module] = use
+					}
+				}
+			}
+			return workspace, member
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+	return goWorkspace{}, false
+}
+
+// readGoSum returns the module hashes recorded in a go.sum file, keyed by
+// "path version".
+func readGoSum(path string) (map[string]string, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	sums := make(map[string]string)
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		sums[fields[0]+" "+fields[1]] = fields[2]
+	}
+	return sums, true
+}
+
+// goRuntimeFindings reports the go and toolchain directives.
+func goRuntimeFindings(file goModFile, repoName string, path string) []core.Finding {
+	var matches []core.Finding
+	add := func(version string, directive string) {
+		matches = append(matches, core.Finding{
+			Name:     "Go",
+			Type:     "Runtime",
+			Category: "",
+			Properties: map[string]interface{}{
+				"Language":  "Go",
+				"Version":   version,
+				"Directive": directive,
+			},
+			Path:     path,
+			RepoName: repoName,
+		})
+	}
+	if file.Go != "" {
+		add(file.Go, "go")
+	}
+	// The toolchain is written as "go1.21.3" or "default".
+	if strings.HasPrefix(file.Toolchain, "go") {
+		add(strings.TrimPrefix(file.Toolchain, "go"), "toolchain")
+	}
+	return matches
+}
+
+func (mp *LibrariesProcessor) parseGoWork(content string, repoName string, path string) ([]core.Finding, error) {
+	file := parseGoModFile(content)
+	matches := goRuntimeFindings(file, repoName, path)
+	for _, use := range file.Uses {
+		module := use
+		// Modules outside the repository are reported by their directory.
+		modPath := filepath.Join(filepath.Dir(path), filepath.FromSlash(use), "go.mod")
+		if withinRepository(path, modPath) {
+			if modContent, err := os.ReadFile(modPath); err == nil {
+				if name := parseGoModFile(string(modContent)).Module; name != "" {
+					module = name
+				}
+			}
+		}
+		matches = append(matches, core.Finding{
+			Name:     module,
+			Type:     "Library",
+			Category: "",
+			Properties: map[string]interface{}{
+				"Language": "Go",
+				"Version":  "N/A",
+				"Direct":   true,
+				"Source":   "local",
+				"Resolved": use,
+				"Internal": true,
+			},
+			Path:     path,
+			RepoName: repoName,
+		})
+	}
+	return matches, nil
+}
@@ -0,0 +1,173 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGoModFile(t *testing.T) {
+	file := parseGoModFile(`module example.com/app // the app
+
+go 1.22
+
+toolchain go1.22.3
+
+require github.com/pkg/errors v0.9.1
+
+require (
+	golang.org/x/text v0.14.0 // indirect
+	"github.com/old/lib" v1.0.0
+)
+
+exclude github.com/pkg/errors v0.9.0
+
+replace (
+	github.com/old/lib => github.com/fork/lib v1.0.1
+	golang.org/x/text v0.14.0 => ../text
+)
+`)
+
+	assert.Equal(t, "example.com/app", file.Module)
+	assert.Equal(t, "1.22", file.Go)
+	assert.Equal(t, "go1.22.3", file.Toolchain)
+	assert.Equal(t, []goRequire{
+		{goModuleVersion: goModuleVersion{Path: "github.com/pkg/errors", Version: "v0.9.1"}},
+		{goModuleVersion: goModuleVersion{Path: "golang.org/x/text", Version: "v0.14.0"}, Indirect: true},
+		{goModuleVersion: goModuleVersion{Path: "github.com/old/lib", Version: "v1.0.0"}},
+	}, file.Requires)
+	assert.Equal(t, []goModuleVersion{{Path: "github.com/pkg/errors", Version: "v0.9.0"}}, file.Excludes)
+	assert.Equal(t, []goReplace{
+		{Old: goModuleVersion{Path: "github.com/old/lib"}, New: goModuleVersion{Path: "github.com/fork/lib", Version: "v1.0.1"}},
+		{Old: goModuleVersion{Path: "golang.org/x/text", Version: "v0.14.0"}, New: goModuleVersion{Path: "../text"}},
+	}, file.Replaces)
+}
+
+func TestParseGoModReplacesAndGoSum(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.sum"), `github.com/fork/lib v1.0.1 h1:fork=
+github.com/fork/lib v1.0.1/go.mod h1:forkmod=
+github.com/pkg/errors v0.9.1/go.mod h1:errorsmod=
+`)
+	content := `module example.com/app
+
+go 1.22
+toolchain go1.22.3
+
+require (
+	github.com/old/lib v1.0.0
+	github.com/pkg/errors v0.9.1 // indirect
+	golang.org/x/text v0.14.0
+)
+
+exclude golang.org/x/text v0.14.0
+
+replace github.com/old/lib => github.com/fork/lib v1.0.1
+replace golang.org/x/text => ./third_party/text
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "go.mod"), "repo", content)
+	require.NoError(t, err)
+
+	libraries := findingsOfType(findings, "Library")
+	require.Len(t, libraries, 3)
+
+	fork := libraries["github.com/old/lib"]
+	assert.Equal(t, "v1.0.1", fork.Properties["Version"])
+	assert.Equal(t, "v1.0.0", fork.Properties["RequiredVersion"])
+	assert.Equal(t, "github.com/fork/lib", fork.Properties["Replace"])
+	assert.Equal(t, "module", fork.Properties["Source"])
+	assert.Equal(t, "h1:fork=", fork.Properties["Integrity"])
+
+	errors := libraries["github.com/pkg/errors"]
+	assert.Equal(t, false, errors.Properties["Direct"])
+	assert.Equal(t, "missing", errors.Properties["GoSum"])
+
+	text := libraries["golang.org/x/text"]
+	assert.Equal(t, "local", text.Properties["Source"])
+	assert.Equal(t, "N/A", text.Properties["Version"])
+	assert.Equal(t, true, text.Properties["Excluded"])
+	assert.NotContains(t, text.Properties, "GoSum")
+
+	var directives []string
+	for _, finding := range findings {
+		if finding.Type == "Runtime" {
+			directives = append(directives, finding.Properties["Directive"].(string)+" "+finding.Properties["Version"].(string))
+		}
+	}
+	assert.Equal(t, []string{"go 1.22", "toolchain 1.22.3"}, directives)
+}
+
+func TestParseGoModInWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	work := `go 1.22
+
+use (
+	./api
+	./service
+)
+
+replace github.com/pkg/errors => github.com/pkg/errors v0.9.2
+`
+	writeTestFile(t, filepath.Join(dir, "go.work"), work)
+	writeTestFile(t, filepath.Join(dir, "api", "go.mod"), "module example.com/api\n\ngo 1.22\n")
+	serviceMod := `module example.com/service
+
+go 1.22
+
+require (
+	example.com/api v0.0.0
+	github.com/pkg/errors v0.9.1
+)
+`
+	writeTestFile(t, filepath.Join(dir, "service", "go.mod"), serviceMod)
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "service", "go.mod"), "repo", serviceMod)
+	require.NoError(t, err)
+	libraries := findingsOfType(findings, "Library")
+	require.Len(t, libraries, 2)
+	assert.Equal(t, true, libraries["example.com/api"].Properties["Internal"])
+	assert.Equal(t, "local", libraries["example.com/api"].Properties["Source"])
+	assert.Equal(t, "v0.9.2", libraries["github.com/pkg/errors"].Properties["Version"])
+
+	findings, err = processor.Process(filepath.Join(dir, "go.work"), "repo", work)
+	require.NoError(t, err)
+	libraries = findingsOfType(findings, "Library")
+	require.Len(t, libraries, 2)
+	assert.Equal(t, "./api", libraries["example.com/api"].Properties["Resolved"])
+	assert.Equal(t, "1.22", findingsOfType(findings, "Runtime")["Go"].Properties["Version"])
+}
+
+func TestParseGoModWorkspaceStaysInRepository(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(parent, "go.work"), "go 1.22\n\nuse ./repo\n\nreplace github.com/pkg/errors => github.com/pkg/errors v0.0.0-host\n")
+	writeTestFile(t, filepath.Join(parent, "host", "go.mod"), "module example.com/host\n")
+	mod := `module example.com/service
+
+go 1.22
+
+require github.com/pkg/errors v0.9.1
+`
+	writeTestFile(t, filepath.Join(dir, "go.mod"), mod)
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "go.mod"), "repo", mod)
+	require.NoError(t, err)
+	assert.Equal(t, "v0.9.1", findingsOfType(findings, "Library")["github.com/pkg/errors"].Properties["Version"])
+
+	work := "go 1.22\n\nuse (\n\t.\n\t../host\n)\n"
+	writeTestFile(t, filepath.Join(dir, "go.work"), work)
+	findings, err = processor.Process(filepath.Join(dir, "go.work"), "repo", work)
+	require.NoError(t, err)
+	libraries := findingsOfType(findings, "Library")
+	assert.Contains(t, libraries, "example.com/service")
+	assert.Contains(t, libraries, "../host")
+	assert.NotContains(t, libraries, "example.com/host")
+}
@@ -0,0 +1,334 @@
+package processors
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var mavenPropertyRe = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// maxMavenParentDepth bounds how far parent and BOM resolution recurses.
+const maxMavenParentDepth = 10
+
+type mavenDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+	Type       string `xml:"type"`
+	Optional   string `xml:"optional"`
+}
+
+type mavenPlugin struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+type mavenProperties struct {
+	Entries []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+// mavenPOM is the subset of a Maven project model used to report dependencies.
+type mavenPOM struct {
+	XMLName    xml.Name `xml:"project"`
+	GroupID    string   `xml:"groupId"`
+	ArtifactID string   `xml:"artifactId"`
+	Version    string   `xml:"version"`
+	Parent     struct {
+		GroupID      string  `xml:"groupId"`
+		ArtifactID   string  `xml:"artifactId"`
+		Version      string  `xml:"version"`
+		RelativePath *string `xml:"relativePath"`
+	} `xml:"parent"`
+	Properties           mavenProperties   `xml:"properties"`
+	Modules              []string          `xml:"modules>module"`
+	Dependencies         []mavenDependency `xml:"dependencies>dependency"`
+	DependencyManagement []mavenDependency `xml:"dependencyManagement>dependencies>dependency"`
+	Plugins              []mavenPlugin     `xml:"build>plugins>plugin"`
+	PluginManagement     []mavenPlugin     `xml:"build>pluginManagement>plugins>plugin"`
+}
+
+func (p mavenPOM) groupID() string {
+	if p.GroupID != "" {
+		return p.GroupID
+	}
+	return p.Parent.GroupID
+}
+
+func (p mavenPOM) version() string {
+	if p.Version != "" {
+		return p.Version
+	}
+	return p.Parent.Version
+}
+
+func readMavenPOM(path string) (mavenPOM, bool) {
+	var pom mavenPOM
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return pom, false
+	}
+	if err := xml.Unmarshal(content, &pom); err != nil {
+		return pom, false
+	}
+	return pom, true
+}
+
+// mavenModel is a POM with its parents, properties and managed versions
+// resolved from POMs available in the same repository.
+type mavenModel struct {
+	Properties map[string]string
+	Managed    map[string]mavenDependency
+	Plugins    map[string]string
+	// Unresolved lists parent and imported BOM coordinates that could not be
+	// found locally and may be supplying versions.
+	Unresolved []string
+}
+
+func newMavenModel(pom mavenPOM, path string) *mavenModel {
+	return buildMavenModel(pom, path, 0)
+}
+
+func buildMavenModel(pom mavenPOM, path string, depth int) *mavenModel {
+	model := &mavenModel{
+		Properties: make(map[string]string),
+		Managed:    make(map[string]mavenDependency),
+		Plugins:    make(map[string]string),
+	}
+	model.inherit(pom, path, 0)
+
+	model.Properties["project.groupId"] = pom.groupID()
+	model.Properties["project.artifactId"] = pom.ArtifactID
+	model.Properties["project.version"] = pom.version()
+	model.Properties["pom.groupId"] = pom.groupID()
+	model.Properties["pom.artifactId"] = pom.ArtifactID
+	model.Properties["pom.version"] = pom.version()
+	model.Properties["version"] = pom.version()
+	model.Properties["project.parent.groupId"] = pom.Parent.GroupID
+	model.Properties["project.parent.artifactId"] = pom.Parent.ArtifactID
+	model.Properties["project.parent.version"] = pom.Parent.Version
+	model.Properties["parent.version"] = pom.Parent.Version
+
+	// Managed coordinates commonly use ${project.groupId}, so they can only
+	// be keyed once every property is known.
+	managed := make(map[string]mavenDependency)
+	for _, dep := range model.Managed {
+		dep.GroupID = model.interpolate(dep.GroupID)
+		dep.ArtifactID = model.interpolate(dep.ArtifactID)
+		managed[dep.GroupID+":"+dep.ArtifactID] = dep
+	}
+	model.Managed = managed
+
+	model.importBOMs(path, depth)
+	return model
+}
+
+// inherit merges the properties, dependency management and plugin management
+// of pom's parents (outermost first) and then pom itself.
+func (m *mavenModel) inherit(pom mavenPOM, path string, depth int) {
+	if pom.Parent.ArtifactID != "" && depth < maxMavenParentDepth {
+		if parent, parentPath, ok := findMavenParent(pom, path); ok {
+			m.inherit(parent, parentPath, depth+1)
+		} else {
+			m.Unresolved = append(m.Unresolved, fmt.Sprintf("%s:%s:%s", pom.Parent.GroupID, pom.Parent.ArtifactID, pom.Parent.Version))
+		}
+	}
+	for _, entry := range pom.Properties.Entries {
+		m.Properties[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+	for _, dep := range pom.DependencyManagement {
+		m.Managed[dep.GroupID+":"+dep.ArtifactID] = dep
+	}
+	for _, plugin := range append(pom.PluginManagement, pom.Plugins...) {
+		if plugin.Version != "" {
+			m.Plugins[mavenPluginName(plugin)] = plugin.Version
+		}
+	}
+}
+
+// importBOMs replaces "import" scoped dependencyManagement entries with the
+// managed dependencies of the BOM, when the BOM is part of the repository.
+func (m *mavenModel) importBOMs(path string, depth int) {
+	for key, dep := range m.Managed {
+		if dep.Scope != "import" {
+			continue
+		}
+		delete(m.Managed, key)
+		dep.Version = m.interpolate(dep.Version)
+		bom, bomPath, ok := findMavenModule(path, dep.GroupID, dep.ArtifactID)
+		if !ok || depth >= maxMavenParentDepth {
+			m.Unresolved = append(m.Unresolved, fmt.Sprintf("%s:%s:%s", dep.GroupID, dep.ArtifactID, dep.Version))
+			continue
+		}
+		imported := buildMavenModel(bom, bomPath, depth+1)
+		for importedKey, managed := range imported.Managed {
+			if _, exists := m.Managed[importedKey]; !exists {
+				managed.Version = imported.interpolate(managed.Version)
+				m.Managed[importedKey] = managed
+			}
+		}
+		m.Unresolved = append(m.Unresolved, imported.Unresolved...)
+	}
+}
+
+// interpolate replaces ${property} references, leaving unknown ones intact.
+func (m *mavenModel) interpolate(value string) string {
+	for i := 0; i < maxMavenParentDepth && strings.Contains(value, "${"); i++ {
+		replaced := mavenPropertyRe.ReplaceAllStringFunc(value, func(ref string) string {
+			if resolved, ok := m.Properties[ref[2:len(ref)-1]]; ok {
+				return resolved
+			}
+			return ref
+		})
+		if replaced == value {
+			break
+		}
+		value = replaced
+	}
+	return value
+}
+
+func mavenPluginName(plugin mavenPlugin) string {
+	groupID := plugin.GroupID
+	if groupID == "" {
+		groupID = "org.apache.maven.plugins"
+	}
+	return groupID + ":" + plugin.ArtifactID
+}
+
+// findMavenParent locates pom's parent using <relativePath> (defaulting to
+// ../pom.xml) and then any ancestor directory's pom.xml, looking no further
+// than the root of the repository.
+func findMavenParent(pom mavenPOM, path string) (mavenPOM, string, bool) {
+	dir := filepath.Dir(path)
+	relative := "../pom.xml"
+	if pom.Parent.RelativePath != nil {
+		relative = strings.TrimSpace(*pom.Parent.RelativePath)
+	}
+	if relative != "" {
+		candidate := filepath.Join(dir, filepath.FromSlash(relative))
+		if !strings.HasSuffix(candidate, ".xml") {
+			candidate = filepath.Join(candidate, "pom.xml")
+		}
+		if withinRepository(path, candidate) {
+			if parent, ok := readMavenPOM(candidate); ok && parent.ArtifactID == pom.Parent.ArtifactID {
+				return parent, candidate, true
+			}
+		}
+	}
+	for i := 0; i < maxMavenParentDepth; i++ {
+		parentDir := filepath.Dir(dir)
+		if parentDir == dir || !withinRepository(path, parentDir) {
+			break
+		}
+		dir = parentDir
+		candidate := filepath.Join(dir, "pom.xml")
+		if parent, ok := readMavenPOM(candidate); ok && parent.ArtifactID == pom.Parent.ArtifactID && parent.groupID() == pom.Parent.GroupID {
+			return parent, candidate, true
+		}
+	}
+	return mavenPOM{}, "", false
+}
+
+// mavenReactor describes the multi-module build a POM belongs to.
+type mavenReactor struct {
+	Root    string
+	Modules map[string]string
+}
+
+// findMavenReactor walks up from path to the outermost aggregator POM in the
+// repository whose <modules> include path, and collects the coordinates of
+// every module in it that is in the repository.
+func findMavenReactor(path string) (mavenReactor, bool) {
+	rootPath := ""
+	current, _ := filepath.Abs(path)
+	start := current
+	dir := filepath.Dir(current)
+	for i := 0; i < maxMavenParentDepth; i++ {
+		parentDir := filepath.Dir(dir)
+		if parentDir == dir || !withinRepository(start, parentDir) {
+			break
+		}
+		dir = parentDir
+		candidate := filepath.Join(dir, "pom.xml")
+		pom, ok := readMavenPOM(candidate)
+		if !ok || !mavenAggregates(pom, dir, current) {
+			break
+		}
+		rootPath = candidate
+		current = candidate
+	}
+	if rootPath == "" {
+		pom, ok := readMavenPOM(path)
+		if !ok || len(pom.Modules) == 0 {
+			return mavenReactor{}, false
+		}
+		rootPath = path
+	}
+
+	reactor := mavenReactor{Modules: make(map[string]string)}
+	// Modules are collected once each, so a module listing itself or modules
+	// listing each other do not loop.
+	visited := make(map[string]bool)
+	var collect func(pomPath string, depth int)
+	collect = func(pomPath string, depth int) {
+		pomPath = filepath.Clean(pomPath)
+		if visited[pomPath] || depth > maxMavenParentDepth || !withinRepository(rootPath, pomPath) {
+			return
+		}
+		visited[pomPath] = true
+		pom, ok := readMavenPOM(pomPath)
+		if !ok {
+			return
+		}
+		if reactor.Root == "" {
+			reactor.Root = pom.groupID() + ":" + pom.ArtifactID
+		}
+		reactor.Modules[pom.groupID()+":"+pom.ArtifactID] = pomPath
+		for _, module := range pom.Modules {
+			modulePath := filepath.Join(filepath.Dir(pomPath), filepath.FromSlash(module))
+			if !strings.HasSuffix(modulePath, ".xml") {
+				modulePath = filepath.Join(modulePath, "pom.xml")
+			}
+			collect(modulePath, depth+1)
+		}
+	}
+	collect(rootPath, 0)
+	return reactor, true
+}
+
+func mavenAggregates(pom mavenPOM, dir string, modulePath string) bool {
+	for _, module := range pom.Modules {
+		candidate := filepath.Join(dir, filepath.FromSlash(module))
+		if !strings.HasSuffix(candidate, ".xml") {
+			candidate = filepath.Join(candidate, "pom.xml")
+		}
+		if candidate == modulePath {
+			return true
+		}
+	}
+	return false
+}
+
+// findMavenModule finds the POM for groupID:artifactID among the modules of
+// the reactor path belongs to.
+func findMavenModule(path string, groupID string, artifactID string) (mavenPOM, string, bool) {
+	reactor, ok := findMavenReactor(path)
+	if !ok {
+		return mavenPOM{}, "", false
+	}
+	modulePath, ok := reactor.Modules[groupID+":"+artifactID]
+	if !ok {
+		return mavenPOM{}, "", false
+	}
+	pom, ok := readMavenPOM(modulePath)
+	return pom, modulePath, ok
+}
@@ -0,0 +1,249 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestParsePomXMLResolvesParentsPropertiesAndBOMs(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "pom.xml"), `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1.0.0</version>
+  <packaging>pom</packaging>
+  <modules>
+    <module>bom</module>
+    <module>core</module>
+    <module>app</module>
+  </modules>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <version>3.1.5</version>
+    <relativePath/>
+  </parent>
+  <properties>
+    <jackson.version>2.15.3</jackson.version>
+    <compiler.version>3.11.0</compiler.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>${project.groupId}</groupId>
+        <artifactId>bom</artifactId>
+        <version>${project.version}</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+      <dependency>
+        <groupId>com.fasterxml.jackson.core</groupId>
+        <artifactId>jackson-databind</artifactId>
+        <version>${jackson.version}</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <build>
+    <pluginManagement>
+      <plugins>
+        <plugin>
+          <artifactId>maven-compiler-plugin</artifactId>
+          <version>${compiler.version}</version>
+        </plugin>
+      </plugins>
+    </pluginManagement>
+  </build>
+</project>`)
+	writeTestFile(t, filepath.Join(dir, "bom", "pom.xml"), `<project>
+  <groupId>com.example</groupId>
+  <artifactId>bom</artifactId>
+  <version>1.0.0</version>
+  <properties>
+    <guava.version>32.1.3-jre</guava.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.google.guava</groupId>
+        <artifactId>guava</artifactId>
+        <version>${guava.version}</version>
+        <scope>runtime</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`)
+	writeTestFile(t, filepath.Join(dir, "core", "pom.xml"), `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1.0.0</version>
+  </parent>
+  <artifactId>core</artifactId>
+</project>`)
+
+	content := `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>com.fasterxml.jackson.core</groupId>
+      <artifactId>jackson-databind</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+      <optional>true</optional>
+    </dependency>
+    <dependency>
+      <groupId>${project.groupId}</groupId>
+      <artifactId>core</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.springframework.boot</groupId>
+      <artifactId>spring-boot-starter-web</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>org.junit.jupiter</groupId>
+      <artifactId>junit-jupiter</artifactId>
+      <version>5.10.0</version>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+</project>`
+	path := filepath.Join(dir, "app", "pom.xml")
+	writeTestFile(t, path, content)
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(path, "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 6)
+
+	jackson := byName["com.fasterxml.jackson.core:jackson-databind"]
+	assert.Equal(t, "2.15.3", jackson.Properties["Version"])
+	assert.Equal(t, "compile", jackson.Properties["Scope"])
+	assert.Equal(t, "com.example:app", jackson.Properties["Module"])
+	assert.Equal(t, "com.example:parent", jackson.Properties["Reactor"])
+
+	guava := byName["com.google.guava:guava"]
+	assert.Equal(t, "32.1.3-jre", guava.Properties["Version"])
+	assert.Equal(t, "runtime", guava.Properties["Scope"])
+	assert.Equal(t, true, guava.Properties["Optional"])
+
+	core := byName["com.example:core"]
+	assert.Equal(t, "1.0.0", core.Properties["Version"])
+	assert.Equal(t, true, core.Properties["Internal"])
+
+	web := byName["org.springframework.boot:spring-boot-starter-web"]
+	assert.Equal(t, "N/A", web.Properties["Version"])
+	assert.Equal(t, "org.springframework.boot:spring-boot-starter-parent:3.1.5", web.Properties["ManagedBy"])
+
+	assert.Equal(t, "test", byName["org.junit.jupiter:junit-jupiter"].Properties["Scope"])
+
+	compiler := byName["org.apache.maven.plugins:maven-compiler-plugin"]
+	assert.Equal(t, "Build Plugin", compiler.Type)
+	assert.Equal(t, "3.11.0", compiler.Properties["Version"])
+}
+
+func TestParsePomXMLStaysInRepository(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(parent, "pom.xml"), `<project>
+  <groupId>com.example</groupId>
+  <artifactId>host</artifactId>
+  <version>1.0.0</version>
+  <modules>
+    <module>repo</module>
+  </modules>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.google.guava</groupId>
+        <artifactId>guava</artifactId>
+        <version>0.0.1-host</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`)
+	content := `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>host</artifactId>
+    <version>1.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+    </dependency>
+  </dependencies>
+</project>`
+	path := filepath.Join(dir, "pom.xml")
+	writeTestFile(t, path, content)
+
+	findings, err := NewLibrariesProcessor().Process(path, "repo", content)
+	require.NoError(t, err)
+	guava := findingsByName(findings)["com.google.guava:guava"]
+	assert.Equal(t, "N/A", guava.Properties["Version"])
+	assert.Equal(t, "com.example:host:1.0.0", guava.Properties["ManagedBy"])
+	assert.Nil(t, guava.Properties["Reactor"])
+}
+
+func TestFindMavenReactorModuleCycles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(dir, "pom.xml"), `<project>
+  <groupId>com.example</groupId>
+  <artifactId>root</artifactId>
+  <modules>
+    <module>.</module>
+    <module>./</module>
+    <module>a</module>
+    <module>a/pom.xml</module>
+    <module>b</module>
+    <module>b/../b</module>
+    <module>pom.xml</module>
+    <module>../</module>
+  </modules>
+</project>`)
+	for _, module := range []string{"a", "b"} {
+		writeTestFile(t, filepath.Join(dir, module, "pom.xml"), `<project>
+  <groupId>com.example</groupId>
+  <artifactId>`+module+`</artifactId>
+  <modules>
+    <module>..</module>
+    <module>../a</module>
+    <module>../b</module>
+  </modules>
+</project>`)
+	}
+
+	reactor, ok := findMavenReactor(filepath.Join(dir, "a", "pom.xml"))
+	require.True(t, ok)
+	assert.Equal(t, "com.example:root", reactor.Root)
+	assert.Len(t, reactor.Modules, 3)
+}
@@ -0,0 +1,716 @@
+package processors
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// packageManifest is the subset of package.json used to find direct
+// dependencies and workspaces next to a lockfile.
+type packageManifest struct {
+	Name                 string            `json:"name"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	Workspaces           json.RawMessage   `json:"workspaces"`
+}
+
+func (m packageManifest) workspaceGlobs() []string {
+	if len(m.Workspaces) == 0 {
+		return nil
+	}
+	var globs []string
+	if err := json.Unmarshal(m.Workspaces, &globs); err == nil {
+		return globs
+	}
+	var nested struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(m.Workspaces, &nested); err == nil {
+		return nested.Packages
+	}
+	return nil
+}
+
+// readPackageManifest reads the package.json in dir, returning false when it
+// is missing or unreadable.
+func readPackageManifest(dir string) (packageManifest, bool) {
+	var manifest packageManifest
+	content, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return manifest, false
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return manifest, false
+	}
+	return manifest, true
+}
+
+// workspaceManifest is a package.json belonging to the root or a workspace,
+// keyed by its path relative to the lockfile directory ("" for the root).
+type workspaceManifest struct {
+	Path     string
+	Manifest packageManifest
+}
+
+// readWorkspaceManifests loads the root package.json next to a lockfile and the
+// package.json of every workspace it declares.
+func readWorkspaceManifests(dir string) []workspaceManifest {
+	root, ok := readPackageManifest(dir)
+	if !ok {
+		return nil
+	}
+	manifests := []workspaceManifest{{Path: "", Manifest: root}}
+	for _, pattern := range root.workspaceGlobs() {
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+		if err != nil {
+			continue
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			manifest, ok := readPackageManifest(match)
+			if !ok {
+				continue
+			}
+			rel, err := filepath.Rel(dir, match)
+			if err != nil {
+				continue
+			}
+			manifests = append(manifests, workspaceManifest{Path: filepath.ToSlash(rel), Manifest: manifest})
+		}
+	}
+	return manifests
+}
+
+func workspaceName(manifest workspaceManifest) string {
+	if manifest.Path == "" {
+		return ""
+	}
+	if manifest.Manifest.Name != "" {
+		return manifest.Manifest.Name
+	}
+	return manifest.Path
+}
+
+func (mp *LibrariesProcessor) parsePackageLockJSON(content string, repoName string, path string) ([]core.Finding, error) {
+	type lockDependency struct {
+		Version      string                     `json:"version"`
+		Resolved     string                     `json:"resolved"`
+		Integrity    string                     `json:"integrity"`
+		Dev          bool                       `json:"dev"`
+		Requires     map[string]string          `json:"requires"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+
+	type lockPackage struct {
+		Name                 string            `json:"name"`
+		Version              string            `json:"version"`
+		Resolved             string            `json:"resolved"`
+		Integrity            string            `json:"integrity"`
+		Link                 bool              `json:"link"`
+		Dev                  bool              `json:"dev"`
+		DevOptional          bool              `json:"devOptional"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+
+	type packageLock struct {
+		Packages     map[string]lockPackage     `json:"packages"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+
+	var lock packageLock
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil, err
+	}
+
+	graph := newLockfileGraph()
+	requires := make(map[string][]string)
+
+	if len(lock.Packages) > 0 {
+		// lockfileVersion 2 and 3 list every installed package by its
+		// node_modules path, with "" as the root and workspaces by their path.
+		for key, pkg := range lock.Packages {
+			if key == "" || pkg.Link || !strings.Contains(key, "node_modules/") {
+				continue
+			}
+			name := pkg.Name
+			if name == "" {
+				name = npmPackageName(key)
+			}
+			dev := pkg.Dev || pkg.DevOptional
+			graph.Packages[key] = &lockedPackage{
+				Name:      name,
+				Version:   pkg.Version,
+				Integrity: pkg.Integrity,
+				Resolved:  pkg.Resolved,
+				Dev:       &dev,
+			}
+			requires[key] = append(sortedKeys(pkg.Dependencies), sortedKeys(pkg.OptionalDependencies)...)
+		}
+		for key, pkg := range lock.Packages {
+			if key != "" && (pkg.Link || strings.Contains(key, "node_modules/")) {
+				continue
+			}
+			workspace := ""
+			if key != "" {
+				workspace = pkg.Name
+				if workspace == "" {
+					workspace = key
+				}
+			}
+			for _, name := range append(sortedKeys(pkg.Dependencies), sortedKeys(pkg.OptionalDependencies)...) {
+				if target := resolveNodeModule(lock.Packages, key, name); target != "" {
+					graph.Roots = append(graph.Roots, lockfileRoot{Key: target, Workspace: workspace})
+				}
+			}
+			for _, name := range sortedKeys(pkg.DevDependencies) {
+				if target := resolveNodeModule(lock.Packages, key, name); target != "" {
+					graph.Roots = append(graph.Roots, lockfileRoot{Key: target, Dev: true, Workspace: workspace})
+				}
+			}
+		}
+		for key, names := range requires {
+			for _, name := range names {
+				if target := resolveNodeModule(lock.Packages, key, name); target != "" {
+					graph.Packages[key].Dependencies = append(graph.Packages[key].Dependencies, target)
+				}
+			}
+		}
+	} else {
+		// lockfileVersion 1 nests dependencies, so flatten them into the same
+		// node_modules path keys used by later versions.
+		var flatten func(prefix string, deps map[string]json.RawMessage) error
+		flatten = func(prefix string, deps map[string]json.RawMessage) error {
+			for name, raw := range deps {
+				var dep lockDependency
+				if err := json.Unmarshal(raw, &dep); err != nil {
+					return err
+				}
+				key := prefix + "node_modules/" + name
+				dev := dep.Dev
+				graph.Packages[key] = &lockedPackage{
+					Name:      name,
+					Version:   dep.Version,
+					Integrity: dep.Integrity,
+					Resolved:  dep.Resolved,
+					Dev:       &dev,
+				}
+				requires[key] = sortedKeys(dep.Requires)
+				if err := flatten(key+"/", dep.Dependencies); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := flatten("", lock.Dependencies); err != nil {
+			return nil, err
+		}
+		for key, names := range requires {
+			for _, name := range names {
+				if target := resolveNodeModule(graph.Packages, key, name); target != "" {
+					graph.Packages[key].Dependencies = append(graph.Packages[key].Dependencies, target)
+				}
+			}
+		}
+		if manifest, ok := readPackageManifest(filepath.Dir(path)); ok {
+			for _, name := range append(sortedKeys(manifest.Dependencies), sortedKeys(manifest.OptionalDependencies)...) {
+				if target := resolveNodeModule(graph.Packages, "", name); target != "" {
+					graph.Roots = append(graph.Roots, lockfileRoot{Key: target})
+				}
+			}
+			for _, name := range sortedKeys(manifest.DevDependencies) {
+				if target := resolveNodeModule(graph.Packages, "", name); target != "" {
+					graph.Roots = append(graph.Roots, lockfileRoot{Key: target, Dev: true})
+				}
+			}
+		}
+	}
+
+	return graph.findings("Node.js", repoName, path), nil
+}
+
+// resolveNodeModule applies Node's module resolution to find which installed
+// package satisfies a require of name from the package at from.
+func resolveNodeModule[T any](packages map[string]T, from string, name string) string {
+	current := from
+	for {
+		candidate := "node_modules/" + name
+		if current != "" {
+			candidate = current + "/node_modules/" + name
+		}
+		if _, ok := packages[candidate]; ok {
+			return candidate
+		}
+		if current == "" {
+			return ""
+		}
+		if idx := strings.LastIndex(current, "/node_modules/"); idx >= 0 {
+			current = current[:idx]
+		} else {
+			current = ""
+		}
+	}
+}
+
+// npmPackageName extracts the package name from a node_modules path such as
+// "node_modules/a/node_modules/@scope/b".
+func npmPackageName(key string) string {
+	idx := strings.LastIndex(key, "node_modules/")
+	return key[idx+len("node_modules/"):]
+}
+
+func (mp *LibrariesProcessor) parseYarnLock(content string, repoName string, path string) ([]core.Finding, error) {
+	if strings.Contains(content, "__metadata:") {
+		return mp.parseYarnBerryLock(content, repoName, path)
+	}
+
+	type yarnEntry struct {
+		descriptors  []string
+		version      string
+		resolved     string
+		integrity    string
+		dependencies map[string]string
+	}
+
+	var entries []*yarnEntry
+	var current *yarnEntry
+	inDependencies := false
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		switch {
+		case indent == 0:
+			current = &yarnEntry{dependencies: make(map[string]string)}
+			for _, descriptor := range strings.Split(strings.TrimSuffix(trimmed, ":"), ",") {
+				if descriptor = unquoteYarn(descriptor); descriptor != "" {
+					current.descriptors = append(current.descriptors, descriptor)
+				}
+			}
+			inDependencies = false
+			if len(current.descriptors) == 0 {
+				// Not an entry; its fields are skipped with it.
+				current = nil
+				continue
+			}
+			entries = append(entries, current)
+		case current == nil:
+			continue
+		case indent == 2:
+			key, value := splitYarnField(trimmed)
+			inDependencies = false
+			switch key {
+			case "version":
+				current.version = value
+			case "resolved":
+				current.resolved = value
+			case "integrity":
+				current.integrity = value
+			case "dependencies", "optionalDependencies":
+				inDependencies = true
+			}
+		case indent >= 4 && inDependencies:
+			name, rng := splitYarnField(trimmed)
+			current.dependencies[name] = rng
+		}
+	}
+
+	graph := newLockfileGraph()
+	byDescriptor := make(map[string]string)
+	for _, entry := range entries {
+		if len(entry.descriptors) == 0 {
+			continue
+		}
+		key := entry.descriptors[0]
+		graph.Packages[key] = &lockedPackage{
+			Name:      yarnDescriptorName(key),
+			Version:   entry.version,
+			Integrity: entry.integrity,
+			Resolved:  entry.resolved,
+		}
+		for _, descriptor := range entry.descriptors {
+			byDescriptor[descriptor] = key
+		}
+	}
+	for _, entry := range entries {
+		if len(entry.descriptors) == 0 {
+			continue
+		}
+		pkg := graph.Packages[entry.descriptors[0]]
+		for _, name := range sortedKeys(entry.dependencies) {
+			if target, ok := byDescriptor[name+"@"+entry.dependencies[name]]; ok {
+				pkg.Dependencies = append(pkg.Dependencies, target)
+			}
+		}
+	}
+
+	graph.addManifestRoots(filepath.Dir(path), func(name, rng string) (string, bool) {
+		key, ok := byDescriptor[name+"@"+rng]
+		return key, ok
+	})
+
+	return graph.findings("Node.js", repoName, path), nil
+}
+
+func (mp *LibrariesProcessor) parseYarnBerryLock(content string, repoName string, path string) ([]core.Finding, error) {
+	type berryEntry struct {
+		Version              string            `yaml:"version"`
+		Resolution           string            `yaml:"resolution"`
+		Checksum             string            `yaml:"checksum"`
+		Dependencies         map[string]string `yaml:"dependencies"`
+		OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+	}
+
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &raw); err != nil {
+		return nil, err
+	}
+
+	graph := newLockfileGraph()
+	byDescriptor := make(map[string]string)
+	entries := make(map[string]berryEntry)
+	workspaces := make(map[string]string)
+
+	for header, node := range raw {
+		if header == "__metadata" {
+			continue
+		}
+		var entry berryEntry
+		if err := node.Decode(&entry); err != nil {
+			return nil, err
+		}
+		key := entry.Resolution
+		if key == "" {
+			key = header
+		}
+		entries[key] = entry
+		for _, descriptor := range strings.Split(header, ",") {
+			byDescriptor[strings.TrimSpace(descriptor)] = key
+		}
+		if strings.Contains(key, "@workspace:") {
+			workspaces[key] = strings.SplitN(key, "@workspace:", 2)[1]
+			continue
+		}
+		graph.Packages[key] = &lockedPackage{
+			Name:      yarnDescriptorName(key),
+			Version:   entry.Version,
+			Integrity: entry.Checksum,
+			Resolved:  entry.Resolution,
+		}
+	}
+
+	lookup := func(name, rng string) (string, bool) {
+		if key, ok := byDescriptor[name+"@"+rng]; ok {
+			return key, true
+		}
+		key, ok := byDescriptor[name+"@npm:"+rng]
+		return key, ok
+	}
+
+	for key, entry := range entries {
+		deps := make(map[string]string)
+		for name, rng := range entry.Dependencies {
+			deps[name] = rng
+		}
+		for name, rng := range entry.OptionalDependencies {
+			deps[name] = rng
+		}
+
+		if wsPath, ok := workspaces[key]; ok {
+			// Workspace entries do not record whether a dependency is dev only,
+			// so consult the workspace's package.json when it is available.
+			manifest, _ := readPackageManifest(filepath.Join(filepath.Dir(path), filepath.FromSlash(wsPath)))
+			workspace := ""
+			if wsPath != "." {
+				workspace = yarnDescriptorName(key)
+			}
+			for _, name := range sortedKeys(deps) {
+				target, found := lookup(name, deps[name])
+				if !found || graph.Packages[target] == nil {
+					continue
+				}
+				_, dev := manifest.DevDependencies[name]
+				graph.Roots = append(graph.Roots, lockfileRoot{Key: target, Dev: dev, Workspace: workspace})
+			}
+			continue
+		}
+
+		pkg := graph.Packages[key]
+		for _, name := range sortedKeys(deps) {
+			if target, found := lookup(name, deps[name]); found {
+				pkg.Dependencies = append(pkg.Dependencies, target)
+			}
+		}
+	}
+
+	return graph.findings("Node.js", repoName, path), nil
+}
+
+// addManifestRoots marks the dependencies declared in the package.json files
+// next to a lockfile as roots, using lookup to map name and range to a key.
+func (g *lockfileGraph) addManifestRoots(dir string, lookup func(name, rng string) (string, bool)) {
+	for _, ws := range readWorkspaceManifests(dir) {
+		workspace := workspaceName(ws)
+		prod := make(map[string]string)
+		for name, rng := range ws.Manifest.Dependencies {
+			prod[name] = rng
+		}
+		for name, rng := range ws.Manifest.OptionalDependencies {
+			prod[name] = rng
+		}
+		for _, name := range sortedKeys(prod) {
+			if key, ok := lookup(name, prod[name]); ok {
+				g.Roots = append(g.Roots, lockfileRoot{Key: key, Workspace: workspace})
+			}
+		}
+		for _, name := range sortedKeys(ws.Manifest.DevDependencies) {
+			if key, ok := lookup(name, ws.Manifest.DevDependencies[name]); ok {
+				g.Roots = append(g.Roots, lockfileRoot{Key: key, Dev: true, Workspace: workspace})
+			}
+		}
+	}
+}
+
+func splitYarnField(line string) (string, string) {
+	line = strings.TrimSuffix(line, ":")
+	if strings.HasPrefix(line, "\"") {
+		if end := strings.Index(line[1:], "\""); end >= 0 {
+			return line[1 : end+1], unquoteYarn(line[end+2:])
+		}
+	}
+	parts := strings.SplitN(line, " ", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], unquoteYarn(parts[1])
+}
+
+func unquoteYarn(value string) string {
+	return strings.Trim(strings.TrimSpace(value), "\"")
+}
+
+// yarnDescriptorName returns the package name from a descriptor such as
+// "@scope/name@npm:^1.0.0".
+func yarnDescriptorName(descriptor string) string {
+	if descriptor == "" {
+		return ""
+	}
+	idx := strings.Index(descriptor[1:], "@")
+	if idx < 0 {
+		return descriptor
+	}
+	return descriptor[:idx+1]
+}
+
+// pnpmDependency accepts both the plain version strings used by pnpm 5 and the
+// {specifier, version} objects used from pnpm 6 onwards.
+type pnpmDependency struct {
+	Version string
+}
+
+func (d *pnpmDependency) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		d.Version = node.Value
+		return nil
+	}
+	var value struct {
+		Version string `yaml:"version"`
+	}
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	d.Version = value.Version
+	return nil
+}
+
+type pnpmImporter struct {
+	Dependencies         map[string]pnpmDependency `yaml:"dependencies"`
+	DevDependencies      map[string]pnpmDependency `yaml:"devDependencies"`
+	OptionalDependencies map[string]pnpmDependency `yaml:"optionalDependencies"`
+}
+
+func (mp *LibrariesProcessor) parsePnpmLock(content string, repoName string, path string) ([]core.Finding, error) {
+	type pnpmPackage struct {
+		Name       string `yaml:"name"`
+		Version    string `yaml:"version"`
+		Resolution struct {
+			Integrity string `yaml:"integrity"`
+			Tarball   string `yaml:"tarball"`
+		} `yaml:"resolution"`
+		Dependencies         map[string]string `yaml:"dependencies"`
+		OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+		Dev                  *bool             `yaml:"dev"`
+	}
+
+	type pnpmLock struct {
+		pnpmImporter `yaml:",inline"`
+		Importers    map[string]pnpmImporter `yaml:"importers"`
+		Packages     map[string]pnpmPackage  `yaml:"packages"`
+		Snapshots    map[string]pnpmPackage  `yaml:"snapshots"`
+	}
+
+	var lock pnpmLock
+	if err := yaml.Unmarshal([]byte(content), &lock); err != nil {
+		return nil, err
+	}
+
+	graph := newLockfileGraph()
+	byNameVersion := make(map[string]string)
+	keys := lock.Packages
+	if len(lock.Snapshots) > 0 {
+		// pnpm 9 keeps integrity in packages and the dependency graph in
+		// snapshots, whose keys may carry a peer dependency suffix.
+		keys = make(map[string]pnpmPackage)
+		for key, snapshot := range lock.Snapshots {
+			pkg := lock.Packages[pnpmStripPeerSuffix(key)]
+			pkg.Dependencies = snapshot.Dependencies
+			pkg.OptionalDependencies = snapshot.OptionalDependencies
+			if snapshot.Dev != nil {
+				pkg.Dev = snapshot.Dev
+			}
+			keys[key] = pkg
+		}
+	}
+
+	for key, pkg := range keys {
+		name, version := parsePnpmPackageKey(key)
+		if pkg.Name != "" {
+			name = pkg.Name
+		}
+		if name == "" {
+			continue
+		}
+		byNameVersion[name+"@"+version] = key
+		displayVersion := pkg.Version
+		if displayVersion == "" {
+			displayVersion = pnpmStripPeers(version)
+		}
+		graph.Packages[key] = &lockedPackage{
+			Name:      name,
+			Version:   displayVersion,
+			Integrity: pkg.Resolution.Integrity,
+			Resolved:  pkg.Resolution.Tarball,
+			Dev:       pkg.Dev,
+		}
+	}
+
+	lookup := func(name, version string) (string, bool) {
+		if strings.HasPrefix(version, "link:") || strings.HasPrefix(version, "file:") {
+			return "", false
+		}
+		if _, ok := keys[version]; ok {
+			return version, true
+		}
+		if key, ok := byNameVersion[name+"@"+version]; ok {
+			return key, true
+		}
+		// pnpm 5 and 6 record aliased dependencies by their package key
+		// without the leading slash.
+		if _, ok := keys["/"+version]; ok {
+			return "/" + version, true
+		}
+		return "", false
+	}
+
+	for key, pkg := range keys {
+		deps := make(map[string]string)
+		for name, version := range pkg.Dependencies {
+			deps[name] = version
+		}
+		for name, version := range pkg.OptionalDependencies {
+			deps[name] = version
+		}
+		for _, name := range sortedKeys(deps) {
+			if target, ok := lookup(name, deps[name]); ok {
+				graph.Packages[key].Dependencies = append(graph.Packages[key].Dependencies, target)
+			}
+		}
+	}
+
+	importers := lock.Importers
+	if len(importers) == 0 {
+		importers = map[string]pnpmImporter{".": lock.pnpmImporter}
+	}
+	for _, importerPath := range sortedKeys(importers) {
+		importer := importers[importerPath]
+		workspace := ""
+		if importerPath != "." {
+			workspace = importerPath
+			if manifest, ok := readPackageManifest(filepath.Join(filepath.Dir(path), filepath.FromSlash(importerPath))); ok && manifest.Name != "" {
+				workspace = manifest.Name
+			}
+		}
+		addRoots := func(deps map[string]pnpmDependency, dev bool) {
+			for _, name := range sortedKeys(deps) {
+				if target, ok := lookup(name, deps[name].Version); ok {
+					graph.Roots = append(graph.Roots, lockfileRoot{Key: target, Dev: dev, Workspace: workspace})
+				}
+			}
+		}
+		addRoots(importer.Dependencies, false)
+		addRoots(importer.OptionalDependencies, false)
+		addRoots(importer.DevDependencies, true)
+	}
+
+	return graph.findings("Node.js", repoName, path), nil
+}
+
+// parsePnpmPackageKey splits the package keys used across pnpm lockfile
+// versions ("/name/1.0.0_peer", "/name@1.0.0(peer)" and "name@1.0.0(peer)")
+// into a name and a version that still carries any peer suffix.
+func parsePnpmPackageKey(key string) (string, string) {
+	trimmed := strings.TrimPrefix(key, "/")
+	if trimmed == "" {
+		return "", ""
+	}
+	// pnpm 5 style "name/version" or "@scope/name/version"
+	segments := strings.Split(pnpmStripPeerSuffix(trimmed), "/")
+	nameSegments := 1
+	if strings.HasPrefix(trimmed, "@") {
+		nameSegments = 2
+	}
+	if len(segments) > nameSegments && startsWithDigit(segments[nameSegments]) {
+		name := path.Join(segments[:nameSegments]...)
+		return name, strings.TrimPrefix(trimmed, name+"/")
+	}
+	base := pnpmStripPeerSuffix(trimmed)
+	if at := strings.LastIndex(base, "@"); at > 0 {
+		return trimmed[:at], trimmed[at+1:]
+	}
+	return "", ""
+}
+
+func startsWithDigit(value string) bool {
+	return value != "" && value[0] >= '0' && value[0] <= '9'
+}
+
+// pnpmStripPeerSuffix removes a "(peer@version)" suffix from a key or version.
+func pnpmStripPeerSuffix(value string) string {
+	if idx := strings.Index(value, "("); idx >= 0 {
+		return value[:idx]
+	}
+	return value
+}
+
+// pnpmStripPeers removes the peer dependency suffix pnpm appends to versions.
+func pnpmStripPeers(version string) string {
+	version = pnpmStripPeerSuffix(version)
+	if idx := strings.Index(version, "_"); idx >= 0 {
+		version = version[:idx]
+	}
+	return version
+}
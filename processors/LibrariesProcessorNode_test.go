@@ -0,0 +1,404 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reaandrew/techdetector/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findingsByName(findings []core.Finding) map[string]core.Finding {
+	result := make(map[string]core.Finding)
+	for _, finding := range findings {
+		key := finding.Name
+		if workspace, ok := finding.Properties["Workspace"]; ok {
+			key += "|" + workspace.(string)
+		}
+		result[key] = finding
+	}
+	return result
+}
+
+func TestSupportsJavaScriptLockfiles(t *testing.T) {
+	processor := NewLibrariesProcessor()
+
+	assert.True(t, processor.Supports("app/package-lock.json"))
+	assert.True(t, processor.Supports("app/npm-shrinkwrap.json"))
+	assert.True(t, processor.Supports("app/yarn.lock"))
+	assert.True(t, processor.Supports("app/pnpm-lock.yaml"))
+	assert.False(t, processor.Supports("app/node_modules/left-pad/package-lock.json"))
+}
+
+func TestParsePackageLockJSONV3(t *testing.T) {
+	processor := NewLibrariesProcessor()
+	content := `{
+  "name": "app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {
+      "name": "app",
+      "workspaces": ["packages/*"],
+      "dependencies": {"express": "^4.18.0"},
+      "devDependencies": {"jest": "^29.0.0"}
+    },
+    "packages/web": {
+      "name": "@app/web",
+      "version": "1.0.0",
+      "dependencies": {"react": "^18.0.0"}
+    },
+    "node_modules/@app/web": {"resolved": "packages/web", "link": true},
+    "node_modules/express": {
+      "version": "4.18.2",
+      "resolved": "https://registry.npmjs.org/express/-/express-4.18.2.tgz",
+      "integrity": "sha512-express",
+      "dependencies": {"debug": "2.6.9"}
+    },
+    "node_modules/debug": {"version": "2.6.9", "integrity": "sha512-debug"},
+    "node_modules/jest": {"version": "29.7.0", "dev": true, "integrity": "sha512-jest"},
+    "node_modules/react": {"version": "18.2.0", "integrity": "sha512-react"}
+  }
+}`
+
+	findings, err := processor.Process("app/package-lock.json", "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 4)
+
+	express := byName["express"]
+	assert.Equal(t, "Library", express.Type)
+	assert.Equal(t, "4.18.2", express.Properties["Version"])
+	assert.Equal(t, true, express.Properties["Direct"])
+	assert.Equal(t, "prod", express.Properties["Scope"])
+	assert.Equal(t, "sha512-express", express.Properties["Integrity"])
+	assert.Equal(t, "https://registry.npmjs.org/express/-/express-4.18.2.tgz", express.Properties["Resolved"])
+
+	debug := byName["debug"]
+	assert.Equal(t, false, debug.Properties["Direct"])
+	assert.Equal(t, "prod", debug.Properties["Scope"])
+
+	assert.Equal(t, "dev", byName["jest"].Properties["Scope"])
+
+	react := byName["react|@app/web"]
+	assert.Equal(t, true, react.Properties["Direct"])
+	assert.Equal(t, "18.2.0", react.Properties["Version"])
+}
+
+func TestParsePackageLockJSONV1NestedDependencies(t *testing.T) {
+	processor := NewLibrariesProcessor()
+	content := `{
+  "name": "app",
+  "lockfileVersion": 1,
+  "requires": true,
+  "dependencies": {
+    "a": {
+      "version": "1.0.0",
+      "integrity": "sha1-a",
+      "requires": {"b": "^2.0.0"},
+      "dependencies": {
+        "b": {"version": "2.1.0", "integrity": "sha1-b2"}
+      }
+    },
+    "b": {"version": "1.0.0", "dev": true, "integrity": "sha1-b1"}
+  }
+}`
+
+	findings, err := processor.Process(filepath.Join(t.TempDir(), "npm-shrinkwrap.json"), "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+
+	versions := make(map[string]core.Finding)
+	for _, finding := range findings {
+		versions[finding.Name+"@"+finding.Properties["Version"].(string)] = finding
+	}
+	assert.Equal(t, true, versions["a@1.0.0"].Properties["Direct"])
+	assert.Equal(t, false, versions["b@2.1.0"].Properties["Direct"])
+	assert.Equal(t, "prod", versions["b@2.1.0"].Properties["Scope"])
+	assert.Equal(t, "dev", versions["b@1.0.0"].Properties["Scope"])
+}
+
+func TestParseYarnLockClassic(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{
+  "dependencies": {"lodash": "^4.17.0"},
+  "devDependencies": {"chalk": "^4.0.0"}
+}`), 0o644))
+
+	content := `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@types/node@*", "@types/node@^18.0.0":
+  version "18.11.9"
+  resolved "https://registry.yarnpkg.com/@types/node/-/node-18.11.9.tgz#abc"
+  integrity sha512-types
+
+chalk@^4.0.0:
+  version "4.1.2"
+  resolved "https://registry.yarnpkg.com/chalk/-/chalk-4.1.2.tgz#def"
+  integrity sha512-chalk
+  dependencies:
+    supports-color "^7.1.0"
+
+lodash@^4.17.0:
+  version "4.17.21"
+  integrity sha512-lodash
+
+supports-color@^7.1.0:
+  version "7.2.0"
+  integrity sha512-supports
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "yarn.lock"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 4)
+
+	assert.Equal(t, "18.11.9", byName["@types/node"].Properties["Version"])
+	assert.Equal(t, true, byName["lodash"].Properties["Direct"])
+	assert.Equal(t, "prod", byName["lodash"].Properties["Scope"])
+	assert.Equal(t, "sha512-lodash", byName["lodash"].Properties["Integrity"])
+	assert.Equal(t, "dev", byName["chalk"].Properties["Scope"])
+	assert.Equal(t, false, byName["supports-color"].Properties["Direct"])
+	assert.Equal(t, "dev", byName["supports-color"].Properties["Scope"])
+}
+
+func TestParseYarnLockBerry(t *testing.T) {
+	content := `__metadata:
+  version: 6
+  cacheKey: 8
+
+"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+  dependencies:
+    left-pad: ^1.3.0
+  languageName: unknown
+  linkType: soft
+
+"@app/api@workspace:packages/api":
+  version: 0.0.0-use.local
+  resolution: "@app/api@workspace:packages/api"
+  dependencies:
+    ms: ^2.1.0
+  languageName: unknown
+  linkType: soft
+
+"left-pad@npm:^1.3.0":
+  version: 1.3.0
+  resolution: "left-pad@npm:1.3.0"
+  checksum: 5fcc2d
+  languageName: node
+  linkType: hard
+
+"ms@npm:^2.1.0, ms@npm:^2.1.1":
+  version: 2.1.3
+  resolution: "ms@npm:2.1.3"
+  checksum: aa92de
+  languageName: node
+  linkType: hard
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(t.TempDir(), "yarn.lock"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 2)
+
+	leftPad := byName["left-pad"]
+	assert.Equal(t, "1.3.0", leftPad.Properties["Version"])
+	assert.Equal(t, true, leftPad.Properties["Direct"])
+	assert.Equal(t, "5fcc2d", leftPad.Properties["Integrity"])
+
+	ms := byName["ms|@app/api"]
+	assert.Equal(t, "2.1.3", ms.Properties["Version"])
+	assert.Equal(t, true, ms.Properties["Direct"])
+}
+
+func TestParseYarnLockMalformed(t *testing.T) {
+	content := `:
+"":
+  version: 1.0.0
+  dependencies:
+    "": ^1.0.0
+, left-pad@^1.3.0:
+  version "1.3.0"
+  dependencies:
+    "" "^2.0.0"
+,
+`
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(t.TempDir(), "yarn.lock"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 1)
+	assert.Equal(t, "1.3.0", byName["left-pad"].Properties["Version"])
+
+	assert.Equal(t, "", yarnDescriptorName(""))
+	assert.Equal(t, "@", yarnDescriptorName("@"))
+}
+
+func TestParsePnpmLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "pnpm 5",
+			content: `lockfileVersion: 5.4
+
+specifiers:
+  react-dom: ^18.2.0
+  typescript: ^5.0.0
+
+dependencies:
+  react-dom: 18.2.0_react@18.2.0
+
+devDependencies:
+  typescript: 5.1.6
+
+packages:
+
+  /react-dom/18.2.0_react@18.2.0:
+    resolution: {integrity: sha512-dom}
+    dependencies:
+      react: 18.2.0
+    dev: false
+
+  /react/18.2.0:
+    resolution: {integrity: sha512-react}
+    dev: false
+
+  /typescript/5.1.6:
+    resolution: {integrity: sha512-ts}
+    dev: true
+`,
+		},
+		{
+			name: "pnpm 6",
+			content: `lockfileVersion: '6.0'
+
+dependencies:
+  react-dom:
+    specifier: ^18.2.0
+    version: 18.2.0(react@18.2.0)
+
+devDependencies:
+  typescript:
+    specifier: ^5.0.0
+    version: 5.1.6
+
+packages:
+
+  /react-dom@18.2.0(react@18.2.0):
+    resolution: {integrity: sha512-dom}
+    dependencies:
+      react: 18.2.0
+    dev: false
+
+  /react@18.2.0:
+    resolution: {integrity: sha512-react}
+    dev: false
+
+  /typescript@5.1.6:
+    resolution: {integrity: sha512-ts}
+    dev: true
+`,
+		},
+		{
+			name: "pnpm 9",
+			content: `lockfileVersion: '9.0'
+
+importers:
+
+  .:
+    dependencies:
+      react-dom:
+        specifier: ^18.2.0
+        version: 18.2.0(react@18.2.0)
+    devDependencies:
+      typescript:
+        specifier: ^5.0.0
+        version: 5.1.6
+
+packages:
+
+  react-dom@18.2.0:
+    resolution: {integrity: sha512-dom}
+
+  react@18.2.0:
+    resolution: {integrity: sha512-react}
+
+  typescript@5.1.6:
+    resolution: {integrity: sha512-ts}
+
+snapshots:
+
+  react-dom@18.2.0(react@18.2.0):
+    dependencies:
+      react: 18.2.0
+
+  react@18.2.0: {}
+
+  typescript@5.1.6: {}
+`,
+		},
+	}
+
+	processor := NewLibrariesProcessor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := processor.Process(filepath.Join(t.TempDir(), "pnpm-lock.yaml"), "repo", tt.content)
+			require.NoError(t, err)
+			byName := findingsByName(findings)
+			require.Len(t, byName, 3)
+
+			assert.Equal(t, "18.2.0", byName["react-dom"].Properties["Version"])
+			assert.Equal(t, true, byName["react-dom"].Properties["Direct"])
+			assert.Equal(t, "sha512-dom", byName["react-dom"].Properties["Integrity"])
+			assert.Equal(t, false, byName["react"].Properties["Direct"])
+			assert.Equal(t, "prod", byName["react"].Properties["Scope"])
+			assert.Equal(t, "dev", byName["typescript"].Properties["Scope"])
+			assert.Equal(t, true, byName["typescript"].Properties["Direct"])
+		})
+	}
+}
+
+func TestParsePnpmLockWorkspaces(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "packages", "api"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "packages", "api", "package.json"), []byte(`{"name": "@app/api"}`), 0o644))
+
+	content := `lockfileVersion: '9.0'
+
+importers:
+
+  .: {}
+
+  packages/api:
+    dependencies:
+      ms:
+        specifier: ^2.1.0
+        version: 2.1.3
+
+packages:
+
+  ms@2.1.3:
+    resolution: {integrity: sha512-ms}
+
+snapshots:
+
+  ms@2.1.3: {}
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "pnpm-lock.yaml"), "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "ms", findings[0].Name)
+	assert.Equal(t, "@app/api", findings[0].Properties["Workspace"])
+	assert.Equal(t, "prod", findings[0].Properties["Scope"])
+}
@@ -0,0 +1,125 @@
+package processors
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// composerManifest is the subset of composer.json used to report dependencies.
+type composerManifest struct {
+	Require    map[string]string `json:"require"`
+	RequireDev map[string]string `json:"require-dev"`
+}
+
+// isComposerPlatformPackage reports whether name is a platform requirement
+// such as "php" or "ext-json" rather than an installable package.
+func isComposerPlatformPackage(name string) bool {
+	if strings.Contains(name, "/") {
+		return false
+	}
+	return name == "php" || name == "hhvm" || name == "composer" ||
+		strings.HasPrefix(name, "ext-") || strings.HasPrefix(name, "lib-") ||
+		strings.HasPrefix(name, "php-") || strings.HasPrefix(name, "composer-")
+}
+
+func (mp *LibrariesProcessor) parseComposerJSON(content string, repoName string, path string) ([]core.Finding, error) {
+	var manifest composerManifest
+	if err := json.Unmarshal([]byte(content), &manifest); err != nil {
+		return nil, err
+	}
+
+	var matches []core.Finding
+	add := func(deps map[string]string, scope string) {
+		for _, name := range sortedKeys(deps) {
+			if isComposerPlatformPackage(name) {
+				continue
+			}
+			matches = append(matches, core.Finding{
+				Name:     name,
+				Type:     "Library",
+				Category: "",
+				Properties: map[string]interface{}{
+					"Language": "PHP",
+					"Version":  deps[name],
+					"Direct":   true,
+					"Scope":    scope,
+				},
+				Path:     path,
+				RepoName: repoName,
+			})
+		}
+	}
+	add(manifest.Require, "prod")
+	add(manifest.RequireDev, "dev")
+
+	return matches, nil
+}
+
+func (mp *LibrariesProcessor) parseComposerLock(content string, repoName string, path string) ([]core.Finding, error) {
+	type composerPackage struct {
+		Name    string            `json:"name"`
+		Version string            `json:"version"`
+		Require map[string]string `json:"require"`
+		Source  struct {
+			URL       string `json:"url"`
+			Reference string `json:"reference"`
+		} `json:"source"`
+		Dist struct {
+			URL    string `json:"url"`
+			Shasum string `json:"shasum"`
+		} `json:"dist"`
+	}
+
+	type composerLock struct {
+		Packages    []composerPackage `json:"packages"`
+		PackagesDev []composerPackage `json:"packages-dev"`
+	}
+
+	var lock composerLock
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil, err
+	}
+
+	graph := newLockfileGraph()
+	add := func(packages []composerPackage, dev bool) {
+		for _, pkg := range packages {
+			dev := dev
+			locked := &lockedPackage{
+				Name:      pkg.Name,
+				Version:   pkg.Version,
+				Integrity: pkg.Dist.Shasum,
+				Resolved:  pkg.Dist.URL,
+				Dev:       &dev,
+			}
+			if locked.Resolved == "" {
+				locked.Resolved = pkg.Source.URL
+			}
+			for _, name := range sortedKeys(pkg.Require) {
+				if !isComposerPlatformPackage(name) {
+					locked.Dependencies = append(locked.Dependencies, strings.ToLower(name))
+				}
+			}
+			graph.Packages[strings.ToLower(pkg.Name)] = locked
+		}
+	}
+	add(lock.Packages, false)
+	add(lock.PackagesDev, true)
+
+	if manifestContent, err := os.ReadFile(filepath.Join(filepath.Dir(path), "composer.json")); err == nil {
+		var manifest composerManifest
+		if err := json.Unmarshal(manifestContent, &manifest); err == nil {
+			for _, name := range sortedKeys(manifest.Require) {
+				graph.Roots = append(graph.Roots, lockfileRoot{Key: strings.ToLower(name)})
+			}
+			for _, name := range sortedKeys(manifest.RequireDev) {
+				graph.Roots = append(graph.Roots, lockfileRoot{Key: strings.ToLower(name), Dev: true})
+			}
+		}
+	}
+
+	return graph.findings("PHP", repoName, path), nil
+}
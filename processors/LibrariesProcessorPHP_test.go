@@ -0,0 +1,69 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testComposerJSON = `{
+  "require": {
+    "php": ">=8.1",
+    "ext-json": "*",
+    "laravel/framework": "^10.0"
+  },
+  "require-dev": {
+    "phpunit/phpunit": "^10.1"
+  }
+}`
+
+func TestParseComposerJSON(t *testing.T) {
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process("composer.json", "repo", testComposerJSON)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 2)
+
+	assert.Equal(t, "PHP", byName["laravel/framework"].Properties["Language"])
+	assert.Equal(t, "^10.0", byName["laravel/framework"].Properties["Version"])
+	assert.Equal(t, "dev", byName["phpunit/phpunit"].Properties["Scope"])
+}
+
+func TestParseComposerLock(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "composer.json"), []byte(testComposerJSON), 0o644))
+
+	content := `{
+  "packages": [
+    {
+      "name": "laravel/framework",
+      "version": "v10.28.0",
+      "require": {"php": "^8.1", "symfony/console": "^6.2"},
+      "dist": {"type": "zip", "url": "https://api.github.com/repos/laravel/framework/zipball/abc", "shasum": ""}
+    },
+    {
+      "name": "symfony/console",
+      "version": "v6.3.4",
+      "dist": {"type": "zip", "url": "https://api.github.com/repos/symfony/console/zipball/def", "shasum": "c0ffee"}
+    }
+  ],
+  "packages-dev": [
+    {"name": "phpunit/phpunit", "version": "10.4.1"}
+  ]
+}`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "composer.lock"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 3)
+
+	assert.Equal(t, "v10.28.0", byName["laravel/framework"].Properties["Version"])
+	assert.Equal(t, true, byName["laravel/framework"].Properties["Direct"])
+	assert.Equal(t, false, byName["symfony/console"].Properties["Direct"])
+	assert.Equal(t, "c0ffee", byName["symfony/console"].Properties["Integrity"])
+	assert.Equal(t, "dev", byName["phpunit/phpunit"].Properties["Scope"])
+}
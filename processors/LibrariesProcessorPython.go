@@ -0,0 +1,953 @@
+package processors
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+var (
+	pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+	pythonRequirementRe  = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[([^\]]*)\])?\s*(.*)$`)
+	pythonEggRe          = regexp.MustCompile(`#egg=([A-Za-z0-9][A-Za-z0-9._-]*)`)
+	pythonStringRe       = regexp.MustCompile(`'([^'\\]*)'|"([^"\\]*)"`)
+	pythonIdentifierRe   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+	pythonDictKeyRe      = regexp.MustCompile(`['"]([^'"]+)['"]\s*:\s*`)
+)
+
+// pythonRequirement is a single PEP 508 dependency specification.
+type pythonRequirement struct {
+	Name    string
+	Version string
+	Extras  []string
+	Markers string
+	URL     string
+	Hashes  []string
+}
+
+// normalizePythonName applies the PEP 503 name normalisation so the same
+// package is reported identically whichever file declared it.
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparators.ReplaceAllString(strings.TrimSpace(name), "-"))
+}
+
+// parsePEP508 parses a requirement such as
+// "requests[security]>=2.8.1,<3; python_version < '3.8'".
+func parsePEP508(spec string) (pythonRequirement, bool) {
+	var req pythonRequirement
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return req, false
+	}
+
+	// A URL requirement may itself contain ";", so PEP 508 requires its
+	// markers to be separated by whitespace.
+	markerIdx := strings.Index(spec, ";")
+	if strings.Contains(spec, "://") {
+		markerIdx = strings.Index(spec, " ;")
+	}
+	if markerIdx >= 0 {
+		req.Markers = strings.TrimSpace(strings.TrimLeft(spec[markerIdx:], " ;"))
+		spec = strings.TrimSpace(spec[:markerIdx])
+	}
+
+	match := pythonRequirementRe.FindStringSubmatch(spec)
+	if match == nil {
+		return req, false
+	}
+	req.Name = normalizePythonName(match[1])
+	if match[2] != "" {
+		for _, extra := range strings.Split(match[2], ",") {
+			if extra = strings.TrimSpace(extra); extra != "" {
+				req.Extras = append(req.Extras, extra)
+			}
+		}
+	}
+
+	rest := strings.TrimSpace(match[3])
+	switch {
+	case strings.HasPrefix(rest, "@"):
+		req.URL = strings.TrimSpace(rest[1:])
+		req.Version = "N/A"
+	case rest == "":
+		req.Version = "N/A"
+	default:
+		rest = strings.Trim(rest, "()")
+		req.Version = strings.Join(strings.Fields(rest), "")
+	}
+	return req, true
+}
+
+// properties returns the finding properties for a requirement, only adding the
+// optional fields when they are present.
+func (r pythonRequirement) properties() map[string]interface{} {
+	properties := map[string]interface{}{
+		"Language": "Python",
+		"Version":  r.Version,
+	}
+	if len(r.Extras) > 0 {
+		properties["Extras"] = r.Extras
+	}
+	if r.Markers != "" {
+		properties["Markers"] = r.Markers
+	}
+	if r.URL != "" {
+		properties["URL"] = r.URL
+	}
+	if len(r.Hashes) > 0 {
+		properties["Hashes"] = r.Hashes
+	}
+	return properties
+}
+
+func pythonFinding(name string, properties map[string]interface{}, repoName string, path string) core.Finding {
+	return core.Finding{
+		Name:       name,
+		Type:       "Library",
+		Category:   "",
+		Properties: properties,
+		Path:       path,
+		RepoName:   repoName,
+	}
+}
+
+// isRequirementsFile reports whether path is a pip requirements file, such as
+// requirements-dev.txt or a pip-tools input under requirements/.
+func isRequirementsFile(path string) bool {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	if ext != ".txt" && ext != ".in" {
+		return false
+	}
+	if strings.HasPrefix(base, "requirements") {
+		return true
+	}
+	return filepath.Base(filepath.Dir(path)) == "requirements"
+}
+
+// requirementsLines returns the logical lines of a requirements file with
+// line continuations joined and comments removed.
+func requirementsLines(content string) []string {
+	var lines []string
+	var current strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			line = ""
+		} else if idx := strings.Index(line, " #"); idx >= 0 {
+			line = line[:idx]
+		}
+		if strings.HasSuffix(line, "\\") {
+			current.WriteString(strings.TrimSuffix(line, "\\") + " ")
+			continue
+		}
+		current.WriteString(line)
+		if trimmed := strings.TrimSpace(current.String()); trimmed != "" {
+			lines = append(lines, trimmed)
+		}
+		current.Reset()
+	}
+	if trimmed := strings.TrimSpace(current.String()); trimmed != "" {
+		lines = append(lines, trimmed)
+	}
+	return lines
+}
+
+// requirementsOption returns the value of an option line such as
+// "-r base.txt" or "--requirement=base.txt" when it is one of names.
+func requirementsOption(line string, names ...string) (string, bool) {
+	for _, name := range names {
+		if strings.HasPrefix(line, name+"=") {
+			return strings.TrimSpace(line[len(name)+1:]), true
+		}
+		if strings.HasPrefix(line, name+" ") || (len(name) == 2 && strings.HasPrefix(line, name) && len(line) > 2) {
+			return strings.TrimSpace(line[len(name):]), true
+		}
+	}
+	return "", false
+}
+
+type includedRequirement struct {
+	pythonRequirement
+	Include string
+}
+
+// readRequirements parses a requirements file, following -r includes relative
+// to the including file and applying -c constraints to unpinned requirements.
+// Includes and constraints outside the repository are ignored.
+func readRequirements(content string, path string, include string, visited map[string]bool) []includedRequirement {
+	var requirements []includedRequirement
+	constraints := make(map[string]string)
+	dir := filepath.Dir(path)
+
+	for _, line := range requirementsLines(content) {
+		if target, ok := requirementsOption(line, "-r", "--requirement"); ok {
+			includePath := filepath.Join(dir, target)
+			if visited[includePath] || !withinRepository(path, includePath) {
+				continue
+			}
+			visited[includePath] = true
+			included, err := os.ReadFile(includePath)
+			if err != nil {
+				continue
+			}
+			label := target
+			if include != "" {
+				label = filepath.ToSlash(filepath.Join(filepath.Dir(include), target))
+			}
+			requirements = append(requirements, readRequirements(string(included), includePath, label, visited)...)
+			continue
+		}
+		if target, ok := requirementsOption(line, "-c", "--constraint"); ok {
+			constraintPath := filepath.Join(dir, target)
+			if !withinRepository(path, constraintPath) {
+				continue
+			}
+			constraintContent, err := os.ReadFile(constraintPath)
+			if err != nil {
+				continue
+			}
+			for _, constraint := range requirementsLines(string(constraintContent)) {
+				if req, ok := parsePEP508(strings.SplitN(constraint, " --", 2)[0]); ok && req.Version != "N/A" {
+					constraints[req.Name] = req.Version
+				}
+			}
+			continue
+		}
+
+		var req pythonRequirement
+		if target, ok := requirementsOption(line, "-e", "--editable"); ok {
+			egg := pythonEggRe.FindStringSubmatch(target)
+			if egg == nil {
+				continue
+			}
+			req = pythonRequirement{Name: normalizePythonName(egg[1]), Version: "N/A", URL: strings.SplitN(target, "#", 2)[0]}
+		} else if strings.HasPrefix(line, "-") {
+			continue
+		} else if strings.Contains(line, "://") && !strings.Contains(line, "@") || strings.HasPrefix(line, "git+") {
+			egg := pythonEggRe.FindStringSubmatch(line)
+			if egg == nil {
+				continue
+			}
+			req = pythonRequirement{Name: normalizePythonName(egg[1]), Version: "N/A", URL: strings.SplitN(line, "#", 2)[0]}
+		} else {
+			parts := strings.Split(line, " --")
+			parsed, ok := parsePEP508(parts[0])
+			if !ok {
+				continue
+			}
+			req = parsed
+			for _, option := range parts[1:] {
+				if hash, ok := requirementsOption("--"+strings.TrimSpace(option), "--hash"); ok {
+					req.Hashes = append(req.Hashes, hash)
+				}
+			}
+		}
+		requirements = append(requirements, includedRequirement{pythonRequirement: req, Include: include})
+	}
+
+	for i := range requirements {
+		if constraint, ok := constraints[requirements[i].Name]; ok && requirements[i].Version == "N/A" {
+			requirements[i].Version = constraint
+		}
+	}
+	return requirements
+}
+
+func (mp *LibrariesProcessor) parsePipfile(content string, repoName string, path string) ([]core.Finding, error) {
+	type pipfile struct {
+		Packages    map[string]interface{} `toml:"packages"`
+		DevPackages map[string]interface{} `toml:"dev-packages"`
+	}
+
+	var pf pipfile
+	if _, err := toml.Decode(content, &pf); err != nil {
+		return nil, err
+	}
+
+	var matches []core.Finding
+	add := func(packages map[string]interface{}, scope string) {
+		for _, name := range sortedKeys(packages) {
+			req := pythonRequirement{Name: normalizePythonName(name), Version: "N/A"}
+			switch value := packages[name].(type) {
+			case string:
+				if value != "*" && value != "" {
+					req.Version = value
+				}
+			case map[string]interface{}:
+				if version, ok := value["version"].(string); ok && version != "*" {
+					req.Version = version
+				}
+				if markers, ok := value["markers"].(string); ok {
+					req.Markers = markers
+				}
+				if extras, ok := value["extras"].([]interface{}); ok {
+					for _, extra := range extras {
+						if s, ok := extra.(string); ok {
+							req.Extras = append(req.Extras, s)
+						}
+					}
+				}
+				for _, vcs := range []string{"git", "hg", "svn", "path", "file"} {
+					if url, ok := value[vcs].(string); ok {
+						req.URL = url
+					}
+				}
+			}
+			properties := req.properties()
+			properties["Scope"] = scope
+			matches = append(matches, pythonFinding(req.Name, properties, repoName, path))
+		}
+	}
+	add(pf.Packages, "prod")
+	add(pf.DevPackages, "dev")
+
+	return matches, nil
+}
+
+func (mp *LibrariesProcessor) parsePipfileLock(content string, repoName string, path string) ([]core.Finding, error) {
+	type lockedRequirement struct {
+		Version string   `json:"version"`
+		Hashes  []string `json:"hashes"`
+		Markers string   `json:"markers"`
+		Extras  []string `json:"extras"`
+		Git     string   `json:"git"`
+		Ref     string   `json:"ref"`
+	}
+
+	type pipfileLock struct {
+		Default map[string]lockedRequirement `json:"default"`
+		Develop map[string]lockedRequirement `json:"develop"`
+	}
+
+	var lock pipfileLock
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil, err
+	}
+
+	// Pipfile.lock does not record which packages were asked for, so read
+	// the Pipfile beside it when it is available.
+	var declared map[string]bool
+	if pipfileContent, err := os.ReadFile(filepath.Join(filepath.Dir(path), "Pipfile")); err == nil {
+		if findings, err := mp.parsePipfile(string(pipfileContent), repoName, path); err == nil {
+			declared = make(map[string]bool)
+			for _, finding := range findings {
+				declared[finding.Name] = true
+			}
+		}
+	}
+
+	var matches []core.Finding
+	add := func(packages map[string]lockedRequirement, scope string) {
+		for _, name := range sortedKeys(packages) {
+			pkg := packages[name]
+			normalized := normalizePythonName(name)
+			version := strings.TrimPrefix(pkg.Version, "==")
+			if version == "" {
+				version = pkg.Ref
+			}
+			req := pythonRequirement{
+				Name:    normalized,
+				Version: version,
+				Extras:  pkg.Extras,
+				Markers: pkg.Markers,
+				URL:     pkg.Git,
+				Hashes:  pkg.Hashes,
+			}
+			properties := req.properties()
+			properties["Scope"] = scope
+			if declared != nil {
+				properties["Direct"] = declared[normalized]
+			}
+			matches = append(matches, pythonFinding(normalized, properties, repoName, path))
+		}
+	}
+	add(lock.Default, "prod")
+	add(lock.Develop, "dev")
+
+	return matches, nil
+}
+
+// pyprojectRoots reads the pyproject.toml beside a lockfile and returns the
+// normalised names of its direct dependencies mapped to whether they are dev
+// only.
+func pyprojectRoots(dir string) map[string]bool {
+	type dependencyGroup struct {
+		Dependencies map[string]interface{} `toml:"dependencies"`
+	}
+	type pyproject struct {
+		Project struct {
+			Dependencies         []string            `toml:"dependencies"`
+			OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+		} `toml:"project"`
+		DependencyGroups map[string][]interface{} `toml:"dependency-groups"`
+		Tool             struct {
+			Poetry struct {
+				Dependencies    map[string]interface{}     `toml:"dependencies"`
+				DevDependencies map[string]interface{}     `toml:"dev-dependencies"`
+				Group           map[string]dependencyGroup `toml:"group"`
+			} `toml:"poetry"`
+		} `toml:"tool"`
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	if err != nil {
+		return nil
+	}
+	var py pyproject
+	if _, err := toml.Decode(string(content), &py); err != nil {
+		return nil
+	}
+
+	roots := make(map[string]bool)
+	addDev := func(name string) {
+		if _, ok := roots[name]; !ok {
+			roots[name] = true
+		}
+	}
+	for name := range py.Tool.Poetry.Dependencies {
+		roots[normalizePythonName(name)] = false
+	}
+	for _, spec := range py.Project.Dependencies {
+		if req, ok := parsePEP508(spec); ok {
+			roots[req.Name] = false
+		}
+	}
+	for _, specs := range py.Project.OptionalDependencies {
+		for _, spec := range specs {
+			if req, ok := parsePEP508(spec); ok {
+				roots[req.Name] = false
+			}
+		}
+	}
+	for name := range py.Tool.Poetry.DevDependencies {
+		addDev(normalizePythonName(name))
+	}
+	for _, group := range py.Tool.Poetry.Group {
+		for name := range group.Dependencies {
+			addDev(normalizePythonName(name))
+		}
+	}
+	for _, specs := range py.DependencyGroups {
+		for _, spec := range specs {
+			if s, ok := spec.(string); ok {
+				if req, ok := parsePEP508(s); ok {
+					addDev(req.Name)
+				}
+			}
+		}
+	}
+	delete(roots, "python")
+	return roots
+}
+
+func (mp *LibrariesProcessor) parsePoetryLock(content string, repoName string, path string) ([]core.Finding, error) {
+	type poetryPackage struct {
+		Name         string                 `toml:"name"`
+		Version      string                 `toml:"version"`
+		Category     string                 `toml:"category"`
+		Dependencies map[string]interface{} `toml:"dependencies"`
+		Files        []struct {
+			Hash string `toml:"hash"`
+		} `toml:"files"`
+		Source struct {
+			Type      string `toml:"type"`
+			URL       string `toml:"url"`
+			Reference string `toml:"reference"`
+		} `toml:"source"`
+	}
+
+	type poetryLock struct {
+		Packages []poetryPackage `toml:"package"`
+	}
+
+	var lock poetryLock
+	if _, err := toml.Decode(content, &lock); err != nil {
+		return nil, err
+	}
+
+	graph := newLockfileGraph()
+	for _, pkg := range lock.Packages {
+		key := normalizePythonName(pkg.Name)
+		locked := &lockedPackage{
+			Name:     key,
+			Version:  pkg.Version,
+			Resolved: pkg.Source.URL,
+		}
+		for _, file := range pkg.Files {
+			locked.Hashes = append(locked.Hashes, file.Hash)
+		}
+		if pkg.Category != "" {
+			dev := pkg.Category == "dev"
+			locked.Dev = &dev
+		}
+		for _, dep := range sortedKeys(pkg.Dependencies) {
+			locked.Dependencies = append(locked.Dependencies, normalizePythonName(dep))
+		}
+		graph.Packages[key] = locked
+	}
+
+	roots := pyprojectRoots(filepath.Dir(path))
+	for _, name := range sortedKeys(roots) {
+		if _, ok := graph.Packages[name]; ok {
+			graph.Roots = append(graph.Roots, lockfileRoot{Key: name, Dev: roots[name]})
+		}
+	}
+
+	return graph.findings("Python", repoName, path), nil
+}
+
+func (mp *LibrariesProcessor) parseUvLock(content string, repoName string, path string) ([]core.Finding, error) {
+	type uvDependency struct {
+		Name    string `toml:"name"`
+		Version string `toml:"version"`
+	}
+
+	type uvPackage struct {
+		Name    string `toml:"name"`
+		Version string `toml:"version"`
+		Source  struct {
+			Registry string `toml:"registry"`
+			Editable string `toml:"editable"`
+			Virtual  string `toml:"virtual"`
+			Git      string `toml:"git"`
+			URL      string `toml:"url"`
+		} `toml:"source"`
+		Dependencies         []uvDependency            `toml:"dependencies"`
+		OptionalDependencies map[string][]uvDependency `toml:"optional-dependencies"`
+		DevDependencies      map[string][]uvDependency `toml:"dev-dependencies"`
+		Sdist                struct {
+			URL  string `toml:"url"`
+			Hash string `toml:"hash"`
+		} `toml:"sdist"`
+		Wheels []struct {
+			Hash string `toml:"hash"`
+		} `toml:"wheels"`
+	}
+
+	type uvLock struct {
+		Packages []uvPackage `toml:"package"`
+	}
+
+	var lock uvLock
+	if _, err := toml.Decode(content, &lock); err != nil {
+		return nil, err
+	}
+
+	graph := newLockfileGraph()
+	byName := make(map[string][]string)
+	for _, pkg := range lock.Packages {
+		name := normalizePythonName(pkg.Name)
+		byName[name] = append(byName[name], name+"@"+pkg.Version)
+	}
+	resolve := func(dep uvDependency) (string, bool) {
+		name := normalizePythonName(dep.Name)
+		if dep.Version != "" {
+			return name + "@" + dep.Version, true
+		}
+		keys := byName[name]
+		if len(keys) == 0 {
+			return "", false
+		}
+		return keys[0], true
+	}
+	resolveAll := func(deps []uvDependency) []string {
+		var keys []string
+		for _, dep := range deps {
+			if key, ok := resolve(dep); ok {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+
+	for _, pkg := range lock.Packages {
+		name := normalizePythonName(pkg.Name)
+		var optional []uvDependency
+		for _, group := range sortedKeys(pkg.OptionalDependencies) {
+			optional = append(optional, pkg.OptionalDependencies[group]...)
+		}
+
+		// Editable and virtual sources are the project itself or one of its
+		// workspace members rather than installed libraries.
+		if local := pkg.Source.Editable + pkg.Source.Virtual; local != "" {
+			workspace := ""
+			if local != "." {
+				workspace = name
+			}
+			for _, key := range resolveAll(append(pkg.Dependencies, optional...)) {
+				graph.Roots = append(graph.Roots, lockfileRoot{Key: key, Workspace: workspace})
+			}
+			for _, group := range sortedKeys(pkg.DevDependencies) {
+				for _, key := range resolveAll(pkg.DevDependencies[group]) {
+					graph.Roots = append(graph.Roots, lockfileRoot{Key: key, Dev: true, Workspace: workspace})
+				}
+			}
+			continue
+		}
+
+		locked := &lockedPackage{
+			Name:         name,
+			Version:      pkg.Version,
+			Resolved:     pkg.Sdist.URL,
+			Dependencies: resolveAll(append(pkg.Dependencies, optional...)),
+		}
+		if pkg.Sdist.Hash != "" {
+			locked.Hashes = []string{pkg.Sdist.Hash}
+		} else if len(pkg.Wheels) > 0 {
+			locked.Hashes = []string{pkg.Wheels[0].Hash}
+		}
+		if locked.Resolved == "" {
+			locked.Resolved = pkg.Source.Git + pkg.Source.URL
+		}
+		graph.Packages[name+"@"+pkg.Version] = locked
+	}
+
+	return graph.findings("Python", repoName, path), nil
+}
+
+// parseINI reads a setup.cfg style file into sections of keys, joining
+// indented continuation lines onto the previous key's value.
+func parseINI(content string) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	section := ""
+	key := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			key = ""
+			continue
+		}
+		if sections[section] == nil {
+			sections[section] = make(map[string]string)
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if key != "" {
+				sections[section][key] += "\n" + trimmed
+			}
+			continue
+		}
+		parts := strings.SplitN(trimmed, "=", 2)
+		if len(parts) != 2 {
+			parts = strings.SplitN(trimmed, ":", 2)
+		}
+		if len(parts) != 2 {
+			continue
+		}
+		key = strings.TrimSpace(parts[0])
+		sections[section][key] = strings.TrimSpace(parts[1])
+	}
+	return sections
+}
+
+func (mp *LibrariesProcessor) parseSetupCfg(content string, repoName string, path string) ([]core.Finding, error) {
+	sections := parseINI(content)
+
+	var matches []core.Finding
+	add := func(value string, scope string, group string) {
+		for _, line := range strings.Split(value, "\n") {
+			req, ok := parsePEP508(line)
+			if !ok {
+				continue
+			}
+			properties := req.properties()
+			properties["Scope"] = scope
+			if group != "" {
+				properties["Group"] = group
+			}
+			matches = append(matches, pythonFinding(req.Name, properties, repoName, path))
+		}
+	}
+
+	options := sections["options"]
+	add(options["install_requires"], "prod", "")
+	add(options["tests_require"], "dev", "")
+	extras := sections["options.extras_require"]
+	for _, group := range sortedKeys(extras) {
+		add(extras[group], "optional", group)
+	}
+
+	return matches, nil
+}
+
+// parseSetupPy statically extracts install_requires, tests_require and
+// extras_require from a setup.py without executing it. Literal lists, names
+// bound to literal lists and requirements files read by the script are
+// understood.
+func (mp *LibrariesProcessor) parseSetupPy(content string, repoName string, path string) ([]core.Finding, error) {
+	var matches []core.Finding
+	add := func(specs []string, scope string, group string) {
+		for _, spec := range specs {
+			req, ok := parsePEP508(spec)
+			if !ok {
+				continue
+			}
+			properties := req.properties()
+			properties["Scope"] = scope
+			if group != "" {
+				properties["Group"] = group
+			}
+			matches = append(matches, pythonFinding(req.Name, properties, repoName, path))
+		}
+	}
+
+	for _, keyword := range []struct {
+		name  string
+		scope string
+	}{{"install_requires", "prod"}, {"tests_require", "dev"}} {
+		if expr, ok := pythonKeywordArgument(content, keyword.name); ok {
+			add(mp.pythonListStrings(content, expr, path), keyword.scope, "")
+		}
+	}
+
+	if expr, ok := pythonKeywordArgument(content, "extras_require"); ok {
+		expr = pythonResolveName(content, expr)
+		groups := make(map[string][]string)
+		for _, entry := range pythonDictKeyRe.FindAllStringSubmatchIndex(expr, -1) {
+			group := expr[entry[2]:entry[3]]
+			value := pythonBalanced(expr[entry[1]:])
+			if value == "" {
+				value = pythonIdentifierRe.FindString(expr[entry[1]:])
+			}
+			groups[group] = mp.pythonListStrings(content, value, path)
+		}
+		for _, group := range sortedKeys(groups) {
+			add(groups[group], "optional", group)
+		}
+	}
+
+	return matches, nil
+}
+
+// pythonKeywordArgument finds "name=" in a setup() call and returns the
+// expression assigned to it.
+func pythonKeywordArgument(content string, name string) (string, bool) {
+	loc := regexp.MustCompile(`\b` + name + `\s*=\s*`).FindStringIndex(content)
+	if loc == nil {
+		return "", false
+	}
+	rest := content[loc[1]:]
+	if expr := pythonBalanced(rest); expr != "" {
+		return expr, true
+	}
+	end := strings.IndexAny(rest, ",)\n")
+	if end < 0 {
+		end = len(rest)
+	}
+	return strings.TrimSpace(rest[:end]), true
+}
+
+// pythonResolveName follows a bare identifier to the literal it was assigned.
+func pythonResolveName(content string, expr string) string {
+	if pythonIdentifierRe.FindString(expr) != expr || expr == "" {
+		return expr
+	}
+	loc := regexp.MustCompile(`(?m)^\s*` + expr + `\s*=\s*`).FindStringIndex(content)
+	if loc == nil {
+		return expr
+	}
+	if literal := pythonBalanced(content[loc[1]:]); literal != "" {
+		return literal
+	}
+	return expr
+}
+
+// pythonListStrings returns the requirement strings an expression evaluates
+// to, reading any requirements file in the repository the expression opens.
+func (mp *LibrariesProcessor) pythonListStrings(content string, expr string, path string) []string {
+	expr = pythonResolveName(content, expr)
+	if strings.HasPrefix(expr, "[") || strings.HasPrefix(expr, "(") {
+		var values []string
+		for _, match := range pythonStringRe.FindAllStringSubmatch(expr, -1) {
+			values = append(values, match[1]+match[2])
+		}
+		return values
+	}
+
+	var values []string
+	for _, match := range pythonStringRe.FindAllStringSubmatch(expr, -1) {
+		file := match[1] + match[2]
+		if !strings.HasSuffix(file, ".txt") && !strings.HasSuffix(file, ".in") {
+			continue
+		}
+		includePath := filepath.Join(filepath.Dir(path), file)
+		if !withinRepository(path, includePath) {
+			continue
+		}
+		included, err := os.ReadFile(includePath)
+		if err != nil {
+			continue
+		}
+		for _, req := range readRequirements(string(included), includePath, file, map[string]bool{includePath: true}) {
+			spec := req.Name
+			if req.Version != "N/A" {
+				spec += req.Version
+			}
+			values = append(values, spec)
+		}
+	}
+	return values
+}
+
+// pythonBalanced returns the bracketed literal at the start of s, honouring
+// quoted strings, or "" when s does not start with a bracket.
+func pythonBalanced(s string) string {
+	s = strings.TrimLeft(s, " \t\r\n")
+	if s == "" || !strings.ContainsRune("[({", rune(s[0])) {
+		return ""
+	}
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '[' || c == '(' || c == '{':
+			depth++
+		case c == ']' || c == ')' || c == '}':
+			depth--
+			if depth == 0 {
+				return s[:i+1]
+			}
+		}
+	}
+	return ""
+}
+
+func (mp *LibrariesProcessor) parseCondaEnvironment(content string, repoName string, path string) ([]core.Finding, error) {
+	type environment struct {
+		Dependencies []interface{} `yaml:"dependencies"`
+	}
+
+	var env environment
+	if err := yaml.Unmarshal([]byte(content), &env); err != nil {
+		return nil, err
+	}
+
+	var matches []core.Finding
+	for _, dependency := range env.Dependencies {
+		switch dep := dependency.(type) {
+		case string:
+			channel := ""
+			spec := dep
+			if idx := strings.Index(spec, "::"); idx >= 0 {
+				channel = spec[:idx]
+				spec = spec[idx+2:]
+			}
+			name, version := splitCondaSpec(spec)
+			if name == "" {
+				continue
+			}
+			properties := map[string]interface{}{
+				"Language": condaPackageLanguage(name),
+				"Version":  version,
+				"Source":   "conda",
+			}
+			if channel != "" {
+				properties["Channel"] = channel
+			}
+			matches = append(matches, pythonFinding(normalizePythonName(name), properties, repoName, path))
+		case map[string]interface{}:
+			pipDeps, ok := dep["pip"].([]interface{})
+			if !ok {
+				continue
+			}
+			var lines []string
+			for _, pipDep := range pipDeps {
+				if s, ok := pipDep.(string); ok {
+					lines = append(lines, s)
+				}
+			}
+			for _, req := range readRequirements(strings.Join(lines, "\n"), path, "", map[string]bool{}) {
+				properties := req.properties()
+				properties["Source"] = "pip"
+				if req.Include != "" {
+					properties["Include"] = req.Include
+				}
+				matches = append(matches, pythonFinding(req.Name, properties, repoName, path))
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// condaPythonPackages are common conda packages that are Python packages
+// but whose names do not say so.
+var condaPythonPackages = map[string]bool{
+	"aiohttp": true, "attrs": true, "black": true, "bokeh": true, "boto3": true, "click": true,
+	"cython": true, "dask": true, "django": true, "fastapi": true, "flake8": true, "flask": true,
+	"h5py": true, "ipykernel": true, "ipython": true, "ipywidgets": true, "jinja2": true,
+	"jupyter": true, "jupyterlab": true, "keras": true, "lightgbm": true, "matplotlib": true,
+	"mypy": true, "nltk": true, "notebook": true, "numba": true, "numpy": true, "pandas": true,
+	"pillow": true, "pip": true, "plotly": true, "polars": true, "requests": true,
+	"scikit-image": true, "scikit-learn": true, "scipy": true, "seaborn": true, "setuptools": true,
+	"spacy": true, "sqlalchemy": true, "statsmodels": true, "sympy": true, "tensorflow": true,
+	"torchvision": true, "tornado": true, "tqdm": true, "transformers": true, "wheel": true,
+	"xarray": true, "xgboost": true,
+}
+
+// condaPackageLanguage returns the language of a conda package. Conda
+// environments also install system libraries and other runtimes, such as
+// cudatoolkit or nodejs, so only the Python interpreter, packages named
+// py*/python-* and condaPythonPackages are taken to be Python. R packages are
+// named r-*.
+func condaPackageLanguage(name string) string {
+	name = normalizePythonName(name)
+	switch {
+	case strings.HasPrefix(name, "py") || condaPythonPackages[name]:
+		return "Python"
+	case name == "r" || strings.HasPrefix(name, "r-"):
+		return "R"
+	}
+	return "Unknown"
+}
+
+// splitCondaSpec splits a conda match spec such as "numpy=1.21=py39h" or
+// "pandas 1.3.*" into a name and version constraint, dropping any build string.
+func splitCondaSpec(spec string) (string, string) {
+	spec = strings.TrimSpace(spec)
+	idx := strings.IndexAny(spec, "=<>!~ ")
+	if idx < 0 {
+		return spec, "N/A"
+	}
+	name := spec[:idx]
+	rest := strings.TrimSpace(spec[idx:])
+	if fields := strings.Fields(rest); len(fields) > 0 && !strings.ContainsAny(fields[0][:1], "=<>!~") {
+		// "name version build" form
+		rest = "=" + fields[0]
+	}
+	if strings.HasPrefix(rest, "=") && !strings.HasPrefix(rest, "==") {
+		if build := strings.Index(rest[1:], "="); build >= 0 {
+			rest = rest[:build+1]
+		}
+	}
+	rest = strings.Join(strings.Fields(rest), "")
+	if rest == "" {
+		return name, "N/A"
+	}
+	return name, rest
+}
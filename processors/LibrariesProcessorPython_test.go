@@ -0,0 +1,369 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePEP508(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected pythonRequirement
+	}{
+		{"Django==3.2", pythonRequirement{Name: "django", Version: "==3.2"}},
+		{"zope.interface >= 5.0, < 6", pythonRequirement{Name: "zope-interface", Version: ">=5.0,<6"}},
+		{"requests[security,socks]>=2.8.1; python_version < '3.8'", pythonRequirement{
+			Name:    "requests",
+			Version: ">=2.8.1",
+			Extras:  []string{"security", "socks"},
+			Markers: "python_version < '3.8'",
+		}},
+		{"pip @ https://github.com/pypa/pip/archive/22.0.zip ; sys_platform == 'win32'", pythonRequirement{
+			Name:    "pip",
+			Version: "N/A",
+			URL:     "https://github.com/pypa/pip/archive/22.0.zip",
+			Markers: "sys_platform == 'win32'",
+		}},
+		{"Flask_SQLAlchemy", pythonRequirement{Name: "flask-sqlalchemy", Version: "N/A"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			req, ok := parsePEP508(tt.spec)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, req)
+		})
+	}
+}
+
+func TestParseRequirementsTXTIncludesAndConstraints(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "requirements"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "requirements", "base.in"), []byte("requests[socks]\nclick>=8\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "constraints.txt"), []byte("requests==2.31.0\n"), 0o644))
+
+	content := `-r requirements/base.in
+-c constraints.txt
+--index-url https://pypi.org/simple
+Black==23.1.0 \
+    --hash=sha256:abc
+-e git+https://github.com/org/tool.git#egg=my_tool
+importlib-metadata; python_version < "3.8"  # backport
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "requirements-dev.txt"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 5)
+
+	requests := byName["requests"]
+	assert.Equal(t, "==2.31.0", requests.Properties["Version"])
+	assert.Equal(t, []string{"socks"}, requests.Properties["Extras"])
+	assert.Equal(t, "requirements/base.in", requests.Properties["Include"])
+	assert.Equal(t, ">=8", byName["click"].Properties["Version"])
+
+	black := byName["black"]
+	assert.Equal(t, "==23.1.0", black.Properties["Version"])
+	assert.Equal(t, []string{"sha256:abc"}, black.Properties["Hashes"])
+
+	assert.Equal(t, "git+https://github.com/org/tool.git", byName["my-tool"].Properties["URL"])
+	assert.Equal(t, `python_version < "3.8"`, byName["importlib-metadata"].Properties["Markers"])
+}
+
+func TestParseRequirementsTXTStaysInRepository(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "outside.txt"), []byte("leaked==1.0\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "constraints.txt"), []byte("requests==0.1\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.txt"), []byte("click>=8\n"), 0o644))
+
+	content := `-r base.txt
+-r ../outside.txt
+-c ../constraints.txt
+requests
+`
+	findings, err := NewLibrariesProcessor().Process(filepath.Join(dir, "requirements.txt"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 2)
+	assert.Contains(t, byName, "click")
+	assert.Equal(t, "N/A", byName["requests"].Properties["Version"])
+}
+
+func TestParsePipfileAndLock(t *testing.T) {
+	dir := t.TempDir()
+	pipfile := `[[source]]
+url = "https://pypi.org/simple"
+
+[packages]
+requests = "*"
+Django = {version = ">=4.0", extras = ["bcrypt"]}
+
+[dev-packages]
+pytest = "==7.4.0"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Pipfile"), []byte(pipfile), 0o644))
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "Pipfile"), "repo", pipfile)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 3)
+	assert.Equal(t, "N/A", byName["requests"].Properties["Version"])
+	assert.Equal(t, ">=4.0", byName["django"].Properties["Version"])
+	assert.Equal(t, []string{"bcrypt"}, byName["django"].Properties["Extras"])
+	assert.Equal(t, "dev", byName["pytest"].Properties["Scope"])
+
+	lock := `{
+  "_meta": {"hash": {"sha256": "x"}},
+  "default": {
+    "requests": {"hashes": ["sha256:aaa"], "version": "==2.31.0"},
+    "urllib3": {"hashes": ["sha256:bbb"], "markers": "python_version >= '3.7'", "version": "==2.0.4"}
+  },
+  "develop": {
+    "pytest": {"hashes": ["sha256:ccc"], "version": "==7.4.0"}
+  }
+}`
+	findings, err = processor.Process(filepath.Join(dir, "Pipfile.lock"), "repo", lock)
+	require.NoError(t, err)
+	byName = findingsByName(findings)
+	require.Len(t, byName, 3)
+	assert.Equal(t, "2.31.0", byName["requests"].Properties["Version"])
+	assert.Equal(t, true, byName["requests"].Properties["Direct"])
+	assert.Equal(t, false, byName["urllib3"].Properties["Direct"])
+	assert.Equal(t, []string{"sha256:bbb"}, byName["urllib3"].Properties["Hashes"])
+	assert.Equal(t, "dev", byName["pytest"].Properties["Scope"])
+}
+
+func TestParsePoetryLock(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(`[tool.poetry.dependencies]
+python = "^3.10"
+requests = "^2.31"
+
+[tool.poetry.group.dev.dependencies]
+pytest = "^7.4"
+`), 0o644))
+
+	content := `[[package]]
+name = "certifi"
+version = "2023.7.22"
+optional = false
+files = [
+    {file = "certifi-2023.7.22-py3-none-any.whl", hash = "sha256:certifi"},
+]
+
+[[package]]
+name = "requests"
+version = "2.31.0"
+optional = false
+
+[package.dependencies]
+certifi = ">=2017.4.17"
+
+[[package]]
+name = "pytest"
+version = "7.4.0"
+optional = false
+
+[metadata]
+lock-version = "2.0"
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "poetry.lock"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 3)
+
+	assert.Equal(t, true, byName["requests"].Properties["Direct"])
+	assert.Equal(t, "prod", byName["requests"].Properties["Scope"])
+	assert.Equal(t, false, byName["certifi"].Properties["Direct"])
+	assert.Equal(t, "prod", byName["certifi"].Properties["Scope"])
+	assert.Equal(t, []string{"sha256:certifi"}, byName["certifi"].Properties["Hashes"])
+	assert.Equal(t, "dev", byName["pytest"].Properties["Scope"])
+}
+
+func TestParseUvLock(t *testing.T) {
+	content := `version = 1
+requires-python = ">=3.12"
+
+[[package]]
+name = "app"
+version = "0.1.0"
+source = { editable = "." }
+dependencies = [
+    { name = "httpx" },
+]
+
+[package.dev-dependencies]
+dev = [
+    { name = "ruff" },
+]
+
+[[package]]
+name = "httpx"
+version = "0.27.0"
+source = { registry = "https://pypi.org/simple" }
+dependencies = [
+    { name = "idna" },
+]
+sdist = { url = "https://files.pythonhosted.org/httpx-0.27.0.tar.gz", hash = "sha256:httpx", size = 1 }
+
+[[package]]
+name = "idna"
+version = "3.7"
+source = { registry = "https://pypi.org/simple" }
+wheels = [
+    { url = "https://files.pythonhosted.org/idna-3.7-py3-none-any.whl", hash = "sha256:idna" },
+]
+
+[[package]]
+name = "ruff"
+version = "0.4.4"
+source = { registry = "https://pypi.org/simple" }
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(t.TempDir(), "uv.lock"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 3)
+
+	assert.Equal(t, "0.27.0", byName["httpx"].Properties["Version"])
+	assert.Equal(t, true, byName["httpx"].Properties["Direct"])
+	assert.Equal(t, []string{"sha256:httpx"}, byName["httpx"].Properties["Hashes"])
+	assert.Equal(t, false, byName["idna"].Properties["Direct"])
+	assert.Equal(t, "prod", byName["idna"].Properties["Scope"])
+	assert.Equal(t, "dev", byName["ruff"].Properties["Scope"])
+}
+
+func TestParseSetupCfg(t *testing.T) {
+	content := `[metadata]
+name = example
+
+[options]
+packages = find:
+install_requires =
+    requests>=2.0
+    PyYAML
+
+[options.extras_require]
+docs =
+    sphinx==7.0
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process("setup.cfg", "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 3)
+	assert.Equal(t, ">=2.0", byName["requests"].Properties["Version"])
+	assert.Equal(t, "prod", byName["pyyaml"].Properties["Scope"])
+	assert.Equal(t, "optional", byName["sphinx"].Properties["Scope"])
+	assert.Equal(t, "docs", byName["sphinx"].Properties["Group"])
+}
+
+func TestParseSetupPy(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "requirements-test.txt"), []byte("pytest==7.4.0\n"), 0o644))
+
+	content := `from setuptools import setup
+
+REQUIRES = [
+    "requests>=2.0",  # HTTP
+    'click',
+]
+
+setup(
+    name="example",
+    install_requires=REQUIRES,
+    tests_require=open("requirements-test.txt").read().splitlines(),
+    extras_require={
+        "yaml": ["PyYAML>=6"],
+    },
+)
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "setup.py"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 4)
+	assert.Equal(t, ">=2.0", byName["requests"].Properties["Version"])
+	assert.Equal(t, "N/A", byName["click"].Properties["Version"])
+	assert.Equal(t, "dev", byName["pytest"].Properties["Scope"])
+	assert.Equal(t, "==7.4.0", byName["pytest"].Properties["Version"])
+	assert.Equal(t, "yaml", byName["pyyaml"].Properties["Group"])
+}
+
+func TestParseSetupPyStaysInRepository(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "requirements.txt"), []byte("leaked==1.0\n"), 0o644))
+
+	content := `from setuptools import setup
+
+setup(
+    name="example",
+    install_requires=open("../requirements.txt").read().splitlines() + ["click"],
+)
+`
+	findings, err := NewLibrariesProcessor().Process(filepath.Join(dir, "setup.py"), "repo", content)
+	require.NoError(t, err)
+	assert.NotContains(t, findingsByName(findings), "leaked")
+}
+
+func TestParseCondaEnvironment(t *testing.T) {
+	content := `name: analysis
+channels:
+  - conda-forge
+dependencies:
+  - python=3.11
+  - conda-forge::numpy=1.26=py311h
+  - pandas 2.1.*
+  - pyyaml
+  - cudatoolkit=11.8
+  - nodejs
+  - r-base=4.3
+  - r-ggplot2
+  - pip
+  - pip:
+      - scikit_learn==1.3.0
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process("environment.yml", "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 10)
+	assert.Equal(t, "=3.11", byName["python"].Properties["Version"])
+	assert.Equal(t, "=1.26", byName["numpy"].Properties["Version"])
+	assert.Equal(t, "conda-forge", byName["numpy"].Properties["Channel"])
+	assert.Equal(t, "=2.1.*", byName["pandas"].Properties["Version"])
+	assert.Equal(t, "N/A", byName["pip"].Properties["Version"])
+	assert.Equal(t, "pip", byName["scikit-learn"].Properties["Source"])
+	assert.Equal(t, "==1.3.0", byName["scikit-learn"].Properties["Version"])
+
+	for name, language := range map[string]string{
+		"python":       "Python",
+		"numpy":        "Python",
+		"pandas":       "Python",
+		"pyyaml":       "Python",
+		"pip":          "Python",
+		"scikit-learn": "Python",
+		"cudatoolkit":  "Unknown",
+		"nodejs":       "Unknown",
+		"r-base":       "R",
+		"r-ggplot2":    "R",
+	} {
+		assert.Equal(t, language, byName[name].Properties["Language"], name)
+	}
+}
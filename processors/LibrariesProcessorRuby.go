@@ -0,0 +1,231 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/reaandrew/techdetector/core"
+	"github.com/reaandrew/techdetector/utils"
+)
+
+var (
+	gemfileGemRe        = regexp.MustCompile(`^gem\s*\(?\s*['"]([^'"]+)['"]\s*(.*)$`)
+	gemfileBlockRe      = regexp.MustCompile(`^(group|groups|platforms?|source|git|path|install_if)\b.*\bdo\b`)
+	gemfileSymbolRe     = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+	gemfileGroupOptRe   = regexp.MustCompile(`\bgroups?\s*(?::|=>)\s*(\[[^\]]*\]|:[A-Za-z_][A-Za-z0-9_]*)`)
+	gemfileStringRe     = regexp.MustCompile(`^['"]([^'"]*)['"]`)
+	gemfileLockEntryRe  = regexp.MustCompile(`^([^\s(]+)(?: \(([^)]*)\))?`)
+	gemfileLockSumRe    = regexp.MustCompile(`^(\S+) \(([^)]*)\)\s+(\S+)`)
+	gemfileDevelopGroup = map[string]bool{"development": true, "test": true}
+)
+
+// gemfileGem is a gem declared in a Gemfile with the groups it belongs to.
+type gemfileGem struct {
+	Name        string
+	Constraints []string
+	Groups      []string
+}
+
+func (g gemfileGem) dev() bool {
+	if len(g.Groups) == 0 {
+		return false
+	}
+	for _, group := range g.Groups {
+		if !gemfileDevelopGroup[group] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseGemfileDeclarations reads gem declarations from a Gemfile, tracking
+// the group blocks that enclose them.
+func parseGemfileDeclarations(content string) []gemfileGem {
+	type block struct {
+		groups []string
+	}
+
+	var gems []gemfileGem
+	var stack []block
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if gemfileBlockRe.MatchString(line) {
+			var groups []string
+			if strings.HasPrefix(line, "group") {
+				for _, match := range gemfileSymbolRe.FindAllStringSubmatch(strings.SplitN(line, " do", 2)[0], -1) {
+					groups = append(groups, match[1])
+				}
+			}
+			stack = append(stack, block{groups: groups})
+			continue
+		}
+		if line == "end" {
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		match := gemfileGemRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		gem := gemfileGem{Name: match[1]}
+		rest := strings.TrimSpace(match[2])
+		for strings.HasPrefix(rest, ",") {
+			rest = strings.TrimSpace(rest[1:])
+			constraint := gemfileStringRe.FindStringSubmatch(rest)
+			if constraint == nil {
+				break
+			}
+			gem.Constraints = append(gem.Constraints, constraint[1])
+			rest = strings.TrimSpace(rest[len(constraint[0]):])
+		}
+		for _, b := range stack {
+			gem.Groups = append(gem.Groups, b.groups...)
+		}
+		if option := gemfileGroupOptRe.FindStringSubmatch(rest); option != nil {
+			for _, symbol := range gemfileSymbolRe.FindAllStringSubmatch(option[1], -1) {
+				gem.Groups = append(gem.Groups, symbol[1])
+			}
+		}
+		gems = append(gems, gem)
+	}
+	return gems
+}
+
+func (mp *LibrariesProcessor) parseGemfile(content string, repoName string, path string) ([]core.Finding, error) {
+	var matches []core.Finding
+	for _, gem := range parseGemfileDeclarations(content) {
+		version := strings.Join(gem.Constraints, ", ")
+		if version == "" {
+			version = "N/A"
+		}
+		scope := "prod"
+		if gem.dev() {
+			scope = "dev"
+		}
+		properties := map[string]interface{}{
+			"Language": "Ruby",
+			"Version":  version,
+			"Direct":   true,
+			"Scope":    scope,
+		}
+		if len(gem.Groups) > 0 {
+			properties["Group"] = strings.Join(gem.Groups, ",")
+		}
+		matches = append(matches, core.Finding{
+			Name:       gem.Name,
+			Type:       "Library",
+			Category:   "",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+	return matches, nil
+}
+
+func (mp *LibrariesProcessor) parseGemfileLock(content string, repoName string, path string) ([]core.Finding, error) {
+	graph := newLockfileGraph()
+	var localGems []string
+	localDeps := make(map[string][]string)
+	checksums := make(map[string]string)
+	var direct []string
+
+	section := ""
+	remote := ""
+	current := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		trimmed := strings.TrimSpace(line)
+
+		if indent == 0 {
+			section = trimmed
+			remote = ""
+			continue
+		}
+
+		switch section {
+		case "GEM", "GIT", "PATH":
+			if indent == 2 && strings.HasPrefix(trimmed, "remote:") {
+				remote = strings.TrimSpace(strings.TrimPrefix(trimmed, "remote:"))
+				continue
+			}
+			entry := gemfileLockEntryRe.FindStringSubmatch(trimmed)
+			if entry == nil {
+				continue
+			}
+			switch indent {
+			case 4:
+				current = entry[1]
+				if section == "PATH" {
+					localGems = append(localGems, current)
+					continue
+				}
+				// Platform specific gems append the platform to the version,
+				// e.g. "nokogiri (1.15.4-x86_64-linux)".
+				if graph.Packages[current] == nil {
+					graph.Packages[current] = &lockedPackage{
+						Name:     current,
+						Version:  strings.SplitN(entry[2], "-", 2)[0],
+						Resolved: remote,
+					}
+				}
+			case 6:
+				if utils.Contains(localGems, current) {
+					localDeps[current] = append(localDeps[current], entry[1])
+				} else if pkg := graph.Packages[current]; pkg != nil {
+					pkg.Dependencies = append(pkg.Dependencies, entry[1])
+				}
+			}
+		case "DEPENDENCIES":
+			if entry := gemfileLockEntryRe.FindStringSubmatch(trimmed); entry != nil {
+				direct = append(direct, strings.TrimSuffix(entry[1], "!"))
+			}
+		case "CHECKSUMS":
+			if sum := gemfileLockSumRe.FindStringSubmatch(trimmed); sum != nil {
+				checksums[sum[1]] = sum[3]
+			}
+		}
+	}
+
+	for name, checksum := range checksums {
+		if pkg := graph.Packages[name]; pkg != nil {
+			pkg.Integrity = checksum
+		}
+	}
+
+	// Gemfile.lock does not record groups, so use the Gemfile beside it to
+	// tell development and test gems apart.
+	devGems := make(map[string]bool)
+	if gemfile, err := os.ReadFile(filepath.Join(filepath.Dir(path), "Gemfile")); err == nil {
+		for _, gem := range parseGemfileDeclarations(string(gemfile)) {
+			devGems[gem.Name] = gem.dev()
+		}
+	}
+	for _, name := range direct {
+		if utils.Contains(localGems, name) {
+			for _, dep := range localDeps[name] {
+				graph.Roots = append(graph.Roots, lockfileRoot{Key: dep, Workspace: name})
+			}
+			continue
+		}
+		graph.Roots = append(graph.Roots, lockfileRoot{Key: name, Dev: devGems[name]})
+	}
+
+	return graph.findings("Ruby", repoName, path), nil
+}
@@ -0,0 +1,85 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGemfile = `source "https://rubygems.org"
+
+ruby "3.2.2"
+
+gem "rails", "~> 7.0", ">= 7.0.4"
+gem 'pg' # database
+
+group :development, :test do
+  gem "rspec-rails"
+end
+
+gem "rubocop", require: false, group: :development
+`
+
+func TestParseGemfile(t *testing.T) {
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process("Gemfile", "repo", testGemfile)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 4)
+
+	assert.Equal(t, "~> 7.0, >= 7.0.4", byName["rails"].Properties["Version"])
+	assert.Equal(t, "prod", byName["rails"].Properties["Scope"])
+	assert.Equal(t, "N/A", byName["pg"].Properties["Version"])
+	assert.Equal(t, "dev", byName["rspec-rails"].Properties["Scope"])
+	assert.Equal(t, "development,test", byName["rspec-rails"].Properties["Group"])
+	assert.Equal(t, "dev", byName["rubocop"].Properties["Scope"])
+}
+
+func TestParseGemfileLock(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Gemfile"), []byte(testGemfile), 0o644))
+
+	content := `GEM
+  remote: https://rubygems.org/
+  specs:
+    nokogiri (1.15.4-x86_64-linux)
+      racc (~> 1.4)
+    pg (1.5.4)
+    racc (1.7.1)
+    rails (7.0.8)
+      nokogiri (>= 1.6)
+    rspec-rails (6.0.3)
+
+PLATFORMS
+  x86_64-linux
+
+DEPENDENCIES
+  pg
+  rails (~> 7.0, >= 7.0.4)
+  rspec-rails
+
+CHECKSUMS
+  pg (1.5.4) sha256=pgsum
+
+BUNDLED WITH
+   2.4.19
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "Gemfile.lock"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 5)
+
+	assert.Equal(t, "7.0.8", byName["rails"].Properties["Version"])
+	assert.Equal(t, true, byName["rails"].Properties["Direct"])
+	assert.Equal(t, "1.15.4", byName["nokogiri"].Properties["Version"])
+	assert.Equal(t, false, byName["racc"].Properties["Direct"])
+	assert.Equal(t, "prod", byName["racc"].Properties["Scope"])
+	assert.Equal(t, "dev", byName["rspec-rails"].Properties["Scope"])
+	assert.Equal(t, "sha256=pgsum", byName["pg"].Properties["Integrity"])
+	assert.Equal(t, "https://rubygems.org/", byName["pg"].Properties["Resolved"])
+}
@@ -0,0 +1,280 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// cargoManifest is the subset of Cargo.toml used to report dependencies.
+type cargoManifest struct {
+	Package struct {
+		Name    string      `toml:"name"`
+		Version interface{} `toml:"version"`
+	} `toml:"package"`
+	Workspace struct {
+		Members      []string               `toml:"members"`
+		Dependencies map[string]interface{} `toml:"dependencies"`
+	} `toml:"workspace"`
+	Dependencies      map[string]interface{} `toml:"dependencies"`
+	DevDependencies   map[string]interface{} `toml:"dev-dependencies"`
+	BuildDependencies map[string]interface{} `toml:"build-dependencies"`
+	Target            map[string]struct {
+		Dependencies      map[string]interface{} `toml:"dependencies"`
+		DevDependencies   map[string]interface{} `toml:"dev-dependencies"`
+		BuildDependencies map[string]interface{} `toml:"build-dependencies"`
+	} `toml:"target"`
+}
+
+func readCargoManifest(path string) (cargoManifest, bool) {
+	var manifest cargoManifest
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return manifest, false
+	}
+	if _, err := toml.Decode(string(content), &manifest); err != nil {
+		return manifest, false
+	}
+	return manifest, true
+}
+
+// findCargoWorkspaceRoot walks up from dir looking for the Cargo.toml that
+// declares a [workspace], which is where inherited dependencies are defined.
+func findCargoWorkspaceRoot(dir string) (cargoManifest, string, bool) {
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return cargoManifest{}, "", false
+		}
+		dir = parent
+		candidate := filepath.Join(dir, "Cargo.toml")
+		if manifest, ok := readCargoManifest(candidate); ok && (manifest.Workspace.Members != nil || manifest.Workspace.Dependencies != nil) {
+			return manifest, dir, true
+		}
+	}
+}
+
+// cargoDependency is a single dependency entry from a Cargo.toml table.
+type cargoDependency struct {
+	Name      string
+	Version   string
+	Source    string
+	Optional  bool
+	Inherited bool
+}
+
+func parseCargoDependency(name string, value interface{}) cargoDependency {
+	dep := cargoDependency{Name: name}
+	switch v := value.(type) {
+	case string:
+		dep.Version = v
+	case map[string]interface{}:
+		if version, ok := v["version"].(string); ok {
+			dep.Version = version
+		}
+		if pkg, ok := v["package"].(string); ok {
+			dep.Name = pkg
+		}
+		if optional, ok := v["optional"].(bool); ok {
+			dep.Optional = optional
+		}
+		if inherited, ok := v["workspace"].(bool); ok {
+			dep.Inherited = inherited
+		}
+		if git, ok := v["git"].(string); ok {
+			dep.Source = git
+			for _, ref := range []string{"rev", "tag", "branch"} {
+				if value, ok := v[ref].(string); ok {
+					dep.Source += "#" + value
+				}
+			}
+		} else if path, ok := v["path"].(string); ok {
+			dep.Source = path
+		}
+	}
+	return dep
+}
+
+func (mp *LibrariesProcessor) parseCargoToml(content string, repoName string, path string) ([]core.Finding, error) {
+	var manifest cargoManifest
+	if _, err := toml.Decode(content, &manifest); err != nil {
+		return nil, err
+	}
+
+	workspaceDeps := manifest.Workspace.Dependencies
+	workspace := ""
+	if root, _, ok := findCargoWorkspaceRoot(filepath.Dir(path)); ok {
+		workspaceDeps = root.Workspace.Dependencies
+		workspace = manifest.Package.Name
+	}
+
+	var matches []core.Finding
+	add := func(deps map[string]interface{}, scope string, target string) {
+		for _, key := range sortedKeys(deps) {
+			dep := parseCargoDependency(key, deps[key])
+			if dep.Inherited {
+				// "dep = { workspace = true }" takes the version declared in
+				// the workspace root's [workspace.dependencies].
+				inherited := parseCargoDependency(key, workspaceDeps[key])
+				dep.Name, dep.Version, dep.Source = inherited.Name, inherited.Version, inherited.Source
+			}
+			version := dep.Version
+			if version == "" {
+				version = "N/A"
+			}
+			properties := map[string]interface{}{
+				"Language": "Rust",
+				"Version":  version,
+				"Direct":   true,
+				"Scope":    scope,
+			}
+			if dep.Source != "" {
+				properties["Source"] = dep.Source
+			}
+			if dep.Optional {
+				properties["Optional"] = true
+			}
+			if target != "" {
+				properties["Target"] = target
+			}
+			if workspace != "" {
+				properties["Workspace"] = workspace
+			}
+			matches = append(matches, core.Finding{
+				Name:       dep.Name,
+				Type:       "Library",
+				Category:   "",
+				Properties: properties,
+				Path:       path,
+				RepoName:   repoName,
+			})
+		}
+	}
+
+	add(manifest.Dependencies, "prod", "")
+	add(manifest.DevDependencies, "dev", "")
+	add(manifest.BuildDependencies, "build", "")
+	for _, target := range sortedKeys(manifest.Target) {
+		add(manifest.Target[target].Dependencies, "prod", target)
+		add(manifest.Target[target].DevDependencies, "dev", target)
+		add(manifest.Target[target].BuildDependencies, "build", target)
+	}
+
+	return matches, nil
+}
+
+// cargoWorkspaceManifests returns the manifests of the crates in the
+// workspace rooted at dir, keyed by crate name.
+func cargoWorkspaceManifests(dir string) map[string]cargoManifest {
+	manifests := make(map[string]cargoManifest)
+	root, ok := readCargoManifest(filepath.Join(dir, "Cargo.toml"))
+	if !ok {
+		return manifests
+	}
+	if root.Package.Name != "" {
+		manifests[root.Package.Name] = root
+	}
+	for _, member := range root.Workspace.Members {
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(member), "Cargo.toml"))
+		if err != nil {
+			continue
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if manifest, ok := readCargoManifest(match); ok && manifest.Package.Name != "" {
+				manifests[manifest.Package.Name] = manifest
+			}
+		}
+	}
+	return manifests
+}
+
+func (mp *LibrariesProcessor) parseCargoLock(content string, repoName string, path string) ([]core.Finding, error) {
+	type cargoPackage struct {
+		Name         string   `toml:"name"`
+		Version      string   `toml:"version"`
+		Source       string   `toml:"source"`
+		Checksum     string   `toml:"checksum"`
+		Dependencies []string `toml:"dependencies"`
+	}
+
+	type cargoLock struct {
+		Packages []cargoPackage `toml:"package"`
+	}
+
+	var lock cargoLock
+	if _, err := toml.Decode(content, &lock); err != nil {
+		return nil, err
+	}
+
+	graph := newLockfileGraph()
+	byName := make(map[string][]string)
+	for _, pkg := range lock.Packages {
+		byName[pkg.Name] = append(byName[pkg.Name], pkg.Name+" "+pkg.Version)
+	}
+	// Dependencies are written as "name", "name version" or
+	// "name version (source)" depending on how ambiguous the name is.
+	resolve := func(dep string) (string, bool) {
+		fields := strings.Fields(dep)
+		if len(fields) >= 2 {
+			return fields[0] + " " + fields[1], true
+		}
+		keys := byName[dep]
+		if len(keys) == 0 {
+			return "", false
+		}
+		return keys[0], true
+	}
+
+	var locals []cargoPackage
+	for _, pkg := range lock.Packages {
+		// Packages without a source are the crates of the workspace itself.
+		if pkg.Source == "" {
+			locals = append(locals, pkg)
+			continue
+		}
+		locked := &lockedPackage{
+			Name:      pkg.Name,
+			Version:   pkg.Version,
+			Integrity: pkg.Checksum,
+			Resolved:  pkg.Source,
+		}
+		for _, dep := range pkg.Dependencies {
+			if key, ok := resolve(dep); ok {
+				locked.Dependencies = append(locked.Dependencies, key)
+			}
+		}
+		graph.Packages[pkg.Name+" "+pkg.Version] = locked
+	}
+
+	manifests := cargoWorkspaceManifests(filepath.Dir(path))
+	for _, local := range locals {
+		workspace := ""
+		if len(locals) > 1 {
+			workspace = local.Name
+		}
+		manifest, haveManifest := manifests[local.Name]
+		for _, dep := range local.Dependencies {
+			key, ok := resolve(dep)
+			if !ok {
+				continue
+			}
+			dev := false
+			if haveManifest {
+				name := strings.Fields(dep)[0]
+				_, inDeps := manifest.Dependencies[name]
+				_, inBuild := manifest.BuildDependencies[name]
+				_, inDev := manifest.DevDependencies[name]
+				dev = inDev && !inDeps && !inBuild
+			}
+			graph.Roots = append(graph.Roots, lockfileRoot{Key: key, Dev: dev, Workspace: workspace})
+		}
+	}
+
+	return graph.findings("Rust", repoName, path), nil
+}
@@ -0,0 +1,112 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCargoTomlWorkspaceInheritance(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte(`[workspace]
+members = ["crates/*"]
+
+[workspace.dependencies]
+serde = { version = "1.0.190", features = ["derive"] }
+`), 0o644))
+	crateDir := filepath.Join(dir, "crates", "api")
+	require.NoError(t, os.MkdirAll(crateDir, 0o755))
+
+	content := `[package]
+name = "api"
+version = "0.1.0"
+
+[dependencies]
+serde = { workspace = true }
+tokio = { version = "1.33", optional = true }
+internal = { path = "../internal" }
+
+[dev-dependencies]
+insta = "1.34"
+
+[target.'cfg(windows)'.dependencies]
+winapi = "0.3"
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(crateDir, "Cargo.toml"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 5)
+
+	serde := byName["serde|api"]
+	assert.Equal(t, "Rust", serde.Properties["Language"])
+	assert.Equal(t, "1.0.190", serde.Properties["Version"])
+	assert.Equal(t, true, byName["tokio|api"].Properties["Optional"])
+	assert.Equal(t, "../internal", byName["internal|api"].Properties["Source"])
+	assert.Equal(t, "N/A", byName["internal|api"].Properties["Version"])
+	assert.Equal(t, "dev", byName["insta|api"].Properties["Scope"])
+	assert.Equal(t, "cfg(windows)", byName["winapi|api"].Properties["Target"])
+}
+
+func TestParseCargoLock(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte(`[package]
+name = "app"
+version = "0.1.0"
+
+[dependencies]
+serde_json = "1"
+
+[dev-dependencies]
+pretty_assertions = "1"
+`), 0o644))
+
+	content := `version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = [
+ "pretty_assertions",
+ "serde_json",
+]
+
+[[package]]
+name = "itoa"
+version = "1.0.9"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "itoa-sum"
+
+[[package]]
+name = "pretty_assertions"
+version = "1.4.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "pa-sum"
+
+[[package]]
+name = "serde_json"
+version = "1.0.108"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "sj-sum"
+dependencies = [
+ "itoa 1.0.9",
+]
+`
+
+	processor := NewLibrariesProcessor()
+	findings, err := processor.Process(filepath.Join(dir, "Cargo.lock"), "repo", content)
+	require.NoError(t, err)
+	byName := findingsByName(findings)
+	require.Len(t, byName, 3)
+
+	assert.Equal(t, "1.0.108", byName["serde_json"].Properties["Version"])
+	assert.Equal(t, true, byName["serde_json"].Properties["Direct"])
+	assert.Equal(t, "sj-sum", byName["serde_json"].Properties["Integrity"])
+	assert.Equal(t, false, byName["itoa"].Properties["Direct"])
+	assert.Equal(t, "prod", byName["itoa"].Properties["Scope"])
+	assert.Equal(t, "dev", byName["pretty_assertions"].Properties["Scope"])
+}
@@ -18,15 +18,27 @@ func TestSupports(t *testing.T) {
 		{"pom.xml", true},
 		{"build.gradle", true}, // Assuming build.gradle is supported
 		{"go.mod", true},
+		{"go.work", true},
 		{"package.json", true},
 		{"requirements.txt", true},
 		{"pyproject.toml", true},
 		{"example.csproj", true},
+		{"setup.py", true},
+		{"Pipfile", true},
+		{"poetry.lock", true},
+		{"requirements-dev.txt", true},
+		{"requirements/base.in", true},
+		{"example.fsproj", true},
+		{"packages.config", true},
+		{"paket.lock", true},
+		{"global.json", true},
+		{"pubspec.yaml", true},
+		{"Podfile.lock", true},
+		{"Package.swift", true},
 		// Unsupported files
 		{"README.md", false},
 		{"Dockerfile", false},
 		{"main.go", false},
-		{"setup.py", false},
 		{"example.txt", false},
 	}
 
@@ -81,6 +93,7 @@ func TestParsePomXML(t *testing.T) {
 					Properties: map[string]interface{}{
 						"Language": "Java",
 						"Version":  "5.3.8",
+						"Scope":    "compile",
 					},
 					RepoName: "test-repo",
 					Path:     "sample/pom.xml",
@@ -91,6 +104,7 @@ func TestParsePomXML(t *testing.T) {
 					Properties: map[string]interface{}{
 						"Language": "Java",
 						"Version":  "2.12.3",
+						"Scope":    "compile",
 					},
 					RepoName: "test-repo",
 					Path:     "sample/pom.xml",
@@ -177,6 +191,7 @@ require (
 					Properties: map[string]interface{}{
 						"Language": "Go",
 						"Version":  "v1.8.1",
+						"Direct":   true,
 					},
 					RepoName: "test-repo",
 					Path:     "sample/go.mod",
@@ -187,6 +202,18 @@ require (
 					Properties: map[string]interface{}{
 						"Language": "Go",
 						"Version":  "v1.7.0",
+						"Direct":   true,
+					},
+					RepoName: "test-repo",
+					Path:     "sample/go.mod",
+				},
+				{
+					Name: "Go",
+					Type: "Runtime",
+					Properties: map[string]interface{}{
+						"Language":  "Go",
+						"Version":   "1.16",
+						"Directive": "go",
 					},
 					RepoName: "test-repo",
 					Path:     "sample/go.mod",
@@ -211,9 +238,22 @@ go 1.16
 
 require github.com/sirupsen/logrus
 `,
-			repoName:    "test-repo",
-			path:        "sample/go.mod",
-			expected:    []core.Finding{}, // Malformed require line should be ignored
+			repoName: "test-repo",
+			path:     "sample/go.mod",
+			// Malformed require line should be ignored
+			expected: []core.Finding{
+				{
+					Name: "Go",
+					Type: "Runtime",
+					Properties: map[string]interface{}{
+						"Language":  "Go",
+						"Version":   "1.16",
+						"Directive": "go",
+					},
+					RepoName: "test-repo",
+					Path:     "sample/go.mod",
+				},
+			},
 			expectError: false,
 		},
 		{
@@ -837,6 +877,17 @@ func TestParseCsProj(t *testing.T) {
 					RepoName: "test-repo",
 					Path:     "sample/example.csproj",
 				},
+				{
+					Name: ".NET",
+					Type: "Runtime",
+					Properties: map[string]interface{}{
+						"Language":        "C#",
+						"Version":         "5.0",
+						"TargetFramework": "net5.0",
+					},
+					RepoName: "test-repo",
+					Path:     "sample/example.csproj",
+				},
 			},
 			expectError: false,
 		},
@@ -1317,6 +1368,7 @@ func TestProcess(t *testing.T) {
 					Properties: map[string]interface{}{
 						"Language": "Java",
 						"Version":  "5.3.8",
+						"Scope":    "compile",
 					},
 					RepoName: "test-repo",
 					Path:     "sample/pom.xml",
@@ -0,0 +1,174 @@
+package processors
+
+import (
+	"sort"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// lockedPackage is a single resolved package taken from a lockfile.
+// Dependencies holds the keys of the other lockedPackages it depends on.
+type lockedPackage struct {
+	Name         string
+	Version      string
+	Integrity    string
+	Hashes       []string
+	Resolved     string
+	Dependencies []string
+	Dev          *bool
+}
+
+// lockfileRoot is a dependency declared directly by the root package or by one
+// of its workspaces.
+type lockfileRoot struct {
+	Key       string
+	Dev       bool
+	Workspace string
+}
+
+// lockfileGraph is the format independent model every lockfile parser
+// produces, so that direct/transitive flags and scopes are worked out
+// in one place.
+type lockfileGraph struct {
+	Packages map[string]*lockedPackage
+	Roots    []lockfileRoot
+}
+
+func newLockfileGraph() *lockfileGraph {
+	return &lockfileGraph{Packages: make(map[string]*lockedPackage)}
+}
+
+// findings walks the graph from its roots to flag direct dependencies, work out
+// a scope for packages the lockfile does not mark, and attribute packages to a
+// workspace when only one workspace pulls them in.
+func (g *lockfileGraph) findings(language string, repoName string, path string) []core.Finding {
+	roots := g.Roots
+	if len(roots) == 0 {
+		// Without a manifest, anything nothing else depends on is taken to
+		// be a direct dependency.
+		required := make(map[string]bool)
+		for _, pkg := range g.Packages {
+			for _, dep := range pkg.Dependencies {
+				required[dep] = true
+			}
+		}
+		for _, key := range sortedKeys(g.Packages) {
+			if !required[key] {
+				roots = append(roots, lockfileRoot{Key: key})
+			}
+		}
+	}
+
+	reachable := func(starts []string) map[string]bool {
+		seen := make(map[string]bool)
+		queue := append([]string(nil), starts...)
+		for len(queue) > 0 {
+			key := queue[0]
+			queue = queue[1:]
+			pkg, ok := g.Packages[key]
+			if !ok || seen[key] {
+				continue
+			}
+			seen[key] = true
+			queue = append(queue, pkg.Dependencies...)
+		}
+		return seen
+	}
+
+	var prodStarts []string
+	workspaceStarts := make(map[string][]string)
+	direct := make(map[string][]lockfileRoot)
+	for _, root := range roots {
+		if !root.Dev {
+			prodStarts = append(prodStarts, root.Key)
+		}
+		workspaceStarts[root.Workspace] = append(workspaceStarts[root.Workspace], root.Key)
+		direct[root.Key] = append(direct[root.Key], root)
+	}
+	prod := reachable(prodStarts)
+	haveScopes := len(g.Roots) > 0
+
+	reachedBy := make(map[string][]string)
+	for _, workspace := range sortedKeys(workspaceStarts) {
+		for key := range reachable(workspaceStarts[workspace]) {
+			reachedBy[key] = append(reachedBy[key], workspace)
+		}
+	}
+
+	var matches []core.Finding
+	emitted := make(map[string]bool)
+	for _, key := range sortedKeys(g.Packages) {
+		pkg := g.Packages[key]
+		scope := ""
+		if pkg.Dev != nil {
+			scope = "prod"
+			if *pkg.Dev {
+				scope = "dev"
+			}
+		} else if haveScopes {
+			scope = "dev"
+			if prod[key] {
+				scope = "prod"
+			}
+		}
+
+		var attributions []lockfileRoot
+		if roots, ok := direct[key]; ok {
+			attributions = roots
+		} else {
+			workspace := ""
+			if len(reachedBy[key]) == 1 {
+				workspace = reachedBy[key][0]
+			}
+			attributions = []lockfileRoot{{Key: key, Workspace: workspace}}
+		}
+
+		_, isDirect := direct[key]
+		for _, attribution := range attributions {
+			id := pkg.Name + "@" + pkg.Version + "|" + attribution.Workspace
+			if emitted[id] {
+				continue
+			}
+			emitted[id] = true
+
+			properties := map[string]interface{}{
+				"Language": language,
+				"Version":  pkg.Version,
+				"Direct":   isDirect,
+			}
+			if scope != "" {
+				properties["Scope"] = scope
+			}
+			if pkg.Integrity != "" {
+				properties["Integrity"] = pkg.Integrity
+			}
+			if len(pkg.Hashes) > 0 {
+				properties["Hashes"] = pkg.Hashes
+			}
+			if pkg.Resolved != "" {
+				properties["Resolved"] = pkg.Resolved
+			}
+			if attribution.Workspace != "" {
+				properties["Workspace"] = attribution.Workspace
+			}
+			matches = append(matches, core.Finding{
+				Name:       pkg.Name,
+				Type:       "Library",
+				Category:   "",
+				Properties: properties,
+				Path:       path,
+				RepoName:   repoName,
+			})
+		}
+	}
+	return matches
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
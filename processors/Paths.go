@@ -0,0 +1,74 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// inDirectory reports whether any directory component of filePath is one of
+// dirs. Components are matched whole, so ".git" does not match ".github".
+func inDirectory(filePath string, dirs ...string) bool {
+	components := strings.Split(filepath.ToSlash(filepath.Dir(filePath)), "/")
+	for _, component := range components {
+		for _, dir := range dirs {
+			if component == dir {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// repositoryRoot returns the root of the repository path is in: the nearest
+// directory holding .git, starting from path itself. A relative path with no .git above it is
+// taken to be relative to the root, which is then ".". ok is false when the
+// root cannot be told.
+func repositoryRoot(path string) (root string, ok bool) {
+	dir := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	if !filepath.IsAbs(path) {
+		return ".", true
+	}
+	return "", false
+}
+
+// repositoryPath returns path relative to the root of its repository, with
+// forward slashes, or its base name when the root cannot be told. Unlike the
+// absolute path, it is the same on every machine and in every clone.
+func repositoryPath(path string) string {
+	root, ok := repositoryRoot(path)
+	if !ok {
+		return filepath.Base(path)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.Base(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// withinRepository reports whether path is inside the repository that from
+// is in. Paths are allowed when the repository root cannot be told.
+func withinRepository(from string, path string) bool {
+	root, ok := repositoryRoot(from)
+	if !ok {
+		return true
+	}
+	return withinDirectory(root, path)
+}
+
+// withinDirectory reports whether path is dir or is inside it.
+func withinDirectory(dir string, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,35 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryPaths(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "checkout")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	file := filepath.Join(repo, "deploy", "prod", "kustomization.yaml")
+
+	root, ok := repositoryRoot(file)
+	require.True(t, ok)
+	assert.Equal(t, repo, root)
+	assert.Equal(t, "deploy/prod", repositoryPath(filepath.Dir(file)))
+	assert.Equal(t, ".", repositoryPath(repo))
+
+	assert.True(t, withinRepository(file, filepath.Join(repo, "base", "kustomization.yaml")))
+	assert.False(t, withinRepository(file, filepath.Join(dir, "secrets.yaml")))
+	assert.False(t, withinRepository(file, filepath.Join(repo, "..", "checkout-other", "x")))
+
+	assert.Equal(t, "overlays/prod", repositoryPath("overlays/prod"))
+	assert.True(t, withinRepository("ci/pipeline.yml", "ci/jobs/test.yml"))
+	assert.False(t, withinRepository("ci/pipeline.yml", "../outside.yml"))
+
+	outside := filepath.Join(dir, "plain", "prod")
+	assert.Equal(t, "prod", repositoryPath(outside))
+	assert.True(t, withinRepository(filepath.Join(outside, "a.yml"), filepath.Join(dir, "b.yml")))
+}
@@ -0,0 +1,399 @@
+package processors
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+var (
+	pulumiStackFileRe        = regexp.MustCompile(`^Pulumi\.(.+)\.ya?ml$`)
+	pulumiTSNamespaceImport  = regexp.MustCompile(`import\s+\*\s+as\s+(\w+)\s+from\s+['"]@pulumi/([\w-]+)(?:/([\w/]+))?['"]`)
+	pulumiTSNamedImport      = regexp.MustCompile(`import\s*\{([^}]*)\}\s*from\s*['"]@pulumi/([\w-]+)(?:/([\w/]+))?['"]`)
+	pulumiTSRequire          = regexp.MustCompile(`(?:const|let|var)\s+(\w+)\s*=\s*require\(\s*['"]@pulumi/([\w-]+)(?:/([\w/]+))?['"]\s*\)`)
+	pulumiPythonImport       = regexp.MustCompile(`import\s+pulumi_(\w+?)(?:\.(\w+))?(?:\s+as\s+(\w+))?\s*$`)
+	pulumiPythonFromImport   = regexp.MustCompile(`from\s+pulumi_(\w+?)(?:\.(\w+))?\s+import\s+(\([^)]*\)|[^\n]+)`)
+	pulumiJavaImport         = regexp.MustCompile(`import\s+com\.pulumi\.(\w+)\.(\w+)\.([A-Z]\w*)\s*;`)
+	pulumiGoImport           = regexp.MustCompile(`(?:(\w+)\s+)?"github\.com/pulumi/pulumi-([\w-]+)/sdk(?:/v\d+)?/go/[\w-]+(?:/([\w/-]+))?"`)
+	pulumiResourceClassRe    = regexp.MustCompile(`^[A-Z]\w*$`)
+	pulumiNonResourceClassRe = regexp.MustCompile(`(?:Args|Input|Output|Arrays?|Map)$`)
+)
+
+// pulumiResourcePatterns match the instantiation of a resource, whose first
+// argument is its name, in each language. Go resources also take the context
+// first.
+var pulumiResourcePatterns = map[string][]*regexp.Regexp{
+	"TypeScript": {regexp.MustCompile(`new\s+(?:([\w.]+)\.)?(\w+)\s*\(\s*(?:['"` + "`" + `]([^'"` + "`" + `]*)['"` + "`" + `]|\w+)`)},
+	"JavaScript": {regexp.MustCompile(`new\s+(?:([\w.]+)\.)?(\w+)\s*\(\s*(?:['"` + "`" + `]([^'"` + "`" + `]*)['"` + "`" + `]|\w+)`)},
+	"Python":     {regexp.MustCompile(`(?:\b([\w.]+)\.)?\b([A-Z]\w*)\s*\(\s*(?:f?['"]([^'"]*)['"]|\w+\s*[,)])`)},
+	"Java":       {regexp.MustCompile(`new\s+()(\w+)\s*\(\s*(?:"([^"]*)"|\w+)`)},
+	"Go":         {regexp.MustCompile(`\b(\w+)\.New(\w+)\s*\(\s*\w+\s*,\s*(?:"([^"]*)"|\w+)`)},
+}
+
+// pulumiProviderPrefixes maps Pulumi packages to the prefix of the Terraform
+// types of the same provider when the two names differ. Other packages use
+// their own name.
+var pulumiProviderPrefixes = map[string]string{
+	"awsx":          "aws",
+	"azure":         "azurerm",
+	"azure-native":  "azurerm",
+	"gcp":           "google",
+	"google-native": "google",
+}
+
+// pulumiNonProviderPackages are the Pulumi SDK packages that declare no
+// cloud resources.
+var pulumiNonProviderPackages = map[string]bool{
+	"pulumi":     true,
+	"policy":     true,
+	"automation": true,
+}
+
+// pulumiModule is a provider package, or a module of one, that a program
+// imports.
+type pulumiModule struct {
+	Package string
+	Module  string
+}
+
+// pulumiImports are the provider packages a program imports, by the alias
+// the program refers to them with and by the classes it imports directly.
+type pulumiImports struct {
+	Packages map[string]string
+	Aliases  map[string]pulumiModule
+	Classes  map[string]pulumiModule
+}
+
+func (p pulumiImports) add(module pulumiModule, importPath string, alias string, classes ...string) {
+	if pulumiNonProviderPackages[module.Package] {
+		return
+	}
+	p.Packages[module.Package] = importPath
+	if alias != "" {
+		p.Aliases[alias] = module
+	}
+	for _, class := range classes {
+		p.Classes[class] = module
+	}
+}
+
+// addNames binds the names imported from a package or module. Classes are
+// capitalised; other names are modules of the package.
+func (p pulumiImports) addNames(module pulumiModule, importPath string, list string) {
+	for _, name := range importedNames(list) {
+		if pulumiResourceClassRe.MatchString(name[0]) {
+			p.add(module, importPath, "", name[1])
+		} else if module.Module == "" {
+			p.add(pulumiModule{Package: module.Package, Module: name[0]}, importPath, name[1])
+		}
+	}
+}
+
+// parsePulumiImports reads the provider package imports of a program.
+func parsePulumiImports(language string, content string) pulumiImports {
+	imports := pulumiImports{Packages: map[string]string{}, Aliases: map[string]pulumiModule{}, Classes: map[string]pulumiModule{}}
+	switch language {
+	case "TypeScript", "JavaScript":
+		for _, re := range []*regexp.Regexp{pulumiTSNamespaceImport, pulumiTSRequire} {
+			for _, match := range re.FindAllStringSubmatch(content, -1) {
+				imports.add(pulumiModule{Package: match[2], Module: match[3]}, "@pulumi/"+match[2], match[1])
+			}
+		}
+		for _, match := range pulumiTSNamedImport.FindAllStringSubmatch(content, -1) {
+			imports.addNames(pulumiModule{Package: match[2], Module: match[3]}, "@pulumi/"+match[2], match[1])
+		}
+	case "Python":
+		pythonPackage := func(name string) string {
+			return strings.ReplaceAll(name, "_", "-")
+		}
+		for _, line := range strings.Split(content, "\n") {
+			match := pulumiPythonImport.FindStringSubmatch(strings.TrimSpace(line))
+			if match == nil {
+				continue
+			}
+			alias := match[3]
+			if alias == "" {
+				alias = "pulumi_" + match[1]
+				if match[2] != "" {
+					alias += "." + match[2]
+				}
+			}
+			imports.add(pulumiModule{Package: pythonPackage(match[1]), Module: match[2]}, "pulumi_"+match[1], alias)
+		}
+		for _, match := range pulumiPythonFromImport.FindAllStringSubmatch(content, -1) {
+			imports.addNames(pulumiModule{Package: pythonPackage(match[1]), Module: match[2]}, "pulumi_"+match[1], match[3])
+		}
+	case "Java":
+		for _, match := range pulumiJavaImport.FindAllStringSubmatch(content, -1) {
+			imports.add(pulumiModule{Package: match[1], Module: match[2]}, "com.pulumi."+match[1], "", match[3])
+		}
+	case "Go":
+		for _, match := range pulumiGoImport.FindAllStringSubmatch(content, -1) {
+			module := match[3]
+			alias := match[1]
+			if alias == "" {
+				alias = module[strings.LastIndex(module, "/")+1:]
+			}
+			if module == "" {
+				continue
+			}
+			imports.add(pulumiModule{Package: match[2], Module: module}, "github.com/pulumi/pulumi-"+match[2], alias)
+		}
+	}
+	return imports
+}
+
+// resolve returns the module a resource class qualified by qualifier comes
+// from. A qualifier may be a package alias followed by a module, as in
+// aws.s3.Bucket.
+func (p pulumiImports) resolve(qualifier string, class string) (pulumiModule, bool) {
+	if qualifier == "" {
+		module, ok := p.Classes[class]
+		return module, ok
+	}
+	if module, ok := p.Aliases[qualifier]; ok {
+		return module, true
+	}
+	alias, rest, ok := strings.Cut(qualifier, ".")
+	if module, found := p.Aliases[alias]; ok && found && module.Module == "" {
+		return pulumiModule{Package: module.Package, Module: rest}, true
+	}
+	return pulumiModule{}, false
+}
+
+// parsePulumiToken splits a resource type token such as "aws:s3/bucket:Bucket"
+// or "aws:s3:Bucket" into its package, module and class.
+func parsePulumiToken(token string) (pulumiModule, string, bool) {
+	parts := strings.Split(token, ":")
+	if len(parts) != 3 {
+		return pulumiModule{}, "", false
+	}
+	module, _, _ := strings.Cut(parts[1], "/")
+	return pulumiModule{Package: parts[0], Module: module}, parts[2], true
+}
+
+// pulumiTerraformType names the Terraform type a Pulumi resource corresponds
+// to; most Pulumi providers are bridged from the Terraform provider of the
+// same name.
+func pulumiTerraformType(module pulumiModule, class string) string {
+	prefix, ok := pulumiProviderPrefixes[module.Package]
+	if !ok {
+		prefix = strings.ReplaceAll(module.Package, "-", "_")
+	}
+	return terraformEquivalentType(prefix, module.Module, class)
+}
+
+// PulumiProcessor reports Pulumi projects and stacks, the provider packages
+// programs import and the resources they declare, in TypeScript, JavaScript,
+// Python, Java, Go and YAML. Resources are mapped to the vendors and services
+// of Terraform resources through the provider table.
+type PulumiProcessor struct {
+	Providers []TerraformProvider
+}
+
+// NewPulumiProcessor creates a PulumiProcessor that names services from the
+// provider tables in f.
+func NewPulumiProcessor(f fs.FS) *PulumiProcessor {
+	providers, err := LoadTerraformProviders(f)
+	if err != nil {
+		log.Printf("Failed to load Terraform providers: %v", err)
+	}
+	return &PulumiProcessor{Providers: providers}
+}
+
+func (p PulumiProcessor) Supports(filePath string) bool {
+	base := filepath.Base(filePath)
+	if base == "Pulumi.yaml" || base == "Pulumi.yml" || pulumiStackFileRe.MatchString(base) {
+		return true
+	}
+	_, ok := infrastructureCodeLanguage(filePath)
+	return ok
+}
+
+func (p PulumiProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	base := filepath.Base(path)
+	if base == "Pulumi.yaml" || base == "Pulumi.yml" {
+		return p.processProject(path, repoName, content)
+	}
+	if match := pulumiStackFileRe.FindStringSubmatch(base); match != nil {
+		return p.processStack(match[1], path, repoName, content)
+	}
+
+	language, _ := infrastructureCodeLanguage(path)
+	if !strings.Contains(content, "pulumi") {
+		return nil, nil
+	}
+	imports := parsePulumiImports(language, content)
+
+	var matches []core.Finding
+	for _, name := range sortedKeys(imports.Packages) {
+		matches = append(matches, p.providerFinding(name, imports.Packages[name], language, path, repoName))
+	}
+	for _, resource := range findCodeConstructs(content, pulumiResourcePatterns[language]) {
+		if pulumiNonResourceClassRe.MatchString(resource.Class) {
+			continue
+		}
+		module, ok := imports.resolve(resource.Qualifier, resource.Class)
+		if !ok || module.Module == "" && resource.Class == "Provider" {
+			continue
+		}
+		matches = append(matches, p.resourceFinding(module, resource.Class, resource.ID, language, path, repoName))
+	}
+	return matches, nil
+}
+
+// providerFinding reports a provider package a program uses.
+func (p PulumiProcessor) providerFinding(name string, importPath string, language string, path string, repoName string) core.Finding {
+	props, _, _ := terraformTypeProperties(p.Providers, pulumiTerraformType(pulumiModule{Package: name}, ""))
+	props["import"] = importPath
+	props["language"] = language
+	return core.Finding{
+		Name:       name,
+		Type:       "Pulumi Provider",
+		Category:   "Pulumi",
+		Properties: props,
+		Path:       path,
+		RepoName:   repoName,
+	}
+}
+
+// resourceFinding reports a resource with its type token.
+func (p PulumiProcessor) resourceFinding(module pulumiModule, class string, name string, language string, path string, repoName string) core.Finding {
+	token := module.Package + ":" + module.Module + ":" + class
+	if module.Module == "" {
+		token = module.Package + ":index:" + class
+	}
+	props := map[string]interface{}{
+		"resource_token": token,
+		"language":       language,
+	}
+	if name != "" {
+		props["resource_name"] = name
+	}
+	return infrastructureCodeFinding(p.Providers, "Pulumi", pulumiTerraformType(module, class), props, path, repoName)
+}
+
+// processProject reports a project and, for YAML programs, the resources
+// and providers declared in it.
+func (p PulumiProcessor) processProject(path string, repoName string, content string) ([]core.Finding, error) {
+	var project struct {
+		Name        string      `yaml:"name"`
+		Runtime     interface{} `yaml:"runtime"`
+		Description string      `yaml:"description"`
+		Main        string      `yaml:"main"`
+		Backend     struct {
+			URL string `yaml:"url"`
+		} `yaml:"backend"`
+		Resources map[string]struct {
+			Type string `yaml:"type"`
+		} `yaml:"resources"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &project); err != nil {
+		return nil, fmt.Errorf("failed to parse Pulumi project %s: %w", path, err)
+	}
+
+	runtime := project.Runtime
+	if options, ok := runtime.(map[string]interface{}); ok {
+		runtime = options["name"]
+	}
+	props := map[string]interface{}{
+		"runtime": runtime,
+	}
+	for key, value := range map[string]string{
+		"description": project.Description,
+		"main":        project.Main,
+		"backend":     project.Backend.URL,
+	} {
+		if value != "" {
+			props[key] = value
+		}
+	}
+	matches := []core.Finding{{
+		Name:       project.Name,
+		Type:       "Pulumi Project",
+		Category:   "Pulumi",
+		Properties: props,
+		Path:       path,
+		RepoName:   repoName,
+	}}
+
+	packages := map[string]bool{}
+	for _, name := range sortedKeys(project.Resources) {
+		module, class, ok := parsePulumiToken(project.Resources[name].Type)
+		if !ok {
+			continue
+		}
+		if module.Module == "index" {
+			module.Module = ""
+		}
+		if !pulumiNonProviderPackages[module.Package] && !packages[module.Package] {
+			packages[module.Package] = true
+			matches = append(matches, p.providerFinding(module.Package, module.Package, "YAML", path, repoName))
+		}
+		if module.Package == "pulumi" || module.Module == "" && class == "Provider" {
+			continue
+		}
+		matches = append(matches, p.resourceFinding(module, class, name, "YAML", path, repoName))
+	}
+	return matches, nil
+}
+
+// processStack reports a stack settings file with the providers it
+// configures and how many of its values are secrets.
+func (p PulumiProcessor) processStack(stack string, path string, repoName string, content string) ([]core.Finding, error) {
+	var settings struct {
+		SecretsProvider string                 `yaml:"secretsprovider"`
+		Config          map[string]interface{} `yaml:"config"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse Pulumi stack %s: %w", path, err)
+	}
+
+	// Config keys are namespaced by the project or provider they configure.
+	namespaces := map[string]bool{}
+	secrets := 0
+	props := map[string]interface{}{}
+	for _, key := range sortedKeys(settings.Config) {
+		namespace, setting, ok := strings.Cut(key, ":")
+		if ok {
+			namespaces[namespace] = true
+		}
+		if value, ok := settings.Config[key].(map[string]interface{}); ok {
+			if _, secure := value["secure"]; secure {
+				secrets++
+			}
+		}
+		if ok && (setting == "region" || setting == "location") && namespace != "" {
+			props[namespace+"_"+setting] = settings.Config[key]
+		}
+	}
+	var providers []string
+	for _, namespace := range sortedKeys(namespaces) {
+		if _, _, known := lookupTerraformProvider(p.Providers, pulumiTerraformType(pulumiModule{Package: namespace}, "")); known {
+			providers = append(providers, namespace)
+		}
+	}
+	props["config_keys"] = len(settings.Config)
+	props["secrets"] = secrets
+	if len(providers) > 0 {
+		props["providers"] = providers
+	}
+	if settings.SecretsProvider != "" {
+		props["secrets_provider"] = settings.SecretsProvider
+	}
+	return []core.Finding{{
+		Name:       stack,
+		Type:       "Pulumi Stack",
+		Category:   "Pulumi",
+		Properties: props,
+		Path:       path,
+		RepoName:   repoName,
+	}}, nil
+}
@@ -0,0 +1,159 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// pulumiResources returns the Pulumi resources among findings keyed by name.
+func pulumiResources(t *testing.T, findings []core.Finding) map[string]core.Finding {
+	t.Helper()
+	resources := make(map[string]core.Finding)
+	for _, finding := range findingsByType(findings, "Pulumi Resource Use") {
+		resources[finding.Properties["resource_name"].(string)] = finding
+	}
+	return resources
+}
+
+func TestPulumiProcessor_TypeScript(t *testing.T) {
+	content := `import * as pulumi from "@pulumi/pulumi";
+import * as aws from "@pulumi/aws";
+import { storage } from "@pulumi/gcp";
+import * as random from "@pulumi/random";
+
+const config = new pulumi.Config();
+const provider = new aws.Provider("eu", { region: "eu-west-2" });
+const bucket = new aws.s3.BucketV2("assets", {}, { provider });
+const args: aws.s3.BucketArgs = {};
+const queue = new aws.sqs.Queue("jobs");
+const backups = new storage.Bucket("backups", { location: "EU" });
+const suffix = new random.RandomPet("suffix");
+export const name = bucket.id;
+`
+	processor := NewPulumiProcessor(terraformFS)
+	assert.True(t, processor.Supports("index.ts"))
+
+	findings, err := processor.Process("index.ts", "repo", content)
+	require.NoError(t, err)
+
+	providers := findingsOfType(findings, "Pulumi Provider")
+	require.Len(t, providers, 3)
+	assert.Equal(t, "@pulumi/aws", providers["aws"].Properties["import"])
+	assert.Equal(t, "AWS", providers["aws"].Properties["vendor"])
+	assert.Equal(t, "GCP", providers["gcp"].Properties["vendor"])
+
+	resources := pulumiResources(t, findings)
+	require.Len(t, resources, 4)
+	assert.Equal(t, "AWS Resource", resources["assets"].Name)
+	assert.Equal(t, "aws:s3:BucketV2", resources["assets"].Properties["resource_token"])
+	assert.Equal(t, "Amazon S3", resources["assets"].Properties["service"])
+	assert.Equal(t, "Amazon SQS", resources["jobs"].Properties["service"])
+	assert.Equal(t, "GCP Resource", resources["backups"].Name)
+	assert.Equal(t, "gcp:storage:Bucket", resources["backups"].Properties["resource_token"])
+	assert.Equal(t, "random", resources["suffix"].Properties["provider_type"])
+}
+
+func TestPulumiProcessor_PythonAndGo(t *testing.T) {
+	python := `import pulumi
+import pulumi_aws as aws
+from pulumi_aws import lambda_, iam
+
+role = iam.Role("handler-role", assume_role_policy="{}")
+table = aws.dynamodb.Table("orders",
+    attributes=[aws.dynamodb.TableAttributeArgs(name="id", type="S")],
+    hash_key="id")
+fn = lambda_.Function("handler", role=role.arn, runtime="python3.12")
+pulumi.export("table", table.name)
+`
+	findings, err := NewPulumiProcessor(terraformFS).Process("__main__.py", "repo", python)
+	require.NoError(t, err)
+	assert.Len(t, findingsOfType(findings, "Pulumi Provider"), 1)
+	resources := pulumiResources(t, findings)
+	require.Len(t, resources, 3)
+	assert.Equal(t, "AWS IAM", resources["handler-role"].Properties["service"])
+	assert.Equal(t, "Amazon DynamoDB", resources["orders"].Properties["service"])
+	assert.Equal(t, "AWS Lambda", resources["handler"].Properties["service"])
+	assert.Equal(t, "Python", resources["handler"].Properties["language"])
+
+	golang := `package main
+
+import (
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/s3"
+	"github.com/pulumi/pulumi-azure-native-sdk/storage/v2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func main() {
+	pulumi.Run(func(ctx *pulumi.Context) error {
+		bucket, err := s3.NewBucket(ctx, "assets", &s3.BucketArgs{})
+		if err != nil {
+			return err
+		}
+		ctx.Export("bucket", bucket.ID())
+		return nil
+	})
+}
+`
+	findings, err = NewPulumiProcessor(terraformFS).Process("main.go", "repo", golang)
+	require.NoError(t, err)
+	resources = pulumiResources(t, findings)
+	require.Len(t, resources, 1)
+	assert.Equal(t, "aws:s3:Bucket", resources["assets"].Properties["resource_token"])
+	assert.Equal(t, "Amazon S3", resources["assets"].Properties["service"])
+}
+
+func TestPulumiProcessor_ProjectAndStack(t *testing.T) {
+	project := `name: website
+runtime: yaml
+description: Static website
+backend:
+  url: s3://acme-pulumi-state
+resources:
+  site:
+    type: aws:s3/bucketV2:BucketV2
+  cdn:
+    type: aws:cloudfront:Distribution
+  eu:
+    type: pulumi:providers:aws
+`
+	processor := NewPulumiProcessor(terraformFS)
+	assert.True(t, processor.Supports("infra/Pulumi.yaml"))
+	assert.True(t, processor.Supports("infra/Pulumi.prod.yaml"))
+
+	findings, err := processor.Process("infra/Pulumi.yaml", "repo", project)
+	require.NoError(t, err)
+
+	projects := findingsOfType(findings, "Pulumi Project")
+	require.Len(t, projects, 1)
+	assert.Equal(t, "yaml", projects["website"].Properties["runtime"])
+	assert.Equal(t, "s3://acme-pulumi-state", projects["website"].Properties["backend"])
+	assert.Len(t, findingsOfType(findings, "Pulumi Provider"), 1)
+
+	resources := pulumiResources(t, findings)
+	require.Len(t, resources, 2)
+	assert.Equal(t, "aws:s3:BucketV2", resources["site"].Properties["resource_token"])
+	assert.Equal(t, "Amazon S3", resources["site"].Properties["service"])
+	assert.Equal(t, "Amazon CloudFront", resources["cdn"].Properties["service"])
+
+	stack := `secretsprovider: awskms://alias/pulumi
+config:
+  aws:region: eu-west-2
+  gcp:project: acme
+  website:domain: example.com
+  website:apiKey:
+    secure: v1:abc
+`
+	findings, err = processor.Process("infra/Pulumi.prod.yaml", "repo", stack)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "prod", findings[0].Name)
+	assert.Equal(t, "Pulumi Stack", findings[0].Type)
+	assert.Equal(t, []string{"aws", "gcp"}, findings[0].Properties["providers"])
+	assert.Equal(t, "eu-west-2", findings[0].Properties["aws_region"])
+	assert.Equal(t, 1, findings[0].Properties["secrets"])
+	assert.Equal(t, 4, findings[0].Properties["config_keys"])
+}
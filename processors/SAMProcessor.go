@@ -0,0 +1,178 @@
+package processors
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// isSAMTemplate reports whether a template uses the AWS Serverless
+// Application Model transform.
+func isSAMTemplate(template CloudFormationTemplate) bool {
+	for _, transform := range cloudFormationTransforms(template.Transform) {
+		if strings.HasPrefix(transform, "AWS::Serverless-") {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSAMGlobals applies a Globals section to a resource's properties the
+// way SAM does: the resource's own values win, maps are merged key by key and
+// lists are added to the global list.
+func mergeSAMGlobals(globals map[string]interface{}, properties map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(globals)+len(properties))
+	for key, value := range globals {
+		merged[key] = value
+	}
+	for key, value := range properties {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if global, ok := merged[key].(map[string]interface{}); ok {
+				value = mergeSAMGlobals(global, v)
+			}
+		case []interface{}:
+			if global, ok := merged[key].([]interface{}); ok {
+				value = append(append([]interface{}{}, global...), v...)
+			}
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// samFunction reads an AWS::Serverless::Function or AWS::Lambda::Function.
+// Plain Lambda functions have no Events; their triggers are separate
+// resources.
+func samFunction(name string, properties map[string]interface{}) lambdaFunction {
+	function := lambdaFunction{
+		Name:         name,
+		Runtime:      stringProperty(properties, "Runtime"),
+		Handler:      stringProperty(properties, "Handler"),
+		Architecture: defaultLambdaArchitecture,
+		MemorySize:   properties["MemorySize"],
+		Timeout:      properties["Timeout"],
+		PackageType:  stringProperty(properties, "PackageType"),
+	}
+	if architectures, ok := properties["Architectures"].([]interface{}); ok && len(architectures) > 0 {
+		function.Architecture = fmt.Sprint(architectures[0])
+	}
+	for _, key := range []string{"CodeUri", "ImageUri", "Code"} {
+		if code, ok := properties[key]; ok {
+			function.Code = code
+			break
+		}
+	}
+	// Inline source is reported as such rather than copied.
+	if code, ok := function.Code.(map[string]interface{}); ok {
+		if image, ok := code["ImageUri"]; ok {
+			function.Code = image
+		} else if _, ok := code["ZipFile"]; ok {
+			function.Code = "inline"
+		}
+	}
+	if _, ok := properties["InlineCode"]; ok {
+		function.Code = "inline"
+	}
+	if layers, ok := properties["Layers"].([]interface{}); ok {
+		function.Layers = layers
+	}
+
+	events, _ := properties["Events"].(map[string]interface{})
+	for _, eventName := range sortedKeys(events) {
+		event, _ := events[eventName].(map[string]interface{})
+		trigger, _ := event["Type"].(string)
+		if trigger == "" {
+			continue
+		}
+		function.Events = append(function.Events, lambdaEvent{
+			Name:       eventName,
+			Properties: lambdaEventProperties(trigger, event["Properties"]),
+		})
+	}
+	return function
+}
+
+// stringProperty returns a property that is a string, or "" when it is
+// missing or an intrinsic function.
+func stringProperty(properties map[string]interface{}, key string) string {
+	value, _ := properties[key].(string)
+	return value
+}
+
+// SAMProcessor reports the Lambda functions of AWS Serverless Application
+// Model templates with their runtime, architecture, memory, timeout, handler,
+// event sources and layers, and the layers the templates declare. The other
+// resources are left to CloudFormationProcessor.
+type SAMProcessor struct {
+	Runtimes map[string]LambdaRuntime
+	// Today is the date, as YYYY-MM-DD, runtimes are checked for
+	// deprecation on.
+	Today string
+}
+
+// NewSAMProcessor creates a SAMProcessor that checks runtimes against the
+// table in f.
+func NewSAMProcessor(f fs.FS) *SAMProcessor {
+	runtimes, err := LoadLambdaRuntimes(f)
+	if err != nil {
+		log.Printf("Failed to load Lambda runtimes: %v", err)
+	}
+	return &SAMProcessor{Runtimes: runtimes, Today: time.Now().Format("2006-01-02")}
+}
+
+func (s SAMProcessor) Supports(filePath string) bool {
+	return CloudFormationProcessor{}.Supports(filePath)
+}
+
+func (s SAMProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	if !strings.Contains(content, "AWS::Serverless") {
+		return nil, nil
+	}
+	template, err := parseCloudFormationTemplate([]byte(content))
+	if err != nil || !isSAMTemplate(template) {
+		return nil, nil
+	}
+
+	var matches []core.Finding
+	for _, name := range sortedKeys(template.Resources) {
+		resource := template.Resources[name]
+		switch resource.Type {
+		case "AWS::Serverless::Function":
+			properties := mergeSAMGlobals(template.Globals["Function"], resource.Properties)
+			matches = append(matches, lambdaFunctionFindings(samFunction(name, properties), "SAM", s.Runtimes, s.Today, path, repoName)...)
+		case "AWS::Lambda::Function":
+			matches = append(matches, lambdaFunctionFindings(samFunction(name, resource.Properties), "SAM", s.Runtimes, s.Today, path, repoName)...)
+		case "AWS::Serverless::LayerVersion", "AWS::Lambda::LayerVersion":
+			props := map[string]interface{}{
+				"framework": "SAM",
+				"declared":  true,
+			}
+			for key, property := range map[string]string{
+				"CompatibleRuntimes":      "compatible_runtimes",
+				"CompatibleArchitectures": "compatible_architectures",
+				"ContentUri":              "content",
+				"Content":                 "content",
+				"LayerName":               "layer_name",
+			} {
+				if value, ok := resource.Properties[key]; ok {
+					props[property] = lambdaValue(value)
+				}
+			}
+			matches = append(matches, core.Finding{
+				Name:       name,
+				Type:       "Lambda Layer",
+				Category:   "AWS",
+				Properties: props,
+				Path:       path,
+				RepoName:   repoName,
+			})
+		}
+	}
+	return matches, nil
+}
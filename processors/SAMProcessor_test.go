@@ -0,0 +1,177 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestSAMProcessor_Process(t *testing.T) {
+	content := `AWSTemplateFormatVersion: '2010-09-09'
+Transform: AWS::Serverless-2016-10-31
+
+Globals:
+  Function:
+    Runtime: python3.12
+    MemorySize: 256
+    Timeout: 10
+    Layers:
+      - !Ref SharedLayer
+
+Resources:
+  OrdersFunction:
+    Type: AWS::Serverless::Function
+    Properties:
+      Handler: app.handler
+      CodeUri: src/orders/
+      Architectures: [arm64]
+      Layers:
+        - arn:aws:lambda:eu-west-2:017000801446:layer:AWSLambdaPowertoolsPythonV2:68
+      Events:
+        GetOrder:
+          Type: Api
+          Properties:
+            Path: /orders/{id}
+            Method: get
+        Queue:
+          Type: SQS
+          Properties:
+            Queue: !GetAtt OrdersQueue.Arn
+        Upload:
+          Type: S3
+          Properties:
+            Bucket: !Ref UploadBucket
+            Events: s3:ObjectCreated:*
+        Nightly:
+          Type: Schedule
+          Properties:
+            Schedule: rate(1 day)
+
+  LegacyFunction:
+    Type: AWS::Serverless::Function
+    Properties:
+      Handler: index.handler
+      Runtime: nodejs14.x
+      Timeout: 30
+      InlineCode: exports.handler = async () => {}
+
+  ImageFunction:
+    Type: AWS::Serverless::Function
+    Properties:
+      PackageType: Image
+      ImageUri: 123456789012.dkr.ecr.eu-west-2.amazonaws.com/app:latest
+      Runtime: !Ref AWS::NoValue
+
+  SharedLayer:
+    Type: AWS::Serverless::LayerVersion
+    Properties:
+      ContentUri: layers/shared/
+      CompatibleRuntimes: [python3.12]
+
+  OrdersQueue:
+    Type: AWS::SQS::Queue
+`
+	processor := NewSAMProcessor(lambdaFS)
+	processor.Today = "2026-10-18"
+	assert.True(t, processor.Supports("template.yaml"))
+
+	findings, err := processor.Process("template.yaml", "repo", content)
+	require.NoError(t, err)
+
+	functions := findingsOfType(findings, "Lambda Function")
+	require.Len(t, functions, 3)
+	orders := functions["OrdersFunction"].Properties
+	assert.Equal(t, "python3.12", orders["runtime"])
+	assert.Equal(t, "arm64", orders["architecture"])
+	assert.EqualValues(t, 256, orders["memory_size"])
+	assert.EqualValues(t, 10, orders["timeout"])
+	assert.Equal(t, "app.handler", orders["handler"])
+	assert.Equal(t, "src/orders/", orders["code"])
+	assert.Equal(t, "SAM", orders["framework"])
+	assert.Equal(t, []string{"SharedLayer", "arn:aws:lambda:eu-west-2:017000801446:layer:AWSLambdaPowertoolsPythonV2:68"}, orders["layers"])
+	assert.Equal(t, []string{"api", "schedule", "sqs", "s3"}, orders["events"])
+
+	legacy := functions["LegacyFunction"].Properties
+	assert.Equal(t, "nodejs14.x", legacy["runtime"])
+	assert.EqualValues(t, 30, legacy["timeout"])
+	assert.Equal(t, "x86_64", legacy["architecture"])
+	assert.Equal(t, "inline", legacy["code"])
+
+	image := functions["ImageFunction"].Properties
+	assert.Equal(t, "Image", image["package_type"])
+	assert.Nil(t, image["runtime"])
+
+	events := make(map[string]core.Finding)
+	for _, finding := range findingsByType(findings, "Lambda Event Source") {
+		if finding.Properties["function"] == "OrdersFunction" {
+			events[finding.Name] = finding
+		}
+	}
+	require.Len(t, events, 4)
+	assert.Equal(t, "/orders/{id}", events["api"].Properties["path"])
+	assert.Equal(t, "get", events["api"].Properties["method"])
+	assert.Equal(t, "GetOrder", events["api"].Properties["event"])
+	assert.Equal(t, "Api", events["api"].Properties["trigger"])
+	assert.Equal(t, "OrdersQueue.Arn", events["sqs"].Properties["queue"])
+	assert.Equal(t, "UploadBucket", events["s3"].Properties["bucket"])
+	assert.Equal(t, "s3:ObjectCreated:*", events["s3"].Properties["s3_events"])
+	assert.Equal(t, "rate(1 day)", events["schedule"].Properties["schedule"])
+
+	layers := findingsByType(findings, "Lambda Layer")
+	var declared core.Finding
+	for _, layer := range layers {
+		if layer.Properties["declared"] == true {
+			declared = layer
+		}
+	}
+	assert.Equal(t, "SharedLayer", declared.Name)
+	assert.Equal(t, "layers/shared/", declared.Properties["content"])
+	assert.Equal(t, []interface{}{"python3.12"}, declared.Properties["compatible_runtimes"])
+
+	runtimes := make(map[string]core.Finding)
+	for _, finding := range findingsByType(findings, "Runtime") {
+		runtimes[finding.Properties["Function"].(string)] = finding
+	}
+	require.Len(t, runtimes, 2)
+	assert.Equal(t, "Python", runtimes["OrdersFunction"].Name)
+	assert.Equal(t, "3.12", runtimes["OrdersFunction"].Properties["Version"])
+	assert.Equal(t, "AWS Lambda", runtimes["OrdersFunction"].Properties["Platform"])
+	assert.Equal(t, false, runtimes["OrdersFunction"].Properties["Deprecated"])
+	assert.Equal(t, "Node.js", runtimes["LegacyFunction"].Name)
+	assert.Equal(t, true, runtimes["LegacyFunction"].Properties["Deprecated"])
+	assert.Equal(t, "2023-12-04", runtimes["LegacyFunction"].Properties["DeprecatedOn"])
+}
+
+func TestSAMProcessor_OnlySAMTemplates(t *testing.T) {
+	content := `AWSTemplateFormatVersion: '2010-09-09'
+Resources:
+  Fn:
+    Type: AWS::Lambda::Function
+    Properties:
+      Runtime: go1.x
+`
+	findings, err := NewSAMProcessor(lambdaFS).Process("template.yaml", "repo", content)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestMergeSAMGlobals(t *testing.T) {
+	merged := mergeSAMGlobals(
+		map[string]interface{}{
+			"Timeout":     3,
+			"Layers":      []interface{}{"a"},
+			"Environment": map[string]interface{}{"Variables": map[string]interface{}{"STAGE": "prod", "LOG": "info"}},
+		},
+		map[string]interface{}{
+			"Timeout":     30,
+			"Layers":      []interface{}{"b"},
+			"Environment": map[string]interface{}{"Variables": map[string]interface{}{"LOG": "debug"}},
+		},
+	)
+	assert.EqualValues(t, 30, merged["Timeout"])
+	assert.Equal(t, []interface{}{"a", "b"}, merged["Layers"])
+	assert.Equal(t, map[string]interface{}{"Variables": map[string]interface{}{"STAGE": "prod", "LOG": "debug"}}, merged["Environment"])
+}
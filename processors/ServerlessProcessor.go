@@ -0,0 +1,264 @@
+package processors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// serverlessConfig is a partial representation of a Serverless Framework
+// service. Values may hold ${...} variables, which are reported as written.
+type serverlessConfig struct {
+	Service          interface{} `json:"service"`
+	FrameworkVersion interface{} `json:"frameworkVersion"`
+	Provider         struct {
+		Name         string        `json:"name"`
+		Runtime      string        `json:"runtime"`
+		Architecture string        `json:"architecture"`
+		MemorySize   interface{}   `json:"memorySize"`
+		Timeout      interface{}   `json:"timeout"`
+		Region       interface{}   `json:"region"`
+		Stage        interface{}   `json:"stage"`
+		Layers       []interface{} `json:"layers"`
+	} `json:"provider"`
+	Plugins   interface{}                   `json:"plugins"`
+	Layers    map[string]serverlessLayer    `json:"layers"`
+	Functions map[string]serverlessFunction `json:"functions"`
+}
+
+// serverlessFunction is an entry of a service's functions.
+type serverlessFunction struct {
+	Handler      string                   `json:"handler"`
+	Runtime      string                   `json:"runtime"`
+	Architecture string                   `json:"architecture"`
+	MemorySize   interface{}              `json:"memorySize"`
+	Timeout      interface{}              `json:"timeout"`
+	Image        interface{}              `json:"image"`
+	Layers       []interface{}            `json:"layers"`
+	Events       []map[string]interface{} `json:"events"`
+}
+
+// serverlessLayer is a layer the service packages itself.
+type serverlessLayer struct {
+	Path                    string        `json:"path"`
+	Name                    string        `json:"name"`
+	CompatibleRuntimes      []interface{} `json:"compatibleRuntimes"`
+	CompatibleArchitectures []interface{} `json:"compatibleArchitectures"`
+}
+
+// serverlessPlugins returns the plugins of a service, which are listed
+// directly or under modules alongside a localPath.
+func serverlessPlugins(plugins interface{}) []string {
+	if section, ok := plugins.(map[string]interface{}); ok {
+		plugins = section["modules"]
+	}
+	list, _ := plugins.([]interface{})
+	names := make([]string, 0, len(list))
+	for _, plugin := range list {
+		names = append(names, fmt.Sprint(plugin))
+	}
+	return names
+}
+
+// ServerlessProcessor reports Serverless Framework services: their plugins,
+// the layers they package and, for AWS, each function with its runtime,
+// architecture, memory, timeout, handler, event sources and layers.
+type ServerlessProcessor struct {
+	Runtimes map[string]LambdaRuntime
+	// Today is the date, as YYYY-MM-DD, runtimes are checked for
+	// deprecation on.
+	Today string
+}
+
+// NewServerlessProcessor creates a ServerlessProcessor that checks runtimes
+// against the table in f.
+func NewServerlessProcessor(f fs.FS) *ServerlessProcessor {
+	runtimes, err := LoadLambdaRuntimes(f)
+	if err != nil {
+		log.Printf("Failed to load Lambda runtimes: %v", err)
+	}
+	return &ServerlessProcessor{Runtimes: runtimes, Today: time.Now().Format("2006-01-02")}
+}
+
+func (s ServerlessProcessor) Supports(filePath string) bool {
+	switch strings.ToLower(filepath.Base(filePath)) {
+	case "serverless.yml", "serverless.yaml", "serverless.json":
+		return true
+	}
+	return false
+}
+
+func (s ServerlessProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	// The resources section is CloudFormation, short-form tags included.
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &node); err != nil {
+		return nil, fmt.Errorf("failed to parse Serverless config %s: %w", path, err)
+	}
+	document, err := decodeCloudFormationNode(&node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Serverless config %s: %w", path, err)
+	}
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var config serverlessConfig
+	if err := json.Unmarshal(encoded, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse Serverless config %s: %w", path, err)
+	}
+
+	service := config.Service
+	if section, ok := service.(map[string]interface{}); ok {
+		service = section["name"]
+	}
+	serviceName := fmt.Sprint(service)
+	provider := config.Provider.Name
+	if provider == "" {
+		provider = "aws"
+	}
+
+	newFinding := func(name string, findingType string, properties map[string]interface{}) core.Finding {
+		return core.Finding{
+			Name:       name,
+			Type:       findingType,
+			Category:   "Serverless",
+			Properties: properties,
+			Path:       path,
+			RepoName:   repoName,
+		}
+	}
+
+	serviceProps := map[string]interface{}{
+		"provider": provider,
+	}
+	for key, value := range map[string]interface{}{
+		"framework_version": config.FrameworkVersion,
+		"runtime":           config.Provider.Runtime,
+		"region":            config.Provider.Region,
+		"stage":             config.Provider.Stage,
+	} {
+		if value != nil && value != "" {
+			serviceProps[key] = value
+		}
+	}
+	matches := []core.Finding{newFinding(serviceName, "Serverless Service", serviceProps)}
+
+	for _, plugin := range serverlessPlugins(config.Plugins) {
+		matches = append(matches, newFinding(plugin, "Serverless Plugin", map[string]interface{}{
+			"service": serviceName,
+			"local":   strings.HasPrefix(plugin, "."),
+		}))
+	}
+
+	// Other providers run functions on their own platforms.
+	if provider != "aws" {
+		return matches, nil
+	}
+
+	for _, name := range sortedKeys(config.Layers) {
+		layer := config.Layers[name]
+		props := map[string]interface{}{
+			"framework": "Serverless",
+			"service":   serviceName,
+			"declared":  true,
+		}
+		if layer.Path != "" {
+			props["content"] = layer.Path
+		}
+		if layer.Name != "" {
+			props["layer_name"] = layer.Name
+		}
+		if len(layer.CompatibleRuntimes) > 0 {
+			props["compatible_runtimes"] = layer.CompatibleRuntimes
+		}
+		if len(layer.CompatibleArchitectures) > 0 {
+			props["compatible_architectures"] = layer.CompatibleArchitectures
+		}
+		matches = append(matches, core.Finding{
+			Name:       name,
+			Type:       "Lambda Layer",
+			Category:   "AWS",
+			Properties: props,
+			Path:       path,
+			RepoName:   repoName,
+		})
+	}
+
+	for _, name := range sortedKeys(config.Functions) {
+		fn := config.Functions[name]
+		function := lambdaFunction{
+			Name:         name,
+			Runtime:      firstNonEmpty(fn.Runtime, config.Provider.Runtime),
+			Handler:      fn.Handler,
+			Architecture: firstNonEmpty(fn.Architecture, config.Provider.Architecture, defaultLambdaArchitecture),
+			MemorySize:   fn.MemorySize,
+			Timeout:      fn.Timeout,
+			Layers:       fn.Layers,
+		}
+		if function.MemorySize == nil {
+			function.MemorySize = config.Provider.MemorySize
+		}
+		if function.Timeout == nil {
+			function.Timeout = config.Provider.Timeout
+		}
+		if function.Layers == nil {
+			function.Layers = config.Provider.Layers
+		}
+		if fn.Image != nil {
+			// Container image functions take their runtime from the image.
+			function.PackageType = "Image"
+			function.Runtime = ""
+			if image, ok := fn.Image.(map[string]interface{}); ok {
+				function.Code = firstNonNil(image["uri"], image["name"])
+			} else {
+				function.Code = fn.Image
+			}
+		}
+		for _, event := range fn.Events {
+			// Each event is a map with a single key naming its source.
+			for _, trigger := range sortedKeys(event) {
+				function.Events = append(function.Events, lambdaEvent{
+					Properties: lambdaEventProperties(trigger, event[trigger]),
+				})
+			}
+		}
+
+		for _, finding := range lambdaFunctionFindings(function, "Serverless", s.Runtimes, s.Today, path, repoName) {
+			if finding.Type == "Runtime" {
+				finding.Properties["Service"] = serviceName
+			} else {
+				finding.Properties["service"] = serviceName
+			}
+			matches = append(matches, finding)
+		}
+	}
+	return matches, nil
+}
+
+// firstNonEmpty returns the first of values that is not "".
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// firstNonNil returns the first of values that is not nil.
+func firstNonNil(values ...interface{}) interface{} {
+	for _, value := range values {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
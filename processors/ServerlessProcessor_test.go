@@ -0,0 +1,187 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestServerlessProcessor_Process(t *testing.T) {
+	content := `service: orders
+frameworkVersion: '3'
+
+provider:
+  name: aws
+  runtime: nodejs18.x
+  architecture: arm64
+  memorySize: 512
+  region: eu-west-2
+  stage: ${opt:stage, 'dev'}
+
+plugins:
+  - serverless-offline
+  - ./plugins/tagging
+
+layers:
+  shared:
+    path: layers/shared
+    compatibleRuntimes:
+      - nodejs18.x
+
+functions:
+  create:
+    handler: src/create.handler
+    timeout: 20
+    layers:
+      - { Ref: SharedLambdaLayer }
+    events:
+      - http:
+          path: orders
+          method: post
+      - httpApi: 'GET /orders/{id}'
+      - sqs:
+          arn: !GetAtt OrdersQueue.Arn
+      - s3:
+          bucket: uploads
+          event: s3:ObjectCreated:*
+      - schedule: rate(5 minutes)
+      - stream:
+          type: dynamodb
+          arn: !GetAtt OrdersTable.StreamArn
+  report:
+    handler: report.main
+    runtime: python3.12
+    architecture: x86_64
+    events:
+      - stream: arn:aws:kinesis:eu-west-2:123456789012:stream/orders
+  render:
+    image:
+      uri: 123456789012.dkr.ecr.eu-west-2.amazonaws.com/render:latest
+
+resources:
+  Resources:
+    OrdersQueue:
+      Type: AWS::SQS::Queue
+`
+	processor := NewServerlessProcessor(lambdaFS)
+	processor.Today = "2026-10-18"
+	assert.True(t, processor.Supports("services/orders/serverless.yml"))
+	assert.False(t, processor.Supports("services/orders/serverless.ts"))
+
+	findings, err := processor.Process("serverless.yml", "repo", content)
+	require.NoError(t, err)
+
+	services := findingsOfType(findings, "Serverless Service")
+	require.Len(t, services, 1)
+	service := services["orders"].Properties
+	assert.Equal(t, "aws", service["provider"])
+	assert.Equal(t, "3", service["framework_version"])
+	assert.Equal(t, "eu-west-2", service["region"])
+	assert.Equal(t, "${opt:stage, 'dev'}", service["stage"])
+
+	plugins := findingsOfType(findings, "Serverless Plugin")
+	require.Len(t, plugins, 2)
+	assert.Equal(t, false, plugins["serverless-offline"].Properties["local"])
+	assert.Equal(t, true, plugins["./plugins/tagging"].Properties["local"])
+
+	functions := findingsOfType(findings, "Lambda Function")
+	require.Len(t, functions, 3)
+	create := functions["create"].Properties
+	assert.Equal(t, "nodejs18.x", create["runtime"])
+	assert.Equal(t, "arm64", create["architecture"])
+	assert.EqualValues(t, 512, create["memory_size"])
+	assert.EqualValues(t, 20, create["timeout"])
+	assert.Equal(t, "src/create.handler", create["handler"])
+	assert.Equal(t, []string{"SharedLambdaLayer"}, create["layers"])
+	assert.Equal(t, []string{"api", "http_api", "sqs", "s3", "schedule", "dynamodb"}, create["events"])
+	assert.Equal(t, "orders", create["service"])
+	assert.Equal(t, "Serverless", create["framework"])
+
+	report := functions["report"].Properties
+	assert.Equal(t, "python3.12", report["runtime"])
+	assert.Equal(t, "x86_64", report["architecture"])
+
+	render := functions["render"].Properties
+	assert.Equal(t, "Image", render["package_type"])
+	assert.Nil(t, render["runtime"])
+	assert.Equal(t, "123456789012.dkr.ecr.eu-west-2.amazonaws.com/render:latest", render["code"])
+
+	events := make(map[string]core.Finding)
+	for _, finding := range findingsByType(findings, "Lambda Event Source") {
+		events[finding.Name] = finding
+	}
+	require.Len(t, events, 7)
+	assert.Equal(t, "orders", events["api"].Properties["path"])
+	assert.Equal(t, "post", events["api"].Properties["method"])
+	assert.Equal(t, "GET", events["http_api"].Properties["method"])
+	assert.Equal(t, "/orders/{id}", events["http_api"].Properties["path"])
+	assert.Equal(t, "OrdersQueue.Arn", events["sqs"].Properties["queue"])
+	assert.Equal(t, "uploads", events["s3"].Properties["bucket"])
+	assert.Equal(t, "rate(5 minutes)", events["schedule"].Properties["schedule"])
+	assert.Equal(t, "OrdersTable.StreamArn", events["dynamodb"].Properties["stream"])
+	assert.Equal(t, "report", events["kinesis"].Properties["function"])
+	assert.Equal(t, "stream", events["kinesis"].Properties["trigger"])
+
+	layers := findingsOfType(findings, "Lambda Layer")
+	assert.Equal(t, true, layers["shared"].Properties["declared"])
+	assert.Equal(t, "layers/shared", layers["shared"].Properties["content"])
+	assert.Equal(t, "create", layers["SharedLambdaLayer"].Properties["function"])
+
+	runtimes := make(map[string]core.Finding)
+	for _, finding := range findingsByType(findings, "Runtime") {
+		runtimes[finding.Properties["Function"].(string)] = finding
+	}
+	require.Len(t, runtimes, 2)
+	assert.Equal(t, "Node.js", runtimes["create"].Name)
+	assert.Equal(t, "18", runtimes["create"].Properties["Version"])
+	assert.Equal(t, true, runtimes["create"].Properties["Deprecated"])
+	assert.Equal(t, "orders", runtimes["create"].Properties["Service"])
+	assert.Equal(t, "Python", runtimes["report"].Name)
+	assert.Equal(t, false, runtimes["report"].Properties["Deprecated"])
+}
+
+func TestServerlessProcessor_OtherProviders(t *testing.T) {
+	content := `service:
+  name: thumbnails
+provider:
+  name: google
+  runtime: nodejs18
+plugins:
+  modules:
+    - serverless-google-cloudfunctions
+functions:
+  resize:
+    handler: resize
+`
+	findings, err := NewServerlessProcessor(lambdaFS).Process("serverless.yml", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "thumbnails", findings[0].Name)
+	assert.Equal(t, "google", findings[0].Properties["provider"])
+	assert.Equal(t, "serverless-google-cloudfunctions", findings[1].Name)
+}
+
+func TestServerlessProcessor_VariableRuntime(t *testing.T) {
+	content := `service: api
+provider:
+  name: aws
+  runtime: ${self:custom.runtime}
+functions:
+  hello:
+    handler: handler.hello
+`
+	findings, err := NewServerlessProcessor(lambdaFS).Process("serverless.yml", "repo", content)
+	require.NoError(t, err)
+	assert.Empty(t, findingsByType(findings, "Runtime"))
+	assert.Equal(t, "${self:custom.runtime}", findingsOfType(findings, "Lambda Function")["hello"].Properties["runtime"])
+}
+
+func TestServerlessProcessor_BillionLaughs(t *testing.T) {
+	content := "service: lol\nprovider:\n  name: aws\n" + billionLaughs("custom", 9)
+	_, err := ServerlessProcessor{}.Process("serverless.yml", "repo", content)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "aliases are expanded")
+}
@@ -0,0 +1,263 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// terraformFunctions are the Terraform built-in functions that can be
+// evaluated without touching the filesystem or the network.
+var terraformFunctions = map[string]function.Function{
+	"abs":             stdlib.AbsoluteFunc,
+	"can":             tryfunc.CanFunc,
+	"ceil":            stdlib.CeilFunc,
+	"chomp":           stdlib.ChompFunc,
+	"chunklist":       stdlib.ChunklistFunc,
+	"coalesce":        stdlib.CoalesceFunc,
+	"coalescelist":    stdlib.CoalesceListFunc,
+	"compact":         stdlib.CompactFunc,
+	"concat":          stdlib.ConcatFunc,
+	"contains":        stdlib.ContainsFunc,
+	"distinct":        stdlib.DistinctFunc,
+	"element":         stdlib.ElementFunc,
+	"flatten":         stdlib.FlattenFunc,
+	"floor":           stdlib.FloorFunc,
+	"format":          stdlib.FormatFunc,
+	"formatlist":      stdlib.FormatListFunc,
+	"indent":          stdlib.IndentFunc,
+	"join":            stdlib.JoinFunc,
+	"jsondecode":      stdlib.JSONDecodeFunc,
+	"jsonencode":      stdlib.JSONEncodeFunc,
+	"keys":            stdlib.KeysFunc,
+	"length":          stdlib.LengthFunc,
+	"log":             stdlib.LogFunc,
+	"lookup":          stdlib.LookupFunc,
+	"lower":           stdlib.LowerFunc,
+	"max":             stdlib.MaxFunc,
+	"merge":           stdlib.MergeFunc,
+	"min":             stdlib.MinFunc,
+	"parseint":        stdlib.ParseIntFunc,
+	"pow":             stdlib.PowFunc,
+	"range":           stdlib.RangeFunc,
+	"regex":           stdlib.RegexFunc,
+	"regexall":        stdlib.RegexAllFunc,
+	"replace":         stdlib.ReplaceFunc,
+	"reverse":         stdlib.ReverseListFunc,
+	"setintersection": stdlib.SetIntersectionFunc,
+	"setproduct":      stdlib.SetProductFunc,
+	"setsubtract":     stdlib.SetSubtractFunc,
+	"setunion":        stdlib.SetUnionFunc,
+	"signum":          stdlib.SignumFunc,
+	"slice":           stdlib.SliceFunc,
+	"sort":            stdlib.SortFunc,
+	"split":           stdlib.SplitFunc,
+	"strrev":          stdlib.ReverseFunc,
+	"substr":          stdlib.SubstrFunc,
+	"title":           stdlib.TitleFunc,
+	"tobool":          stdlib.MakeToFunc(cty.Bool),
+	"tolist":          stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+	"tomap":           stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+	"tonumber":        stdlib.MakeToFunc(cty.Number),
+	"toset":           stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+	"tostring":        stdlib.MakeToFunc(cty.String),
+	"trim":            stdlib.TrimFunc,
+	"trimprefix":      stdlib.TrimPrefixFunc,
+	"trimspace":       stdlib.TrimSpaceFunc,
+	"trimsuffix":      stdlib.TrimSuffixFunc,
+	"try":             tryfunc.TryFunc,
+	"upper":           stdlib.UpperFunc,
+	"values":          stdlib.ValuesFunc,
+	"zipmap":          stdlib.ZipmapFunc,
+}
+
+// terraformVarFiles returns the variable definition files of a module
+// directory that Terraform loads without -var-file, in the order their
+// values apply: terraform.tfvars, terraform.tfvars.json, then the
+// *.auto.tfvars and *.auto.tfvars.json files in name order.
+func terraformVarFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var defaults, auto []string
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case entry.IsDir():
+		case name == "terraform.tfvars" || name == "terraform.tfvars.json":
+			defaults = append(defaults, name)
+		case terraformAutoVarFile(name):
+			auto = append(auto, name)
+		}
+	}
+	sort.Strings(defaults)
+	sort.Strings(auto)
+	var files []string
+	for _, name := range append(defaults, auto...) {
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files
+}
+
+// terraformAutoVarFile reports whether Terraform loads the variable
+// definition file name without being asked to.
+func terraformAutoVarFile(name string) bool {
+	return name == "terraform.tfvars" || name == "terraform.tfvars.json" ||
+		strings.HasSuffix(name, ".auto.tfvars") || strings.HasSuffix(name, ".auto.tfvars.json")
+}
+
+// parseTerraformVarFile returns the values a variable definition file sets,
+// in HCL or, for .tfvars.json files, JSON.
+func parseTerraformVarFile(src []byte, filename string) (map[string]cty.Value, bool) {
+	var body hcl.Body
+	if strings.HasSuffix(filename, ".json") {
+		file, diags := hcljson.Parse(src, filename)
+		if diags.HasErrors() {
+			return nil, false
+		}
+		body = file.Body
+	} else {
+		file := parseTerraformBody(src, filename)
+		if file == nil {
+			return nil, false
+		}
+		body = file
+	}
+	attrs, diags := body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, false
+	}
+	values := make(map[string]cty.Value)
+	for name, attr := range attrs {
+		if value, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+			values[name] = value
+		}
+	}
+	return values, true
+}
+
+// terraformModuleContext builds the evaluation context of the module in dir:
+// variable defaults overridden by inputs, which are passed the way Terragrunt
+// passes them, as TF_VAR_ environment variables, and then by the tfvars
+// files Terraform loads by default, followed by the locals that can be
+// computed from them. content is used in place of path's contents on disk. Values that cannot be
+// worked out, such as variables without a default, are left unknown so
+// expressions using them are reported as unresolved rather than guessed.
+func terraformModuleContext(dir string, path string, content string, inputs map[string]cty.Value) *hcl.EvalContext {
+	path = filepath.Clean(path)
+	bodies := make([]*hclsyntax.Body, 0)
+	files, _ := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if !slices.Contains(files, path) {
+		files = append(files, path)
+	}
+	for _, file := range files {
+		src := []byte(content)
+		if file != path {
+			var err error
+			if src, err = os.ReadFile(file); err != nil {
+				continue
+			}
+		}
+		if body := parseTerraformBody(src, file); body != nil {
+			bodies = append(bodies, body)
+		}
+	}
+
+	variables := make(map[string]cty.Value)
+	locals := make(map[string]hcl.Expression)
+	for _, body := range bodies {
+		for _, block := range body.Blocks {
+			switch {
+			case block.Type == "variable" && len(block.Labels) > 0:
+				variables[block.Labels[0]] = cty.DynamicVal
+				if attr, ok := block.Body.Attributes["default"]; ok {
+					if value, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+						variables[block.Labels[0]] = value
+					}
+				}
+			case block.Type == "locals":
+				for name, attr := range block.Body.Attributes {
+					locals[name] = attr.Expr
+				}
+			}
+		}
+	}
+
+	for name, value := range inputs {
+		variables[name] = value
+	}
+	for _, file := range terraformVarFiles(dir) {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		values, _ := parseTerraformVarFile(src, file)
+		for name, value := range values {
+			variables[name] = value
+		}
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(variables),
+			"path": cty.ObjectVal(map[string]cty.Value{
+				"module": cty.StringVal("."),
+				"root":   cty.StringVal("."),
+				"cwd":    cty.StringVal("."),
+			}),
+			"terraform": cty.ObjectVal(map[string]cty.Value{
+				"workspace": cty.StringVal("default"),
+			}),
+		},
+		Functions: terraformFunctions,
+	}
+	evaluateTerraformLocals(ctx, locals)
+	return ctx
+}
+
+// evaluateTerraformLocals sets ctx's "local" variable to the locals that can
+// be evaluated in ctx, leaving the rest unknown. Locals can refer to each
+// other in any order, so they are evaluated until a pass resolves nothing new.
+func evaluateTerraformLocals(ctx *hcl.EvalContext, locals map[string]hcl.Expression) {
+	values := make(map[string]cty.Value)
+	for name := range locals {
+		values[name] = cty.DynamicVal
+	}
+	ctx.Variables["local"] = cty.ObjectVal(values)
+	for progress := true; progress; {
+		progress = false
+		for name, expr := range locals {
+			if values[name].IsWhollyKnown() {
+				continue
+			}
+			value, diags := expr.Value(ctx)
+			if diags.HasErrors() || !value.IsWhollyKnown() {
+				continue
+			}
+			values[name] = value
+			ctx.Variables["local"] = cty.ObjectVal(values)
+			progress = true
+		}
+	}
+}
+
+// parseTerraformBody parses an HCL file, returning nil if it is invalid.
+func parseTerraformBody(src []byte, filename string) *hclsyntax.Body {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	body, _ := file.Body.(*hclsyntax.Body)
+	return body
+}
@@ -0,0 +1,169 @@
+package processors
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestTerraformProcessor_EvaluatesVariablesAndLocals(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "variables.tf"), `
+variable "environment" {
+  default = "dev"
+}
+
+variable "instance_type" {
+  default = "t3.micro"
+}
+
+variable "region" {}
+
+variable "engine_version" {
+  default = "14"
+}
+`)
+	writeTestFile(t, filepath.Join(dir, "locals.tf"), `
+locals {
+  bucket_name = "${local.prefix}-assets"
+  prefix      = "acme-${var.environment}"
+  tags = merge({ team = "platform" }, { env = upper(var.environment) })
+}
+`)
+	writeTestFile(t, filepath.Join(dir, "terraform.tfvars"), `
+environment = "staging"
+region      = "eu-west-2"
+`)
+	writeTestFile(t, filepath.Join(dir, "prod.auto.tfvars"), `
+instance_type = "m5.large"
+`)
+	main := `
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id
+  instance_type = var.instance_type
+  tags          = local.tags
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket = local.bucket_name
+}
+
+resource "aws_db_instance" "db" {
+  engine_version    = "${var.engine_version}.3"
+  availability_zone = "${var.region}a"
+}
+
+module "network" {
+  source = "./modules/network"
+  region = var.region
+  cidr   = cidrsubnet("10.0.0.0/8", 8, 1)
+}
+`
+	path := filepath.Join(dir, "main.tf")
+	writeTestFile(t, path, main)
+
+	processor := NewTerraformProcessor()
+	findings, err := processor.Process(path, "repo", main)
+	require.NoError(t, err)
+
+	resources := make(map[string]core.Finding)
+	var modules []core.Finding
+	for _, finding := range findings {
+		switch finding.Type {
+		case "Terraform Resource Use":
+			resources[finding.Properties["resource_type"].(string)] = finding
+		case "TF Module Use":
+			modules = append(modules, finding)
+		}
+	}
+
+	instance := resources["aws_instance"].Properties["attributes"].(map[string]interface{})
+	assert.Equal(t, "m5.large", instance["instance_type"])
+	assert.Equal(t, map[string]interface{}{"team": "platform", "env": "STAGING"}, instance["tags"])
+	assert.Equal(t, "data.aws_ami.ubuntu.id", instance["ami"])
+	assert.Equal(t, map[string]string{"ami": "data.aws_ami.ubuntu.id"}, resources["aws_instance"].Properties["unresolved"])
+
+	assert.Equal(t, "acme-staging-assets", resources["aws_s3_bucket"].Properties["attributes"].(map[string]interface{})["bucket"])
+	assert.Nil(t, resources["aws_s3_bucket"].Properties["unresolved"])
+
+	db := resources["aws_db_instance"].Properties["attributes"].(map[string]interface{})
+	assert.Equal(t, "14.3", db["engine_version"])
+	assert.Equal(t, "eu-west-2a", db["availability_zone"])
+
+	require.Len(t, modules, 1)
+	inputs := modules[0].Properties["inputs"].(map[string]interface{})
+	assert.Equal(t, "eu-west-2", inputs["region"])
+	// cidrsubnet is not one of the functions evaluated.
+	assert.Equal(t, map[string]string{"cidr": `cidrsubnet("10.0.0.0/8", 8, 1)`}, modules[0].Properties["unresolved"])
+}
+
+func TestTerraformProcessor_VariableWithoutValueIsUnresolved(t *testing.T) {
+	dir := t.TempDir()
+	main := `
+variable "region" {}
+
+resource "google_storage_bucket" "assets" {
+  location = var.region
+}
+`
+	path := filepath.Join(dir, "main.tf")
+	writeTestFile(t, path, main)
+
+	findings, err := NewTerraformProcessor().Process(path, "repo", main)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "var.region", findings[0].Properties["attributes"].(map[string]interface{})["location"])
+	assert.Equal(t, map[string]string{"location": "var.region"}, findings[0].Properties["unresolved"])
+}
+
+func TestTerraformProcessor_OnlyDefaultVarFilesApply(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "dev.tfvars"), `environment = "dev"`)
+	writeTestFile(t, filepath.Join(dir, "prod.tfvars"), `environment = "prod"`)
+	writeTestFile(t, filepath.Join(dir, "staging.tfvars"), `environment = "staging"`)
+	writeTestFile(t, filepath.Join(dir, "terraform.tfvars.json"), `{"region": "eu-west-1", "size": "small"}`)
+	writeTestFile(t, filepath.Join(dir, "z.auto.tfvars.json"), `{"size": "large"}`)
+	main := `
+variable "environment" {}
+variable "region" {}
+variable "size" {}
+
+resource "google_storage_bucket" "assets" {
+  name     = "assets-${var.environment}"
+  location = var.region
+  class    = var.size
+}
+`
+	path := filepath.Join(dir, "main.tf")
+	writeTestFile(t, path, main)
+
+	processor := NewTerraformProcessor()
+	findings, err := processor.Process(path, "repo", main)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	attributes := findings[0].Properties["attributes"].(map[string]interface{})
+	assert.Equal(t, "eu-west-1", attributes["location"])
+	assert.Equal(t, "large", attributes["class"])
+	assert.Equal(t, map[string]string{"name": `"assets-${var.environment}"`}, findings[0].Properties["unresolved"])
+
+	assert.True(t, processor.Supports(filepath.Join(dir, "prod.tfvars")))
+	assert.True(t, processor.Supports(filepath.Join(dir, "terraform.tfvars.json")))
+	findings, err = processor.Process(filepath.Join(dir, "prod.tfvars"), "repo", `environment = "prod"
+replicas    = 3
+`)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "TF Var File", findings[0].Type)
+	assert.Equal(t, "prod.tfvars", findings[0].Name)
+	assert.Equal(t, []string{"environment", "replicas"}, findings[0].Properties["variables"])
+	assert.Equal(t, false, findings[0].Properties["auto_loaded"])
+
+	findings, err = processor.Process(filepath.Join(dir, "z.auto.tfvars.json"), "repo", `{"size": "large"}`)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, true, findings[0].Properties["auto_loaded"])
+}
@@ -2,11 +2,15 @@ package processors
 
 import (
 	"fmt"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/reaandrew/techdetector/core"
+	log "github.com/sirupsen/logrus"
 	"github.com/zclconf/go-cty/cty"
+	"io/fs"
 	"math/big"
+	"path/filepath"
 	"strings"
 )
 
@@ -15,83 +19,74 @@ type TerraformBlock struct {
 	Labels     []string
 	Attributes map[string]interface{}
 	Blocks     []*TerraformBlock
+	// Unresolved holds the source of attributes whose value could not be
+	// evaluated, e.g. references to data sources or to variables without a
+	// value. Their Attributes entry is the same expression text.
+	Unresolved map[string]string
 }
 
 type TerraformBlockProcessor interface {
 	Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error)
 }
 
+// terraformModuleMetaArguments are the arguments of a module block that
+// configure the call rather than set one of the module's input variables.
+var terraformModuleMetaArguments = map[string]bool{
+	"source":     true,
+	"version":    true,
+	"providers":  true,
+	"count":      true,
+	"for_each":   true,
+	"depends_on": true,
+}
+
 type ModuleBlockProcessor struct{}
 
 func (m ModuleBlockProcessor) Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
 	matches := make([]core.Finding, 0)
 	if block.Type == "module" && len(block.Attributes) > 0 {
-		matches = append(matches, core.Finding{
-			Name:     "TF Module",
-			Type:     "TF Module Use",
-			Category: "",
-			Properties: map[string]interface{}{
-				"source": block.Attributes["source"],
-			},
-			RepoName: repoName,
-			Path:     path,
-		})
-	}
-
-	return matches, nil
-}
-
-// -- New AWS Resource Block Processor --
-type AWSResourceBlockProcessor struct{}
-
-func (a AWSResourceBlockProcessor) Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
-	matches := make([]core.Finding, 0)
-
-	// Check if block is a "resource" and if the first label has "aws_" prefix
-	if block.Type == "resource" && len(block.Labels) > 0 && strings.HasPrefix(block.Labels[0], "aws_") {
 		props := map[string]interface{}{
-			"resource_type": block.Labels[0],
-			"vendor":        "AWS",
+			"source": block.Attributes["source"],
 		}
-
-		for key, value := range block.Attributes {
-			props[key] = value
+		if len(block.Labels) > 0 {
+			props["module"] = block.Labels[0]
 		}
-
-		matches = append(matches, core.Finding{
-			Name:       "AWS Resource",
-			Type:       "Terraform Resource Use",
-			Category:   "AWS", // or whatever category you prefer
-			Properties: props,
-			RepoName:   repoName,
-			Path:       path,
-		})
-	}
-
-	return matches, nil
-}
-
-// -- New Azure Resource Block Processor --
-type AzureResourceBlockProcessor struct{}
-
-func (a AzureResourceBlockProcessor) Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
-	matches := make([]core.Finding, 0)
-
-	// Check if block is a "resource" and if the first label has "azurerm_" prefix
-	if block.Type == "resource" && len(block.Labels) > 0 && strings.HasPrefix(block.Labels[0], "azurerm_") {
-		props := map[string]interface{}{
-			"resource_type": block.Labels[0],
-			"vendor":        "Azure",
+		if source, ok := block.Attributes["source"].(string); ok {
+			parsed := ParseTerraformModuleSource(source)
+			props["source_type"] = parsed.Type
+			props["address"] = parsed.Address
+			if parsed.Ref != "" {
+				props["ref"] = parsed.Ref
+			}
+			if parsed.Subdir != "" {
+				props["subdir"] = parsed.Subdir
+			}
+			if parsed.Type == "registry" {
+				props["registry"] = parsed.Registry
+				props["namespace"] = parsed.Namespace
+				props["name"] = parsed.Name
+				props["provider"] = parsed.Provider
+			}
 		}
-
+		if version, ok := block.Attributes["version"]; ok {
+			props["version"] = version
+		}
+		inputs := make(map[string]interface{})
 		for key, value := range block.Attributes {
-			props[key] = value
+			if !terraformModuleMetaArguments[key] {
+				inputs[key] = value
+			}
+		}
+		if len(inputs) > 0 {
+			props["inputs"] = inputs
+		}
+		if len(block.Unresolved) > 0 {
+			props["unresolved"] = block.Unresolved
 		}
-
 		matches = append(matches, core.Finding{
-			Name:       "Azure Resource",
-			Type:       "Terraform Resource Use",
-			Category:   "Azure",
+			Name:       "TF Module",
+			Type:       "TF Module Use",
+			Category:   "",
 			Properties: props,
 			RepoName:   repoName,
 			Path:       path,
@@ -101,49 +96,50 @@ func (a AzureResourceBlockProcessor) Process(block *TerraformBlock, path string,
 	return matches, nil
 }
 
-// -- New GCP Resource Block Processor --
-type GCPResourceBlockProcessor struct{}
-
-func (g GCPResourceBlockProcessor) Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
-	matches := make([]core.Finding, 0)
-
-	// Check if block is a "resource" and if the first label has "google_" prefix
-	if block.Type == "resource" && len(block.Labels) > 0 && strings.HasPrefix(block.Labels[0], "google_") {
-		matches = append(matches, core.Finding{
-			Name:     "GCP Resource",
-			Type:     "Terraform Resource Use",
-			Category: "GCP",
-			Properties: map[string]interface{}{
-				"resource_type": block.Labels[0],
-				"attributes":    block.Attributes,
-				"vendor":        "GCP",
-			},
-			RepoName: repoName,
-			Path:     path,
-		})
-	}
-
-	return matches, nil
-}
-
 type TerraformProcessor struct {
 	processors []TerraformBlockProcessor
 }
 
+// NewTerraformProcessor creates a TerraformProcessor that names the vendor
+// and service of resources from the embedded provider table.
 func NewTerraformProcessor() *TerraformProcessor {
+	return NewTerraformProcessorFromFS(terraformFS)
+}
+
+// NewTerraformProcessorFromFS creates a TerraformProcessor that names the
+// vendor and service of resources from the provider tables in f.
+func NewTerraformProcessorFromFS(f fs.FS) *TerraformProcessor {
+	providers, err := LoadTerraformProviders(f)
+	if err != nil {
+		log.Printf("Failed to load Terraform providers: %v", err)
+	}
 	return &TerraformProcessor{processors: []TerraformBlockProcessor{
+		TerraformSettingsBlockProcessor{},
 		ModuleBlockProcessor{},
-		AWSResourceBlockProcessor{},
-		AzureResourceBlockProcessor{},
-		GCPResourceBlockProcessor{},
+		DataBlockProcessor{Providers: providers},
+		ResourceBlockProcessor{Providers: providers},
 	}}
 }
 
 func (t TerraformProcessor) Supports(filePath string) bool {
-	return strings.HasSuffix(filePath, ".tf")
+	// Terraform and Terragrunt keep downloaded copies of modules in these.
+	if inDirectory(filePath, ".terraform", ".terragrunt-cache") {
+		return false
+	}
+	return strings.HasSuffix(filePath, ".tf") || filepath.Base(filePath) == ".terraform.lock.hcl" ||
+		strings.HasSuffix(filePath, ".tfvars") || strings.HasSuffix(filePath, ".tfvars.json")
 }
 
 func (t TerraformProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	return t.process(path, repoName, content, nil)
+}
+
+// process reports the blocks of a Terraform file, evaluating its expressions
+// with inputs set as variables of the module.
+func (t TerraformProcessor) process(path string, repoName string, content string, inputs map[string]cty.Value) ([]core.Finding, error) {
+	if strings.HasSuffix(path, ".tfvars") || strings.HasSuffix(path, ".tfvars.json") {
+		return terraformVarFileFindings(path, repoName, content), nil
+	}
 	parser := hclparse.NewParser()
 	file, diags := parser.ParseHCL([]byte(content), path)
 	if diags.HasErrors() {
@@ -154,7 +150,11 @@ func (t TerraformProcessor) Process(path string, repoName string, content string
 	if !ok {
 		return nil, fmt.Errorf("Failed to get body")
 	}
-	tfBlocks := ParseBody(body, []byte(content))
+	if filepath.Base(path) == ".terraform.lock.hcl" {
+		return terraformLockFileFindings(ParseBody(body, []byte(content)), path, repoName), nil
+	}
+	ctx := terraformModuleContext(filepath.Dir(path), path, content, inputs)
+	tfBlocks := ParseBodyWithContext(body, []byte(content), ctx)
 
 	matches := make([]core.Finding, 0)
 	for _, tfBlock := range tfBlocks {
@@ -171,6 +171,12 @@ func (t TerraformProcessor) Process(path string, repoName string, content string
 }
 
 func ParseBody(body *hclsyntax.Body, src []byte) []*TerraformBlock {
+	return ParseBodyWithContext(body, src, nil)
+}
+
+// ParseBodyWithContext is ParseBody with variables, locals and functions
+// available to expressions through ctx.
+func ParseBodyWithContext(body *hclsyntax.Body, src []byte, ctx *hcl.EvalContext) []*TerraformBlock {
 	var blocks []*TerraformBlock
 
 	// Parse blocks
@@ -179,16 +185,18 @@ func ParseBody(body *hclsyntax.Body, src []byte) []*TerraformBlock {
 			Type:       block.Type,
 			Labels:     block.Labels,
 			Attributes: make(map[string]interface{}),
+			Unresolved: make(map[string]string),
 		}
 
 		// Parse attributes within the block
 		for name, attr := range block.Body.Attributes {
-			val, diags := attr.Expr.Value(nil)
-			if diags.HasErrors() {
-				// If unable to evaluate (due to variables, functions, etc.), store the expression as a string
+			val, diags := attr.Expr.Value(ctx)
+			if diags.HasErrors() || !val.IsWhollyKnown() {
+				// If unable to evaluate (due to data sources, variables without a value, etc.), store the expression as a string
 				rng := attr.Expr.Range()
 				exprSrc := string(src[rng.Start.Byte:rng.End.Byte])
 				tfBlock.Attributes[name] = exprSrc
+				tfBlock.Unresolved[name] = exprSrc
 				continue
 			}
 
@@ -196,7 +204,7 @@ func ParseBody(body *hclsyntax.Body, src []byte) []*TerraformBlock {
 		}
 
 		// Recursively parse nested blocks
-		tfBlock.Blocks = ParseBody(block.Body, src)
+		tfBlock.Blocks = ParseBodyWithContext(block.Body, src, ctx)
 
 		blocks = append(blocks, tfBlock)
 	}
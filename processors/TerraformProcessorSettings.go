@@ -0,0 +1,318 @@
+package processors
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// TerraformModuleSource is a module source address split into its parts.
+type TerraformModuleSource struct {
+	Type      string
+	Address   string
+	Ref       string
+	Subdir    string
+	Registry  string
+	Namespace string
+	Name      string
+	Provider  string
+}
+
+// ParseTerraformModuleSource classifies a module source the way Terraform's
+// module installer does: local paths, registry addresses, and the go-getter
+// forms (git, GitHub and Bitbucket shorthands, Mercurial, S3, GCS and HTTP).
+func ParseTerraformModuleSource(source string) TerraformModuleSource {
+	parsed := TerraformModuleSource{Address: source}
+	if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") || source == "." || source == ".." {
+		parsed.Type = "local"
+		return parsed
+	}
+
+	address := source
+	if scheme, rest, ok := strings.Cut(address, "::"); ok && !strings.Contains(scheme, "/") {
+		// A forced getter, e.g. "git::https://...".
+		parsed.Type = scheme
+		address = rest
+	}
+
+	// The query string carries the ref (git, hg) and the "//" the subdirectory.
+	if idx := strings.Index(address, "?"); idx >= 0 {
+		if query, err := url.ParseQuery(address[idx+1:]); err == nil {
+			parsed.Ref = query.Get("ref")
+		}
+		address = address[:idx]
+	}
+	schemeEnd := 0
+	if idx := strings.Index(address, "://"); idx >= 0 {
+		schemeEnd = idx + 3
+	}
+	if idx := strings.Index(address[schemeEnd:], "//"); idx >= 0 {
+		parsed.Subdir = address[schemeEnd+idx+2:]
+		address = address[:schemeEnd+idx]
+	}
+	parsed.Address = address
+
+	if parsed.Type != "" {
+		return parsed
+	}
+	switch {
+	case strings.HasPrefix(address, "git@") || strings.HasSuffix(address, ".git"):
+		parsed.Type = "git"
+	case strings.HasPrefix(address, "github.com/"):
+		parsed.Type = "github"
+	case strings.HasPrefix(address, "bitbucket.org/"):
+		parsed.Type = "bitbucket"
+	case strings.Contains(address, ".s3.amazonaws.com/") || strings.Contains(address, ".s3-"):
+		parsed.Type = "s3"
+	case strings.HasPrefix(address, "www.googleapis.com/storage/"):
+		parsed.Type = "gcs"
+	case strings.HasPrefix(address, "http://") || strings.HasPrefix(address, "https://"):
+		parsed.Type = "http"
+	default:
+		parts := strings.Split(address, "/")
+		if len(parts) == 4 {
+			parsed.Registry, parts = parts[0], parts[1:]
+		} else {
+			parsed.Registry = "registry.terraform.io"
+		}
+		if len(parts) == 3 {
+			parsed.Type = "registry"
+			parsed.Namespace, parsed.Name, parsed.Provider = parts[0], parts[1], parts[2]
+		} else {
+			parsed.Type = "unknown"
+			parsed.Registry = ""
+		}
+	}
+	return parsed
+}
+
+// terraformProviderSource splits a provider source address such as
+// "hashicorp/aws" or "registry.terraform.io/hashicorp/aws" into its host,
+// namespace and type. Sources without a namespace default to hashicorp.
+func terraformProviderSource(source string) (host string, namespace string, providerType string) {
+	parts := strings.Split(source, "/")
+	switch len(parts) {
+	case 1:
+		return "registry.terraform.io", "hashicorp", parts[0]
+	case 2:
+		return "registry.terraform.io", parts[0], parts[1]
+	default:
+		return parts[0], parts[1], parts[2]
+	}
+}
+
+// terraformObjectAttributes returns the literal string attributes of an
+// object expression that ParseBody could not evaluate as a whole, e.g. a
+// required_providers entry whose configuration_aliases reference providers.
+func terraformObjectAttributes(raw string) map[string]string {
+	attributes := make(map[string]string)
+	expr, diags := hclsyntax.ParseExpression([]byte(raw), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return attributes
+	}
+	object, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return attributes
+	}
+	for _, item := range object.Items {
+		key := hcl.ExprAsKeyword(item.KeyExpr)
+		if key == "" {
+			continue
+		}
+		if value, diags := item.ValueExpr.Value(nil); !diags.HasErrors() && value.Type() == cty.String && value.IsKnown() && !value.IsNull() {
+			attributes[key] = value.AsString()
+		}
+	}
+	return attributes
+}
+
+// TerraformSettingsBlockProcessor reports the terraform block: the required
+// Terraform version, the required providers and the state backend.
+type TerraformSettingsBlockProcessor struct{}
+
+func (s TerraformSettingsBlockProcessor) Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
+	matches := make([]core.Finding, 0)
+	if block.Type != "terraform" {
+		return matches, nil
+	}
+
+	if version, ok := block.Attributes["required_version"].(string); ok {
+		matches = append(matches, core.Finding{
+			Name:     "Terraform",
+			Type:     "TF Required Version",
+			Category: "Terraform",
+			Properties: map[string]interface{}{
+				"required_version": version,
+			},
+			RepoName: repoName,
+			Path:     path,
+		})
+	}
+
+	for _, nested := range block.Blocks {
+		switch nested.Type {
+		case "required_providers":
+			for _, localName := range sortedKeys(nested.Attributes) {
+				matches = append(matches, terraformProviderFinding(localName, nested.Attributes[localName], path, repoName))
+			}
+		case "backend", "cloud":
+			props := map[string]interface{}{
+				"backend": nested.Type,
+			}
+			if len(nested.Labels) > 0 {
+				props["backend"] = nested.Labels[0]
+			}
+			for key, value := range nested.Attributes {
+				props[key] = value
+			}
+			for _, workspaces := range nested.Blocks {
+				if workspaces.Type == "workspaces" {
+					for key, value := range workspaces.Attributes {
+						props["workspaces_"+key] = value
+					}
+				}
+			}
+			matches = append(matches, core.Finding{
+				Name:       fmt.Sprint(props["backend"]),
+				Type:       "TF Backend",
+				Category:   "Terraform",
+				Properties: props,
+				RepoName:   repoName,
+				Path:       path,
+			})
+		}
+	}
+	return matches, nil
+}
+
+func terraformProviderFinding(localName string, value interface{}, path string, repoName string) core.Finding {
+	source, version := localName, ""
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if s, ok := v["source"].(string); ok {
+			source = s
+		}
+		if s, ok := v["version"].(string); ok {
+			version = s
+		}
+	case string:
+		if strings.HasPrefix(strings.TrimSpace(v), "{") {
+			attributes := terraformObjectAttributes(v)
+			if attributes["source"] != "" {
+				source = attributes["source"]
+			}
+			version = attributes["version"]
+		} else {
+			// The pre-0.13 form: aws = "~> 3.0".
+			version = v
+		}
+	}
+
+	host, namespace, providerType := terraformProviderSource(source)
+	props := map[string]interface{}{
+		"local_name": localName,
+		"source":     namespace + "/" + providerType,
+		"registry":   host,
+		"namespace":  namespace,
+		"provider":   providerType,
+	}
+	if version != "" {
+		props["version"] = version
+	}
+	return core.Finding{
+		Name:       namespace + "/" + providerType,
+		Type:       "TF Provider Use",
+		Category:   "Terraform",
+		Properties: props,
+		RepoName:   repoName,
+		Path:       path,
+	}
+}
+
+// DataBlockProcessor reports data sources, naming their vendor and service
+// from the provider table.
+type DataBlockProcessor struct {
+	Providers []TerraformProvider
+}
+
+func (d DataBlockProcessor) Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
+	matches := make([]core.Finding, 0)
+	if block.Type == "data" && len(block.Labels) > 1 {
+		props, _, _ := terraformTypeProperties(d.Providers, block.Labels[0])
+		props["data_type"] = block.Labels[0]
+		props["name"] = block.Labels[1]
+		props["provider"] = props["provider_type"]
+		matches = append(matches, core.Finding{
+			Name:       block.Labels[0],
+			Type:       "TF Data Source Use",
+			Category:   "Terraform",
+			Properties: props,
+			RepoName:   repoName,
+			Path:       path,
+		})
+	}
+	return matches, nil
+}
+
+// terraformLockFileFindings reports the provider versions selected in a
+// .terraform.lock.hcl.
+func terraformLockFileFindings(blocks []*TerraformBlock, path string, repoName string) []core.Finding {
+	var matches []core.Finding
+	for _, block := range blocks {
+		if block.Type != "provider" || len(block.Labels) == 0 {
+			continue
+		}
+		host, namespace, providerType := terraformProviderSource(block.Labels[0])
+		props := map[string]interface{}{
+			"source":    namespace + "/" + providerType,
+			"registry":  host,
+			"namespace": namespace,
+			"provider":  providerType,
+			"version":   block.Attributes["version"],
+		}
+		if constraints, ok := block.Attributes["constraints"]; ok {
+			props["constraints"] = constraints
+		}
+		if hashes, ok := block.Attributes["hashes"].([]interface{}); ok {
+			props["hashes"] = len(hashes)
+		}
+		matches = append(matches, core.Finding{
+			Name:       namespace + "/" + providerType,
+			Type:       "TF Provider Lock",
+			Category:   "Terraform",
+			Properties: props,
+			RepoName:   repoName,
+			Path:       path,
+		})
+	}
+	return matches
+}
+
+// terraformVarFileFindings reports a variable definition file with the
+// variables it sets and whether Terraform loads it by default. Files such as
+// prod.tfvars are only used with -var-file, so their values are not applied
+// when evaluating the module.
+func terraformVarFileFindings(path string, repoName string, content string) []core.Finding {
+	values, ok := parseTerraformVarFile([]byte(content), path)
+	if !ok {
+		return nil
+	}
+	return []core.Finding{{
+		Name:     filepath.Base(path),
+		Type:     "TF Var File",
+		Category: "Terraform",
+		Properties: map[string]interface{}{
+			"variables":   sortedKeys(values),
+			"auto_loaded": terraformAutoVarFile(filepath.Base(path)),
+		},
+		RepoName: repoName,
+		Path:     path,
+	}}
+}
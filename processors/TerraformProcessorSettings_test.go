@@ -0,0 +1,130 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestParseTerraformModuleSource(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected TerraformModuleSource
+	}{
+		{"./modules/vpc", TerraformModuleSource{Type: "local", Address: "./modules/vpc"}},
+		{"terraform-aws-modules/vpc/aws", TerraformModuleSource{Type: "registry", Address: "terraform-aws-modules/vpc/aws", Registry: "registry.terraform.io", Namespace: "terraform-aws-modules", Name: "vpc", Provider: "aws"}},
+		{"app.terraform.io/acme/network/azurerm//modules/subnet", TerraformModuleSource{Type: "registry", Address: "app.terraform.io/acme/network/azurerm", Subdir: "modules/subnet", Registry: "app.terraform.io", Namespace: "acme", Name: "network", Provider: "azurerm"}},
+		{"git::https://example.com/infra.git//modules/db?ref=v1.2.0", TerraformModuleSource{Type: "git", Address: "https://example.com/infra.git", Subdir: "modules/db", Ref: "v1.2.0"}},
+		{"git@github.com:org/modules.git?ref=main", TerraformModuleSource{Type: "git", Address: "git@github.com:org/modules.git", Ref: "main"}},
+		{"github.com/org/terraform-modules//iam?ref=3f2a1b", TerraformModuleSource{Type: "github", Address: "github.com/org/terraform-modules", Subdir: "iam", Ref: "3f2a1b"}},
+		{"s3::https://s3-eu-west-1.amazonaws.com/bucket/vpc.zip", TerraformModuleSource{Type: "s3", Address: "https://s3-eu-west-1.amazonaws.com/bucket/vpc.zip"}},
+		{"https://example.com/vpc-module.zip", TerraformModuleSource{Type: "http", Address: "https://example.com/vpc-module.zip"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseTerraformModuleSource(tt.source))
+		})
+	}
+}
+
+func TestTerraformProcessor_Settings(t *testing.T) {
+	content := `
+terraform {
+  required_version = ">= 1.5.0"
+
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version               = "~> 5.0"
+      configuration_aliases = [aws.east]
+    }
+    datadog = {
+      source  = "DataDog/datadog"
+      version = "3.30.0"
+    }
+    random = "~> 3.0"
+  }
+
+  backend "s3" {
+    bucket = "state-bucket"
+    key    = "network/terraform.tfstate"
+    region = "eu-west-2"
+  }
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.2"
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+`
+
+	processor := NewTerraformProcessor()
+	findings, err := processor.Process("main.tf", "repo", content)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		byType[finding.Type] = append(byType[finding.Type], finding)
+	}
+
+	require.Len(t, byType["TF Required Version"], 1)
+	assert.Equal(t, ">= 1.5.0", byType["TF Required Version"][0].Properties["required_version"])
+
+	providers := findingsByName(byType["TF Provider Use"])
+	require.Len(t, providers, 3)
+	assert.Equal(t, "~> 5.0", providers["hashicorp/aws"].Properties["version"])
+	assert.Equal(t, "aws", providers["hashicorp/aws"].Properties["local_name"])
+	assert.Equal(t, "3.30.0", providers["DataDog/datadog"].Properties["version"])
+	assert.Equal(t, "~> 3.0", providers["hashicorp/random"].Properties["version"])
+
+	backends := byType["TF Backend"]
+	require.Len(t, backends, 1)
+	assert.Equal(t, "s3", backends[0].Name)
+	assert.Equal(t, "state-bucket", backends[0].Properties["bucket"])
+
+	modules := byType["TF Module Use"]
+	require.Len(t, modules, 1)
+	assert.Equal(t, "vpc", modules[0].Properties["module"])
+	assert.Equal(t, "registry", modules[0].Properties["source_type"])
+	assert.Equal(t, "5.1.2", modules[0].Properties["version"])
+	assert.Equal(t, "terraform-aws-modules", modules[0].Properties["namespace"])
+
+	data := byType["TF Data Source Use"]
+	require.Len(t, data, 1)
+	assert.Equal(t, "aws_ami", data[0].Name)
+	assert.Equal(t, "ubuntu", data[0].Properties["name"])
+	assert.Equal(t, "aws", data[0].Properties["provider"])
+}
+
+func TestTerraformProcessor_LockFile(t *testing.T) {
+	content := `
+provider "registry.terraform.io/hashicorp/aws" {
+  version     = "5.31.0"
+  constraints = "~> 5.0"
+  hashes = [
+    "h1:abc=",
+    "zh:def",
+  ]
+}
+`
+
+	processor := NewTerraformProcessor()
+	assert.True(t, processor.Supports("infra/.terraform.lock.hcl"))
+
+	findings, err := processor.Process("infra/.terraform.lock.hcl", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "TF Provider Lock", findings[0].Type)
+	assert.Equal(t, "hashicorp/aws", findings[0].Name)
+	assert.Equal(t, "5.31.0", findings[0].Properties["version"])
+	assert.Equal(t, "~> 5.0", findings[0].Properties["constraints"])
+	assert.Equal(t, 2, findings[0].Properties["hashes"])
+}
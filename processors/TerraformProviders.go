@@ -0,0 +1,217 @@
+package processors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// TerraformService is the normalised name and category of the resources whose
+// type starts with a service prefix, e.g. "s3" for aws_s3_bucket.
+type TerraformService struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// TerraformProvider is an entry of data/terraform/*.json: the resource type
+// prefix of a provider, who makes it, and the services its resources belong
+// to. Service and ServiceCategory apply to resources no service matches.
+type TerraformProvider struct {
+	Prefix          string                      `json:"prefix"`
+	Source          string                      `json:"source"`
+	Vendor          string                      `json:"vendor"`
+	Category        string                      `json:"category"`
+	Service         string                      `json:"service,omitempty"`
+	ServiceCategory string                      `json:"service_category,omitempty"`
+	Services        map[string]TerraformService `json:"services,omitempty"`
+}
+
+// LoadTerraformProviders reads every table in data/terraform of f in name
+// order. An entry whose prefix was already loaded overrides the earlier one,
+// keeping any services it does not redefine, so a later file can extend or
+// correct the embedded table.
+func LoadTerraformProviders(f fs.FS) ([]TerraformProvider, error) {
+	files, err := fs.Glob(f, "data/terraform/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Terraform provider tables: %w", err)
+	}
+
+	var providers []TerraformProvider
+	index := make(map[string]int)
+	for _, file := range files {
+		content, err := fs.ReadFile(f, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Terraform providers '%s': %w", path.Base(file), err)
+		}
+		var entries []TerraformProvider
+		if err := json.Unmarshal(content, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse Terraform providers '%s': %w", path.Base(file), err)
+		}
+		for _, entry := range entries {
+			i, ok := index[entry.Prefix]
+			if !ok {
+				index[entry.Prefix] = len(providers)
+				providers = append(providers, entry)
+				continue
+			}
+			services := providers[i].Services
+			if services == nil {
+				services = make(map[string]TerraformService)
+			}
+			for prefix, service := range entry.Services {
+				services[prefix] = service
+			}
+			entry.Services = services
+			providers[i] = entry
+		}
+	}
+	return providers, nil
+}
+
+// terraformPrefixMatches reports whether a resource type belongs to prefix,
+// which must be followed by an underscore or end the type.
+func terraformPrefixMatches(resourceType string, prefix string) bool {
+	return resourceType == prefix || strings.HasPrefix(resourceType, prefix+"_")
+}
+
+// lookupTerraformProvider returns the provider with the longest prefix
+// matching resourceType and the service the resource belongs to.
+func lookupTerraformProvider(providers []TerraformProvider, resourceType string) (TerraformProvider, TerraformService, bool) {
+	var provider TerraformProvider
+	found := false
+	for _, candidate := range providers {
+		if terraformPrefixMatches(resourceType, candidate.Prefix) && len(candidate.Prefix) > len(provider.Prefix) {
+			provider, found = candidate, true
+		}
+	}
+	if !found {
+		return provider, TerraformService{}, false
+	}
+
+	service := TerraformService{Name: provider.Service, Category: provider.ServiceCategory}
+	rest := strings.TrimPrefix(strings.TrimPrefix(resourceType, provider.Prefix), "_")
+	longest := ""
+	for prefix, candidate := range provider.Services {
+		if terraformPrefixMatches(rest, prefix) && len(prefix) > len(longest) {
+			longest, service = prefix, candidate
+		}
+	}
+	if service.Name == "" {
+		service.Name = provider.Vendor
+	}
+	return provider, service, true
+}
+
+// terraformTypeProperties describes a resource or data source type: the
+// provider it comes from and, when the type is in the provider table, its
+// vendor and service.
+func terraformTypeProperties(providers []TerraformProvider, resourceType string) (map[string]interface{}, TerraformProvider, bool) {
+	provider, service, ok := lookupTerraformProvider(providers, resourceType)
+	if !ok {
+		// Unknown providers are still reported under the prefix Terraform
+		// itself uses to find the provider of a type.
+		prefix, _, _ := strings.Cut(resourceType, "_")
+		return map[string]interface{}{"provider_type": prefix}, provider, false
+	}
+	props := map[string]interface{}{
+		"provider_type":   provider.Prefix,
+		"provider_source": provider.Source,
+		"vendor":          provider.Vendor,
+		"service":         service.Name,
+	}
+	if service.Category != "" {
+		props["service_category"] = service.Category
+	}
+	return props, provider, true
+}
+
+// ResourceBlockProcessor reports resources of every provider, naming their
+// vendor and service from the provider table.
+type ResourceBlockProcessor struct {
+	Providers []TerraformProvider
+}
+
+func (r ResourceBlockProcessor) Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
+	matches := make([]core.Finding, 0)
+	if block.Type != "resource" || len(block.Labels) == 0 {
+		return matches, nil
+	}
+
+	resourceType := block.Labels[0]
+	typeProps, provider, known := terraformTypeProperties(r.Providers, resourceType)
+	// The resource's own arguments are kept apart from the type properties,
+	// which would otherwise replace arguments such as "service" or "provider".
+	props := typeProps
+	props["resource_type"] = resourceType
+	props["attributes"] = block.Attributes
+	if len(block.Unresolved) > 0 {
+		props["unresolved"] = block.Unresolved
+	}
+
+	name, category := "Terraform Resource", "Terraform"
+	if known {
+		name, category = provider.Vendor+" Resource", provider.Category
+	}
+	matches = append(matches, core.Finding{
+		Name:       name,
+		Type:       "Terraform Resource Use",
+		Category:   category,
+		Properties: props,
+		RepoName:   repoName,
+		Path:       path,
+	})
+	return matches, nil
+}
+
+// embeddedTerraformProviders is the embedded provider table, loaded the first
+// time it is needed.
+var embeddedTerraformProviders = sync.OnceValue(func() []TerraformProvider {
+	providers, err := LoadTerraformProviders(terraformFS)
+	if err != nil {
+		log.Printf("Failed to load Terraform providers: %v", err)
+	}
+	return providers
+})
+
+// resourcesWithPrefix reports the resources of one provider as
+// ResourceBlockProcessor does with the embedded provider table.
+func resourcesWithPrefix(prefix string, block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
+	if block.Type != "resource" || len(block.Labels) == 0 || !strings.HasPrefix(block.Labels[0], prefix) {
+		return make([]core.Finding, 0), nil
+	}
+	return ResourceBlockProcessor{Providers: embeddedTerraformProviders()}.Process(block, path, repoName)
+}
+
+// AWSResourceBlockProcessor reports aws_ resources.
+//
+// Deprecated: use ResourceBlockProcessor, which reports every provider.
+type AWSResourceBlockProcessor struct{}
+
+func (a AWSResourceBlockProcessor) Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
+	return resourcesWithPrefix("aws_", block, path, repoName)
+}
+
+// AzureResourceBlockProcessor reports azurerm_ resources.
+//
+// Deprecated: use ResourceBlockProcessor, which reports every provider.
+type AzureResourceBlockProcessor struct{}
+
+func (a AzureResourceBlockProcessor) Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
+	return resourcesWithPrefix("azurerm_", block, path, repoName)
+}
+
+// GCPResourceBlockProcessor reports google_ resources.
+//
+// Deprecated: use ResourceBlockProcessor, which reports every provider.
+type GCPResourceBlockProcessor struct{}
+
+func (g GCPResourceBlockProcessor) Process(block *TerraformBlock, path string, repoName string) ([]core.Finding, error) {
+	return resourcesWithPrefix("google_", block, path, repoName)
+}
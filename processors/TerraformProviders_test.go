@@ -0,0 +1,162 @@
+package processors
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestLoadTerraformProviders_Embedded(t *testing.T) {
+	providers, err := LoadTerraformProviders(terraformFS)
+	require.NoError(t, err)
+
+	tests := []struct {
+		resourceType string
+		vendor       string
+		service      string
+		category     string
+	}{
+		{"aws_s3_bucket", "AWS", "Amazon S3", "Storage"},
+		{"aws_s3control_bucket", "AWS", "Amazon S3", "Storage"},
+		{"aws_route_table", "AWS", "Amazon VPC", "Networking"},
+		{"azurerm_kubernetes_cluster", "Azure", "Azure Kubernetes Service", "Containers"},
+		{"google_compute_instance", "GCP", "Compute Engine", "Compute"},
+		{"google_compute_firewall", "GCP", "Virtual Private Cloud", "Networking"},
+		{"kubernetes_deployment", "Kubernetes", "Kubernetes", "Containers"},
+		{"datadog_monitor", "Datadog", "Datadog", "Monitoring"},
+		{"http", "HashiCorp", "HTTP", "Utility"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			provider, service, ok := lookupTerraformProvider(providers, tt.resourceType)
+			require.True(t, ok)
+			assert.Equal(t, tt.vendor, provider.Vendor)
+			assert.Equal(t, tt.service, service.Name)
+			assert.Equal(t, tt.category, service.Category)
+		})
+	}
+
+	_, _, ok := lookupTerraformProvider(providers, "httpbin_thing")
+	assert.False(t, ok)
+}
+
+func TestLoadTerraformProviders_Override(t *testing.T) {
+	f := fstest.MapFS{
+		"data/terraform/providers.json": {Data: []byte(`[
+  {"prefix": "acme", "source": "acme/acme", "vendor": "Acme", "category": "Acme",
+   "services": {"queue": {"name": "Acme Queue", "category": "Messaging"}}}
+]`)},
+		"data/terraform/zz-local.json": {Data: []byte(`[
+  {"prefix": "acme", "source": "acme-corp/acme", "vendor": "Acme Corp", "category": "Acme",
+   "services": {"db": {"name": "Acme DB", "category": "Database"}}}
+]`)},
+	}
+
+	providers, err := LoadTerraformProviders(f)
+	require.NoError(t, err)
+	require.Len(t, providers, 1)
+	assert.Equal(t, "Acme Corp", providers[0].Vendor)
+	assert.Equal(t, "acme-corp/acme", providers[0].Source)
+	assert.Len(t, providers[0].Services, 2)
+}
+
+func TestTerraformProcessor_ProviderTable(t *testing.T) {
+	content := `
+resource "aws_s3_bucket" "assets" {
+  bucket = "assets"
+}
+
+resource "cloudflare_record" "www" {
+  name = "www"
+}
+
+resource "github_repository" "app" {
+  name = "app"
+}
+
+resource "acme_widget" "thing" {
+  size = 3
+}
+
+data "vault_generic_secret" "db" {
+  path = "secret/db"
+}
+`
+
+	processor := NewTerraformProcessor()
+	findings, err := processor.Process("main.tf", "repo", content)
+	require.NoError(t, err)
+
+	resources := make(map[string]core.Finding)
+	var data []core.Finding
+	for _, finding := range findings {
+		switch finding.Type {
+		case "Terraform Resource Use":
+			resources[finding.Properties["resource_type"].(string)] = finding
+		case "TF Data Source Use":
+			data = append(data, finding)
+		}
+	}
+	require.Len(t, resources, 4)
+
+	assert.Equal(t, "Amazon S3", resources["aws_s3_bucket"].Properties["service"])
+	assert.Equal(t, "Storage", resources["aws_s3_bucket"].Properties["service_category"])
+	assert.Equal(t, "assets", resources["aws_s3_bucket"].Properties["attributes"].(map[string]interface{})["bucket"])
+	assert.Equal(t, "Cloudflare", resources["cloudflare_record"].Properties["vendor"])
+	assert.Equal(t, "www", resources["cloudflare_record"].Properties["attributes"].(map[string]interface{})["name"])
+	assert.Equal(t, "integrations/github", resources["github_repository"].Properties["provider_source"])
+
+	// Providers missing from the table are still reported.
+	assert.Equal(t, "acme", resources["acme_widget"].Properties["provider_type"])
+	assert.Nil(t, resources["acme_widget"].Properties["vendor"])
+
+	require.Len(t, data, 1)
+	assert.Equal(t, "HashiCorp Vault", data[0].Properties["service"])
+	assert.Equal(t, "vault", data[0].Properties["provider"])
+
+	assert.Equal(t, "Terraform Resource", resources["acme_widget"].Name)
+	assert.Equal(t, "Terraform", resources["acme_widget"].Category)
+	assert.Equal(t, "Cloudflare Resource", resources["cloudflare_record"].Name)
+	assert.Equal(t, "Cloudflare", resources["cloudflare_record"].Category)
+}
+
+func TestTerraformProcessor_AttributesKeepTheirNames(t *testing.T) {
+	content := `
+resource "google_project_service" "compute" {
+  provider = google-beta
+  service  = "compute.googleapis.com"
+  vendor   = "kept"
+}
+`
+
+	findings, err := NewTerraformProcessor().Process("main.tf", "repo", content)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "GCP", findings[0].Properties["vendor"])
+	assert.Equal(t, "google", findings[0].Properties["provider_type"])
+	attributes := findings[0].Properties["attributes"].(map[string]interface{})
+	assert.Equal(t, "compute.googleapis.com", attributes["service"])
+	assert.Equal(t, "google-beta", attributes["provider"])
+	assert.Equal(t, "kept", attributes["vendor"])
+}
+
+func TestDeprecatedResourceBlockProcessors(t *testing.T) {
+	block := &TerraformBlock{Type: "resource", Labels: []string{"azurerm_resource_group", "rg"}, Attributes: map[string]interface{}{"location": "uksouth"}}
+
+	findings, err := AzureResourceBlockProcessor{}.Process(block, "main.tf", "repo")
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Azure Resource", findings[0].Name)
+	assert.Equal(t, "Azure", findings[0].Category)
+	assert.Equal(t, "uksouth", findings[0].Properties["attributes"].(map[string]interface{})["location"])
+
+	for _, processor := range []TerraformBlockProcessor{AWSResourceBlockProcessor{}, GCPResourceBlockProcessor{}} {
+		findings, err := processor.Process(block, "main.tf", "repo")
+		require.NoError(t, err)
+		assert.Empty(t, findings)
+	}
+}
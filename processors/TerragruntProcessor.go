@@ -0,0 +1,402 @@
+package processors
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+// maxTerragruntParentDepth bounds how far up find_in_parent_folders looks
+// when the root of the repository cannot be told.
+const maxTerragruntParentDepth = 20
+
+// terragruntFunctions returns the functions available to a Terragrunt config
+// of the unit in dir: Terraform's built-ins and the Terragrunt functions that
+// only depend on the tree. Functions that call out to a cloud are left out,
+// and get_env only returns its default, so expressions depending on where
+// Terragrunt runs are reported as unresolved.
+func terragruntFunctions(dir string) map[string]function.Function {
+	functions := make(map[string]function.Function, len(terraformFunctions)+3)
+	for name, fn := range terraformFunctions {
+		functions[name] = fn
+	}
+	functions["get_terragrunt_dir"] = function.New(&function.Spec{
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(dir), nil
+		},
+	})
+	functions["find_in_parent_folders"] = function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "args", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name := "terragrunt.hcl"
+			if len(args) > 0 {
+				name = args[0].AsString()
+			}
+			parent := filepath.Dir(dir)
+			for i := 0; i < maxTerragruntParentDepth && withinRepository(dir, parent); i++ {
+				candidate := filepath.Join(parent, name)
+				if _, err := os.Stat(candidate); err == nil {
+					return cty.StringVal(candidate), nil
+				}
+				if filepath.Dir(parent) == parent {
+					break
+				}
+				parent = filepath.Dir(parent)
+			}
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return cty.NilVal, fmt.Errorf("no %s found in the parent folders", name)
+		},
+	})
+	functions["get_env"] = function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "args", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			// The scanning environment says nothing about where Terragrunt
+			// runs, so only a default can be relied on.
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return cty.UnknownVal(cty.String), nil
+		},
+	})
+	return functions
+}
+
+// terragruntConfig is a parsed terragrunt.hcl, or a file it includes, with
+// its locals evaluated for the unit it is loaded for.
+type terragruntConfig struct {
+	Body   *hclsyntax.Body
+	Src    []byte
+	Ctx    *hcl.EvalContext
+	Blocks []*TerraformBlock
+}
+
+func loadTerragruntConfig(src []byte, filename string, dir string) (*terragruntConfig, error) {
+	body := parseTerraformBody(src, filename)
+	if body == nil {
+		return nil, fmt.Errorf("failed to parse Terragrunt config '%s'", filename)
+	}
+	ctx := &hcl.EvalContext{
+		Variables: make(map[string]cty.Value),
+		Functions: terragruntFunctions(dir),
+	}
+	locals := make(map[string]hcl.Expression)
+	for _, block := range body.Blocks {
+		if block.Type == "locals" {
+			for name, attr := range block.Body.Attributes {
+				locals[name] = attr.Expr
+			}
+		}
+	}
+	evaluateTerraformLocals(ctx, locals)
+	return &terragruntConfig{
+		Body:   body,
+		Src:    src,
+		Ctx:    ctx,
+		Blocks: ParseBodyWithContext(body, src, ctx),
+	}, nil
+}
+
+// inputs evaluates the inputs attribute entry by entry, so one input that
+// refers to a dependency's outputs does not hide the rest. Unresolved inputs
+// keep their expression in values and are unknown in variables.
+func (c *terragruntConfig) inputs() (values map[string]interface{}, variables map[string]cty.Value, unresolved map[string]string) {
+	values = make(map[string]interface{})
+	variables = make(map[string]cty.Value)
+	unresolved = make(map[string]string)
+	attr, ok := c.Body.Attributes["inputs"]
+	if !ok {
+		return values, variables, unresolved
+	}
+
+	if value, diags := attr.Expr.Value(c.Ctx); !diags.HasErrors() && value.IsWhollyKnown() && (value.Type().IsObjectType() || value.Type().IsMapType()) {
+		for name, item := range value.AsValueMap() {
+			values[name] = ConvertCtyValueToGo(item)
+			variables[name] = item
+		}
+		return values, variables, unresolved
+	}
+	object, ok := attr.Expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		unresolved["inputs"] = c.source(attr.Expr)
+		return values, variables, unresolved
+	}
+	for _, item := range object.Items {
+		name := hcl.ExprAsKeyword(item.KeyExpr)
+		if name == "" {
+			key, diags := item.KeyExpr.Value(c.Ctx)
+			if diags.HasErrors() || !key.IsKnown() || key.Type() != cty.String {
+				continue
+			}
+			name = key.AsString()
+		}
+		value, diags := item.ValueExpr.Value(c.Ctx)
+		if diags.HasErrors() || !value.IsWhollyKnown() {
+			values[name] = c.source(item.ValueExpr)
+			variables[name] = cty.DynamicVal
+			unresolved[name] = c.source(item.ValueExpr)
+			continue
+		}
+		values[name] = ConvertCtyValueToGo(value)
+		variables[name] = value
+	}
+	return values, variables, unresolved
+}
+
+func (c *terragruntConfig) source(expr hcl.Expression) string {
+	rng := expr.Range()
+	return string(c.Src[rng.Start.Byte:rng.End.Byte])
+}
+
+// parseTerragruntSource parses the source of a Terragrunt terraform block.
+// Besides Terraform's module sources Terragrunt accepts registry modules as
+// "tfr://host/namespace/name/provider?version=x", with an empty host meaning
+// the public registry.
+func parseTerragruntSource(source string) (TerraformModuleSource, string) {
+	if !strings.HasPrefix(source, "tfr://") {
+		return ParseTerraformModuleSource(source), ""
+	}
+	address := strings.TrimPrefix(source, "tfr://")
+	version := ""
+	if idx := strings.Index(address, "?"); idx >= 0 {
+		if query, err := url.ParseQuery(address[idx+1:]); err == nil {
+			version = query.Get("version")
+		}
+		address = address[:idx]
+	}
+	host, rest, _ := strings.Cut(address, "/")
+	if host == "" {
+		host = "registry.terraform.io"
+	}
+	return ParseTerraformModuleSource(host + "/" + rest), version
+}
+
+// TerragruntProcessor reports Terragrunt units: the module each one deploys,
+// its includes, dependencies, remote state and inputs. Units deploying a
+// module from the same repository also report the module's resources,
+// evaluated with the unit's inputs.
+type TerragruntProcessor struct {
+	terraform *TerraformProcessor
+}
+
+// NewTerragruntProcessor creates a TerragruntProcessor that reports the
+// resources of local modules with terraform.
+func NewTerragruntProcessor(terraform *TerraformProcessor) *TerragruntProcessor {
+	return &TerragruntProcessor{terraform: terraform}
+}
+
+func (t TerragruntProcessor) Supports(filePath string) bool {
+	return filepath.Base(filePath) == "terragrunt.hcl" && !inDirectory(filePath, ".terragrunt-cache")
+}
+
+func (t TerragruntProcessor) Process(path string, repoName string, content string) ([]core.Finding, error) {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		dir = filepath.Dir(path)
+	}
+	config, err := loadTerragruntConfig([]byte(content), path, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	unit := repositoryPath(filepath.Dir(path))
+	newFinding := func(name string, findingType string, properties map[string]interface{}) core.Finding {
+		properties["unit"] = unit
+		return core.Finding{
+			Name:       name,
+			Type:       findingType,
+			Category:   "Terragrunt",
+			Properties: properties,
+			RepoName:   repoName,
+			Path:       path,
+		}
+	}
+	relative := func(target string) string {
+		if rel, err := filepath.Rel(dir, target); err == nil && filepath.IsAbs(target) {
+			return filepath.ToSlash(rel)
+		}
+		return target
+	}
+
+	var matches []core.Finding
+	inputs, variables, unresolved := config.inputs()
+	unitProperties := map[string]interface{}{}
+	var moduleDir string
+	for _, block := range config.Blocks {
+		switch block.Type {
+		case "terraform":
+			source, ok := block.Attributes["source"].(string)
+			if !ok || block.Unresolved["source"] != "" {
+				if ok {
+					unitProperties["source"] = source
+					unresolved["source"] = source
+				}
+				continue
+			}
+			parsed, version := parseTerragruntSource(source)
+			if filepath.IsAbs(source) {
+				parsed = TerraformModuleSource{Type: "local", Address: source}
+			}
+			unitProperties["source"] = relative(source)
+			unitProperties["source_type"] = parsed.Type
+			unitProperties["address"] = relative(parsed.Address)
+			if parsed.Ref != "" {
+				unitProperties["ref"] = parsed.Ref
+			}
+			if parsed.Subdir != "" {
+				unitProperties["subdir"] = parsed.Subdir
+			}
+			if parsed.Type == "registry" {
+				unitProperties["registry"] = parsed.Registry
+				unitProperties["namespace"] = parsed.Namespace
+				unitProperties["name"] = parsed.Name
+				unitProperties["provider"] = parsed.Provider
+			}
+			if version != "" {
+				unitProperties["version"] = version
+			}
+			if parsed.Type == "local" {
+				// "//" separates the module root from a subdirectory; both
+				// are local, so the path is the two joined.
+				moduleDir = strings.ReplaceAll(source, "//", "/")
+				if !filepath.IsAbs(moduleDir) {
+					moduleDir = filepath.Join(dir, moduleDir)
+				}
+			}
+
+		case "include":
+			includePath, _ := block.Attributes["path"].(string)
+			properties := map[string]interface{}{
+				"resolved": false,
+			}
+			if len(block.Labels) > 0 {
+				properties["include"] = block.Labels[0]
+			}
+			for _, key := range []string{"expose", "merge_strategy"} {
+				if value, ok := block.Attributes[key]; ok {
+					properties[key] = value
+				}
+			}
+			if includePath != "" && !filepath.IsAbs(includePath) {
+				includePath = filepath.Join(dir, includePath)
+			}
+			// Configs outside the repository are not read.
+			if !withinRepository(dir, includePath) {
+				matches = append(matches, newFinding(relative(includePath), "Terragrunt Include", properties))
+				continue
+			}
+			if src, err := os.ReadFile(includePath); err == nil && block.Unresolved["path"] == "" {
+				properties["resolved"] = true
+				// Included inputs apply unless the unit sets them itself.
+				if parent, err := loadTerragruntConfig(src, includePath, dir); err == nil {
+					_, parentVariables, _ := parent.inputs()
+					for name, value := range parentVariables {
+						if _, ok := variables[name]; !ok {
+							variables[name] = value
+						}
+					}
+				}
+			}
+			matches = append(matches, newFinding(relative(includePath), "Terragrunt Include", properties))
+
+		case "dependency":
+			configPath, _ := block.Attributes["config_path"].(string)
+			properties := map[string]interface{}{
+				"kind": "dependency",
+			}
+			if len(block.Labels) > 0 {
+				properties["dependency"] = block.Labels[0]
+			}
+			if skip, ok := block.Attributes["skip_outputs"]; ok {
+				properties["skip_outputs"] = skip
+			}
+			if _, ok := block.Attributes["mock_outputs"]; ok {
+				properties["mock_outputs"] = true
+			}
+			matches = append(matches, newFinding(relative(configPath), "Terragrunt Dependency", properties))
+
+		case "dependencies":
+			paths, _ := block.Attributes["paths"].([]interface{})
+			for _, dependency := range paths {
+				matches = append(matches, newFinding(relative(fmt.Sprint(dependency)), "Terragrunt Dependency", map[string]interface{}{
+					"kind": "ordering",
+				}))
+			}
+
+		case "remote_state":
+			backend := fmt.Sprint(block.Attributes["backend"])
+			properties := map[string]interface{}{
+				"backend": backend,
+			}
+			if stateConfig, ok := block.Attributes["config"].(map[string]interface{}); ok {
+				for key, value := range stateConfig {
+					properties[key] = value
+				}
+			} else if raw, ok := block.Unresolved["config"]; ok {
+				properties["unresolved"] = map[string]string{"config": raw}
+			}
+			matches = append(matches, newFinding(backend, "TF Backend", properties))
+		}
+	}
+
+	if len(inputs) > 0 {
+		unitProperties["inputs"] = inputs
+	}
+	if len(unresolved) > 0 {
+		unitProperties["unresolved"] = unresolved
+	}
+	if moduleDir != "" {
+		moduleMatches := t.moduleFindings(moduleDir, path, dir, repoName, variables)
+		unitProperties["module_resolved"] = moduleMatches != nil
+		matches = append(matches, moduleMatches...)
+	}
+	return append([]core.Finding{newFinding(unit, "Terragrunt Unit", unitProperties)}, matches...), nil
+}
+
+// moduleFindings reports the Terraform files of a local module as part of the
+// unit at path, or nil when the module is not in the tree or is outside the
+// repository.
+func (t TerragruntProcessor) moduleFindings(moduleDir string, path string, dir string, repoName string, inputs map[string]cty.Value) []core.Finding {
+	if !withinRepository(dir, moduleDir) {
+		return nil
+	}
+	unit := repositoryPath(filepath.Dir(path))
+	files, _ := filepath.Glob(filepath.Join(moduleDir, "*.tf"))
+	if len(files) == 0 {
+		return nil
+	}
+	matches := make([]core.Finding, 0)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		findings, err := t.terraform.process(file, repoName, string(content), inputs)
+		if err != nil {
+			continue
+		}
+		for _, finding := range findings {
+			finding.Properties["terragrunt_unit"] = unit
+			finding.Properties["module_file"] = filepath.ToSlash(file)
+			if rel, err := filepath.Rel(dir, file); err == nil {
+				finding.Properties["module_file"] = filepath.ToSlash(rel)
+			}
+			finding.Path = path
+			matches = append(matches, finding)
+		}
+	}
+	return matches
+}
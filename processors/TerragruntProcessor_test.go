@@ -0,0 +1,244 @@
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reaandrew/techdetector/core"
+)
+
+func TestTerragruntProcessor_Supports(t *testing.T) {
+	processor := NewTerragruntProcessor(NewTerraformProcessor())
+	assert.True(t, processor.Supports("live/prod/vpc/terragrunt.hcl"))
+	assert.False(t, processor.Supports("live/prod/vpc/.terragrunt-cache/abc/terragrunt.hcl"))
+	assert.False(t, processor.Supports("live/prod/vpc/main.tf"))
+}
+
+func TestTerragruntProcessor_LocalModule(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(dir, "terragrunt.hcl"), `
+remote_state {
+  backend = "s3"
+  config = {
+    bucket = "acme-state"
+    key    = "${path_relative_to_include()}/terraform.tfstate"
+  }
+}
+
+inputs = {
+  region = "eu-west-2"
+}
+`)
+	writeTestFile(t, filepath.Join(dir, "modules", "vpc", "main.tf"), `
+variable "cidr" {}
+
+variable "region" {
+  default = "us-east-1"
+}
+
+variable "key_arn" {}
+
+resource "aws_vpc" "main" {
+  cidr_block = var.cidr
+  tags = {
+    Region = var.region
+  }
+}
+
+resource "aws_flow_log" "main" {
+  kms_key_id = var.key_arn
+}
+`)
+	unitPath := filepath.Join(dir, "live", "prod", "vpc", "terragrunt.hcl")
+	unit := `
+include "root" {
+  path   = find_in_parent_folders()
+  expose = true
+}
+
+locals {
+  env = "prod"
+}
+
+terraform {
+  source = "../../../modules//vpc"
+}
+
+dependency "kms" {
+  config_path = "../kms"
+  mock_outputs = {
+    key_arn = "arn:aws:kms:mock"
+  }
+}
+
+dependencies {
+  paths = ["../iam"]
+}
+
+inputs = {
+  cidr    = "10.0.0.0/16"
+  name    = "${local.env}-vpc"
+  key_arn = dependency.kms.outputs.key_arn
+}
+`
+	writeTestFile(t, unitPath, unit)
+
+	processor := NewTerragruntProcessor(NewTerraformProcessor())
+	findings, err := processor.Process(unitPath, "repo", unit)
+	require.NoError(t, err)
+
+	byType := make(map[string][]core.Finding)
+	for _, finding := range findings {
+		byType[finding.Type] = append(byType[finding.Type], finding)
+	}
+
+	require.Len(t, byType["Terragrunt Unit"], 1)
+	unitFinding := byType["Terragrunt Unit"][0]
+	assert.Equal(t, "live/prod/vpc", unitFinding.Name)
+	assert.Equal(t, "live/prod/vpc", unitFinding.Properties["unit"])
+	assert.Equal(t, "local", unitFinding.Properties["source_type"])
+	assert.Equal(t, true, unitFinding.Properties["module_resolved"])
+	inputs := unitFinding.Properties["inputs"].(map[string]interface{})
+	assert.Equal(t, "prod-vpc", inputs["name"])
+	assert.Equal(t, map[string]string{"key_arn": "dependency.kms.outputs.key_arn"}, unitFinding.Properties["unresolved"])
+
+	require.Len(t, byType["Terragrunt Include"], 1)
+	include := byType["Terragrunt Include"][0]
+	assert.Equal(t, "../../../terragrunt.hcl", include.Name)
+	assert.Equal(t, true, include.Properties["resolved"])
+	assert.Equal(t, true, include.Properties["expose"])
+
+	dependencies := findingsByName(byType["Terragrunt Dependency"])
+	require.Len(t, dependencies, 2)
+	assert.Equal(t, "kms", dependencies["../kms"].Properties["dependency"])
+	assert.Equal(t, true, dependencies["../kms"].Properties["mock_outputs"])
+	assert.Equal(t, "ordering", dependencies["../iam"].Properties["kind"])
+
+	resources := make(map[string]core.Finding)
+	for _, finding := range byType["Terraform Resource Use"] {
+		resources[finding.Properties["resource_type"].(string)] = finding
+	}
+	require.Len(t, resources, 2)
+	vpc := resources["aws_vpc"]
+	assert.Equal(t, unitPath, vpc.Path)
+	assert.Equal(t, "live/prod/vpc", vpc.Properties["terragrunt_unit"])
+	assert.Equal(t, "../../../modules/vpc/main.tf", vpc.Properties["module_file"])
+	attributes := vpc.Properties["attributes"].(map[string]interface{})
+	assert.Equal(t, "10.0.0.0/16", attributes["cidr_block"])
+	// The region comes from the included root config's inputs.
+	assert.Equal(t, map[string]interface{}{"Region": "eu-west-2"}, attributes["tags"])
+	assert.Equal(t, map[string]string{"kms_key_id": "var.key_arn"}, resources["aws_flow_log"].Properties["unresolved"])
+}
+
+func TestTerragruntProcessor_RemoteSourcesAndState(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected map[string]interface{}
+	}{
+		{
+			source: "git::https://github.com/acme/modules.git//vpc?ref=v1.2.0",
+			expected: map[string]interface{}{
+				"source_type": "git",
+				"address":     "https://github.com/acme/modules.git",
+				"subdir":      "vpc",
+				"ref":         "v1.2.0",
+			},
+		},
+		{
+			source: "tfr:///terraform-aws-modules/vpc/aws?version=5.1.2",
+			expected: map[string]interface{}{
+				"source_type": "registry",
+				"registry":    "registry.terraform.io",
+				"namespace":   "terraform-aws-modules",
+				"version":     "5.1.2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			content := `
+terraform {
+  source = "` + tt.source + `"
+}
+`
+			findings, err := NewTerragruntProcessor(NewTerraformProcessor()).Process(filepath.Join(t.TempDir(), "terragrunt.hcl"), "repo", content)
+			require.NoError(t, err)
+			require.Len(t, findings, 1)
+			for key, value := range tt.expected {
+				assert.Equal(t, value, findings[0].Properties[key], key)
+			}
+			assert.Nil(t, findings[0].Properties["module_resolved"])
+		})
+	}
+
+	dir := t.TempDir()
+	root := filepath.Join(dir, "terragrunt.hcl")
+	content := `
+remote_state {
+  backend = "gcs"
+  config = {
+    bucket = "acme-state"
+    prefix = "${path_relative_to_include()}"
+  }
+}
+`
+	require.NoError(t, os.WriteFile(root, []byte(content), 0o644))
+	findings, err := NewTerragruntProcessor(NewTerraformProcessor()).Process(root, "repo", content)
+	require.NoError(t, err)
+	backends := findingsOfType(findings, "TF Backend")
+	require.Len(t, backends, 1)
+	assert.Equal(t, "Terragrunt", backends["gcs"].Category)
+	assert.NotNil(t, backends["gcs"].Properties["unresolved"])
+}
+
+func TestTerragruntProcessor_StaysInRepository(t *testing.T) {
+	parent := t.TempDir()
+	repo := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	writeTestFile(t, filepath.Join(parent, "terragrunt.hcl"), `inputs = {
+  secret = "outside"
+}
+`)
+	writeTestFile(t, filepath.Join(parent, "common.hcl"), `inputs = {
+  region = "outside"
+}
+`)
+	writeTestFile(t, filepath.Join(parent, "modules", "vpc", "main.tf"), `resource "aws_vpc" "main" {}
+`)
+	unitPath := filepath.Join(repo, "live", "terragrunt.hcl")
+	unit := `
+include "root" {
+  path = find_in_parent_folders()
+}
+
+include "common" {
+  path = "../../common.hcl"
+}
+
+terraform {
+  source = "../../modules//vpc"
+}
+`
+	writeTestFile(t, unitPath, unit)
+
+	findings, err := NewTerragruntProcessor(NewTerraformProcessor()).Process(unitPath, "repo", unit)
+	require.NoError(t, err)
+
+	includes := findingsByType(findings, "Terragrunt Include")
+	require.Len(t, includes, 2)
+	for _, include := range includes {
+		assert.Equal(t, false, include.Properties["resolved"], include.Properties["include"])
+	}
+	assert.Empty(t, findingsByType(findings, "Terraform Resource Use"))
+
+	units := findingsByType(findings, "Terragrunt Unit")
+	require.Len(t, units, 1)
+	assert.Equal(t, false, units[0].Properties["module_resolved"])
+	assert.Nil(t, units[0].Properties["inputs"])
+}